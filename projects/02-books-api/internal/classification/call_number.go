@@ -0,0 +1,145 @@
+package classification
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SchemeDewey = "DDC"
+	SchemeCDU   = "CDU"
+)
+
+var (
+	leadingArticles = []string{"el ", "la ", "los ", "las ", "un ", "una ", "lo ", "the ", "a ", "an "}
+
+	accentFolder = strings.NewReplacer(
+		"á", "a", "à", "a", "ä", "a", "â", "a",
+		"é", "e", "è", "e", "ë", "e", "ê", "e",
+		"í", "i", "ì", "i", "ï", "i", "î", "i",
+		"ó", "o", "ò", "o", "ö", "o", "ô", "o",
+		"ú", "u", "ù", "u", "ü", "u", "û", "u",
+		"ñ", "n", "ç", "c",
+	)
+)
+
+// Cutter builds a two-figure Cutter code following the Library of Congress
+// Cutter table: the initial letter plus one digit for the second letter and
+// one for the third.
+func Cutter(entry string) string {
+	letters := foldLetters(stripArticle(entry))
+	if len(letters) == 0 {
+		return ""
+	}
+
+	first := letters[0]
+	code := []byte{first - 'a' + 'A'}
+	next := 1
+
+	switch {
+		case strings.IndexByte("aeiou", first) >= 0:
+			if len(letters) > 1 {
+				code = append(code, vowelDigit(letters[1]))
+				next = 2
+			}
+		case first == 's':
+			if len(letters) > 1 {
+				if letters[1] == 'c' && len(letters) > 2 && letters[2] == 'h' {
+					code = append(code, '3')
+					next = 3
+				} else {
+					code = append(code, sDigit(letters[1]))
+					next = 2
+				}
+			}
+		case first == 'q':
+			if len(letters) > 2 && letters[1] == 'u' {
+				code = append(code, quDigit(letters[2]))
+				next = 3
+			} else if len(letters) > 1 {
+				code = append(code, '2')
+				next = 2
+			}
+		default:
+			if len(letters) > 1 {
+				code = append(code, consonantDigit(letters[1]))
+				next = 2
+			}
+	}
+
+	if len(letters) > next {
+		code = append(code, expansionDigit(letters[next]))
+	}
+
+	return string(code)
+}
+
+// CallNumber joins the classification number, the Cutter code and the
+// publication year, e.g. "863 C47 1605".
+func CallNumber(classNumber, cutter string, year int64) string {
+	parts := []string{strings.TrimSpace(classNumber)}
+
+	if cutter = strings.TrimSpace(cutter); cutter != "" {
+		parts = append(parts, cutter)
+	}
+
+	if year > 0 {
+		parts = append(parts, fmt.Sprintf("%d", year))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func stripArticle(entry string) string {
+	lower := strings.ToLower(strings.TrimSpace(entry))
+
+	for _, article := range leadingArticles {
+		if strings.HasPrefix(lower, article) {
+			return lower[len(article):]
+		}
+	}
+
+	return lower
+}
+
+func foldLetters(value string) []byte {
+	var letters []byte
+
+	for _, r := range accentFolder.Replace(value) {
+		if r >= 'a' && r <= 'z' {
+			letters = append(letters, byte(r))
+		}
+	}
+
+	return letters
+}
+
+func digitFor(c, first byte, bounds string) byte {
+	for i := 0; i < len(bounds); i++ {
+		if c <= bounds[i] {
+			return first + byte(i)
+		}
+	}
+
+	return '9'
+}
+
+func vowelDigit(c byte) byte {
+	return digitFor(c, '2', "ckmnqrt")
+}
+
+func sDigit(c byte) byte {
+	return digitFor(c, '2', "bdglstv")
+}
+
+func quDigit(c byte) byte {
+	return digitFor(c, '3', "dhnqsx")
+}
+
+func consonantDigit(c byte) byte {
+	return digitFor(c, '3', "dhnqtx")
+}
+
+func expansionDigit(c byte) byte {
+	return digitFor(c, '3', "dhlosv")
+}
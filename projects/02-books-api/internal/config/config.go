@@ -0,0 +1,486 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/logger"
+)
+
+// Config is the configuration of the server. Every setting has a key, used in
+// the file and, with dashes, as a flag, and an environment variable.
+type Config struct {
+	File      string // The file read, if any
+	PrintOnly bool   // Print the effective configuration and exit
+
+	Server   ServerConfig
+	Database DatabaseConfig
+	Log      LogConfig
+	CORS     CORSConfig
+	Storage  StorageConfig
+	SMTP     SMTPConfig
+	SMS      SMSConfig
+	Metadata MetadataConfig
+	Jobs     JobsConfig
+}
+
+type ServerConfig struct {
+	Port            int           `key:"server.port" env:"PORT"`
+	ReadTimeout     time.Duration `key:"server.read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `key:"server.write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `key:"server.idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `key:"server.shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+type DatabaseConfig struct {
+	Path    string   `key:"database.path" env:"DB_PATH"`
+	Pragmas []string `key:"database.pragmas" env:"DB_PRAGMAS"` // Run on every connection, as busy_timeout=5000
+}
+
+type LogConfig struct {
+	Path        string        `key:"log.path" env:"LOG_PATH"`
+	Format      string        `key:"log.format" env:"LOG_FORMAT"`
+	Level       string        `key:"log.level" env:"LOG_LEVEL"`
+	MaxSizeMB   int           `key:"log.max_size_mb" env:"LOG_MAX_SIZE_MB"`
+	RotateEvery time.Duration `key:"log.rotate_every" env:"LOG_ROTATE_EVERY"`
+	MaxBackups  int           `key:"log.max_backups" env:"LOG_MAX_BACKUPS"`
+	MaxAgeDays  int           `key:"log.max_age_days" env:"LOG_MAX_AGE_DAYS"`
+}
+
+type CORSConfig struct {
+	Origins []string `key:"cors.origins" env:"CORS_ORIGINS"` // Empty disables CORS, * allows any origin
+}
+
+type StorageConfig struct {
+	Path        string `key:"storage.path" env:"STORAGE_PATH"`
+	ReportsPath string `key:"storage.reports_path" env:"REPORTS_PATH"`
+}
+
+type SMTPConfig struct {
+	Addr     string `key:"smtp.addr" env:"SMTP_ADDR"`
+	Username string `key:"smtp.username" env:"SMTP_USERNAME"`
+	Password string `key:"smtp.password" env:"SMTP_PASSWORD" secret:"true"`
+	From     string `key:"smtp.from" env:"SMTP_FROM"`
+}
+
+type SMSConfig struct {
+	GatewayURL string `key:"sms.gateway_url" env:"SMS_GATEWAY_URL"`
+	Token      string `key:"sms.gateway_token" env:"SMS_GATEWAY_TOKEN" secret:"true"`
+}
+
+type MetadataConfig struct {
+	BaseURL string `key:"metadata.base_url" env:"METADATA_BASE_URL"`
+}
+
+type JobsConfig struct {
+	ReportInterval       time.Duration `key:"jobs.report_interval" env:"JOB_REPORT_INTERVAL"`
+	NotificationInterval time.Duration `key:"jobs.notification_interval" env:"JOB_NOTIFICATION_INTERVAL"`
+	WebhookInterval      time.Duration `key:"jobs.webhook_interval" env:"JOB_WEBHOOK_INTERVAL"`
+	EventInterval        time.Duration `key:"jobs.event_interval" env:"JOB_EVENT_INTERVAL"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    60 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Path:    "./books.db",
+			Pragmas: []string{"busy_timeout=5000"},
+		},
+		Log: LogConfig{
+			Path:        "./api.log",
+			Format:      "json",
+			Level:       "info",
+			MaxSizeMB:   10,
+			RotateEvery: 24 * time.Hour,
+			MaxBackups:  7,
+			MaxAgeDays:  30,
+		},
+		Storage: StorageConfig{
+			Path:        "./storage",
+			ReportsPath: "./reports",
+		},
+		Metadata: MetadataConfig{
+			BaseURL: "https://openlibrary.org",
+		},
+		Jobs: JobsConfig{
+			ReportInterval:       time.Minute,
+			NotificationInterval: time.Minute,
+			WebhookInterval:      15 * time.Second,
+			EventInterval:        time.Second,
+		},
+	}
+}
+
+// Load reads the defaults, then the environment, then the file given by
+// -config or CONFIG_FILE and last the flags, each one overriding the ones
+// before, and validates the result. So a key in the file beats its
+// environment variable and a flag beats both; the environment only fills
+// what the file leaves out.
+func Load(name string, args []string) (*Config, error) {
+	config := Default()
+	settings := config.settings()
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.StringVar(&config.File, "config", os.Getenv("CONFIG_FILE"), "Archivo de configuración YAML o TOML (env CONFIG_FILE)")
+	flags.BoolVar(&config.PrintOnly, "print-config", false, "Muestra la configuración efectiva y termina")
+
+	flagValues := make(map[string]*string, len(settings))
+	for _, setting := range settings {
+		flagValues[setting.flag()] = flags.String(setting.flag(), "", fmt.Sprintf("%s (env %s)", setting.key, setting.env))
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, setting := range settings {
+		if value := os.Getenv(setting.env); value != "" {
+			if err := setting.set(value); err != nil {
+				return nil, fmt.Errorf("%s inválido: %s", setting.env, value)
+			}
+		}
+	}
+
+	if config.File != "" {
+		values, err := readFile(config.File)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, setting := range settings {
+			value, ok := values[setting.key]
+			if !ok {
+				continue
+			}
+
+			delete(values, setting.key)
+
+			if err := setting.set(value); err != nil {
+				return nil, fmt.Errorf("%s inválido en %s: %s", setting.key, config.File, value)
+			}
+		}
+
+		for key := range values {
+			return nil, fmt.Errorf("Clave desconocida en %s: %s", config.File, key)
+		}
+	}
+
+	var flagErr error
+
+	flags.Visit(func(f *flag.Flag) {
+		value, ok := flagValues[f.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+
+		for _, setting := range settings {
+			if setting.flag() == f.Name {
+				if err := setting.set(*value); err != nil {
+					flagErr = fmt.Errorf("-%s inválido: %s", f.Name, *value)
+				}
+			}
+		}
+	})
+
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+var pragmaRegex = regexp.MustCompile(`^[a-z_]+=[A-Za-z0-9_.-]+$`)
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("server.port debe estar entre 1 y 65535")
+	}
+
+	positive := map[string]time.Duration{
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.shutdown_timeout":    c.Server.ShutdownTimeout,
+		"jobs.report_interval":       c.Jobs.ReportInterval,
+		"jobs.notification_interval": c.Jobs.NotificationInterval,
+		"jobs.webhook_interval":      c.Jobs.WebhookInterval,
+		"jobs.event_interval":        c.Jobs.EventInterval,
+	}
+
+	for _, setting := range c.settings() {
+		if duration, ok := positive[setting.key]; ok && duration <= 0 {
+			invalid("%s debe ser mayor que 0", setting.key)
+		}
+	}
+
+	if c.Database.Path == "" {
+		invalid("database.path es obligatorio")
+	}
+
+	for _, pragma := range c.Database.Pragmas {
+		if !pragmaRegex.MatchString(pragma) {
+			invalid("database.pragmas debe tener la forma nombre=valor: %s", pragma)
+		}
+	}
+
+	if c.Log.Path == "" {
+		invalid("log.path es obligatorio")
+	}
+
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		invalid("log.format debe ser json o text")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		invalid("log.level debe ser debug, info, warn o error")
+	}
+
+	if c.Log.MaxSizeMB < 0 || c.Log.RotateEvery < 0 || c.Log.MaxBackups < 0 || c.Log.MaxAgeDays < 0 {
+		invalid("Los límites de rotación del log no pueden ser negativos")
+	}
+
+	for _, origin := range c.CORS.Origins {
+		if origin == "*" {
+			continue
+		}
+
+		parsed, err := url.Parse(origin)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || strings.TrimSuffix(parsed.Path, "/") != "" {
+			invalid("cors.origins debe contener * o orígenes como https://ejemplo.com: %s", origin)
+		}
+	}
+
+	if c.Storage.Path == "" || c.Storage.ReportsPath == "" {
+		invalid("storage.path y storage.reports_path son obligatorios")
+	}
+
+	if parsed, err := url.Parse(c.Metadata.BaseURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		invalid("metadata.base_url debe ser una URL absoluta")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Configuración inválida: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// LoggerConfig converts the log settings, already validated.
+func (c *Config) LoggerConfig() logger.Config {
+	config := logger.Config{
+		Path:        c.Log.Path,
+		Format:      c.Log.Format,
+		MaxSize:     int64(c.Log.MaxSizeMB) << 20,
+		RotateEvery: c.Log.RotateEvery,
+		MaxBackups:  c.Log.MaxBackups,
+		MaxAge:      time.Duration(c.Log.MaxAgeDays) * 24 * time.Hour,
+	}
+
+	config.Level.UnmarshalText([]byte(c.Log.Level))
+
+	return config
+}
+
+// Print writes the effective configuration as a TOML file, with the secrets
+// redacted.
+func (c *Config) Print(w io.Writer) error {
+	section := ""
+
+	for _, setting := range c.settings() {
+		settingSection, key, _ := strings.Cut(setting.key, ".")
+
+		if settingSection != section {
+			if section != "" {
+				fmt.Fprintln(w)
+			}
+
+			section = settingSection
+			fmt.Fprintf(w, "[%s]\n", section)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s = %s\n", key, setting.toml()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogValue logs the effective configuration with the secrets redacted.
+func (c *Config) LogValue() slog.Value {
+	var (
+		groups  []slog.Attr
+		section string
+		attrs   []any
+	)
+
+	flush := func() {
+		if section != "" {
+			groups = append(groups, slog.Group(section, attrs...))
+		}
+	}
+
+	for _, setting := range c.settings() {
+		settingSection, key, _ := strings.Cut(setting.key, ".")
+
+		if settingSection != section {
+			flush()
+			section, attrs = settingSection, nil
+		}
+
+		if setting.value.Kind() == reflect.Int {
+			attrs = append(attrs, slog.Int64(key, setting.value.Int()))
+		} else {
+			attrs = append(attrs, slog.String(key, setting.String()))
+		}
+	}
+
+	flush()
+
+	return slog.GroupValue(groups...)
+}
+
+type setting struct {
+	key    string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// settings lists the tagged fields of every section, in declaration order.
+func (c *Config) settings() []*setting {
+	var settings []*setting
+
+	sections := reflect.ValueOf(c).Elem()
+
+	for i := range sections.NumField() {
+		section := sections.Field(i)
+		if section.Kind() != reflect.Struct {
+			continue
+		}
+
+		for j := range section.NumField() {
+			field := section.Type().Field(j)
+
+			settings = append(settings, &setting{
+				key:    field.Tag.Get("key"),
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+
+	return settings
+}
+
+func (s *setting) flag() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// set parses a value; lists are separated by commas.
+func (s *setting) set(value string) error {
+	value = strings.TrimSpace(value)
+
+	switch {
+		case s.value.Type() == durationType:
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+
+			s.value.SetInt(int64(duration))
+		case s.value.Kind() == reflect.Int:
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+
+			s.value.SetInt(int64(number))
+		case s.value.Kind() == reflect.Slice:
+			var items []string
+
+			for item := range strings.SplitSeq(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+
+			s.value.Set(reflect.ValueOf(items))
+		default:
+			s.value.SetString(value)
+	}
+
+	return nil
+}
+
+func (s *setting) redacted() bool {
+	return s.secret && s.value.String() != ""
+}
+
+func (s *setting) String() string {
+	if s.redacted() {
+		return "********"
+	}
+
+	switch {
+		case s.value.Type() == durationType:
+			return time.Duration(s.value.Int()).String()
+		case s.value.Kind() == reflect.Slice:
+			return strings.Join(s.value.Interface().([]string), ",")
+	}
+
+	return fmt.Sprint(s.value.Interface())
+}
+
+func (s *setting) toml() string {
+	switch {
+		case s.redacted():
+			return strconv.Quote("********")
+		case s.value.Type() == durationType:
+			return strconv.Quote(s.String())
+		case s.value.Kind() == reflect.Int:
+			return s.String()
+		case s.value.Kind() == reflect.Slice:
+			items := s.value.Interface().([]string)
+
+			quoted := make([]string, len(items))
+			for i, item := range items {
+				quoted[i] = strconv.Quote(item)
+			}
+
+			return "[" + strings.Join(quoted, ", ") + "]"
+	}
+
+	return strconv.Quote(s.value.String())
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv hides the environment of the machine running the tests.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("CONFIG_FILE", "")
+
+	for _, setting := range Default().settings() {
+		t.Setenv(setting.env, "")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", "server:\n  port: 8082\nlog:\n  level: warn\n")
+	tomlFile := writeFile(t, "config.toml", "[server]\nport = 8084\n")
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		args  []string
+		port  int
+		level string
+	}{
+		{"defaults", nil, nil, 8080, "info"},
+		{"env over defaults", map[string]string{"PORT": "8081"}, nil, 8081, "info"},
+		{"file over env", map[string]string{"PORT": "8081", "LOG_LEVEL": "debug"}, []string{"-config", yamlFile}, 8082, "warn"},
+		{"env keeps what the file doesn't set", map[string]string{"LOG_LEVEL": "debug"}, []string{"-config", tomlFile}, 8084, "debug"},
+		{"flag over file and env", map[string]string{"PORT": "8081"}, []string{"-config", yamlFile, "-server-port", "8083"}, 8083, "warn"},
+		{"file from CONFIG_FILE", map[string]string{"CONFIG_FILE": yamlFile}, nil, 8082, "warn"},
+		{"-config over CONFIG_FILE", map[string]string{"CONFIG_FILE": yamlFile}, []string{"-config", tomlFile}, 8084, "info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			config, err := Load("books", tt.args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if config.Server.Port != tt.port || config.Log.Level != tt.level {
+				t.Errorf("port %d and level %s, want %d and %s", config.Server.Port, config.Log.Level, tt.port, tt.level)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		file    string
+		args    []string
+		wantErr string
+	}{
+		{"invalid env", map[string]string{"SERVER_READ_TIMEOUT": "30"}, "", nil, "SERVER_READ_TIMEOUT inválido: 30"},
+		{"invalid file value", nil, "[server]\nport = \"abc\"\n", nil, "server.port inválido en"},
+		{"unknown file key", nil, "[server]\nhost = \"0.0.0.0\"\n", nil, "Clave desconocida en"},
+		{"unparsable file", nil, "[server\n", nil, "sección inválida"},
+		{"invalid flag", nil, "", []string{"-log-max-backups", "many"}, "-log-max-backups inválido: many"},
+		{"unknown flag", nil, "", []string{"-verbose"}, "flag provided but not defined"},
+		{"fails validation", map[string]string{"LOG_FORMAT": "xml"}, "", nil, "log.format debe ser json o text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, "config.toml", tt.file)}, args...)
+			}
+
+			_, err := Load("books", args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	clearEnv(t)
+
+	config, err := Load("books", []string{"-cors-origins", "https://a.example.com,https://b.example.com", "-jobs-event-interval", "2s"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var printed bytes.Buffer
+	if err := config.Print(&printed); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+
+	reloaded, err := Load("books", []string{"-config", writeFile(t, "printed.toml", printed.String())})
+	if err != nil {
+		t.Fatalf("Load of the printed file: %v\n%s", err, printed.String())
+	}
+
+	reloaded.File = ""
+
+	if !reflect.DeepEqual(reloaded, config) {
+		t.Errorf("reloaded = %+v, want %+v", reloaded, config)
+	}
+
+	if reloaded.Jobs.EventInterval != 2*time.Second {
+		t.Errorf("event interval = %s, want 2s", reloaded.Jobs.EventInterval)
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readFile reads the settings of a YAML or TOML file, by its extension, as
+// section.key and their values; lists are joined with commas. Only what the
+// configuration needs is supported: sections of keys with strings, numbers
+// and lists of strings. Anything else, like inline tables, nested lists or
+// deeper YAML nesting, is an error instead of being misread.
+func readFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error al abrir el archivo de configuración: %w", err)
+	}
+	defer file.Close()
+
+	var parse func(section, line string) (string, string, string, error)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+		case ".toml":
+			parse = parseTOMLLine
+		case ".yaml", ".yml":
+			parse = parseYAMLLine
+		default:
+			return nil, fmt.Errorf("El archivo de configuración debe ser .yaml, .yml o .toml: %s", path)
+	}
+
+	yaml := strings.ToLower(filepath.Ext(path)) != ".toml"
+	values := make(map[string]string)
+	section := ""
+	listKey := "" // YAML key whose items come in the next lines
+	listIndent := 0
+	number := 0
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		number++
+
+		line := stripComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok && listKey != "" && indent > 0 {
+			value, err := parseItem(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, number, err)
+			}
+
+			values[listKey] = strings.TrimPrefix(values[listKey]+","+value, ",")
+			continue
+		}
+
+		if listKey != "" && indent > listIndent {
+			return nil, fmt.Errorf("%s:%d: anidamiento no soportado bajo %s", path, number, listKey)
+		}
+
+		newSection, key, value, err := parse(section, line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, number, err)
+		}
+
+		section, listKey = newSection, ""
+
+		if key == "" {
+			continue
+		}
+
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("%s:%d: clave repetida %s", path, number, key)
+		}
+
+		values[key] = value
+
+		if yaml && value == "" {
+			listKey, listIndent = key, indent
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error al leer el archivo de configuración: %w", err)
+	}
+
+	return values, nil
+}
+
+// parseTOMLLine reads [section] headers and key = value lines.
+func parseTOMLLine(section, line string) (string, string, string, error) {
+	line = strings.TrimSpace(line)
+
+	if strings.HasPrefix(line, "[[") {
+		return section, "", "", fmt.Errorf("tablas de arreglos no soportadas: %s", line)
+	}
+
+	if strings.HasPrefix(line, "[") {
+		if !strings.HasSuffix(line, "]") {
+			return section, "", "", fmt.Errorf("sección inválida: %s", line)
+		}
+
+		return strings.TrimSpace(line[1 : len(line)-1]), "", "", nil
+	}
+
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return section, "", "", fmt.Errorf("se esperaba clave = valor: %s", line)
+	}
+
+	value, err := parseValue(value)
+	if err != nil {
+		return section, "", "", err
+	}
+
+	return section, qualify(section, strings.TrimSpace(key)), value, nil
+}
+
+// parseYAMLLine reads section: lines and the key: value lines indented under
+// them. A key without a value takes the "- item" lines that follow.
+func parseYAMLLine(section, line string) (string, string, string, error) {
+	key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok {
+		return section, "", "", fmt.Errorf("se esperaba clave: valor: %s", strings.TrimSpace(line))
+	}
+
+	key = strings.TrimSpace(key)
+
+	if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+		if strings.TrimSpace(value) == "" {
+			return key, "", "", nil
+		}
+
+		section = ""
+	} else if section == "" {
+		return section, "", "", fmt.Errorf("clave indentada fuera de una sección: %s", key)
+	}
+
+	value, err := parseValue(value)
+	if err != nil {
+		return section, "", "", err
+	}
+
+	return section, qualify(section, key), value, nil
+}
+
+func qualify(section, key string) string {
+	if section == "" {
+		return key
+	}
+
+	return section + "." + key
+}
+
+// parseValue reads a string, quoted or not, or a [list] of them.
+func parseValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	if !strings.HasPrefix(value, "[") {
+		return parseScalar(value)
+	}
+
+	if !strings.HasSuffix(value, "]") {
+		return "", fmt.Errorf("lista inválida: %s", value)
+	}
+
+	var items []string
+
+	for item := range strings.SplitSeq(value[1:len(value)-1], ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		item, err := parseItem(item)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, item)
+	}
+
+	return strings.Join(items, ","), nil
+}
+
+// parseItem reads a list item, which can't be a list itself nor have commas
+// because the items are joined with them.
+func parseItem(value string) (string, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		return "", fmt.Errorf("listas anidadas no soportadas: %s", strings.TrimSpace(value))
+	}
+
+	item, err := parseScalar(value)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(item, ",") {
+		return "", fmt.Errorf("elemento de lista inválido: %s", strings.TrimSpace(value))
+	}
+
+	return item, nil
+}
+
+func parseScalar(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, "'") {
+		if len(value) < 2 || !strings.HasSuffix(value, "'") || strings.Contains(value[1:len(value)-1], "'") {
+			return "", fmt.Errorf("cadena inválida: %s", value)
+		}
+
+		return value[1 : len(value)-1], nil
+	}
+
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("cadena inválida: %s", value)
+		}
+
+		return unquoted, nil
+	}
+
+	if value != "" && strings.ContainsRune("{|>&*!", rune(value[0])) {
+		return "", fmt.Errorf("valor no soportado, use una cadena entre comillas: %s", value)
+	}
+
+	return value, nil
+}
+
+// stripComment drops a # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote rune
+
+	for i, char := range line {
+		switch {
+			case quote != 0:
+				if char == quote && (quote == '\'' || i == 0 || line[i-1] != '\\') {
+					quote = 0
+				}
+			case char == '"' || char == '\'':
+				quote = char
+			case char == '#':
+				return line[:i]
+		}
+	}
+
+	return line
+}
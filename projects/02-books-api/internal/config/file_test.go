@@ -0,0 +1,241 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return path
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]string
+		wantErr string // Part of the error, "" when it must parse
+	}{
+		{
+			name: "yaml sections",
+			file: "config.yaml",
+			content: `
+server:
+  port: 8081 # comment
+  read_timeout: "15s"
+log:
+  path: './api # not a comment.log'
+`,
+			want: map[string]string{"server.port": "8081", "server.read_timeout": "15s", "log.path": "./api # not a comment.log"},
+		},
+		{
+			name: "yaml lists",
+			file: "config.yml",
+			content: `
+cors:
+  origins:
+    - https://a.example.com
+    - "https://b.example.com"
+database:
+  pragmas: [busy_timeout=5000, 'journal_mode=WAL']
+  path: books.db
+`,
+			want: map[string]string{"cors.origins": "https://a.example.com,https://b.example.com", "database.pragmas": "busy_timeout=5000,journal_mode=WAL", "database.path": "books.db"},
+		},
+		{
+			name:    "yaml top level key",
+			file:    "config.yaml",
+			content: "port: 9000\n",
+			want:    map[string]string{"port": "9000"},
+		},
+		{
+			name:    "yaml escaped string",
+			file:    "config.yaml",
+			content: "smtp:\n  from: \"Biblioteca \\\"Central\\\" <a@b.mx>\"\n",
+			want:    map[string]string{"smtp.from": `Biblioteca "Central" <a@b.mx>`},
+		},
+		{
+			name:    "yaml inline table",
+			file:    "config.yaml",
+			content: "server: {port: 8081}\n",
+			wantErr: "valor no soportado",
+		},
+		{
+			name:    "yaml deeper nesting",
+			file:    "config.yaml",
+			content: "server:\n  tls:\n    cert: a.pem\n",
+			wantErr: "anidamiento no soportado",
+		},
+		{
+			name:    "yaml nested list",
+			file:    "config.yaml",
+			content: "cors:\n  origins:\n    - [a, b]\n",
+			wantErr: "listas anidadas",
+		},
+		{
+			name:    "yaml comma inside an item",
+			file:    "config.yaml",
+			content: "cors:\n  origins:\n    - \"a,b\"\n",
+			wantErr: "elemento de lista inválido",
+		},
+		{
+			name:    "yaml block scalar",
+			file:    "config.yaml",
+			content: "smtp:\n  from: |\n    a@b.mx\n",
+			wantErr: "valor no soportado",
+		},
+		{
+			name:    "yaml anchor",
+			file:    "config.yaml",
+			content: "log:\n  path: &path api.log\n",
+			wantErr: "valor no soportado",
+		},
+		{
+			name:    "yaml indented key without section",
+			file:    "config.yaml",
+			content: "  port: 8081\n",
+			wantErr: "fuera de una sección",
+		},
+		{
+			name:    "yaml document marker",
+			file:    "config.yaml",
+			content: "---\nserver:\n  port: 8081\n",
+			wantErr: "config.yaml:1: se esperaba clave: valor",
+		},
+		{
+			name:    "yaml repeated key",
+			file:    "config.yaml",
+			content: "server:\n  port: 1\n  port: 2\n",
+			wantErr: "config.yaml:3: clave repetida server.port",
+		},
+		{
+			name:    "yaml unterminated string",
+			file:    "config.yaml",
+			content: "log:\n  path: \"api.log\n",
+			wantErr: "cadena inválida",
+		},
+		{
+			name: "toml sections",
+			file: "config.toml",
+			content: `
+# comment
+[server]
+port = 8081
+read_timeout = "15s"   # comment
+
+[log]
+path = 'C:\logs\api.log'
+`,
+			want: map[string]string{"server.port": "8081", "server.read_timeout": "15s", "log.path": `C:\logs\api.log`},
+		},
+		{
+			name:    "toml lists",
+			file:    "config.toml",
+			content: "[cors]\norigins = [\"https://a.example.com\", \"https://b.example.com\", ]\n[database]\npragmas = []\n",
+			want:    map[string]string{"cors.origins": "https://a.example.com,https://b.example.com", "database.pragmas": ""},
+		},
+		{
+			name:    "toml list items after an empty value",
+			file:    "config.toml",
+			content: "[cors]\norigins = []\n  - https://a.example.com\n",
+			wantErr: "se esperaba clave = valor",
+		},
+		{
+			name:    "toml inline table",
+			file:    "config.toml",
+			content: "server = { port = 8081 }\n",
+			wantErr: "valor no soportado",
+		},
+		{
+			name:    "toml array of tables",
+			file:    "config.toml",
+			content: "[[server]]\nport = 8081\n",
+			wantErr: "tablas de arreglos",
+		},
+		{
+			name:    "toml nested list",
+			file:    "config.toml",
+			content: "[cors]\norigins = [[\"a\"], \"b\"]\n",
+			wantErr: "listas anidadas",
+		},
+		{
+			name:    "toml comma inside an item",
+			file:    "config.toml",
+			content: "[cors]\norigins = [\"a,b\"]\n",
+			wantErr: "cadena inválida",
+		},
+		{
+			name:    "toml multiline array",
+			file:    "config.toml",
+			content: "[cors]\norigins = [\n  \"a\",\n]\n",
+			wantErr: "lista inválida",
+		},
+		{
+			name:    "toml multiline string",
+			file:    "config.toml",
+			content: "[smtp]\nfrom = \"\"\"a@b.mx\"\"\"\n",
+			wantErr: "cadena inválida",
+		},
+		{
+			name:    "toml text after a literal string",
+			file:    "config.toml",
+			content: "[log]\npath = 'api.log' extra\n",
+			wantErr: "cadena inválida",
+		},
+		{
+			name:    "toml unclosed section",
+			file:    "config.toml",
+			content: "[server\nport = 8081\n",
+			wantErr: "sección inválida",
+		},
+		{
+			name:    "toml missing equals",
+			file:    "config.toml",
+			content: "[server]\nport 8081\n",
+			wantErr: "config.toml:2: se esperaba clave = valor",
+		},
+		{
+			name:    "unknown extension",
+			file:    "config.json",
+			content: "{}",
+			wantErr: "debe ser .yaml, .yml o .toml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := readFile(writeFile(t, tt.file, tt.content))
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("readFile: %v", err)
+			}
+
+			if !maps.Equal(values, tt.want) {
+				t.Errorf("values = %v, want %v", values, tt.want)
+			}
+		})
+	}
+
+	if _, err := readFile(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("reading a missing file succeeded")
+	}
+}
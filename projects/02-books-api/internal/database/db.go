@@ -1,5 +1,12 @@
 package database
 
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
 func GetMigrationSchema() string {
 	query := `
 		-- migrations/schema.sql
@@ -51,7 +58,7 @@ func GetMigrationSchema() string {
 		-- Publishers table
 		CREATE TABLE IF NOT EXISTS publishers (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL,
 			country TEXT,
 			library_id INTEGER NOT NULL,
 			FOREIGN KEY (library_id) REFERENCES libraries(id)
@@ -60,9 +67,13 @@ func GetMigrationSchema() string {
 		-- Categories table
 		CREATE TABLE IF NOT EXISTS categories (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL,
 			description TEXT,
+			parent_id INTEGER,
+			path TEXT NOT NULL DEFAULT '',
+			depth INTEGER NOT NULL DEFAULT 0,
 			library_id INTEGER NOT NULL,
+			FOREIGN KEY (parent_id) REFERENCES categories(id),
 			FOREIGN KEY (library_id) REFERENCES libraries(id)
 		);
 
@@ -216,6 +227,417 @@ func GetMigrationSchema() string {
 			FOREIGN KEY (library_id) REFERENCES libraries(id)
 		);
 
+		-- Bibliographic metadata cache table
+		CREATE TABLE IF NOT EXISTS metadata_cache (
+			isbn TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (isbn, provider)
+		);
+
+		-- Book fields edited by a cataloguer
+		CREATE TABLE IF NOT EXISTS book_edited_fields (
+			book_id INTEGER NOT NULL,
+			field TEXT NOT NULL,
+			edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			PRIMARY KEY (book_id, field),
+			FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Book covers table
+		CREATE TABLE IF NOT EXISTS book_covers (
+			book_id INTEGER PRIMARY KEY,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			etag TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Works table (groups the editions of the same title)
+		CREATE TABLE IF NOT EXISTS works (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			original_title TEXT,
+			description TEXT,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Series table
+		CREATE TABLE IF NOT EXISTS series (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			description TEXT,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Author aliases table (variant names of an authority record)
+		CREATE TABLE IF NOT EXISTS author_aliases (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			author_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Inventory audits table (physical stock-take of a zone or a shelf)
+		CREATE TABLE IF NOT EXISTS inventory_audits (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			zone_id INTEGER,
+			shelf_id INTEGER,
+			status TEXT NOT NULL DEFAULT 'Open',
+			notes TEXT,
+			started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			closed_at TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (zone_id) REFERENCES library_zones(id),
+			FOREIGN KEY (shelf_id) REFERENCES shelves(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Inventory scans table (barcodes read during an audit)
+		CREATE TABLE IF NOT EXISTS inventory_scans (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			audit_id INTEGER NOT NULL,
+			barcode TEXT NOT NULL,
+			shelf_id INTEGER,
+			copy_id INTEGER,
+			scanned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (audit_id) REFERENCES inventory_audits(id) ON DELETE CASCADE,
+			FOREIGN KEY (shelf_id) REFERENCES shelves(id),
+			FOREIGN KEY (copy_id) REFERENCES copies(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Copy events table (append-only history of every copy)
+		CREATE TABLE IF NOT EXISTS copy_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			copy_id INTEGER NOT NULL,
+			event_type TEXT NOT NULL,
+			from_value TEXT,
+			to_value TEXT,
+			librarian_id INTEGER,
+			loan_id INTEGER,
+			audit_id INTEGER,
+			occurred_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (librarian_id) REFERENCES users(id),
+			FOREIGN KEY (loan_id) REFERENCES loans(id),
+			FOREIGN KEY (audit_id) REFERENCES inventory_audits(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Label layouts table (sheets of labels, in millimeters)
+		CREATE TABLE IF NOT EXISTS label_layouts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code TEXT NOT NULL,
+			name TEXT NOT NULL,
+			page_width REAL NOT NULL,
+			page_height REAL NOT NULL,
+			column_count INTEGER NOT NULL,
+			row_count INTEGER NOT NULL,
+			label_width REAL NOT NULL,
+			label_height REAL NOT NULL,
+			margin_top REAL NOT NULL DEFAULT 0,
+			margin_left REAL NOT NULL DEFAULT 0,
+			horizontal_gap REAL NOT NULL DEFAULT 0,
+			vertical_gap REAL NOT NULL DEFAULT 0,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id),
+			UNIQUE(code, library_id)
+		);
+
+		-- Vendors table (acquisitions)
+		CREATE TABLE IF NOT EXISTS vendors (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			contact_name TEXT,
+			email TEXT,
+			phone TEXT,
+			account_number TEXT,
+			notes TEXT,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id),
+			UNIQUE(name, library_id)
+		);
+
+		-- Budgets table (one row per fund and fiscal year)
+		CREATE TABLE IF NOT EXISTS budgets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			fiscal_year INTEGER NOT NULL,
+			amount REAL NOT NULL,
+			notes TEXT,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id),
+			UNIQUE(name, fiscal_year, library_id)
+		);
+
+		-- Purchase orders table
+		CREATE TABLE IF NOT EXISTS purchase_orders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_number TEXT NOT NULL,
+			vendor_id INTEGER NOT NULL,
+			budget_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'Draft',
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			ordered_at TIMESTAMP,
+			closed_at TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (vendor_id) REFERENCES vendors(id),
+			FOREIGN KEY (budget_id) REFERENCES budgets(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id),
+			UNIQUE(order_number, library_id)
+		);
+
+		-- Purchase order lines table
+		CREATE TABLE IF NOT EXISTS purchase_order_lines (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INTEGER NOT NULL,
+			book_id INTEGER,
+			isbn TEXT,
+			title TEXT,
+			quantity INTEGER NOT NULL,
+			quantity_received INTEGER NOT NULL DEFAULT 0,
+			unit_price REAL NOT NULL,
+			notes TEXT,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (order_id) REFERENCES purchase_orders(id) ON DELETE CASCADE,
+			FOREIGN KEY (book_id) REFERENCES books(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Purchase receipts table (the copy created for every unit received)
+		CREATE TABLE IF NOT EXISTS purchase_receipts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			line_id INTEGER NOT NULL,
+			copy_id INTEGER NOT NULL,
+			librarian_id INTEGER,
+			received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (line_id) REFERENCES purchase_order_lines(id),
+			FOREIGN KEY (librarian_id) REFERENCES users(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Invoices table
+		CREATE TABLE IF NOT EXISTS invoices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INTEGER NOT NULL,
+			vendor_id INTEGER NOT NULL,
+			invoice_number TEXT NOT NULL,
+			invoice_date TIMESTAMP,
+			amount REAL NOT NULL,
+			notes TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (order_id) REFERENCES purchase_orders(id),
+			FOREIGN KEY (vendor_id) REFERENCES vendors(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id),
+			UNIQUE(invoice_number, vendor_id, library_id)
+		);
+
+		-- Stats versions table (bumped by triggers to invalidate cached statistics)
+		CREATE TABLE IF NOT EXISTS stats_versions (
+			library_id INTEGER PRIMARY KEY,
+			version INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Report definitions table (registry entries, run on demand or by cron)
+		CREATE TABLE IF NOT EXISTS report_definitions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			report_type TEXT NOT NULL,
+			parameters TEXT NOT NULL DEFAULT '{}',
+			format TEXT NOT NULL DEFAULT 'csv',
+			schedule TEXT,
+			paused BOOLEAN NOT NULL DEFAULT 0,
+			last_run_at TIMESTAMP,
+			next_run_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id),
+			UNIQUE(name, library_id)
+		);
+
+		-- Report runs table (every generated file)
+		CREATE TABLE IF NOT EXISTS report_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			definition_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			report_type TEXT NOT NULL,
+			format TEXT NOT NULL,
+			run_trigger TEXT NOT NULL DEFAULT 'Manual',
+			status TEXT NOT NULL DEFAULT 'Running',
+			file_key TEXT,
+			file_name TEXT,
+			size INTEGER NOT NULL DEFAULT 0,
+			row_count INTEGER NOT NULL DEFAULT 0,
+			error TEXT,
+			started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			finished_at TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (definition_id) REFERENCES report_definitions(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Document templates table (only the ones a library customized)
+		CREATE TABLE IF NOT EXISTS document_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			document_type TEXT NOT NULL,
+			body TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			UNIQUE(document_type, library_id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Notification outbox table (events are written in the same transaction as the change)
+		CREATE TABLE IF NOT EXISTS notification_outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_type TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			subject_id INTEGER,
+			dedup_key TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'Pending',
+			channel TEXT,
+			recipient TEXT,
+			mandatory BOOLEAN NOT NULL DEFAULT 0,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			sent_at TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			UNIQUE(dedup_key, library_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Notification templates table (only the ones a library customized)
+		CREATE TABLE IF NOT EXISTS notification_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_type TEXT NOT NULL,
+			language TEXT NOT NULL DEFAULT 'es',
+			subject TEXT NOT NULL,
+			body TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			UNIQUE(event_type, language, library_id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Notification preferences table (users without preferences get the defaults)
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			channel TEXT NOT NULL DEFAULT 'email',
+			event_types TEXT NOT NULL DEFAULT '[]',
+			language TEXT NOT NULL DEFAULT 'es',
+			quiet_hours_start TEXT,
+			quiet_hours_end TEXT,
+			timezone TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			UNIQUE(user_id, library_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Webhook subscriptions table (event_types is a JSON list)
+		CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			event_types TEXT NOT NULL DEFAULT '[]',
+			description TEXT,
+			active BOOLEAN NOT NULL DEFAULT 1,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Events table (the circulation events, written in the same transaction as the change; the webhooks and the event stream read them)
+		CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_type TEXT NOT NULL,
+			subject_id INTEGER NOT NULL,
+			payload TEXT,
+			status TEXT NOT NULL DEFAULT 'Pending',
+			occurred_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Webhook deliveries table (one per event and subscription)
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subscription_id INTEGER NOT NULL,
+			event_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'Pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_status_code INTEGER,
+			last_error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP,
+			library_id INTEGER NOT NULL,
+			UNIQUE(subscription_id, event_id),
+			FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id),
+			FOREIGN KEY (event_id) REFERENCES events(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Webhook attempts table (every request made for a delivery)
+		CREATE TABLE IF NOT EXISTS webhook_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			delivery_id INTEGER NOT NULL,
+			attempt INTEGER NOT NULL,
+			requested_at TIMESTAMP NOT NULL,
+			status_code INTEGER,
+			response_body TEXT,
+			error TEXT,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
+		-- Audit log table (every change of an entity, chained by hash within each library)
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			request_id TEXT NOT NULL,
+			actor_id INTEGER,
+			remote_addr TEXT,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER,
+			action TEXT NOT NULL,
+			before TEXT,
+			after TEXT,
+			occurred_at TIMESTAMP NOT NULL,
+			prev_hash TEXT NOT NULL,
+			hash TEXT NOT NULL UNIQUE,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (actor_id) REFERENCES users(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		);
+
 		-- Create indexes for better performance
 		CREATE INDEX IF NOT EXISTS idx_libraries_name ON libraries(name);
 		CREATE INDEX IF NOT EXISTS idx_libraries_username ON libraries(username);
@@ -231,12 +653,405 @@ func GetMigrationSchema() string {
 		CREATE INDEX IF NOT EXISTS idx_loans_copy_id ON loans(copy_id);
 		CREATE INDEX IF NOT EXISTS idx_loans_status ON loans(status);
 		CREATE INDEX IF NOT EXISTS idx_loans_due_date ON loans(due_date);
+		CREATE INDEX IF NOT EXISTS idx_loans_loan_date ON loans(loan_date);
 		CREATE INDEX IF NOT EXISTS idx_reservations_user_id ON reservations(user_id);
 		CREATE INDEX IF NOT EXISTS idx_reservations_book_id ON reservations(book_id);
 		CREATE INDEX IF NOT EXISTS idx_reservations_status ON reservations(status);
 		CREATE INDEX IF NOT EXISTS idx_fines_user_id ON fines(user_id);
 		CREATE INDEX IF NOT EXISTS idx_fines_status ON fines(status);
+		CREATE INDEX IF NOT EXISTS idx_author_aliases_author_id ON author_aliases(author_id);
+		CREATE INDEX IF NOT EXISTS idx_inventory_audits_status ON inventory_audits(status);
+		CREATE INDEX IF NOT EXISTS idx_inventory_scans_audit_id ON inventory_scans(audit_id);
+		CREATE INDEX IF NOT EXISTS idx_copy_events_copy_id ON copy_events(copy_id, occurred_at);
+		CREATE INDEX IF NOT EXISTS idx_purchase_orders_budget_id ON purchase_orders(budget_id);
+		CREATE INDEX IF NOT EXISTS idx_purchase_order_lines_order_id ON purchase_order_lines(order_id);
+		CREATE INDEX IF NOT EXISTS idx_purchase_receipts_line_id ON purchase_receipts(line_id);
+		CREATE INDEX IF NOT EXISTS idx_invoices_order_id ON invoices(order_id);
+		CREATE INDEX IF NOT EXISTS idx_report_definitions_next_run_at ON report_definitions(next_run_at);
+		CREATE INDEX IF NOT EXISTS idx_report_runs_definition_id ON report_runs(definition_id);
+		CREATE INDEX IF NOT EXISTS idx_notification_outbox_status ON notification_outbox(status, next_attempt_at);
+		CREATE INDEX IF NOT EXISTS idx_notification_outbox_user_id ON notification_outbox(user_id);
+		CREATE INDEX IF NOT EXISTS idx_events_status ON events(status);
+		CREATE INDEX IF NOT EXISTS idx_events_library ON events(library_id, id);
+		CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status, next_attempt_at);
+		CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id);
+		CREATE INDEX IF NOT EXISTS idx_webhook_attempts_delivery_id ON webhook_attempts(delivery_id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_library ON audit_log(library_id, id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(library_id, entity_type, entity_id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_request_id ON audit_log(request_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_author_aliases_author_name ON author_aliases(author_id, name COLLATE NOCASE);
 	`
 
 	return query
 }
+
+func GetMigrationAlterations() []string {
+	alterations := []string{
+		`ALTER TABLE books ADD COLUMN work_id INTEGER REFERENCES works(id)`,
+		`ALTER TABLE books ADD COLUMN series_id INTEGER REFERENCES series(id)`,
+		`ALTER TABLE books ADD COLUMN volume_number INTEGER`,
+		`ALTER TABLE reservations ADD COLUMN work_id INTEGER REFERENCES works(id)`,
+		`ALTER TABLE books ADD COLUMN classification_scheme TEXT`,
+		`ALTER TABLE books ADD COLUMN classification_number TEXT`,
+		`ALTER TABLE books ADD COLUMN cutter_number TEXT`,
+		`ALTER TABLE books ADD COLUMN call_number TEXT`,
+		`ALTER TABLE authors ADD COLUMN birth_year INTEGER`,
+		`ALTER TABLE authors ADD COLUMN death_year INTEGER`,
+		`CREATE INDEX IF NOT EXISTS idx_books_work_id ON books(work_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_books_series_id ON books(series_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_reservations_work_id ON reservations(work_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_books_call_number ON books(call_number)`,
+		`CREATE INDEX IF NOT EXISTS idx_categories_path ON categories(path)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_library_parent_name ON categories(library_id, COALESCE(parent_id, 0), name COLLATE NOCASE)`,
+		`CREATE INDEX IF NOT EXISTS idx_copies_book_id ON copies(book_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_publishers_library_name ON publishers(library_id, name COLLATE NOCASE)`,
+		`DROP VIEW IF EXISTS book_availability`,
+		bookAvailabilityView,
+		`CREATE TRIGGER IF NOT EXISTS trg_copies_insert_book_status AFTER INSERT ON copies
+		BEGIN
+			UPDATE books SET status = (SELECT status FROM book_availability WHERE book_id = books.id) WHERE id = NEW.book_id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_copies_update_book_status AFTER UPDATE OF status, book_id ON copies
+		BEGIN
+			UPDATE books SET status = (SELECT status FROM book_availability WHERE book_id = books.id) WHERE id IN (OLD.book_id, NEW.book_id);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_copies_delete_book_status AFTER DELETE ON copies
+		BEGIN
+			UPDATE books SET status = (SELECT status FROM book_availability WHERE book_id = books.id) WHERE id = OLD.book_id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_reservations_insert_book_status AFTER INSERT ON reservations
+		BEGIN
+			UPDATE books SET status = (SELECT status FROM book_availability WHERE book_id = books.id) WHERE id = NEW.book_id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_reservations_update_book_status AFTER UPDATE OF status, book_id ON reservations
+		BEGIN
+			UPDATE books SET status = (SELECT status FROM book_availability WHERE book_id = books.id) WHERE id IN (OLD.book_id, NEW.book_id);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_reservations_delete_book_status AFTER DELETE ON reservations
+		BEGIN
+			UPDATE books SET status = (SELECT status FROM book_availability WHERE book_id = books.id) WHERE id = OLD.book_id;
+		END`,
+		`UPDATE books SET status = (SELECT status FROM book_availability WHERE book_id = books.id)`,
+		`CREATE TRIGGER IF NOT EXISTS trg_copy_events_no_update BEFORE UPDATE ON copy_events
+		BEGIN
+			SELECT RAISE(ABORT, 'El historial de copias no se puede modificar');
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_copy_events_no_delete BEFORE DELETE ON copy_events
+		BEGIN
+			SELECT RAISE(ABORT, 'El historial de copias no se puede modificar');
+		END`,
+		`CREATE INDEX IF NOT EXISTS idx_copies_shelf_id ON copies(shelf_id)`,
+		`ALTER TABLE users ADD COLUMN card_expiration TIMESTAMP`,
+		`CREATE TRIGGER IF NOT EXISTS trg_reservations_update_hold_available AFTER UPDATE OF status ON reservations
+		WHEN NEW.status = 'Active' AND OLD.status != 'Active'
+		BEGIN
+			INSERT OR IGNORE INTO notification_outbox (event_type, user_id, subject_id, dedup_key, library_id)
+			VALUES ('hold_available', NEW.user_id, NEW.id, 'hold_available:' || NEW.id, NEW.library_id);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_fines_insert_fine_created AFTER INSERT ON fines
+		WHEN NEW.status = 'Pending'
+		BEGIN
+			INSERT OR IGNORE INTO notification_outbox (event_type, user_id, subject_id, dedup_key, mandatory, library_id)
+			VALUES ('fine_created', NEW.user_id, NEW.id, 'fine_created:' || NEW.id, NEW.reason = 'Loss', NEW.library_id);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_audit_log_no_update BEFORE UPDATE ON audit_log
+		BEGIN
+			SELECT RAISE(ABORT, 'El registro de auditoría no se puede modificar');
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS trg_audit_log_no_delete BEFORE DELETE ON audit_log
+		BEGIN
+			SELECT RAISE(ABORT, 'El registro de auditoría no se puede eliminar');
+		END`,
+	}
+
+	alterations = append(alterations, circulationEventTriggers()...)
+
+	return append(alterations, statsVersionTriggers()...)
+}
+
+// circulationEventTriggers record the circulation events the webhooks and the
+// event stream are fed from.
+func circulationEventTriggers() []string {
+	events := []struct {
+		name      string
+		trigger   string
+		condition string
+	}{
+		{"loan.created", "AFTER INSERT ON loans", ""},
+		{"loan.returned", "AFTER UPDATE OF return_date ON loans", "OLD.return_date IS NULL AND NEW.return_date IS NOT NULL"},
+		{"fine.created", "AFTER INSERT ON fines", ""},
+		{"reservation.ready", "AFTER UPDATE OF status ON reservations", "NEW.status = 'Active' AND OLD.status != 'Active'"},
+		{"user.suspended", "AFTER UPDATE OF status ON users", "NEW.status = 'Suspended' AND OLD.status != 'Suspended'"},
+	}
+
+	var triggers []string
+
+	for _, e := range events {
+		name := strings.ReplaceAll(e.name, ".", "_")
+
+		when := ""
+		if e.condition != "" {
+			when = "WHEN " + e.condition
+		}
+
+		triggers = append(triggers, fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS trg_event_%s %s
+		%s
+		BEGIN
+			INSERT INTO events (event_type, subject_id, library_id) VALUES ('%s', NEW.id, NEW.library_id);
+		END`, name, e.trigger, when, e.name))
+	}
+
+	return triggers
+}
+
+// statsVersionTriggers bump the stats version of the library on every write to
+// the tables the circulation statistics read, so cached results are discarded.
+func statsVersionTriggers() []string {
+	var triggers []string
+
+	for _, table := range []string{"loans", "fines", "users", "book_categories", "copy_events"} {
+		for _, operation := range []string{"INSERT", "UPDATE", "DELETE"} {
+			row := "NEW"
+			if operation == "DELETE" {
+				row = "OLD"
+			}
+
+			triggers = append(triggers, fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS trg_%[1]s_%[2]s_stats_version AFTER %[3]s ON %[1]s
+			BEGIN
+				INSERT OR IGNORE INTO stats_versions (library_id, version) VALUES (%[4]s.library_id, 0);
+				UPDATE stats_versions SET version = version + 1 WHERE library_id = %[4]s.library_id;
+			END`, table, strings.ToLower(operation), operation, row))
+		}
+	}
+
+	return triggers
+}
+
+// book_availability derives the availability of every book from its copies and
+// reservations. An Active reservation holds one of the copies on the shelf.
+// books.status is only a cache of its status column, kept by the triggers.
+const bookAvailabilityView = `
+	CREATE VIEW IF NOT EXISTS book_availability AS
+	SELECT
+		book_id,
+		library_id,
+		total_copies,
+		MAX(shelf_copies - ready_holds, 0) AS available_copies,
+		reserved_copies + MIN(ready_holds, shelf_copies) AS on_hold_copies,
+		on_loan_copies,
+		earliest_due_date,
+		queue_length,
+		CASE
+			WHEN shelf_copies > ready_holds THEN 'Available'
+			WHEN on_loan_copies > 0 THEN 'Borrowed'
+			WHEN reserved_copies + MIN(ready_holds, shelf_copies) > 0 THEN 'Reserved'
+			WHEN damaged_copies > 0 THEN 'Maintenance'
+			ELSE 'Unavailable'
+		END AS status
+	FROM (
+		SELECT
+			b.id AS book_id,
+			b.library_id,
+			(SELECT COUNT(*) FROM copies c WHERE c.book_id = b.id AND c.status != 'Withdrawn') AS total_copies,
+			(SELECT COUNT(*) FROM copies c WHERE c.book_id = b.id AND c.status = 'Available') AS shelf_copies,
+			(SELECT COUNT(*) FROM copies c WHERE c.book_id = b.id AND c.status = 'Reserved') AS reserved_copies,
+			(SELECT COUNT(*) FROM copies c WHERE c.book_id = b.id AND c.status = 'Borrowed') AS on_loan_copies,
+			(SELECT COUNT(*) FROM copies c WHERE c.book_id = b.id AND c.status = 'Damaged') AS damaged_copies,
+			(SELECT COUNT(*) FROM reservations r WHERE r.book_id = b.id AND r.status = 'Active') AS ready_holds,
+			(
+				SELECT MIN(l.due_date) FROM loans l
+				INNER JOIN copies c ON c.id = l.copy_id
+				WHERE c.book_id = b.id AND l.status IN ('Active', 'Overdue')
+			) AS earliest_due_date,
+			(
+				SELECT COUNT(*) FROM reservations r
+				WHERE r.status = 'Pending' AND ((r.book_id = b.id AND r.work_id IS NULL) OR r.work_id = b.work_id)
+			) AS queue_length
+		FROM books b
+	)
+`
+
+// SchemaVersion is stored as the user_version of the database once the
+// migrations are applied, so readiness can tell a database migrated by a newer
+// build. It has to be raised with every change to the schema or its
+// alterations.
+const SchemaVersion = 2
+
+var schemaObjectPattern = regexp.MustCompile(`CREATE (?:UNIQUE )?(TABLE|VIEW|TRIGGER|INDEX) IF NOT EXISTS (\w+)`)
+
+// SchemaObjects returns the type of every table, view, trigger and index the
+// migrations create, by name.
+func SchemaObjects() map[string]string {
+	objects := map[string]string{}
+
+	statements := append([]string{GetMigrationSchema()}, GetMigrationAlterations()...)
+
+	for _, statement := range statements {
+		for _, match := range schemaObjectPattern.FindAllStringSubmatch(statement, -1) {
+			objects[match[2]] = strings.ToLower(match[1])
+		}
+	}
+
+	return objects
+}
+
+// ApplyMigrationAlterations brings the schema up to SchemaVersion. A database
+// already migrated by a newer build is left as it is, its alterations could
+// undo the newer ones; readiness reports it.
+func ApplyMigrationAlterations(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	if version > SchemaVersion {
+		return nil
+	}
+
+	if err := migrateCategoriesTable(db); err != nil {
+		return err
+	}
+
+	if err := migratePublishersTable(db); err != nil {
+		return err
+	}
+
+	if err := migrateCopiesShelf(db); err != nil {
+		return err
+	}
+
+	for _, alteration := range GetMigrationAlterations() {
+		_, err := db.Exec(alteration)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return err
+		}
+	}
+
+	_, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion))
+	return err
+}
+
+// Older databases declared categories.name as globally UNIQUE, which SQLite
+// can only drop by rebuilding the table.
+func migrateCategoriesTable(db *sql.DB) error {
+	var definition string
+
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'categories'`).Scan(&definition)
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(definition, "name TEXT UNIQUE NOT NULL") {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE categories_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			description TEXT,
+			parent_id INTEGER,
+			path TEXT NOT NULL DEFAULT '',
+			depth INTEGER NOT NULL DEFAULT 0,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (parent_id) REFERENCES categories(id),
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		)`,
+		`INSERT INTO categories_new (id, name, description, parent_id, path, depth, library_id)
+			SELECT id, name, description, NULL, '/' || id || '/', 0, library_id FROM categories`,
+		`DROP TABLE categories`,
+		`ALTER TABLE categories_new RENAME TO categories`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Older databases declared publishers.name as globally UNIQUE, so two
+// libraries could not share a publisher.
+func migratePublishersTable(db *sql.DB) error {
+	var definition string
+
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'publishers'`).Scan(&definition)
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(definition, "name TEXT UNIQUE NOT NULL") {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE publishers_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			country TEXT,
+			library_id INTEGER NOT NULL,
+			FOREIGN KEY (library_id) REFERENCES libraries(id)
+		)`,
+		`INSERT INTO publishers_new (id, name, country, library_id) SELECT id, name, country, library_id FROM publishers`,
+		`DROP TABLE publishers`,
+		`ALTER TABLE publishers_new RENAME TO publishers`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Copies used to be located through the shelf of their book. The first time
+// copies get their own shelf_id it is filled from the book, only once, so
+// copies later taken off a shelf are not put back on every start.
+func migrateCopiesShelf(db *sql.DB) error {
+	var count int
+
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('copies') WHERE name = 'shelf_id'`).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`ALTER TABLE copies ADD COLUMN shelf_id INTEGER REFERENCES shelves(id)`,
+		`ALTER TABLE copies ADD COLUMN temporary_location TEXT`,
+		`UPDATE copies SET shelf_id = (SELECT shelf_id FROM books WHERE books.id = copies.book_id)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
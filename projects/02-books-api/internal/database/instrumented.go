@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/metrics"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Open opens the SQLite database timing every query by the store method that
+// made it. The time of a query lasts until its rows are closed, as SQLite
+// runs it while they are read. The pragmas, as busy_timeout=5000, are run on
+// every new connection since most of them only apply to their own.
+func Open(dataSourceName string, pragmas ...string) *sql.DB {
+	return sql.OpenDB(&instrumentedConnector{
+		driver:  &sqlite3.SQLiteDriver{},
+		dsn:     dataSourceName,
+		pragmas: pragmas,
+	})
+}
+
+type instrumentedConnector struct {
+	driver  driver.Driver
+	dsn     string
+	pragmas []string
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		for _, pragma := range c.pragmas {
+			if _, err := execer.ExecContext(ctx, "PRAGMA "+pragma, nil); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("Error al aplicar PRAGMA %s: %w", pragma, err)
+			}
+		}
+	}
+
+	return &instrumentedConn{conn}, nil
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedStmt{stmt}, nil
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start, method := time.Now(), queryMethod()
+
+	result, err := execer.ExecContext(ctx, query, args)
+	observeQuery(method, start, err)
+
+	return result, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start, method := time.Now(), queryMethod()
+
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		observeQuery(method, start, err)
+		return nil, err
+	}
+
+	return &instrumentedRows{Rows: rows, method: method, start: start}, nil
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+type instrumentedStmt struct {
+	driver.Stmt
+}
+
+func (s *instrumentedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start, method := time.Now(), queryMethod()
+
+	result, err := s.Stmt.Exec(args)
+	observeQuery(method, start, err)
+
+	return result, err
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return s.Exec(namedValues(args))
+	}
+
+	start, method := time.Now(), queryMethod()
+
+	result, err := execer.ExecContext(ctx, args)
+	observeQuery(method, start, err)
+
+	return result, err
+}
+
+func (s *instrumentedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start, method := time.Now(), queryMethod()
+
+	rows, err := s.Stmt.Query(args)
+	if err != nil {
+		observeQuery(method, start, err)
+		return nil, err
+	}
+
+	return &instrumentedRows{Rows: rows, method: method, start: start}, nil
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return s.Query(namedValues(args))
+	}
+
+	start, method := time.Now(), queryMethod()
+
+	rows, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		observeQuery(method, start, err)
+		return nil, err
+	}
+
+	return &instrumentedRows{Rows: rows, method: method, start: start}, nil
+}
+
+type instrumentedRows struct {
+	driver.Rows
+	method string
+	start  time.Time
+	err    error
+	closed bool
+}
+
+func (r *instrumentedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+
+	return err
+}
+
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+
+	if !r.closed {
+		r.closed = true
+		observeQuery(r.method, r.start, r.err)
+	}
+
+	return err
+}
+
+func observeQuery(method string, start time.Time, err error) {
+	metrics.DBQueryDuration.Observe(time.Since(start).Seconds(), method)
+
+	if err != nil {
+		metrics.DBQueryErrors.Inc(method)
+	}
+}
+
+const storePackage = "/internal/store."
+
+// queryMethod names the store method running the query, as LoanStore.GetByID;
+// queries made outside the stores, as the migrations, are named other.
+func queryMethod() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	for {
+		frame, more := frames.Next()
+
+		if _, name, ok := strings.Cut(frame.Function, storePackage); ok {
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+
+			// Closures are named after the function that holds them
+			for closure := strings.LastIndex(name, ".func"); closure != -1; closure = strings.LastIndex(name, ".func") {
+				name = name[:closure]
+			}
+
+			return name
+		}
+
+		if !more {
+			return "other"
+		}
+	}
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	return values
+}
@@ -3,7 +3,10 @@ package database
 import (
 	"database/sql"
 	"encoding/json"
+	"strings"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 type NullString struct {
@@ -117,3 +120,30 @@ func (nt *NullTime) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// Scan also accepts the text form SQLite returns for computed columns, such
+// as MIN(due_date), which carry no declared TIMESTAMP type.
+func (nt *NullTime) Scan(value any) error {
+	var text string
+
+	switch v := value.(type) {
+		case string:
+			text = v
+		case []byte:
+			text = string(v)
+		default:
+			return nt.NullTime.Scan(value)
+	}
+
+	text = strings.TrimSuffix(text, "Z")
+
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.ParseInLocation(layout, text, time.UTC); err == nil {
+			nt.Valid = true
+			nt.Time = t
+			return nil
+		}
+	}
+
+	return nt.NullTime.Scan(value)
+}
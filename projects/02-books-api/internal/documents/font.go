@@ -0,0 +1,119 @@
+package documents
+
+// font is one of the standard Type 1 fonts every PDF reader ships with, so
+// nothing has to be embedded. Widths are in thousandths of the font size and
+// indexed by the WinAnsi byte of each character.
+type font struct {
+	baseFont string
+	resource string
+	widths   [256]uint16
+}
+
+var (
+	regularFont = newFont("Helvetica", "F1", helveticaASCII, helveticaLatin1)
+	boldFont    = newFont("Helvetica-Bold", "F2", helveticaBoldASCII, helveticaBoldLatin1)
+)
+
+// Widths of the characters 32 to 126
+var helveticaASCII = []uint16{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldASCII = []uint16{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// Widths of the characters 160 to 255, which WinAnsi shares with Latin-1
+var helveticaLatin1 = []uint16{
+	278, 333, 556, 556, 556, 556, 260, 556, 333, 737, 370, 556, 584, 333, 737, 333,
+	400, 584, 333, 333, 333, 556, 537, 278, 333, 333, 365, 556, 834, 834, 834, 611,
+	667, 667, 667, 667, 667, 667, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278,
+	722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611,
+	556, 556, 556, 556, 556, 556, 889, 500, 556, 556, 556, 556, 278, 278, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 584, 611, 556, 556, 556, 556, 500, 556, 500,
+}
+
+var helveticaBoldLatin1 = []uint16{
+	278, 333, 556, 556, 556, 556, 280, 556, 333, 737, 370, 556, 584, 333, 737, 333,
+	400, 584, 333, 333, 333, 611, 556, 278, 333, 333, 365, 556, 834, 834, 834, 611,
+	722, 722, 722, 722, 722, 722, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278,
+	722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611,
+	556, 556, 556, 556, 556, 556, 889, 556, 556, 556, 556, 556, 278, 278, 278, 278,
+	611, 611, 611, 611, 611, 611, 611, 584, 611, 611, 611, 611, 611, 556, 611, 556,
+}
+
+// WinAnsi bytes 128 to 159 that differ from Latin-1
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+var winAnsiExtraWidths = map[byte]uint16{
+	0x80: 556, 0x82: 222, 0x83: 556, 0x84: 333, 0x85: 1000, 0x86: 556, 0x87: 556,
+	0x88: 333, 0x89: 1000, 0x8A: 667, 0x8B: 333, 0x8C: 1000, 0x8E: 611,
+	0x91: 222, 0x92: 222, 0x93: 333, 0x94: 333, 0x95: 350, 0x96: 556, 0x97: 1000,
+	0x98: 333, 0x99: 1000, 0x9A: 500, 0x9B: 333, 0x9C: 944, 0x9E: 500, 0x9F: 667,
+}
+
+func newFont(baseFont, resource string, ascii, latin1 []uint16) *font {
+	f := &font{baseFont: baseFont, resource: resource}
+
+	for i := range f.widths {
+		f.widths[i] = 556
+	}
+
+	copy(f.widths[32:], ascii)
+	copy(f.widths[160:], latin1)
+
+	for b, width := range winAnsiExtraWidths {
+		f.widths[b] = width
+	}
+
+	return f
+}
+
+// width returns the width in points of the text at the given size.
+func (f *font) width(text string, size float64) float64 {
+	var total int
+
+	for _, b := range encodeWinAnsi(text) {
+		total += int(f.widths[b])
+	}
+
+	return float64(total) * size / 1000
+}
+
+// encodeWinAnsi converts the text to the encoding of the standard fonts.
+// Characters outside of it are replaced by a question mark.
+func encodeWinAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+
+	for _, r := range text {
+		switch {
+			case r < 32:
+				encoded = append(encoded, ' ')
+			case r < 127 || (r >= 0xA0 && r <= 0xFF):
+				encoded = append(encoded, byte(r))
+			default:
+				if b, ok := winAnsiExtras[r]; ok {
+					encoded = append(encoded, b)
+				} else {
+					encoded = append(encoded, '?')
+				}
+		}
+	}
+
+	return encoded
+}
@@ -0,0 +1,199 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// pdfFile collects the objects of a PDF 1.4 file. Objects are numbered from 1
+// in the order they are allocated, so they can reference each other before
+// their content is known.
+type pdfFile struct {
+	objects [][]byte
+}
+
+func (f *pdfFile) alloc() int {
+	f.objects = append(f.objects, nil)
+	return len(f.objects)
+}
+
+func (f *pdfFile) set(ref int, object []byte) {
+	f.objects[ref-1] = object
+}
+
+func (f *pdfFile) add(object []byte) int {
+	ref := f.alloc()
+	f.set(ref, object)
+	return ref
+}
+
+func (f *pdfFile) addStream(dict string, data []byte) (int, error) {
+	var compressed bytes.Buffer
+
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return 0, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	var object bytes.Buffer
+	fmt.Fprintf(&object, "<< %s /Filter /FlateDecode /Length %d >>\nstream\n", dict, compressed.Len())
+	object.Write(compressed.Bytes())
+	object.WriteString("\nendstream")
+
+	return f.add(object.Bytes()), nil
+}
+
+// addImage stores the image as 8 bit RGB samples, flattening any transparency
+// over white.
+func (f *pdfFile) addImage(img image.Image) (int, error) {
+	bounds := img.Bounds()
+	samples := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			white := 0xFFFF - a
+
+			samples = append(samples, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	dict := fmt.Sprintf(
+		"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		bounds.Dx(), bounds.Dy(),
+	)
+
+	return f.addStream(dict, samples)
+}
+
+func (f *pdfFile) bytes(root, info int) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	offsets := make([]int, len(f.objects))
+
+	for i, object := range f.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(object)
+		buf.WriteString("\nendobj\n")
+	}
+
+	xref := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(f.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(f.objects)+1, root, info, xref)
+
+	return buf.Bytes()
+}
+
+// canvas writes the content stream of a page. Coordinates are in points from
+// the top left corner of the page, converted to PDF space on output.
+type canvas struct {
+	content bytes.Buffer
+	height  float64
+}
+
+func (c *canvas) text(x, top float64, f *font, size, gray float64, text string) {
+	if text == "" {
+		return
+	}
+
+	fmt.Fprintf(
+		&c.content,
+		"BT /%s %s Tf %s g %s %s Td (%s) Tj ET\n",
+		f.resource, num(size), num(gray), num(x), num(c.height-top-size*0.8), escapePDFString(encodeWinAnsi(text)),
+	)
+}
+
+func (c *canvas) line(x1, top1, x2, top2, width, gray float64) {
+	fmt.Fprintf(
+		&c.content,
+		"%s w %s G %s %s m %s %s l S\n",
+		num(width), num(gray), num(x1), num(c.height-top1), num(x2), num(c.height-top2),
+	)
+}
+
+func (c *canvas) rect(x, top, width, height, gray float64) {
+	fmt.Fprintf(
+		&c.content,
+		"%s g %s %s %s %s re f\n",
+		num(gray), num(x), num(c.height-top-height), num(width), num(height),
+	)
+}
+
+func (c *canvas) image(name string, x, top, width, height float64) {
+	fmt.Fprintf(
+		&c.content,
+		"q %s 0 0 %s %s %s cm /%s Do Q\n",
+		num(width), num(height), num(x), num(c.height-top-height), name,
+	)
+}
+
+func num(value float64) string {
+	s := strconv.FormatFloat(value, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+
+	if s == "" || s == "-" {
+		return "0"
+	}
+
+	return s
+}
+
+func escapePDFString(data []byte) string {
+	var buf strings.Builder
+
+	for _, b := range data {
+		switch b {
+			case '\\', '(', ')':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			default:
+				buf.WriteByte(b)
+		}
+	}
+
+	return buf.String()
+}
+
+// pdfTextString encodes text for the document information dictionary, which
+// accepts UTF-16 with a byte order mark.
+func pdfTextString(text string) string {
+	var buf strings.Builder
+
+	buf.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&buf, "%04X", unit)
+	}
+	buf.WriteString(">")
+
+	return buf.String()
+}
+
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	return fmt.Sprintf("(D:%s%s%02d'%02d')", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
@@ -0,0 +1,530 @@
+package documents
+
+import (
+	"fmt"
+	"image"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Letter size, the paper the desks print on
+const (
+	pageWidth  = 612.0
+	pageHeight = 792.0
+	margin     = 54.0
+
+	bodySize   = 10.0
+	smallSize  = 8.0
+	tableSize  = 9.0
+	cellMargin = 4.0
+	bulletGap  = 12.0
+)
+
+var numericCellRegex = regexp.MustCompile(`^[-+]?\$?[0-9][0-9.,]*%?$`)
+
+// Branding is the letterhead printed at the top of every page.
+type Branding struct {
+	Name  string
+	Lines []string // Address and contact lines under the name
+	Logo  image.Image
+}
+
+type word struct {
+	text  string
+	bold  bool
+	space bool // Separated from the previous word
+}
+
+type layout struct {
+	branding Branding
+	logo     string
+	pages    []*canvas
+	page     *canvas
+	top      float64
+	table    [][]string
+	blank    bool
+}
+
+// Render lays out the markup produced by a document template and returns the
+// PDF. Each line of the markup is a block:
+//
+//	# Title
+//	## Heading
+//	---                  horizontal rule
+//	* item               bullet, also "- item"
+//	>> text              right aligned text
+//	~ text               small print
+//	| a | b | c |        table row, the first row of a table is its header
+//	text                 paragraph
+//
+// Text can contain **bold** runs, and a backslash escapes the next character.
+// Blank lines add a little vertical space.
+func Render(title, markup string, branding Branding, now time.Time) ([]byte, error) {
+	l := &layout{branding: branding}
+	if branding.Logo != nil {
+		l.logo = "Im1"
+	}
+
+	l.newPage()
+
+	for _, line := range strings.Split(markup, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "|") {
+			if row := splitRow(line); row != nil {
+				l.table = append(l.table, row)
+			}
+
+			continue
+		}
+
+		// Template actions on their own lines leave blanks between the rows
+		if line == "" && len(l.table) > 0 {
+			continue
+		}
+
+		l.flushTable()
+
+		switch {
+			case line == "":
+				if !l.blank {
+					l.top += bodySize * 0.6
+				}
+
+				l.blank = true
+				continue
+			case line == "---":
+				l.ensure(12)
+				l.page.line(margin, l.top+6, pageWidth-margin, l.top+6, 0.75, 0.6)
+				l.top += 12
+			case strings.HasPrefix(line, "## "):
+				l.top += 4
+				l.paragraph(parseInline(line[3:], true), 12, 0, 0, false)
+				l.top += 2
+			case strings.HasPrefix(line, "# "):
+				l.paragraph(parseInline(line[2:], true), 16, 0, 0, false)
+				l.top += 6
+			case strings.HasPrefix(line, "* "), strings.HasPrefix(line, "- "):
+				l.ensure(bodySize * 1.4)
+				l.page.text(margin+2, l.top, regularFont, bodySize, 0, "•")
+				l.paragraph(parseInline(line[2:], false), bodySize, bulletGap, 0, false)
+			case strings.HasPrefix(line, ">> "):
+				l.paragraph(parseInline(line[3:], false), bodySize, 0, 0, true)
+			case strings.HasPrefix(line, "~ "):
+				l.paragraph(parseInline(line[2:], false), smallSize, 0, 0.4, false)
+			default:
+				l.paragraph(parseInline(line, false), bodySize, 0, 0, false)
+		}
+
+		l.blank = false
+	}
+
+	l.flushTable()
+
+	return l.write(title, now)
+}
+
+func (l *layout) newPage() {
+	l.page = &canvas{height: pageHeight}
+	l.pages = append(l.pages, l.page)
+
+	top := margin
+	textLeft := margin
+	headerHeight := 0.0
+
+	if l.logo != "" {
+		bounds := l.branding.Logo.Bounds()
+
+		height := 48.0
+		width := height * float64(bounds.Dx()) / float64(bounds.Dy())
+		if width > 120 {
+			width = 120
+			height = width * float64(bounds.Dy()) / float64(bounds.Dx())
+		}
+
+		l.page.image(l.logo, margin, top, width, height)
+
+		textLeft += width + 12
+		headerHeight = height
+	}
+
+	textWidth := pageWidth - margin - textLeft
+	textTop := top
+
+	l.page.text(textLeft, textTop, boldFont, 14, 0, fitText(l.branding.Name, boldFont, 14, textWidth))
+	textTop += 18
+
+	for _, line := range l.branding.Lines {
+		l.page.text(textLeft, textTop, regularFont, 8.5, 0.35, fitText(line, regularFont, 8.5, textWidth))
+		textTop += 11
+	}
+
+	headerHeight = max(headerHeight, textTop-top)
+
+	l.page.line(margin, top+headerHeight+8, pageWidth-margin, top+headerHeight+8, 1, 0)
+	l.top = top + headerHeight + 22
+	l.blank = true
+}
+
+// ensure starts a new page when the next height does not fit above the footer.
+func (l *layout) ensure(height float64) bool {
+	if l.top+height <= pageHeight-margin-smallSize*2 {
+		return false
+	}
+
+	l.newPage()
+	return true
+}
+
+func (l *layout) paragraph(words []word, size, indent, gray float64, right bool) {
+	width := pageWidth - 2*margin - indent
+	leading := size * 1.4
+
+	for _, line := range wrapWords(words, size, width) {
+		l.ensure(leading)
+
+		x := margin + indent
+		if right {
+			x = pageWidth - margin - lineWidth(line, size)
+		}
+
+		// Consecutive words in the same font go out as a single string
+		var run strings.Builder
+		runFont := wordFont(line[0])
+
+		for i, w := range line {
+			f := wordFont(w)
+
+			if f != runFont {
+				l.page.text(x, l.top, runFont, size, gray, run.String())
+				x += runFont.width(run.String(), size)
+				run.Reset()
+				runFont = f
+			}
+
+			if i > 0 && w.space {
+				run.WriteString(" ")
+			}
+
+			run.WriteString(w.text)
+		}
+
+		l.page.text(x, l.top, runFont, size, gray, run.String())
+
+		l.top += leading
+	}
+}
+
+// flushTable draws the pending table rows. Columns take the width of their
+// content, the widest ones shrink when the table does not fit and their cells
+// are cut.
+func (l *layout) flushTable() {
+	if len(l.table) == 0 {
+		return
+	}
+
+	rows := l.table
+	l.table = nil
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	natural := make([]float64, columns)
+	for i, row := range rows {
+		f := regularFont
+		if i == 0 {
+			f = boldFont
+		}
+
+		for c, cell := range row {
+			natural[c] = max(natural[c], f.width(cell, tableSize)+2*cellMargin)
+		}
+	}
+
+	var total float64
+	for _, width := range natural {
+		total += width
+	}
+
+	available := pageWidth - 2*margin
+	widths := make([]float64, columns)
+
+	if total <= available {
+		for c := range natural {
+			widths[c] = natural[c] * available / total
+		}
+	} else {
+		// Only the widest columns give up space: every column is capped at
+		// the same width, chosen so that the table fits
+		sorted := slices.Clone(natural)
+		slices.Sort(sorted)
+
+		limit, used := 0.0, 0.0
+		for i, width := range sorted {
+			limit = (available - used) / float64(len(sorted)-i)
+			if width > limit {
+				break
+			}
+
+			used += width
+		}
+
+		for c := range natural {
+			widths[c] = min(natural[c], limit)
+		}
+	}
+
+	rowHeight := tableSize * 1.8
+
+	drawRow := func(row []string, header bool) {
+		f := regularFont
+		if header {
+			f = boldFont
+			l.page.rect(margin, l.top, available, rowHeight, 0.9)
+		}
+
+		x := margin
+		for c := 0; c < columns; c++ {
+			if c < len(row) {
+				text := fitText(row[c], f, tableSize, widths[c]-2*cellMargin)
+
+				textX := x + cellMargin
+				if !header && numericCellRegex.MatchString(row[c]) {
+					textX = x + widths[c] - cellMargin - f.width(text, tableSize)
+				}
+
+				l.page.text(textX, l.top+(rowHeight-tableSize)/2, f, tableSize, 0, text)
+			}
+
+			x += widths[c]
+		}
+
+		l.page.line(margin, l.top+rowHeight, pageWidth-margin, l.top+rowHeight, 0.5, 0.75)
+		l.top += rowHeight
+	}
+
+	l.ensure(rowHeight * 2)
+	drawRow(rows[0], true)
+
+	for _, row := range rows[1:] {
+		if l.ensure(rowHeight) {
+			drawRow(rows[0], true)
+		}
+
+		drawRow(row, false)
+	}
+
+	l.top += bodySize * 0.6
+	l.blank = true
+}
+
+func (l *layout) write(title string, now time.Time) ([]byte, error) {
+	file := &pdfFile{}
+
+	catalog := file.alloc()
+	pagesRef := file.alloc()
+	regular := file.add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", regularFont.baseFont)))
+	bold := file.add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", boldFont.baseFont)))
+
+	resources := fmt.Sprintf("/Font << /%s %d 0 R /%s %d 0 R >>", regularFont.resource, regular, boldFont.resource, bold)
+
+	if l.logo != "" {
+		logo, err := file.addImage(l.branding.Logo)
+		if err != nil {
+			return nil, err
+		}
+
+		resources += fmt.Sprintf(" /XObject << /%s %d 0 R >>", l.logo, logo)
+	}
+
+	kids := make([]string, 0, len(l.pages))
+
+	for i, page := range l.pages {
+		footer := fmt.Sprintf("Página %d de %d", i+1, len(l.pages))
+		page.text(
+			(pageWidth-regularFont.width(footer, smallSize))/2, pageHeight-margin, regularFont, smallSize, 0.4, footer,
+		)
+
+		content, err := file.addStream("", page.content.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		ref := file.add([]byte(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			pagesRef, num(pageWidth), num(pageHeight), resources, content,
+		)))
+
+		kids = append(kids, fmt.Sprintf("%d 0 R", ref))
+	}
+
+	file.set(catalog, []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesRef)))
+	file.set(pagesRef, []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))))
+
+	info := file.add([]byte(fmt.Sprintf(
+		"<< /Title %s /Author %s /Producer (books-api) /CreationDate %s >>",
+		pdfTextString(title), pdfTextString(l.branding.Name), pdfDate(now),
+	)))
+
+	return file.bytes(catalog, info), nil
+}
+
+// parseInline splits the text into words, toggling bold at each "**".
+func parseInline(text string, bold bool) []word {
+	var words []word
+	var current strings.Builder
+
+	space := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, word{text: current.String(), bold: bold, space: space && len(words) > 0})
+			current.Reset()
+			space = false
+		}
+	}
+
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		switch {
+			case runes[i] == '\\' && i+1 < len(runes):
+				i++
+				current.WriteRune(runes[i])
+			case runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '*':
+				flush()
+				bold = !bold
+				i++
+			case runes[i] == ' ' || runes[i] == '\t':
+				flush()
+				space = true
+			default:
+				current.WriteRune(runes[i])
+		}
+	}
+
+	flush()
+
+	return words
+}
+
+// splitRow returns the cells of a table row, or nil for the separator rows
+// written as "|---|---|".
+func splitRow(line string) []string {
+	var cells []string
+	var current strings.Builder
+
+	runes := []rune(strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|"))
+	if len(runes) > 0 && runes[len(runes)-1] == '\\' {
+		runes = append(runes, '|')
+	}
+
+	for i := 0; i < len(runes); i++ {
+		switch {
+			case runes[i] == '\\' && i+1 < len(runes):
+				i++
+				current.WriteRune(runes[i])
+			case runes[i] == '|':
+				cells = append(cells, strings.TrimSpace(current.String()))
+				current.Reset()
+			default:
+				current.WriteRune(runes[i])
+		}
+	}
+
+	cells = append(cells, strings.TrimSpace(current.String()))
+
+	separator := true
+	for _, cell := range cells {
+		if strings.Trim(cell, ":-") != "" || cell == "" {
+			separator = false
+			break
+		}
+	}
+
+	if separator {
+		return nil
+	}
+
+	return cells
+}
+
+func wrapWords(words []word, size, width float64) [][]word {
+	var lines [][]word
+	var line []word
+	var lineWidth float64
+
+	for _, w := range words {
+		f := wordFont(w)
+		wordWidth := f.width(w.text, size)
+
+		if len(line) > 0 && w.space {
+			wordWidth += f.width(" ", size)
+		}
+
+		if len(line) > 0 && lineWidth+wordWidth > width {
+			lines = append(lines, line)
+			line, lineWidth = nil, 0
+			wordWidth = f.width(w.text, size)
+		}
+
+		if len(line) == 0 {
+			w.text = fitText(w.text, f, size, width)
+		}
+
+		line = append(line, w)
+		lineWidth += wordWidth
+	}
+
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func lineWidth(line []word, size float64) float64 {
+	var width float64
+
+	for i, w := range line {
+		f := wordFont(w)
+
+		if i > 0 && w.space {
+			width += f.width(" ", size)
+		}
+
+		width += f.width(w.text, size)
+	}
+
+	return width
+}
+
+func wordFont(w word) *font {
+	if w.bold {
+		return boldFont
+	}
+
+	return regularFont
+}
+
+// fitText cuts the text with an ellipsis so it is no wider than width.
+func fitText(text string, f *font, size, width float64) string {
+	if f.width(text, size) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && f.width(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	if len(runes) == 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(string(runes)) + "…"
+}
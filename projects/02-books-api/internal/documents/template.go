@@ -0,0 +1,98 @@
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var markupEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, `*`, `\*`)
+
+var templateFuncs = template.FuncMap{
+	"date":     formatDate("02/01/2006"),
+	"datetime": formatDate("02/01/2006 15:04"),
+	"money":    formatMoney,
+	"upper":    strings.ToUpper,
+	"plural": func(count int, singular, plural string) string {
+		if count == 1 {
+			return singular
+		}
+
+		return plural
+	},
+}
+
+// Parse compiles a document template, the notifications use it too. Besides
+// the text/template builtins the templates can use date, datetime, money,
+// upper and plural.
+func Parse(name, body string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("La plantilla es inválida: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// Execute runs the template and returns the markup for Render.
+func Execute(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("Error al aplicar la plantilla: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// Escape makes a value safe to place in the markup: it keeps it on one line
+// and escapes the characters that would open a table cell or a bold run.
+func Escape(value string) string {
+	return markupEscaper.Replace(strings.Join(strings.Fields(value), " "))
+}
+
+func formatDate(layout string) func(any) string {
+	return func(value any) string {
+		switch t := value.(type) {
+			case time.Time:
+				if t.IsZero() {
+					return ""
+				}
+
+				return t.Format(layout)
+			case *time.Time:
+				if t == nil || t.IsZero() {
+					return ""
+				}
+
+				return t.Format(layout)
+			default:
+				return ""
+		}
+	}
+}
+
+// formatMoney writes the amount with two decimals and thousands separators,
+// like $1,234.50.
+func formatMoney(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	whole, decimals, _ := strings.Cut(fmt.Sprintf("%.2f", amount), ".")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+
+		grouped.WriteRune(digit)
+	}
+
+	return fmt.Sprintf("%s$%s.%s", sign, grouped.String(), decimals)
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Table is a report ready to be written to a file. Cells hold strings,
+// integers, floats, times or nil.
+type Table struct {
+	Columns []string
+	Rows    [][]any
+}
+
+type Format struct {
+	Extension   string
+	ContentType string
+	write       func(w io.Writer, table *Table) error
+}
+
+var Formats = map[string]Format{
+	"csv": {
+		Extension:   "csv",
+		ContentType: "text/csv; charset=utf-8",
+		write:       writeCSV,
+	},
+	"json": {
+		Extension:   "json",
+		ContentType: "application/json",
+		write:       writeJSON,
+	},
+	"xlsx": {
+		Extension:   "xlsx",
+		ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		write:       writeXLSX,
+	},
+}
+
+func Write(w io.Writer, format string, table *Table) error {
+	f, ok := Formats[format]
+	if !ok {
+		return fmt.Errorf("Formato de exportación desconocido: %s", format)
+	}
+
+	return f.write(w, table)
+}
+
+func writeCSV(w io.Writer, table *Table) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(table.Columns); err != nil {
+		return err
+	}
+
+	for _, row := range table.Rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// writeJSON writes an array with one object per row keyed by column.
+func writeJSON(w io.Writer, table *Table) error {
+	records := make([]map[string]any, 0, len(table.Rows))
+
+	for _, row := range table.Rows {
+		record := make(map[string]any, len(table.Columns))
+		for i, column := range table.Columns {
+			record[column] = row[i]
+		}
+
+		records = append(records, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(records)
+}
+
+func formatCell(cell any) string {
+	switch value := cell.(type) {
+		case nil:
+			return ""
+		case string:
+			return value
+		case int:
+			return strconv.Itoa(value)
+		case int64:
+			return strconv.FormatInt(value, 10)
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		case time.Time:
+			return value.Format(time.DateTime)
+		default:
+			return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// The smallest package Excel and LibreOffice open: one sheet with inline
+// strings, a bold header and a date-time style.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Reporte" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`
+)
+
+const (
+	xlsxStyleHeader = 1
+	xlsxStyleDate   = 2
+)
+
+// Day zero of the spreadsheet date serials
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func writeXLSX(w io.Writer, table *Table) error {
+	archive := zip.NewWriter(w)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+
+	if _, err := sheet.Write(xlsxSheet(table)); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func xlsxSheet(table *Table) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]any, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column
+	}
+
+	xlsxRow(&buf, 1, header, xlsxStyleHeader)
+
+	for i, row := range table.Rows {
+		xlsxRow(&buf, i+2, row, 0)
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+
+	return buf.Bytes()
+}
+
+func xlsxRow(buf *bytes.Buffer, number int, cells []any, style int) {
+	buf.WriteString(`<row r="` + strconv.Itoa(number) + `">`)
+
+	for i, cell := range cells {
+		if cell == nil {
+			continue
+		}
+
+		ref := xlsxColumn(i) + strconv.Itoa(number)
+		styleAttr := ""
+		if style != 0 {
+			styleAttr = ` s="` + strconv.Itoa(style) + `"`
+		}
+
+		switch value := cell.(type) {
+			case int, int64, float64:
+				buf.WriteString(`<c r="` + ref + `"` + styleAttr + `><v>` + formatCell(value) + `</v></c>`)
+			case time.Time:
+				serial := value.UTC().Sub(xlsxEpoch).Hours() / 24
+				buf.WriteString(`<c r="` + ref + `" s="` + strconv.Itoa(xlsxStyleDate) + `"><v>` + strconv.FormatFloat(serial, 'f', -1, 64) + `</v></c>`)
+			default:
+				buf.WriteString(`<c r="` + ref + `" t="inlineStr"` + styleAttr + `><is><t xml:space="preserve">`)
+				xml.EscapeText(buf, []byte(formatCell(value)))
+				buf.WriteString(`</t></is></c>`)
+		}
+	}
+
+	buf.WriteString(`</row>`)
+}
+
+// xlsxColumn converts a zero-based column index to its letters (A, Z, AA...).
+func xlsxColumn(index int) string {
+	name := ""
+
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
@@ -0,0 +1,113 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128CodeB  = 100
+	code128CodeC  = 99
+	code128Stop   = 106
+)
+
+// Bar and space widths, in modules, of every Code128 symbol value. Each
+// symbol starts with a bar; the stop symbol carries the final bar.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code128 encodes data as a Code128 symbol and returns the widths, in modules,
+// of its alternating bars and spaces starting with a bar. Printable ASCII is
+// encoded with code set B, switching to code set C for runs of four or more
+// digits, which keeps numeric barcodes short.
+func Code128(data string) ([]int, error) {
+	if data == "" {
+		return nil, errors.New("El código de barras no puede estar vacío")
+	}
+
+	for i := 0; i < len(data); i++ {
+		if data[i] < 32 || data[i] > 126 {
+			return nil, fmt.Errorf("El carácter %q no se puede codificar en Code128", data[i])
+		}
+	}
+
+	var values []int
+
+	leading := digitRun(data, 0)
+
+	setC := leading >= 4 || leading == 2 && len(data) == 2
+	if setC {
+		values = append(values, code128StartC)
+	} else {
+		values = append(values, code128StartB)
+	}
+
+	for i := 0; i < len(data); {
+		run := digitRun(data, i)
+
+		if !setC && run >= 4 {
+			// An odd digit goes out in code set B first so the run pairs up
+			if run%2 == 1 {
+				values = append(values, int(data[i])-32)
+				i++
+			}
+
+			values = append(values, code128CodeC)
+			setC = true
+		}
+
+		if setC {
+			if run >= 2 {
+				values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+				i += 2
+				continue
+			}
+
+			values = append(values, code128CodeB)
+			setC = false
+		}
+
+		values = append(values, int(data[i])-32)
+		i++
+	}
+
+	checksum := values[0]
+	for i := 1; i < len(values); i++ {
+		checksum += i * values[i]
+	}
+
+	values = append(values, checksum%103, code128Stop)
+
+	var widths []int
+
+	for _, value := range values {
+		for _, width := range code128Patterns[value] {
+			widths = append(widths, int(width-'0'))
+		}
+	}
+
+	return widths, nil
+}
+
+func digitRun(data string, start int) int {
+	run := 0
+
+	for i := start; i < len(data) && data[i] >= '0' && data[i] <= '9'; i++ {
+		run++
+	}
+
+	return run
+}
@@ -0,0 +1,324 @@
+package labels
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	mmPerInch       = 25.4
+	quietZoneModule = 10
+)
+
+// Layout describes a sheet of labels; every measure is in millimeters.
+type Layout struct {
+	PageWidth     float64
+	PageHeight    float64
+	Columns       int
+	Rows          int
+	LabelWidth    float64
+	LabelHeight   float64
+	MarginTop     float64
+	MarginLeft    float64
+	HorizontalGap float64
+	VerticalGap   float64
+}
+
+type Label struct {
+	Barcode    string
+	Title      string
+	CallNumber string
+}
+
+// labelBox places the lines of a label, relative to its top left corner.
+type labelBox struct {
+	padding     float64
+	titleTop    float64
+	titleSize   float64
+	callTop     float64
+	callSize    float64
+	barcodeTop  float64
+	barcodeSize float64
+	codeTop     float64
+	codeSize    float64
+}
+
+type placement struct {
+	x, y  float64
+	label Label
+}
+
+func (l Layout) PerPage() int {
+	return l.Columns * l.Rows
+}
+
+// SingleLabel is a page holding exactly one label of the given size.
+func SingleLabel(width, height float64) Layout {
+	return Layout{
+		PageWidth:   width,
+		PageHeight:  height,
+		Columns:     1,
+		Rows:        1,
+		LabelWidth:  width,
+		LabelHeight: height,
+	}
+}
+
+// RenderSVG returns one SVG document per page. skip leaves the first label
+// positions of the first page empty, for sheets that were already used.
+func RenderSVG(layout Layout, labels []Label, skip int) ([][]byte, error) {
+	pages, err := paginate(layout, labels, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	box := newLabelBox(layout.LabelWidth, layout.LabelHeight)
+	documents := make([][]byte, 0, len(pages))
+
+	for _, page := range pages {
+		var buf bytes.Buffer
+
+		fmt.Fprintf(
+			&buf,
+			`<svg xmlns="http://www.w3.org/2000/svg" width="%smm" height="%smm" viewBox="0 0 %s %s">`,
+			mm(layout.PageWidth), mm(layout.PageHeight), mm(layout.PageWidth), mm(layout.PageHeight),
+		)
+		buf.WriteString(`<rect width="100%" height="100%" fill="#fff"/>`)
+
+		for _, item := range page {
+			if err := writeSVGLabel(&buf, layout, box, item); err != nil {
+				return nil, err
+			}
+		}
+
+		buf.WriteString(`</svg>`)
+		documents = append(documents, buf.Bytes())
+	}
+
+	return documents, nil
+}
+
+// RenderPNG returns one PNG image per page at the given resolution.
+func RenderPNG(layout Layout, labels []Label, skip, dpi int) ([][]byte, error) {
+	pages, err := paginate(layout, labels, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	px := func(value float64) int {
+		return int(math.Round(value / mmPerInch * float64(dpi)))
+	}
+
+	box := newLabelBox(layout.LabelWidth, layout.LabelHeight)
+	images := make([][]byte, 0, len(pages))
+
+	for _, page := range pages {
+		img := image.NewRGBA(image.Rect(0, 0, px(layout.PageWidth), px(layout.PageHeight)))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+		for _, item := range page {
+			left, top := px(item.x), px(item.y)
+			width := px(layout.LabelWidth) - 2*px(box.padding)
+
+			textLine := func(text string, lineTop, size float64, centered bool) {
+				scale := max(1, int(math.Round(float64(px(size))/glyphHeight)))
+				text = truncate(foldText(text), (width+scale)/(glyphAdvance*scale))
+
+				x := left + px(box.padding)
+				if centered {
+					x += (width - (len(text)*glyphAdvance*scale - scale)) / 2
+				}
+
+				drawText(img, x, top+px(lineTop), scale, text)
+			}
+
+			textLine(item.label.Title, box.titleTop, box.titleSize, false)
+			textLine(item.label.CallNumber, box.callTop, box.callSize, false)
+
+			widths, err := Code128(item.label.Barcode)
+			if err != nil {
+				return nil, err
+			}
+
+			modules := barcodeModules(widths)
+
+			module := width / modules
+			if module < 1 {
+				return nil, errors.New("La etiqueta es demasiado angosta para el código de barras")
+			}
+
+			x := left + px(box.padding) + (width-module*modules)/2 + quietZoneModule*module
+
+			for i, w := range widths {
+				if i%2 == 0 {
+					fillRect(img, x, top+px(box.barcodeTop), w*module, px(box.barcodeSize), color.Black)
+				}
+
+				x += w * module
+			}
+
+			textLine(item.label.Barcode, box.codeTop, box.codeSize, true)
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+
+		images = append(images, buf.Bytes())
+	}
+
+	return images, nil
+}
+
+func writeSVGLabel(buf *bytes.Buffer, layout Layout, box labelBox, item placement) error {
+	widths, err := Code128(item.label.Barcode)
+	if err != nil {
+		return err
+	}
+
+	width := layout.LabelWidth - 2*box.padding
+
+	fmt.Fprintf(buf, `<g transform="translate(%s %s)">`, mm(item.x), mm(item.y))
+
+	writeSVGText(buf, item.label.Title, box.padding, box.titleTop, box.titleSize, width, "sans-serif", "start")
+	writeSVGText(buf, item.label.CallNumber, box.padding, box.callTop, box.callSize, width, "sans-serif", "start")
+
+	module := width / float64(barcodeModules(widths))
+	x := box.padding + quietZoneModule*module
+
+	for i, w := range widths {
+		if i%2 == 0 {
+			fmt.Fprintf(
+				buf,
+				`<rect x="%s" y="%s" width="%s" height="%s" fill="#000"/>`,
+				mm(x), mm(box.barcodeTop), mm(float64(w)*module), mm(box.barcodeSize),
+			)
+		}
+
+		x += float64(w) * module
+	}
+
+	writeSVGText(buf, item.label.Barcode, layout.LabelWidth/2, box.codeTop, box.codeSize, width, "monospace", "middle")
+
+	buf.WriteString(`</g>`)
+
+	return nil
+}
+
+func writeSVGText(buf *bytes.Buffer, text string, x, top, size, width float64, family, anchor string) {
+	// Character width estimated for a monospace font; narrower fonts just fit
+	text = truncate(text, int(width/(size*0.6)))
+	if text == "" {
+		return
+	}
+
+	fmt.Fprintf(
+		buf,
+		`<text x="%s" y="%s" font-size="%s" font-family="%s" text-anchor="%s">`,
+		mm(x), mm(top+size*0.8), mm(size), family, anchor,
+	)
+	xml.EscapeText(buf, []byte(text))
+	buf.WriteString(`</text>`)
+}
+
+func newLabelBox(width, height float64) labelBox {
+	box := labelBox{
+		padding:   math.Min(2, math.Min(width, height)*0.08),
+		titleSize: height * 0.12,
+		callSize:  height * 0.14,
+		codeSize:  height * 0.1,
+	}
+
+	gap := height * 0.03
+
+	box.titleTop = box.padding
+	box.callTop = box.titleTop + box.titleSize + gap
+	box.barcodeTop = box.callTop + box.callSize + gap
+	box.codeTop = height - box.padding - box.codeSize
+	box.barcodeSize = box.codeTop - gap - box.barcodeTop
+
+	return box
+}
+
+func paginate(layout Layout, labels []Label, skip int) ([][]placement, error) {
+	perPage := layout.PerPage()
+	if perPage <= 0 {
+		return nil, errors.New("El formato debe tener al menos una fila y una columna")
+	}
+
+	if skip < 0 || skip >= perPage {
+		return nil, fmt.Errorf("Las posiciones a omitir deben estar entre 0 y %d", perPage-1)
+	}
+
+	var pages [][]placement
+	var page []placement
+
+	position := skip
+
+	for _, label := range labels {
+		if position == perPage {
+			pages = append(pages, page)
+			page = nil
+			position = 0
+		}
+
+		col, row := position%layout.Columns, position/layout.Columns
+
+		page = append(page, placement{
+			x:     layout.MarginLeft + float64(col)*(layout.LabelWidth+layout.HorizontalGap),
+			y:     layout.MarginTop + float64(row)*(layout.LabelHeight+layout.VerticalGap),
+			label: label,
+		})
+
+		position++
+	}
+
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+func barcodeModules(widths []int) int {
+	modules := 2 * quietZoneModule
+
+	for _, w := range widths {
+		modules += w
+	}
+
+	return modules
+}
+
+func truncate(text string, maxChars int) string {
+	text = strings.TrimSpace(text)
+
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+
+	if maxChars <= 3 {
+		return string(runes[:max(maxChars, 0)])
+	}
+
+	return string(runes[:maxChars-3]) + "..."
+}
+
+func fillRect(img *image.RGBA, x, y, width, height int, c color.Color) {
+	draw.Draw(img, image.Rect(x, y, x+width, y+height), &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+func mm(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
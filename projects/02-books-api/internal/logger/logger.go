@@ -1,16 +1,34 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
 )
 
+type Config struct {
+	Path        string
+	Format      string // json or text
+	Level       slog.Level
+	MaxSize     int64         // Bytes before the file is rotated, 0 never
+	RotateEvery time.Duration // The file is rotated when a new period starts, 0 never
+	MaxBackups  int           // Rotated files kept, 0 keeps them all
+	MaxAge      time.Duration // Rotated files older are removed, 0 keeps them
+}
+
+// Logger writes structured lines to stdout and to a rotating file. The lines
+// logged with the context of a request carry its request_id and library_id.
 type Logger struct {
-	file *os.File
+	*slog.Logger
+	file *RotatingFile
 }
 
 type ResponseWriter struct {
@@ -37,57 +55,81 @@ func (rw *ResponseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
-func NewLogger(logFilePath string) (*Logger, error) {
-	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+// Unwrap lets http.ResponseController flush the streamed responses.
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func NewLogger(config Config) (*Logger, error) {
+	file, err := OpenRotatingFile(config.Path, config.MaxSize, config.RotateEvery, config.MaxBackups, config.MaxAge)
 	if err != nil {
 		return nil, fmt.Errorf("Error al abrir archivo de log: %v", err)
 	}
 
-	logger := &Logger{file: file}
+	output := io.MultiWriter(os.Stdout, file)
+	options := &slog.HandlerOptions{Level: config.Level}
+
+	var handler slog.Handler
 
-	logger.writeLog("=================================================")
-	logger.writeLog(fmt.Sprintf("Logger iniciado - %s", time.Now().Format("2006-01-02 15:04:05")))
-	logger.writeLog("=================================================")
+	switch config.Format {
+		case "json":
+			handler = slog.NewJSONHandler(output, options)
+		case "text":
+			handler = slog.NewTextHandler(output, options)
+		default:
+			file.Close()
+			return nil, fmt.Errorf("Formato de log inválido: %s (json o text)", config.Format)
+	}
+
+	logger := &Logger{
+		Logger: slog.New(contextHandler{handler}),
+		file:   file,
+	}
+
+	logger.Info("Logger iniciado", "format", config.Format, "level", config.Level.String())
 
 	return logger, nil
 }
 
-func (l *Logger) writeLog(message string) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logMessage := fmt.Sprintf("[%s] %s\n", timestamp, message)
-
-	if l.file != nil {
-		l.file.WriteString(logMessage)
+// LogRequest logs a finished request with its latency; server errors are
+// logged as errors. The path is the one requested, the library is taken from
+// its first segment when it is not in the context yet.
+func (l *Logger) LogRequest(r *http.Request, path string, statusCode, responseSize int, duration time.Duration) {
+	ip := r.RemoteAddr
+	if idx := strings.LastIndex(ip, ":"); idx != -1 {
+		ip = ip[:idx]
 	}
 
-	log.Print(message)
-}
+	level := slog.LevelInfo
+	if statusCode >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
 
-func (l *Logger) LogRequest(method, path, remoteAddr, userAgent string, statusCode, responseSize int, duration time.Duration) {
-	ip := remoteAddr
-	if idx := strings.LastIndex(remoteAddr, ":"); idx != -1 {
-		ip = remoteAddr[:idx]
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", path),
+		slog.String("query", r.URL.RawQuery),
+		slog.Int("status", statusCode),
+		slog.Int("size", responseSize),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+		slog.String("ip", ip),
+		slog.String("user_agent", r.UserAgent()),
 	}
 
-	logEntry := fmt.Sprintf(
-		"%-7s | %-50s | IP: %-15s | Status: %3d | Size: %6d bytes | Time: %8s | UA: %s",
-		method,
-		path,
-		ip,
-		statusCode,
-		responseSize,
-		duration.String(),
-		userAgent,
-	)
+	if _, ok := r.Context().Value(middleware.LibraryIDKey).(int64); !ok {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+		if libraryID, err := strconv.ParseInt(segment, 10, 64); err == nil && libraryID > 0 {
+			attrs = append(attrs, slog.Int64("library_id", libraryID))
+		}
+	}
 
-	l.writeLog(logEntry)
+	l.LogAttrs(r.Context(), level, "Petición HTTP", attrs...)
 }
 
 func (l *Logger) Close() error {
 	if l.file != nil {
-		l.writeLog("=================================================")
-		l.writeLog(fmt.Sprintf("Logger detenido - %s", time.Now().Format("2006-01-02 15:04:05")))
-		l.writeLog("=================================================")
+		l.Info("Logger detenido")
 
 		return l.file.Close()
 	}
@@ -99,42 +141,42 @@ func (l *Logger) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		r = middleware.WithRequestID(w, r)
+		path := r.URL.Path
 		rw := NewResponseWriter(w)
 
 		next(rw, r)
 
-		duration := time.Since(start)
-
-		l.LogRequest(
-			r.Method,
-			r.URL.Path,
-			r.RemoteAddr,
-			r.UserAgent(),
-			rw.statusCode,
-			rw.size,
-			duration,
-		)
+		l.LogRequest(r, path, rw.statusCode, rw.size, time.Since(start))
 	}
 }
 
 func (l *Logger) MiddlewareHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+	return l.Middleware(next.ServeHTTP)
+}
 
-		rw := NewResponseWriter(w)
+// contextHandler adds the request and the library of the context to every
+// line logged with it.
+type contextHandler struct {
+	slog.Handler
+}
 
-		next.ServeHTTP(rw, r)
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID, ok := ctx.Value(middleware.RequestIDKey).(string); ok {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	if libraryID, ok := ctx.Value(middleware.LibraryIDKey).(int64); ok {
+		record.AddAttrs(slog.Int64("library_id", libraryID))
+	}
 
-		duration := time.Since(start)
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
 
-		l.LogRequest(
-			r.Method,
-			r.URL.Path,
-			r.RemoteAddr,
-			r.UserAgent(),
-			rw.statusCode,
-			rw.size,
-			duration,
-		)
-	})
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
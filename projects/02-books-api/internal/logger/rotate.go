@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const rotatedLayout = "20060102T150405.000"
+
+// RotatingFile appends to a log file and, once it reaches its size or a new
+// period starts, renames it after the time it was rotated and starts a new
+// one. Only the newest rotated files, and not older than maxAge, are kept.
+type RotatingFile struct {
+	path        string
+	maxSize     int64
+	rotateEvery time.Duration
+	maxBackups  int
+	maxAge      time.Duration
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func OpenRotatingFile(path string, maxSize int64, rotateEvery time.Duration, maxBackups int, maxAge time.Duration) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:        path,
+		maxSize:     maxSize,
+		rotateEvery: rotateEvery,
+		maxBackups:  maxBackups,
+		maxAge:      maxAge,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var rotateErr error
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	} else if f.shouldRotate(int64(len(p)), time.Now()) {
+		rotateErr = f.rotate()
+		if f.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	if err == nil {
+		err = rotateErr
+	}
+
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	return f.file.Close()
+}
+
+// An existing file belongs to the period of its last write, so a file left
+// from yesterday is rotated on the first write of today.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+
+	if info.Size() > 0 {
+		f.openedAt = info.ModTime()
+	}
+
+	return nil
+}
+
+func (f *RotatingFile) shouldRotate(size int64, now time.Time) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.maxSize > 0 && f.size+size > f.maxSize {
+		return true
+	}
+
+	return f.rotateEvery > 0 && !now.Truncate(f.rotateEvery).Equal(f.openedAt.Truncate(f.rotateEvery))
+}
+
+// rotate keeps writing to the current file when the rename or the new file
+// fails, and returns the error; the file is nil only if it cannot be reopened.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return f.reopen(err)
+	}
+
+	extension := filepath.Ext(f.path)
+	backup := strings.TrimSuffix(f.path, extension) + "-" + time.Now().Format(rotatedLayout) + extension
+
+	if err := os.Rename(f.path, backup); err != nil {
+		return f.reopen(err)
+	}
+
+	if err := f.open(); err != nil {
+		return f.reopen(err)
+	}
+
+	f.openedAt = time.Now()
+	f.prune()
+
+	return nil
+}
+
+func (f *RotatingFile) reopen(cause error) error {
+	if err := f.open(); err != nil {
+		f.file = nil
+		return errors.Join(cause, err)
+	}
+
+	return cause
+}
+
+// prune removes the rotated files beyond maxBackups and older than maxAge.
+// Their names sort by the time they were rotated.
+func (f *RotatingFile) prune() {
+	extension := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, extension) + "-"
+
+	matches, err := filepath.Glob(prefix + "*" + extension)
+	if err != nil {
+		return
+	}
+
+	var backups []string
+
+	for _, match := range matches {
+		if _, err := time.Parse(rotatedLayout, strings.TrimSuffix(strings.TrimPrefix(match, prefix), extension)); err == nil {
+			backups = append(backups, match)
+		}
+	}
+
+	slices.Sort(backups)
+	slices.Reverse(backups)
+
+	for i, backup := range backups {
+		remove := f.maxBackups > 0 && i >= f.maxBackups
+
+		if !remove && f.maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && time.Since(info.ModTime()) > f.maxAge {
+				remove = true
+			}
+		}
+
+		if remove {
+			os.Remove(backup)
+		}
+	}
+}
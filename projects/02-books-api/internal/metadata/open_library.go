@@ -0,0 +1,138 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+var yearRegex = regexp.MustCompile(`\b(1[0-9]{3}|20[0-9]{2})\b`)
+
+type OpenLibraryProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+type openLibraryName struct {
+	Name string `json:"name"`
+}
+
+type openLibraryBook struct {
+	Title         string            `json:"title"`
+	Subtitle      string            `json:"subtitle"`
+	Authors       []openLibraryName `json:"authors"`
+	Publishers    []openLibraryName `json:"publishers"`
+	PublishDate   string            `json:"publish_date"`
+	NumberOfPages int64             `json:"number_of_pages"`
+	Notes         json.RawMessage   `json:"notes"`
+	Excerpts      []struct {
+		Text string `json:"text"`
+	} `json:"excerpts"`
+}
+
+func NewOpenLibraryProvider(baseURL string, timeout time.Duration) *OpenLibraryProvider {
+	return &OpenLibraryProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *OpenLibraryProvider) Name() string {
+	return "openlibrary"
+}
+
+func (p *OpenLibraryProvider) Lookup(isbn string) (*models.BookMetadata, error) {
+	isbn = NormalizeISBN(isbn)
+	if isbn == "" {
+		return nil, fmt.Errorf("El ISBN es requerido")
+	}
+
+	bibKey := "ISBN:" + isbn
+
+	query := url.Values{}
+	query.Set("bibkeys", bibKey)
+	query.Set("format", "json")
+	query.Set("jscmd", "data")
+
+	resp, err := p.client.Get(p.baseURL + "/api/books?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("Error al consultar el proveedor de metadatos: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrMetadataNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("El proveedor de metadatos respondió con estado %d", resp.StatusCode)
+	}
+
+	var payload map[string]openLibraryBook
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("Respuesta inválida del proveedor de metadatos: %w", err)
+	}
+
+	book, ok := payload[bibKey]
+	if !ok || strings.TrimSpace(book.Title) == "" {
+		return nil, ErrMetadataNotFound
+	}
+
+	metadata := &models.BookMetadata{
+		ISBN:      isbn,
+		Title:     strings.TrimSpace(book.Title),
+		Subtitle:  strings.TrimSpace(book.Subtitle),
+		Pages:     book.NumberOfPages,
+		Synopsis:  parseNotes(book.Notes),
+		Provider:  p.Name(),
+		FetchedAt: time.Now(),
+	}
+
+	for _, author := range book.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			metadata.Authors = append(metadata.Authors, name)
+		}
+	}
+
+	if len(book.Publishers) > 0 {
+		metadata.Publisher = strings.TrimSpace(book.Publishers[0].Name)
+	}
+
+	if match := yearRegex.FindString(book.PublishDate); match != "" {
+		metadata.PublicationYear, _ = strconv.ParseInt(match, 10, 64)
+	}
+
+	if metadata.Synopsis == "" && len(book.Excerpts) > 0 {
+		metadata.Synopsis = strings.TrimSpace(book.Excerpts[0].Text)
+	}
+
+	return metadata, nil
+}
+
+func parseNotes(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+
+	var typed struct {
+		Value string `json:"value"`
+	}
+
+	if err := json.Unmarshal(raw, &typed); err == nil {
+		return strings.TrimSpace(typed.Value)
+	}
+
+	return ""
+}
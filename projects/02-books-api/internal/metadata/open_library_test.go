@@ -0,0 +1,167 @@
+package metadata
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const openLibraryHit = `{
+	"ISBN:9780140449136": {
+		"title": "The Odyssey",
+		"subtitle": " A New Translation ",
+		"authors": [{"name": "Homer"}, {"name": " "}, {"name": "Emily Wilson"}],
+		"publishers": [{"name": "Penguin Classics"}, {"name": "Otra"}],
+		"publish_date": "March 2003",
+		"number_of_pages": 541,
+		"notes": {"type": "/type/text", "value": " Epic poem. "}
+	}
+}`
+
+func newOpenLibraryServer(t *testing.T, handler http.HandlerFunc) *OpenLibraryProvider {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewOpenLibraryProvider(server.URL+"/", time.Second)
+}
+
+func TestOpenLibraryLookupHit(t *testing.T) {
+	var query string
+
+	provider := newOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/books" {
+			http.NotFound(w, r)
+			return
+		}
+
+		query = r.URL.RawQuery
+		w.Write([]byte(openLibraryHit))
+	})
+
+	metadata, err := provider.Lookup("978-0-14-044913-6")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+
+	if !strings.Contains(query, "bibkeys=ISBN%3A9780140449136") || !strings.Contains(query, "jscmd=data") {
+		t.Errorf("query = %q", query)
+	}
+
+	if metadata.ISBN != "9780140449136" || metadata.Title != "The Odyssey" || metadata.Subtitle != "A New Translation" {
+		t.Errorf("isbn/title/subtitle = %q/%q/%q", metadata.ISBN, metadata.Title, metadata.Subtitle)
+	}
+
+	if !slices.Equal(metadata.Authors, []string{"Homer", "Emily Wilson"}) {
+		t.Errorf("authors = %v", metadata.Authors)
+	}
+
+	if metadata.Publisher != "Penguin Classics" {
+		t.Errorf("publisher = %q", metadata.Publisher)
+	}
+
+	if metadata.PublicationYear != 2003 || metadata.Pages != 541 {
+		t.Errorf("year/pages = %d/%d", metadata.PublicationYear, metadata.Pages)
+	}
+
+	if metadata.Synopsis != "Epic poem." {
+		t.Errorf("synopsis = %q", metadata.Synopsis)
+	}
+
+	if metadata.Provider != "openlibrary" || metadata.FetchedAt.IsZero() {
+		t.Errorf("provider/fetched_at = %q/%v", metadata.Provider, metadata.FetchedAt)
+	}
+}
+
+func TestOpenLibraryLookupMiss(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		payload string
+	}{
+		{"empty payload", http.StatusOK, `{}`},
+		{"empty title", http.StatusOK, `{"ISBN:9780140449136": {"title": " "}}`},
+		{"not found", http.StatusNotFound, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.payload))
+			})
+
+			_, err := provider.Lookup("9780140449136")
+			if !errors.Is(err, ErrMetadataNotFound) {
+				t.Fatalf("err = %v, want ErrMetadataNotFound", err)
+			}
+		})
+	}
+}
+
+func TestOpenLibraryLookupMalformedJSON(t *testing.T) {
+	provider := newOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ISBN:9780140449136": {"title": `))
+	})
+
+	_, err := provider.Lookup("9780140449136")
+	if err == nil || errors.Is(err, ErrMetadataNotFound) {
+		t.Fatalf("err = %v, want a decoding error", err)
+	}
+
+	if !strings.Contains(err.Error(), "Respuesta inválida") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestOpenLibraryLookupServerError(t *testing.T) {
+	provider := newOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := provider.Lookup("9780140449136")
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("err = %v, want the status in the error", err)
+	}
+}
+
+func TestOpenLibraryLookupTimeout(t *testing.T) {
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+			case <-release:
+			case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	provider := NewOpenLibraryProvider(server.URL, 50*time.Millisecond)
+
+	start := time.Now()
+
+	_, err := provider.Lookup("9780140449136")
+	if err == nil || errors.Is(err, ErrMetadataNotFound) {
+		t.Fatalf("err = %v, want a timeout error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Lookup took %v, the client timeout was not applied", elapsed)
+	}
+}
+
+func TestOpenLibraryLookupRequiresISBN(t *testing.T) {
+	provider := newOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the provider must not be called without an ISBN")
+	})
+
+	if _, err := provider.Lookup("--"); err == nil {
+		t.Fatal("err = nil, want an error")
+	}
+}
@@ -0,0 +1,27 @@
+package metadata
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+var ErrMetadataNotFound = errors.New("No se encontraron metadatos para el ISBN")
+
+type MetadataProvider interface {
+	Name() string
+	Lookup(isbn string) (*models.BookMetadata, error)
+}
+
+func NormalizeISBN(isbn string) string {
+	var builder strings.Builder
+
+	for _, r := range strings.ToUpper(isbn) {
+		if (r >= '0' && r <= '9') || r == 'X' {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	HTTPRequests = NewCounterVec(
+		"http_requests_total",
+		"Peticiones HTTP atendidas por ruta, método y estado.",
+		"route", "method", "status",
+	)
+
+	HTTPRequestDuration = NewHistogramVec(
+		"http_request_duration_seconds",
+		"Latencia de las peticiones HTTP por ruta, método y estado.",
+		DefaultBuckets,
+		"route", "method", "status",
+	)
+
+	DBQueryDuration = NewHistogramVec(
+		"db_query_duration_seconds",
+		"Duración de las consultas a la base de datos por método del store.",
+		[]float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		"method",
+	)
+
+	DBQueryErrors = NewCounterVec(
+		"db_query_errors_total",
+		"Consultas a la base de datos que fallaron por método del store.",
+		"method",
+	)
+
+	JobRuns = NewCounterVec(
+		"background_job_runs_total",
+		"Ejecuciones de los procesos en segundo plano por resultado.",
+		"job", "outcome",
+	)
+
+	JobDuration = NewHistogramVec(
+		"background_job_duration_seconds",
+		"Duración de las ejecuciones de los procesos en segundo plano.",
+		DefaultBuckets,
+		"job",
+	)
+
+	JobLastSuccess = NewGaugeVec(
+		"background_job_last_success_timestamp_seconds",
+		"Momento de la última ejecución correcta de cada proceso en segundo plano.",
+		"job",
+	)
+)
+
+var Default = NewRegistry()
+
+func init() {
+	Default.Register(HTTPRequests, HTTPRequestDuration, DBQueryDuration, DBQueryErrors, JobRuns, JobDuration, JobLastSuccess)
+}
+
+func Register(collectors ...Collector) {
+	Default.Register(collectors...)
+}
+
+func Handler() http.HandlerFunc {
+	return Default.Handler()
+}
+
+// ObserveJob records one run of a background job started at start, failed
+// when err is not nil.
+func ObserveJob(job string, start time.Time, err error) {
+	JobDuration.Observe(time.Since(start).Seconds(), job)
+
+	if err != nil {
+		JobRuns.Inc(job, "error")
+		return
+	}
+
+	JobRuns.Inc(job, "success")
+	JobLastSuccess.Set(float64(time.Now().Unix()), job)
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Middleware counts the requests and their latency by the pattern of the
+// route that served them, so the IDs in the path don't make new series.
+// It has to wrap the mux, which sets the pattern on the request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(rw, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(rw.statusCode)
+
+		HTTPRequests.Inc(route, r.Method, status)
+		HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, r.Method, status)
+	})
+}
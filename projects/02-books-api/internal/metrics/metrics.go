@@ -0,0 +1,359 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Collector writes its families in the Prometheus text format.
+type Collector interface {
+	Write(w io.Writer) error
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// Handler serves every collector in the Prometheus text format. A collector
+// that fails is left out and logged, the rest are still served.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.mu.Lock()
+		collectors := slices.Clone(r.collectors)
+		r.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		for _, collector := range collectors {
+			if err := collector.Write(w); err != nil {
+				slog.WarnContext(req.Context(), "Error al obtener las métricas", "error", err)
+			}
+		}
+	}
+}
+
+// vec keeps one series per combination of label values.
+type vec[T any] struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	newSeries  func() *T
+
+	mu     sync.Mutex
+	series map[string]*T
+	labels map[string][]string
+}
+
+func newVec[T any](name, help, kind string, labelNames []string, newSeries func() *T) vec[T] {
+	return vec[T]{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		newSeries:  newSeries,
+		series:     make(map[string]*T),
+		labels:     make(map[string][]string),
+	}
+}
+
+// with returns the series of the label values, locked until the caller
+// releases it.
+func (v *vec[T]) with(labelValues []string) *T {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s espera %d etiquetas", v.name, len(v.labelNames)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.Lock()
+
+	series, ok := v.series[key]
+	if !ok {
+		series = v.newSeries()
+		v.series[key] = series
+		v.labels[key] = slices.Clone(labelValues)
+	}
+
+	return series
+}
+
+// each visits the series ordered by their labels, with the vec locked.
+func (v *vec[T]) each(visit func(labels string, series *T)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		visit(formatLabels(v.labelNames, v.labels[key]), v.series[key])
+	}
+}
+
+func (v *vec[T]) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, v.kind)
+	return err
+}
+
+type CounterVec struct {
+	vec[float64]
+}
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, "counter", labelNames, func() *float64 { return new(float64) })}
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	series := c.with(labelValues)
+	*series += value
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) Write(w io.Writer) error {
+	if err := c.header(w); err != nil {
+		return err
+	}
+
+	var err error
+
+	c.each(func(labels string, value *float64) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s%s %s\n", c.name, labels, formatValue(*value))
+		}
+	})
+
+	return err
+}
+
+type GaugeVec struct {
+	vec[float64]
+}
+
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{newVec(name, help, "gauge", labelNames, func() *float64 { return new(float64) })}
+}
+
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	series := g.with(labelValues)
+	*series = value
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) Write(w io.Writer) error {
+	if err := g.header(w); err != nil {
+		return err
+	}
+
+	var err error
+
+	g.each(func(labels string, value *float64) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s%s %s\n", g.name, labels, formatValue(*value))
+		}
+	})
+
+	return err
+}
+
+type histogram struct {
+	counts []uint64 // One per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+type HistogramVec struct {
+	vec[histogram]
+	buckets []float64
+}
+
+// DefaultBuckets go from 5ms to 10s, for request and query latencies.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &HistogramVec{
+		vec: newVec(name, help, "histogram", labelNames, func() *histogram {
+			return &histogram{counts: make([]uint64, len(buckets))}
+		}),
+		buckets: buckets,
+	}
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	series := h.with(labelValues)
+	defer h.mu.Unlock()
+
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		series.counts[i]++
+	}
+
+	series.sum += value
+	series.count++
+}
+
+func (h *HistogramVec) Write(w io.Writer) error {
+	if err := h.header(w); err != nil {
+		return err
+	}
+
+	var err error
+
+	h.each(func(labels string, series *histogram) {
+		var cumulative uint64
+
+		for i, bucket := range h.buckets {
+			cumulative += series.counts[i]
+
+			if err == nil {
+				_, err = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", formatValue(bucket)), cumulative)
+			}
+		}
+
+		if err == nil {
+			_, err = fmt.Fprintf(
+				w,
+				"%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+				h.name, withLabel(labels, "le", "+Inf"), series.count,
+				h.name, labels, formatValue(series.sum),
+				h.name, labels, series.count,
+			)
+		}
+	})
+
+	return err
+}
+
+// Family is a metric whose samples are read when it is collected.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string // gauge or counter
+	Samples []Sample
+}
+
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// CollectorFunc reads its families on every scrape, as the gauges taken from
+// the database.
+type CollectorFunc func() ([]Family, error)
+
+func (f CollectorFunc) Write(w io.Writer) error {
+	families, err := f()
+	if err != nil {
+		return err
+	}
+
+	for _, family := range families {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.Name, escapeHelp(family.Help), family.Name, family.Type)
+		if err != nil {
+			return err
+		}
+
+		for _, sample := range family.Samples {
+			names := make([]string, 0, len(sample.Labels))
+			for name := range sample.Labels {
+				names = append(names, name)
+			}
+
+			slices.Sort(names)
+
+			values := make([]string, len(names))
+			for i, name := range names {
+				values[i] = sample.Labels[name]
+			}
+
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", family.Name, formatLabels(names, values), formatValue(sample.Value)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+		case math.IsInf(value, 1):
+			return "+Inf"
+		case math.IsInf(value, -1):
+			return "-Inf"
+		case math.IsNaN(value):
+			return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
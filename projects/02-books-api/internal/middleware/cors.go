@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	corsAllowMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders = "Content-Type, Last-Event-ID, " + LibrarianIDHeader + ", " + RequestIDHeader
+	corsMaxAge       = "600"
+)
+
+// CORS lets the browsers of the allowed origins call the API; * allows any.
+// Without origins it does nothing. The preflight requests are answered here.
+func CORS(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+
+	allowAny := slices.Contains(origins, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if !allowAny && !slices.ContainsFunc(origins, func(allowed string) bool {
+			return strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+		}) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const LibrarianIDHeader = "X-Librarian-ID"
+
+// GetLibrarianID reads the optional staff account that performs the request.
+func GetLibrarianID(r *http.Request) (*int64, error) {
+	value := strings.TrimSpace(r.Header.Get(LibrarianIDHeader))
+	if value == "" {
+		return nil, nil
+	}
+
+	librarianID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || librarianID <= 0 {
+		return nil, fmt.Errorf("%s inválido: %s", LibrarianIDHeader, value)
+	}
+
+	return &librarianID, nil
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const RequestIDKey contextKey = "request_id"
+
+// WithRequestID keeps the X-Request-ID the client sent, or a new one, in the
+// context of the request and echoes it in the response.
+func WithRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	if GetRequestID(r) != "" {
+		return r
+	}
+
+	requestID := strings.TrimSpace(r.Header.Get(RequestIDHeader))
+	if requestID == "" || len(requestID) > 128 {
+		requestID = newRequestID()
+	}
+
+	w.Header().Set(RequestIDHeader, requestID)
+
+	return r.WithContext(context.WithValue(r.Context(), RequestIDKey, requestID))
+}
+
+func GetRequestID(r *http.Request) string {
+	requestID, _ := r.Context().Value(RequestIDKey).(string)
+	return requestID
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+type Vendor struct {
+	ID            int64               `json:"id"`
+	Name          string              `json:"name"`
+	ContactName   database.NullString `json:"contact_name"`
+	Email         database.NullString `json:"email"`
+	Phone         database.NullString `json:"phone"`
+	AccountNumber database.NullString `json:"account_number"` // Our customer number at the vendor
+	Notes         database.NullString `json:"notes"`
+	LibraryID     int64               `json:"library_id"`
+}
+
+type Budget struct {
+	ID         int64               `json:"id"`
+	Name       string              `json:"name"`
+	FiscalYear int                 `json:"fiscal_year"`
+	Amount     float64             `json:"amount"`
+	Notes      database.NullString `json:"notes"`
+	Committed  float64             `json:"committed"` // Ordered and not received yet
+	Spent      float64             `json:"spent"`     // Received
+	Invoiced   float64             `json:"invoiced"`
+	Available  float64             `json:"available"` // Amount - Committed - Spent
+	LibraryID  int64               `json:"library_id"`
+}
+
+type PurchaseOrder struct {
+	ID          int64                `json:"id"`
+	OrderNumber string               `json:"order_number"`
+	VendorID    int64                `json:"vendor_id"`
+	BudgetID    int64                `json:"budget_id"`
+	Status      string               `json:"status"` // Draft, Ordered, PartiallyReceived, Received, Closed, Cancelled
+	Notes       database.NullString  `json:"notes"`
+	CreatedAt   time.Time            `json:"created_at"`
+	OrderedAt   database.NullTime    `json:"ordered_at"`
+	ClosedAt    database.NullTime    `json:"closed_at"`
+	Total       float64              `json:"total"`
+	Lines       []*PurchaseOrderLine `json:"lines"`
+	LibraryID   int64                `json:"library_id"`
+}
+
+type PurchaseOrderLine struct {
+	ID               int64               `json:"id"`
+	OrderID          int64               `json:"order_id"`
+	BookID           database.NullInt64  `json:"book_id"` // Null until a book with the ISBN is cataloged
+	ISBN             database.NullString `json:"isbn"`
+	Title            database.NullString `json:"title"`
+	Quantity         int                 `json:"quantity"`
+	QuantityReceived int                 `json:"quantity_received"`
+	UnitPrice        float64             `json:"unit_price"`
+	Notes            database.NullString `json:"notes"`
+	LibraryID        int64               `json:"library_id"`
+}
+
+// LineReceipt is the arrival of some units of an order line. Without codes the
+// copies get one generated from the line.
+type LineReceipt struct {
+	Quantity   int                `json:"quantity"`
+	Codes      []string           `json:"codes,omitempty"`
+	Condition  string             `json:"condition"` // Defaults to New
+	ShelfID    database.NullInt64 `json:"shelf_id"`  // Defaults to the shelf of the book
+	ReceivedAt database.NullTime  `json:"received_at"`
+}
+
+type PurchaseReceipt struct {
+	ID          int64              `json:"id"`
+	LineID      int64              `json:"line_id"`
+	CopyID      int64              `json:"copy_id"`
+	LibrarianID database.NullInt64 `json:"librarian_id"`
+	ReceivedAt  time.Time          `json:"received_at"`
+	LibraryID   int64              `json:"library_id"`
+}
+
+type Invoice struct {
+	ID            int64               `json:"id"`
+	OrderID       int64               `json:"order_id"`
+	VendorID      int64               `json:"vendor_id"`
+	InvoiceNumber string              `json:"invoice_number"`
+	InvoiceDate   database.NullTime   `json:"invoice_date"`
+	Amount        float64             `json:"amount"`
+	Notes         database.NullString `json:"notes"`
+	CreatedAt     time.Time           `json:"created_at"`
+	LibraryID     int64               `json:"library_id"`
+}
+
+// OrderReconciliation compares what the vendor invoiced with what arrived.
+type OrderReconciliation struct {
+	OrderID    int64      `json:"order_id"`
+	Ordered    float64    `json:"ordered"`
+	Received   float64    `json:"received"`
+	Invoiced   float64    `json:"invoiced"`
+	Difference float64    `json:"difference"` // Invoiced - Received
+	Status     string     `json:"status"`     // Balanced, OverInvoiced, UnderInvoiced
+	Invoices   []*Invoice `json:"invoices"`
+}
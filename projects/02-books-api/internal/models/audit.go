@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+// AuditEntry is one change of an entity made by a service, with the API call
+// it was made for. Before and After only hold the fields that changed; a
+// creation has no Before and a deletion no After. Hash covers
+// the entry and the hash of the previous one of the library.
+type AuditEntry struct {
+	ID         int64               `json:"id"`
+	RequestID  string              `json:"request_id"`
+	ActorID    database.NullInt64  `json:"actor_id"` // From X-Librarian-ID
+	RemoteAddr database.NullString `json:"remote_addr"`
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	EntityType string              `json:"entity_type"`
+	EntityID   database.NullInt64  `json:"entity_id"`
+	Action     string              `json:"action"` // create, update, delete or the action done (pay, waive, return...)
+	Before     json.RawMessage     `json:"before"`
+	After      json.RawMessage     `json:"after"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	PrevHash   string              `json:"prev_hash"`
+	Hash       string              `json:"hash"`
+	LibraryID  int64               `json:"library_id"`
+}
+
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	LastHash string `json:"last_hash"`
+	BrokenAt *int64 `json:"broken_at"` // First entry whose hash does not match
+}
@@ -8,5 +8,14 @@ type Author struct {
 	LastName    string              `json:"last_name"`
 	Biography   database.NullString `json:"biography"`
 	Nationality database.NullString `json:"nationality"`
+	BirthYear   database.NullInt64  `json:"birth_year"`
+	DeathYear   database.NullInt64  `json:"death_year"`
+	Aliases     []string            `json:"aliases"` // Variant names: pseudonyms, other spellings
 	LibraryID   int64               `json:"library_id"`
 }
+
+type AuthorDuplicateGroup struct {
+	Authors   []*Author `json:"authors"`
+	MatchType string    `json:"match_type"` // Normalized, Fuzzy
+	Score     float64   `json:"score"`      // Lowest similarity among the matched names
+}
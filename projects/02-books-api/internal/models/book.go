@@ -18,9 +18,29 @@ type Book struct {
 	Synopsis         database.NullString `json:"synopsis"`
 	PublisherID      database.NullInt64  `json:"publisher_id"`
 	ShelfID          database.NullInt64  `json:"shelf_id"`
-	Status           string              `json:"status"` // Available, Borrowed, Reserved, Maintenance
+	WorkID           database.NullInt64  `json:"work_id"`
+	SeriesID         database.NullInt64  `json:"series_id"`
+	VolumeNumber     database.NullInt64  `json:"volume_number"`
+	ClassScheme      database.NullString `json:"classification_scheme"` // DDC, CDU
+	ClassNumber      database.NullString `json:"classification_number"`
+	CutterNumber     database.NullString `json:"cutter_number"` // Overrides the Cutter generated from the main author
+	CallNumber       database.NullString `json:"call_number"`   // Generated from the classification
+	Status           string              `json:"status"`        // Derived: Available, Borrowed, Reserved, Maintenance, Unavailable
 	RegistrationDate time.Time           `json:"registration_date"`
 	LibraryID        int64               `json:"library_id"`
+	Cover            *CoverURLs          `json:"cover"`
+	Availability     *BookAvailability   `json:"availability"`
+}
+
+type BookAvailability struct {
+	BookID          int64             `json:"-"`
+	TotalCopies     int               `json:"total_copies"`
+	AvailableCopies int               `json:"available_copies"`
+	OnHoldCopies    int               `json:"on_hold_copies"` // Reserved copies or held for an active reservation
+	OnLoanCopies    int               `json:"on_loan_copies"`
+	EarliestDueDate database.NullTime `json:"earliest_due_date"`
+	QueueLength     int               `json:"queue_length"` // Pending reservations
+	Status          string            `json:"status"`
 }
 
 type BookAuthor struct {
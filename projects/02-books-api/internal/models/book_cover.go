@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type BookCover struct {
+	BookID      int64     `json:"book_id"`
+	ContentType string    `json:"content_type"` // image/jpeg, image/png
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	ETag        string    `json:"etag"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	LibraryID   int64     `json:"library_id"`
+}
+
+type CoverURLs struct {
+	Original string `json:"original"`
+	Medium   string `json:"medium"`
+	Small    string `json:"small"`
+}
@@ -6,5 +6,13 @@ type Category struct {
 	ID          int64               `json:"id"`
 	Name        string              `json:"name"`
 	Description database.NullString `json:"description"`
+	ParentID    database.NullInt64  `json:"parent_id"`
+	Path        string              `json:"path"` // Materialized path of ancestor IDs, e.g. /1/4/9/
+	Depth       int                 `json:"depth"`
 	LibraryID   int64               `json:"library_id"`
 }
+
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"`
+}
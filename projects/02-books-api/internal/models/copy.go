@@ -3,13 +3,21 @@ package models
 import "github.com/chicho69-cesar/backend-go/books/internal/database"
 
 type Copy struct {
-	ID              int64                `json:"id"`
-	Code            string               `json:"code"` // Barcode
-	BookID          int64                `json:"book_id"`
-	Status          string               `json:"status"`    // Available, Borrowed, Reserved, Damaged, Lost
-	Condition       string               `json:"condition"` // New, Good, Fair, Poor
-	AcquisitionDate database.NullTime    `json:"acquisition_date"`
-	PurchasePrice   database.NullFloat64 `json:"purchase_price"`
-	Notes           database.NullString  `json:"notes"`
-	LibraryID       int64                `json:"library_id"`
+	ID                int64                `json:"id"`
+	Code              string               `json:"code"` // Barcode
+	BookID            int64                `json:"book_id"`
+	Status            string               `json:"status"`    // Available, Borrowed, Reserved, Damaged, Lost, Withdrawn
+	Condition         string               `json:"condition"` // New, Good, Fair, Poor
+	AcquisitionDate   database.NullTime    `json:"acquisition_date"`
+	PurchasePrice     database.NullFloat64 `json:"purchase_price"`
+	Notes             database.NullString  `json:"notes"`
+	ShelfID           database.NullInt64   `json:"shelf_id"`
+	TemporaryLocation database.NullString  `json:"temporary_location"` // Exhibition, binding, etc.; the copy keeps its shelf
+	LibraryID         int64                `json:"library_id"`
+}
+
+type CopyMove struct {
+	CopyIDs           []int64             `json:"copy_ids,omitempty"`
+	ShelfID           database.NullInt64  `json:"shelf_id"`
+	TemporaryLocation database.NullString `json:"temporary_location"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+type CopyEvent struct {
+	ID          int64               `json:"id"`
+	CopyID      int64               `json:"copy_id"`
+	EventType   string              `json:"event_type"` // Created, Loaned, Renewed, Returned, StatusChanged, ConditionChanged, NotesChanged, BookChanged, Moved, TemporaryLocationChanged, AuditScanned, MarkedLost, Withdrawn (to_value holds the reason), Deleted
+	FromValue   database.NullString `json:"from_value"`
+	ToValue     database.NullString `json:"to_value"`
+	LibrarianID database.NullInt64  `json:"librarian_id"` // Staff account that made the change
+	LoanID      database.NullInt64  `json:"loan_id"`
+	AuditID     database.NullInt64  `json:"audit_id"`
+	OccurredAt  time.Time           `json:"occurred_at"`
+	LibraryID   int64               `json:"library_id"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+type DocumentTemplate struct {
+	DocumentType string            `json:"document_type"` // loan_slip, overdue_notice, fine_receipt, patron_statement
+	Description  string            `json:"description"`
+	Body         string            `json:"body"`
+	Customized   bool              `json:"customized"` // False while the library uses the default template
+	UpdatedAt    database.NullTime `json:"updated_at"`
+	LibraryID    int64             `json:"library_id"`
+}
+
+// DocumentData is what the document templates receive. Text fields are
+// already escaped for the markup and statuses come translated.
+type DocumentData struct {
+	Library      DocumentLibrary
+	Patron       DocumentPatron
+	Loan         *DocumentLoan
+	Loans        []DocumentLoan
+	Fine         *DocumentFine
+	Fines        []DocumentFine
+	Reservations []DocumentReservation
+	TotalDue     float64 // Pending fines of the patron
+	GeneratedAt  time.Time
+}
+
+type DocumentLibrary struct {
+	Name    string
+	Address string
+	Phone   string
+	Email   string
+	Website string
+}
+
+type DocumentPatron struct {
+	Code     string
+	Name     string
+	UserType string
+	Email    string
+	Phone    string
+}
+
+type DocumentLoan struct {
+	Code        string
+	Title       string
+	Authors     string
+	CopyCode    string
+	CallNumber  string
+	LoanDate    time.Time
+	DueDate     time.Time
+	ReturnDate  *time.Time
+	Status      string
+	Renewals    int
+	DaysOverdue int
+}
+
+type DocumentFine struct {
+	ID            int64
+	Reason        string
+	Amount        float64
+	Status        string
+	GeneratedDate time.Time
+	PaymentDate   *time.Time
+	LoanCode      string
+	Notes         string
+}
+
+type DocumentReservation struct {
+	Title           string
+	ReservationDate time.Time
+	ExpirationDate  time.Time
+	Status          string
+	Priority        int
+}
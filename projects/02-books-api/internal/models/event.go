@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+// Event is a circulation event recorded by the triggers in the same
+// transaction as the change. The webhooks fan it out once, with the payload
+// they all receive, and the event stream sends it as it comes.
+type Event struct {
+	ID         int64               `json:"id"`
+	EventType  string              `json:"event_type"` // loan.created, loan.returned, fine.created, reservation.ready, user.suspended
+	SubjectID  int64               `json:"subject_id"`
+	Payload    database.NullString `json:"payload"`
+	Status     string              `json:"status"` // Pending, Processed (fanned out to the webhooks)
+	OccurredAt time.Time           `json:"occurred_at"`
+	LibraryID  int64               `json:"library_id"`
+}
+
+// EventPayload is the body posted to the webhooks and sent on the event
+// stream; Data is the loan, fine, reservation or user as the API returns it.
+type EventPayload struct {
+	ID         int64     `json:"id"` // Of the event, the same for every subscription
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	LibraryID  int64     `json:"library_id"`
+	Data       any       `json:"data"`
+}
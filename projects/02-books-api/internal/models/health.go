@@ -0,0 +1,12 @@
+package models
+
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok, failed
+	Error  string `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Status string         `json:"status"` // ok, unavailable
+	Checks []*HealthCheck `json:"checks"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+type InventoryAudit struct {
+	ID        int64               `json:"id"`
+	ZoneID    database.NullInt64  `json:"zone_id"`  // Set for a zone audit
+	ShelfID   database.NullInt64  `json:"shelf_id"` // Set for a shelf audit
+	Status    string              `json:"status"`   // Open, Closed
+	Notes     database.NullString `json:"notes"`
+	StartedAt time.Time           `json:"started_at"`
+	ClosedAt  database.NullTime   `json:"closed_at"`
+	LibraryID int64               `json:"library_id"`
+}
+
+type InventoryScan struct {
+	ID        int64              `json:"id"`
+	AuditID   int64              `json:"audit_id"`
+	Barcode   string             `json:"barcode"`
+	ShelfID   database.NullInt64 `json:"shelf_id"` // Shelf where the copy was found
+	CopyID    database.NullInt64 `json:"copy_id"`  // Null for unknown barcodes
+	ScannedAt time.Time          `json:"scanned_at"`
+	LibraryID int64              `json:"library_id"`
+}
+
+type InventoryItem struct {
+	Copy            *Copy              `json:"copy"`
+	ExpectedShelfID database.NullInt64 `json:"expected_shelf_id"` // Shelf the copy is assigned to
+	ScannedShelfID  database.NullInt64 `json:"scanned_shelf_id"`
+}
+
+type InventoryReport struct {
+	Audit              *InventoryAudit  `json:"audit"`
+	ExpectedCount      int              `json:"expected_count"`
+	ScannedCount       int              `json:"scanned_count"`
+	Missing            []*InventoryItem `json:"missing"`
+	WrongShelf         []*InventoryItem `json:"wrong_shelf"`
+	ScannedWhileLoaned []*InventoryItem `json:"scanned_while_loaned"`
+	UnknownBarcodes    []string         `json:"unknown_barcodes"`
+	MarkedLost         int              `json:"marked_lost"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "github.com/chicho69-cesar/backend-go/books/internal/database"
+
+// LabelLayout is a sheet of labels; every measure is in millimeters.
+type LabelLayout struct {
+	ID            int64   `json:"id"`
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	PageWidth     float64 `json:"page_width"`
+	PageHeight    float64 `json:"page_height"`
+	Columns       int     `json:"columns"`
+	Rows          int     `json:"rows"`
+	LabelWidth    float64 `json:"label_width"`
+	LabelHeight   float64 `json:"label_height"`
+	MarginTop     float64 `json:"margin_top"`
+	MarginLeft    float64 `json:"margin_left"`
+	HorizontalGap float64 `json:"horizontal_gap"`
+	VerticalGap   float64 `json:"vertical_gap"`
+	BuiltIn       bool    `json:"built_in"`
+	LibraryID     int64   `json:"library_id"`
+}
+
+type LabelBatch struct {
+	CopyIDs       []int64           `json:"copy_ids,omitempty"`
+	AcquiredSince database.NullTime `json:"acquired_since"` // Labels every copy acquired since then
+	Layout        string            `json:"layout"`         // Layout code
+	Format        string            `json:"format"`         // svg, png
+	Skip          int               `json:"skip"`           // Positions already used on the first sheet
+}
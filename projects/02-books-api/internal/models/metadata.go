@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type BookMetadata struct {
+	ISBN            string    `json:"isbn"`
+	Title           string    `json:"title"`
+	Subtitle        string    `json:"subtitle"`
+	Authors         []string  `json:"authors"`
+	Publisher       string    `json:"publisher"`
+	PublicationYear int64     `json:"publication_year"`
+	Pages           int64     `json:"pages"`
+	Synopsis        string    `json:"synopsis"`
+	Provider        string    `json:"provider"`
+	FetchedAt       time.Time `json:"fetched_at"`
+}
+
+type BookEnrichment struct {
+	Book           *Book         `json:"book"`
+	Authors        []*Author     `json:"authors"`
+	Publisher      *Publisher    `json:"publisher"`
+	Metadata       *BookMetadata `json:"metadata"`
+	UpdatedFields  []string      `json:"updated_fields"`
+	SkippedFields  []string      `json:"skipped_fields"`  // Fields edited by a cataloguer
+	SkippedAuthors []string      `json:"skipped_authors"` // Names that are not a valid author, as a single word
+	Created        bool          `json:"created"`
+	FromCache      bool          `json:"from_cache"`
+}
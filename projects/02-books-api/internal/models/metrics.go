@@ -0,0 +1,11 @@
+package models
+
+// LibraryGauges is the state of the circulation of a library when the metrics
+// are scraped.
+type LibraryGauges struct {
+	LibraryID          int64
+	ActiveLoans        int64
+	OverdueLoans       int64
+	PendingFines       int64
+	PendingFinesAmount float64
+}
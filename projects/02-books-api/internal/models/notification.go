@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+// Notification is an event of the outbox, delivered to the patron by the
+// dispatcher.
+type Notification struct {
+	ID            int64               `json:"id"`
+	EventType     string              `json:"event_type"` // hold_available, due_soon, overdue, fine_created, card_expiring
+	UserID        int64               `json:"user_id"`
+	SubjectID     database.NullInt64  `json:"subject_id"` // Reservation, loan or fine of the event
+	DedupKey      string              `json:"dedup_key"`
+	Status        string              `json:"status"` // Pending, Sent, Failed, Skipped
+	Recipient     database.NullString `json:"recipient"`
+	Attempts      int                 `json:"attempts"`
+	NextAttemptAt database.NullTime   `json:"next_attempt_at"`
+	LastError     database.NullString `json:"last_error"`
+	CreatedAt     time.Time           `json:"created_at"`
+	SentAt        database.NullTime   `json:"sent_at"`
+	Channel       database.NullString `json:"channel"`   // email, sms; the one it was sent through
+	Mandatory     bool                `json:"mandatory"` // Sent even if the patron opted out, like lost-item billing
+	LibraryID     int64               `json:"library_id"`
+}
+
+type NotificationTemplate struct {
+	EventType   string            `json:"event_type"`
+	Language    string            `json:"language"` // es, en
+	Description string            `json:"description"`
+	Subject     string            `json:"subject"`
+	Body        string            `json:"body"`
+	Customized  bool              `json:"customized"` // False while the library uses the default template
+	UpdatedAt   database.NullTime `json:"updated_at"`
+	LibraryID   int64             `json:"library_id"`
+}
+
+// NotificationPreferences is how a patron wants to be notified. EventTypes
+// empty means every notice; the quiet hours are HH:MM in the server's time and
+// may cross midnight.
+type NotificationPreferences struct {
+	UserID          int64               `json:"user_id"`
+	Channel         string              `json:"channel"` // email, sms, none
+	EventTypes      []string            `json:"event_types"`
+	Language        string              `json:"language"` // es, en
+	QuietHoursStart database.NullString `json:"quiet_hours_start"`
+	QuietHoursEnd   database.NullString `json:"quiet_hours_end"`
+	Timezone        database.NullString `json:"timezone"` // IANA name, like America/Mexico_City; the quiet hours are in it
+	UpdatedAt       database.NullTime   `json:"updated_at"`
+	LibraryID       int64               `json:"library_id"`
+}
+
+// NotificationData is what the notification templates receive. Unlike the
+// documents, the text is plain and goes out unescaped.
+type NotificationData struct {
+	Library        DocumentLibrary
+	Patron         DocumentPatron
+	Loan           *DocumentLoan
+	Fine           *DocumentFine
+	Reservation    *DocumentReservation
+	CardExpiration *time.Time
+	TotalDue       float64 // Pending fines of the patron
+	GeneratedAt    time.Time
+}
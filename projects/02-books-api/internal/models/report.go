@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+type ReportParameter struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Default     string   `json:"default"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// ReportType is one of the reports the registry knows how to generate.
+type ReportType struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Parameters  []ReportParameter `json:"parameters"`
+}
+
+type ReportDefinition struct {
+	ID         int64               `json:"id"`
+	Name       string              `json:"name"`
+	ReportType string              `json:"report_type"` // overdue, fines, circulation
+	Parameters map[string]string   `json:"parameters"`
+	Format     string              `json:"format"`   // csv, xlsx, json
+	Schedule   database.NullString `json:"schedule"` // Cron expression; null runs only on demand
+	Paused     bool                `json:"paused"`   // A paused definition only runs on demand
+	LastRunAt  database.NullTime   `json:"last_run_at"`
+	NextRunAt  database.NullTime   `json:"next_run_at"`
+	CreatedAt  time.Time           `json:"created_at"`
+	LibraryID  int64               `json:"library_id"`
+}
+
+type ReportRun struct {
+	ID           int64               `json:"id"`
+	DefinitionID int64               `json:"definition_id"`
+	Name         string              `json:"name"`
+	ReportType   string              `json:"report_type"`
+	Format       string              `json:"format"`
+	Trigger      string              `json:"trigger"` // Manual, Scheduled
+	Status       string              `json:"status"`  // Running, Succeeded, Failed
+	FileKey      database.NullString `json:"-"`
+	FileName     database.NullString `json:"file_name"`
+	Size         int64               `json:"size"`
+	Rows         int                 `json:"rows"`
+	Error        database.NullString `json:"error"`
+	DownloadURL  string              `json:"download_url,omitempty"`
+	StartedAt    time.Time           `json:"started_at"`
+	FinishedAt   database.NullTime   `json:"finished_at"`
+	LibraryID    int64               `json:"library_id"`
+}
+
+type OverdueLoanRow struct {
+	LoanCode    string
+	UserCode    string
+	UserName    string
+	UserType    string
+	Email       database.NullString
+	Title       string
+	CopyCode    string
+	LoanDate    time.Time
+	DueDate     time.Time
+	DaysOverdue int
+}
+
+type FineRow struct {
+	FineID        int64
+	UserCode      string
+	UserName      string
+	Reason        string
+	Amount        float64
+	Status        string
+	GeneratedDate time.Time
+	PaymentDate   database.NullTime
+}
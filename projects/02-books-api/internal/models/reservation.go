@@ -1,15 +1,20 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
 
 type Reservation struct {
-	ID              int64     `json:"id"`
-	UserID          int64     `json:"user_id"`
-	BookID          int64     `json:"book_id"`
-	ReservationDate time.Time `json:"reservation_date"`
-	ExpirationDate  time.Time `json:"expiration_date"`
-	Status          string    `json:"status"` // Pending, Active, Cancelled, Expired
-	Priority        int       `json:"priority"`
-	Notified        bool      `json:"notified"`
-	LibraryID       int64     `json:"library_id"`
+	ID              int64              `json:"id"`
+	UserID          int64              `json:"user_id"`
+	BookID          int64              `json:"book_id"` // For work-level holds, the edition that fulfills it
+	WorkID          database.NullInt64 `json:"work_id"` // Set when any edition of the work fulfills the hold
+	ReservationDate time.Time          `json:"reservation_date"`
+	ExpirationDate  time.Time          `json:"expiration_date"`
+	Status          string             `json:"status"` // Pending, Active, Cancelled, Expired
+	Priority        int                `json:"priority"`
+	Notified        bool               `json:"notified"`
+	LibraryID       int64              `json:"library_id"`
 }
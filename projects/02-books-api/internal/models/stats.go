@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// CirculationBucket holds the activity of one day, week (starting on Monday)
+// or month, identified by its first day.
+type CirculationBucket struct {
+	Period         string  `json:"period,omitempty"`
+	Loans          int     `json:"loans"`
+	Returns        int     `json:"returns"`
+	Renewals       int     `json:"renewals"`
+	NewPatrons     int     `json:"new_patrons"`
+	FinesCollected float64 `json:"fines_collected"`
+}
+
+type CirculationBreakdown struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Loans    int    `json:"loans"`
+	Returns  int    `json:"returns"`
+	Renewals int    `json:"renewals"`
+}
+
+type TopBook struct {
+	BookID int64  `json:"book_id"`
+	Title  string `json:"title"`
+	Loans  int    `json:"loans"`
+}
+
+type TopPatron struct {
+	UserID   int64  `json:"user_id"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	UserType string `json:"user_type"`
+	Loans    int    `json:"loans"`
+}
+
+type CirculationStats struct {
+	From        string                             `json:"from"`
+	To          string                             `json:"to"`
+	Interval    string                             `json:"interval"`
+	Totals      CirculationBucket                  `json:"totals"`
+	Buckets     []*CirculationBucket               `json:"buckets"`
+	Breakdowns  map[string][]*CirculationBreakdown `json:"breakdowns,omitempty"`
+	TopBooks    []*TopBook                         `json:"top_books"`
+	TopPatrons  []*TopPatron                       `json:"top_patrons"`
+	GeneratedAt time.Time                          `json:"generated_at"`
+}
@@ -18,5 +18,6 @@ type User struct {
 	UserType         string              `json:"user_type"` // Student, Teacher, Staff, External
 	Status           string              `json:"status"`    // Active, Suspended, Inactive
 	RegistrationDate time.Time           `json:"registration_date"`
+	CardExpiration   database.NullTime   `json:"card_expiration"` // Null when the card does not expire
 	LibraryID        int64               `json:"library_id"`
 }
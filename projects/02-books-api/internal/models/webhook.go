@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+type WebhookSubscription struct {
+	ID          int64               `json:"id"`
+	URL         string              `json:"url"`
+	Secret      string              `json:"secret,omitempty"` // Only returned when it is created or replaced
+	EventTypes  []string            `json:"event_types"`      // Empty means every event
+	Description database.NullString `json:"description"`
+	Active      bool                `json:"active"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   database.NullTime   `json:"updated_at"`
+	LibraryID   int64               `json:"library_id"`
+}
+
+type WebhookDelivery struct {
+	ID             int64               `json:"id"`
+	SubscriptionID int64               `json:"subscription_id"`
+	EventID        int64               `json:"event_id"`
+	EventType      string              `json:"event_type"`
+	Status         string              `json:"status"` // Pending, Delivered, Failed
+	Attempts       int                 `json:"attempts"`
+	NextAttemptAt  database.NullTime   `json:"next_attempt_at"`
+	LastStatusCode database.NullInt64  `json:"last_status_code"`
+	LastError      database.NullString `json:"last_error"`
+	CreatedAt      time.Time           `json:"created_at"`
+	DeliveredAt    database.NullTime   `json:"delivered_at"`
+	LibraryID      int64               `json:"library_id"`
+	Payload        json.RawMessage     `json:"payload,omitempty"`
+	AttemptLog     []*WebhookAttempt   `json:"attempt_log,omitempty"`
+	URL            string              `json:"-"`
+	Secret         string              `json:"-"`
+}
+
+// WebhookAttempt is one request made for a delivery and what the receiver
+// answered.
+type WebhookAttempt struct {
+	ID           int64               `json:"id"`
+	DeliveryID   int64               `json:"delivery_id"`
+	Attempt      int                 `json:"attempt"`
+	RequestedAt  time.Time           `json:"requested_at"`
+	StatusCode   database.NullInt64  `json:"status_code"`
+	ResponseBody database.NullString `json:"response_body"`
+	Error        database.NullString `json:"error"`
+	DurationMs   int64               `json:"duration_ms"`
+	LibraryID    int64               `json:"library_id"`
+}
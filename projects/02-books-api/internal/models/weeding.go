@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+)
+
+type WeedingCopy struct {
+	CopyID          int64               `json:"copy_id"`
+	Code            string              `json:"code"`
+	BookID          int64               `json:"book_id"`
+	Title           string              `json:"title"`
+	CallNumber      database.NullString `json:"call_number"`
+	ZoneID          database.NullInt64  `json:"zone_id"`
+	ShelfID         database.NullInt64  `json:"shelf_id"`
+	Status          string              `json:"status"`
+	Condition       string              `json:"condition"`
+	AcquisitionDate database.NullTime   `json:"acquisition_date"`
+	LastLoanDate    database.NullTime   `json:"last_loan_date"`
+	LoanCount       int                 `json:"loan_count"`
+}
+
+// TitleDemand compares the copies of a title with its reservation queue and
+// its recent loans.
+type TitleDemand struct {
+	BookID              int64               `json:"book_id"`
+	Title               string              `json:"title"`
+	CallNumber          database.NullString `json:"call_number"`
+	Copies              int                 `json:"copies"`
+	PendingReservations int                 `json:"pending_reservations"`
+	RecentLoans         int                 `json:"recent_loans"`
+	SuggestedChange     int                 `json:"suggested_change"` // Copies to buy (positive) or to weed (negative)
+}
+
+type CategoryAge struct {
+	CategoryID  int64                `json:"category_id"`
+	Name        string               `json:"name"`
+	Path        string               `json:"path"`
+	Books       int                  `json:"books"`
+	OldBooks    int                  `json:"old_books"` // Published before the age limit
+	OldShare    float64              `json:"old_share"`
+	OldestYear  database.NullInt64   `json:"oldest_year"`
+	AverageYear database.NullFloat64 `json:"average_year"`
+}
+
+type WeedingReport struct {
+	GeneratedAt     time.Time          `json:"generated_at"`
+	ZoneID          database.NullInt64 `json:"zone_id"`
+	IdleMonths      int                `json:"idle_months"`
+	MaxAge          int                `json:"max_age"`
+	HoldsRatio      float64            `json:"holds_ratio"`
+	NotLoaned       []*WeedingCopy     `json:"not_loaned"`
+	PoorCondition   []*WeedingCopy     `json:"poor_condition"`
+	Understocked    []*TitleDemand     `json:"understocked"`
+	Overstocked     []*TitleDemand     `json:"overstocked"`
+	AgingCategories []*CategoryAge     `json:"aging_categories"`
+}
+
+// CopyWithdrawal takes copies out of the collection for good.
+type CopyWithdrawal struct {
+	CopyIDs []int64             `json:"copy_ids,omitempty"`
+	Reason  database.NullString `json:"reason"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "github.com/chicho69-cesar/backend-go/books/internal/database"
+
+type Work struct {
+	ID            int64               `json:"id"`
+	Title         string              `json:"title"`
+	OriginalTitle database.NullString `json:"original_title"`
+	Description   database.NullString `json:"description"`
+	LibraryID     int64               `json:"library_id"`
+}
+
+type Series struct {
+	ID          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Description database.NullString `json:"description"`
+	LibraryID   int64               `json:"library_id"`
+}
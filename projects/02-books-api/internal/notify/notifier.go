@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+)
+
+var ErrInvalidRecipient = errors.New("El destinatario es inválido")
+
+// Message is a notification ready to be delivered to a patron.
+type Message struct {
+	ID       string // Stable for the event, so the receiving side can drop repeats
+	FromName string
+	ReplyTo  string
+	To       string
+	Subject  string
+	Body     string
+}
+
+// Notifier delivers messages through a channel, email or SMS.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// IsPermanent reports whether retrying the message would fail again, like a
+// mailbox the server rejected or a request the gateway refused.
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrInvalidRecipient) {
+		return true
+	}
+
+	var protocolErr *textproto.Error
+	if errors.As(err, &protocolErr) {
+		return protocolErr.Code >= 500
+	}
+
+	var gatewayErr *GatewayError
+	if errors.As(err, &gatewayErr) {
+		code := gatewayErr.StatusCode
+		return code >= 400 && code < 500 && code != 408 && code != 429
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var smsPhoneRegex = regexp.MustCompile(`^[+]?[0-9]{10,15}$`)
+
+// SMSGatewayNotifier sends the messages as text messages through an HTTP
+// gateway: a JSON POST with the number and the text, authenticated with a
+// bearer token when there is one.
+type SMSGatewayNotifier struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// GatewayError is a response of the gateway other than 2xx.
+type GatewayError struct {
+	StatusCode int
+	Body       string
+}
+
+type smsRequest struct {
+	ID      string `json:"id"`
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+func (e *GatewayError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("La pasarela de SMS respondió con estado %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("La pasarela de SMS respondió con estado %d: %s", e.StatusCode, e.Body)
+}
+
+func NewSMSGatewayNotifier(gatewayURL, token string, timeout time.Duration) (*SMSGatewayNotifier, error) {
+	parsed, err := url.Parse(gatewayURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("La dirección de la pasarela de SMS es inválida: %s", gatewayURL)
+	}
+
+	return &SMSGatewayNotifier{
+		url:    gatewayURL,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Send delivers only the body, a text message has no subject. The message ID
+// goes as the idempotency key so the gateway can drop repeats.
+func (n *SMSGatewayNotifier) Send(ctx context.Context, msg Message) error {
+	to := strings.NewReplacer("-", "", " ", "").Replace(msg.To)
+	if !smsPhoneRegex.MatchString(to) {
+		return fmt.Errorf("%w: %s", ErrInvalidRecipient, msg.To)
+	}
+
+	payload, err := json.Marshal(smsRequest{ID: msg.ID, To: to, Message: msg.Body})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", msg.ID)
+
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error al conectar con la pasarela de SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &GatewayError{StatusCode: resp.StatusCode, Body: strings.Join(strings.Fields(string(body)), " ")}
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
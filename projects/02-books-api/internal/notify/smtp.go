@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier sends the messages by email. Servers on port 465 are reached
+// through TLS, the rest upgrade the connection when they offer STARTTLS.
+type SMTPNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	timeout  time.Duration
+}
+
+func NewSMTPNotifier(addr, username, password, from string, timeout time.Duration) (*SMTPNotifier, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("La dirección del servidor SMTP es inválida: %w", err)
+	}
+
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("El remitente de los correos es inválido: %w", err)
+	}
+
+	return &SMTPNotifier{
+		addr:     addr,
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+		timeout:  timeout,
+	}, nil
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidRecipient, msg.To)
+	}
+
+	msg.To = to.Address
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	conn, err := n.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("Error al conectar con el servidor SMTP: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return fmt.Errorf("Error al conectar con el servidor SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if _, isTLS := conn.(*tls.Conn); !isTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+				return fmt.Errorf("Error al cifrar la conexión SMTP: %w", err)
+			}
+		}
+	}
+
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return fmt.Errorf("Error al autenticarse en el servidor SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return fmt.Errorf("El servidor SMTP rechazó el remitente: %w", err)
+	}
+
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("El servidor SMTP rechazó el destinatario: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("Error al enviar el correo: %w", err)
+	}
+
+	if _, err := writer.Write(n.compose(msg)); err != nil {
+		return fmt.Errorf("Error al enviar el correo: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("El servidor SMTP rechazó el correo: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (n *SMTPNotifier) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{}
+
+	if strings.HasSuffix(n.addr, ":465") {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: n.host}}
+		return tlsDialer.DialContext(ctx, "tcp", n.addr)
+	}
+
+	return dialer.DialContext(ctx, "tcp", n.addr)
+}
+
+// compose builds the message with UTF-8 headers and a quoted-printable body,
+// so accents arrive intact through any server.
+func (n *SMTPNotifier) compose(msg Message) []byte {
+	var buf bytes.Buffer
+
+	from := n.from
+	if msg.FromName != "" {
+		from = (&mail.Address{Name: msg.FromName, Address: n.from}).String()
+	}
+
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+
+	header("From", from)
+	header("To", msg.To)
+
+	if replyTo, err := mail.ParseAddress(msg.ReplyTo); err == nil {
+		header("Reply-To", replyTo.String())
+	}
+
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+
+	if msg.ID != "" {
+		header("Message-ID", fmt.Sprintf("<%s@%s>", msg.ID, n.host))
+	}
+
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	body.Write([]byte(msg.Body))
+	body.Close()
+
+	return buf.Bytes()
+}
@@ -0,0 +1,318 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a minimal SMTP server that records what it receives.
+// rcptReply, when set, replaces the answer to RCPT TO.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	rcptReply string
+	auth      bool
+	silent    bool
+
+	mu       sync.Mutex
+	from     string
+	rcpt     []string
+	data     string
+	authLine string
+}
+
+func newFakeSMTPServer(t *testing.T, configure func(*fakeSMTPServer)) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	server := &fakeSMTPServer{listener: listener}
+	if configure != nil {
+		configure(server)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go server.serve()
+
+	return server
+}
+
+func (s *fakeSMTPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if s.silent {
+		io.Copy(io.Discard, conn)
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+
+	reply("220 fake ESMTP")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+			case "EHLO", "HELO":
+				if s.auth {
+					reply("250-fake")
+					reply("250 AUTH PLAIN")
+				} else {
+					reply("250 fake")
+				}
+			case "AUTH":
+				s.mu.Lock()
+				s.authLine = line
+				s.mu.Unlock()
+				reply("235 Authentication succeeded")
+			case "MAIL":
+				s.mu.Lock()
+				s.from = line
+				s.mu.Unlock()
+				reply("250 OK")
+			case "RCPT":
+				if s.rcptReply != "" {
+					reply(s.rcptReply)
+					continue
+				}
+
+				s.mu.Lock()
+				s.rcpt = append(s.rcpt, line)
+				s.mu.Unlock()
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					if dataLine == ".\r\n" {
+						break
+					}
+
+					data.WriteString(dataLine)
+				}
+
+				s.mu.Lock()
+				s.data = data.String()
+				s.mu.Unlock()
+				reply("250 Queued")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) received() (string, []string, string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.from, s.rcpt, s.data, s.authLine
+}
+
+func newTestSMTPNotifier(t *testing.T, addr, username string) *SMTPNotifier {
+	t.Helper()
+
+	notifier, err := NewSMTPNotifier(addr, username, "secreto", "biblioteca@example.com", time.Second)
+	if err != nil {
+		t.Fatalf("NewSMTPNotifier: %v", err)
+	}
+
+	return notifier
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	notifier := newTestSMTPNotifier(t, server.addr(), "")
+
+	msg := Message{
+		ID:       "notification-1-7",
+		FromName: "Biblioteca Central",
+		ReplyTo:  "mostrador@example.com",
+		To:       "Ana Pérez <ana@example.com>",
+		Subject:  "Su reservación está lista",
+		Body:     "Hola, Ana:\n\nEl libro «Pedro Páramo» ya está disponible.\n",
+	}
+
+	if err := notifier.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	from, rcpt, data, _ := server.received()
+
+	if from != "MAIL FROM:<biblioteca@example.com> BODY=8BITMIME" && from != "MAIL FROM:<biblioteca@example.com>" {
+		t.Errorf("MAIL = %q", from)
+	}
+
+	if len(rcpt) != 1 || rcpt[0] != "RCPT TO:<ana@example.com>" {
+		t.Errorf("RCPT = %v", rcpt)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+
+	if got := parsed.Header.Get("Message-ID"); got != "<notification-1-7@127.0.0.1>" {
+		t.Errorf("Message-ID = %q", got)
+	}
+
+	if got := parsed.Header.Get("To"); got != "ana@example.com" {
+		t.Errorf("To = %q", got)
+	}
+
+	if got := parsed.Header.Get("Reply-To"); got != "<mostrador@example.com>" {
+		t.Errorf("Reply-To = %q", got)
+	}
+
+	fromHeader, err := parsed.Header.AddressList("From")
+	if err != nil || len(fromHeader) != 1 || fromHeader[0].Name != "Biblioteca Central" {
+		t.Errorf("From = %v (%v)", fromHeader, err)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("body: %v", err)
+	}
+
+	if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != msg.Body {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestSMTPNotifierAuthenticates(t *testing.T) {
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.auth = true })
+	notifier := newTestSMTPNotifier(t, server.addr(), "usuario")
+
+	if err := notifier.Send(context.Background(), Message{To: "ana@example.com", Subject: "Aviso", Body: "Hola"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	_, _, _, authLine := server.received()
+
+	credentials := base64.StdEncoding.EncodeToString([]byte("\x00usuario\x00secreto"))
+	if authLine != "AUTH PLAIN "+credentials {
+		t.Errorf("AUTH = %q", authLine)
+	}
+}
+
+func TestSMTPNotifierInvalidRecipient(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	notifier := newTestSMTPNotifier(t, server.addr(), "")
+
+	err := notifier.Send(context.Background(), Message{To: "no es un correo", Subject: "Aviso", Body: "Hola"})
+	if !errors.Is(err, ErrInvalidRecipient) || !IsPermanent(err) {
+		t.Fatalf("err = %v, want a permanent ErrInvalidRecipient", err)
+	}
+
+	if from, _, _, _ := server.received(); from != "" {
+		t.Errorf("the server was contacted: %q", from)
+	}
+}
+
+func TestSMTPNotifierRejectedRecipient(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		permanent bool
+	}{
+		{"mailbox unavailable", "550 No such user", true},
+		{"mailbox busy", "451 Try again later", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.rcptReply = tt.reply })
+			notifier := newTestSMTPNotifier(t, server.addr(), "")
+
+			err := notifier.Send(context.Background(), Message{To: "ana@example.com", Subject: "Aviso", Body: "Hola"})
+			if err == nil {
+				t.Fatal("err = nil, want the rejection")
+			}
+
+			if IsPermanent(err) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, !tt.permanent, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestSMTPNotifierTimeout(t *testing.T) {
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.silent = true })
+
+	notifier, err := NewSMTPNotifier(server.addr(), "", "", "biblioteca@example.com", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewSMTPNotifier: %v", err)
+	}
+
+	start := time.Now()
+
+	err = notifier.Send(context.Background(), Message{To: "ana@example.com", Subject: "Aviso", Body: "Hola"})
+	if err == nil || IsPermanent(err) {
+		t.Fatalf("err = %v, want a temporary error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send took %v, the timeout was not applied", elapsed)
+	}
+}
+
+func TestNewSMTPNotifierValidates(t *testing.T) {
+	if _, err := NewSMTPNotifier("sin-puerto", "", "", "biblioteca@example.com", time.Second); err == nil {
+		t.Error("an address without port was accepted")
+	}
+
+	if _, err := NewSMTPNotifier("127.0.0.1:25", "", "", "biblioteca", time.Second); err == nil {
+		t.Error("an invalid sender was accepted")
+	}
+}
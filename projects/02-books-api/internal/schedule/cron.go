@@ -0,0 +1,211 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week (0 or 7 is Sunday).
+type Cron struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// As in cron, when both days and weekdays are restricted either one matches
+	anyDay     bool
+	anyWeekday bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var weekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+func Parse(expression string) (*Cron, error) {
+	expression = strings.TrimSpace(expression)
+
+	if macro, ok := macros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, errors.New("La expresión cron debe tener 5 campos: minuto, hora, día, mes y día de la semana")
+	}
+
+	cron := &Cron{
+		anyDay:     fields[2] == "*" || fields[2] == "?",
+		anyWeekday: fields[4] == "*" || fields[4] == "?",
+	}
+
+	var err error
+
+	if cron.minutes, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("Minuto inválido: %w", err)
+	}
+
+	if cron.hours, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("Hora inválida: %w", err)
+	}
+
+	if cron.days, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("Día del mes inválido: %w", err)
+	}
+
+	if cron.months, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("Mes inválido: %w", err)
+	}
+
+	if cron.weekdays, err = parseField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("Día de la semana inválido: %w", err)
+	}
+
+	if cron.weekdays&(1<<7) != 0 {
+		cron.weekdays |= 1
+	}
+
+	return cron, nil
+}
+
+// Next returns the first minute strictly after t that matches the expression,
+// in the location of t, or the zero time if there is none in five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (c *Cron) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	switch {
+		case c.anyDay && c.anyWeekday:
+			return true
+		case c.anyDay:
+			return weekday
+		case c.anyWeekday:
+			return day
+		default:
+			return day || weekday
+	}
+}
+
+// parseField turns a field with lists, ranges and steps (1,15 1-5 */10 MON-FRI)
+// into a bit set of the allowed values.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			var err error
+
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("paso inválido en %q", part)
+			}
+
+			part = part[:slash]
+		}
+
+		low, high := min, max
+
+		switch {
+			case part == "*" || part == "?":
+			case strings.Contains(part, "-"):
+				bounds := strings.SplitN(part, "-", 2)
+
+				var err error
+
+				if low, err = parseValue(bounds[0], names); err != nil {
+					return 0, err
+				}
+
+				if high, err = parseValue(bounds[1], names); err != nil {
+					return 0, err
+				}
+			default:
+				value, err := parseValue(part, names)
+				if err != nil {
+					return 0, err
+				}
+
+				low = value
+				high = value
+
+				if step > 1 {
+					high = max
+				}
+		}
+
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q fuera del rango %d-%d", part, min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(value string, names map[string]int) (int, error) {
+	if number, ok := names[strings.ToUpper(value)]; ok {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("valor inválido %q", value)
+	}
+
+	return number, nil
+}
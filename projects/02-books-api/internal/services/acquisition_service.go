@@ -0,0 +1,865 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/metadata"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+var ErrBudgetExceeded = errors.New("El presupuesto no alcanza para la orden")
+
+var validOrderStatuses = map[string]bool{
+	"Draft":             true,
+	"Ordered":           true,
+	"PartiallyReceived": true,
+	"Received":          true,
+	"Closed":            true,
+	"Cancelled":         true,
+}
+
+type VendorService struct {
+	vendorStore  store.IVendorStore
+	orderStore   store.IPurchaseOrderStore
+	auditService *AuditService
+}
+
+type BudgetService struct {
+	budgetStore  store.IBudgetStore
+	orderStore   store.IPurchaseOrderStore
+	auditService *AuditService
+}
+
+type PurchaseOrderService struct {
+	orderStore   store.IPurchaseOrderStore
+	vendorStore  store.IVendorStore
+	budgetStore  store.IBudgetStore
+	bookStore    store.IBookStore
+	copyStore    store.ICopyStore
+	copyService  *CopyService
+	auditService *AuditService
+}
+
+func NewVendorService(vendorStore store.IVendorStore, orderStore store.IPurchaseOrderStore, auditService *AuditService) *VendorService {
+	return &VendorService{
+		vendorStore:  vendorStore,
+		orderStore:   orderStore,
+		auditService: auditService,
+	}
+}
+
+func NewBudgetService(budgetStore store.IBudgetStore, orderStore store.IPurchaseOrderStore, auditService *AuditService) *BudgetService {
+	return &BudgetService{
+		budgetStore:  budgetStore,
+		orderStore:   orderStore,
+		auditService: auditService,
+	}
+}
+
+func NewPurchaseOrderService(orderStore store.IPurchaseOrderStore, vendorStore store.IVendorStore, budgetStore store.IBudgetStore, bookStore store.IBookStore, copyStore store.ICopyStore, copyService *CopyService, auditService *AuditService) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		orderStore:   orderStore,
+		vendorStore:  vendorStore,
+		budgetStore:  budgetStore,
+		bookStore:    bookStore,
+		copyStore:    copyStore,
+		copyService:  copyService,
+		auditService: auditService,
+	}
+}
+
+func (s *VendorService) GetAllVendors(libraryID int64) ([]*models.Vendor, error) {
+	vendors, err := s.vendorStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los proveedores: %w", err)
+	}
+
+	return vendors, nil
+}
+
+func (s *VendorService) GetVendorByID(libraryID, id int64) (*models.Vendor, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID del proveedor es inválido")
+	}
+
+	vendor, err := s.vendorStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el proveedor con ID %d: %w", id, err)
+	}
+
+	return vendor, nil
+}
+
+func (s *VendorService) CreateVendor(ctx context.Context, libraryID int64, vendor *models.Vendor) (*models.Vendor, error) {
+	trimVendor(vendor)
+
+	if err := validations.ValidateVendor(vendor); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	existingVendor, err := s.vendorStore.GetByName(libraryID, vendor.Name)
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar el nombre del proveedor: %w", err)
+	}
+
+	if existingVendor != nil {
+		return nil, fmt.Errorf("Ya existe un proveedor con el nombre %s", vendor.Name)
+	}
+
+	createdVendor, err := s.vendorStore.Create(libraryID, vendor)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear el proveedor: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "vendor", createdVendor.ID, "create", nil, createdVendor); err != nil {
+		return nil, err
+	}
+
+	return createdVendor, nil
+}
+
+func (s *VendorService) UpdateVendor(ctx context.Context, libraryID, id int64, vendor *models.Vendor) (*models.Vendor, error) {
+	previousVendor, err := s.GetVendorByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	trimVendor(vendor)
+
+	if err := validations.ValidateVendor(vendor); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	existingVendor, err := s.vendorStore.GetByName(libraryID, vendor.Name)
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar el nombre del proveedor: %w", err)
+	}
+
+	if existingVendor != nil && existingVendor.ID != id {
+		return nil, fmt.Errorf("Ya existe otro proveedor con el nombre %s", vendor.Name)
+	}
+
+	updatedVendor, err := s.vendorStore.Update(libraryID, id, vendor)
+	if err != nil {
+		return nil, fmt.Errorf("Error al actualizar el proveedor con ID %d: %w", id, err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "vendor", id, "update", previousVendor, updatedVendor); err != nil {
+		return nil, err
+	}
+
+	return updatedVendor, nil
+}
+
+func (s *VendorService) DeleteVendor(ctx context.Context, libraryID, id int64) error {
+	vendor, err := s.GetVendorByID(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	orders, err := s.orderStore.GetAll(libraryID, store.PurchaseOrderFilter{VendorID: &id})
+	if err != nil {
+		return fmt.Errorf("Error al verificar las órdenes del proveedor: %w", err)
+	}
+
+	if len(orders) > 0 {
+		return fmt.Errorf("No se puede eliminar el proveedor porque tiene %d orden(es) de compra", len(orders))
+	}
+
+	if err := s.vendorStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar el proveedor con ID %d: %w", id, err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "vendor", id, "delete", vendor, nil)
+}
+
+func (s *BudgetService) GetAllBudgets(libraryID int64, fiscalYear int) ([]*models.Budget, error) {
+	budgets, err := s.budgetStore.GetAll(libraryID, fiscalYear)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los presupuestos: %w", err)
+	}
+
+	for _, budget := range budgets {
+		if err := attachBudgetTotals(s.budgetStore, libraryID, budget); err != nil {
+			return nil, err
+		}
+	}
+
+	return budgets, nil
+}
+
+func (s *BudgetService) GetBudgetByID(libraryID, id int64) (*models.Budget, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID del presupuesto es inválido")
+	}
+
+	budget, err := s.budgetStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el presupuesto con ID %d: %w", id, err)
+	}
+
+	if err := attachBudgetTotals(s.budgetStore, libraryID, budget); err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *BudgetService) CreateBudget(ctx context.Context, libraryID int64, budget *models.Budget) (*models.Budget, error) {
+	budget.Name = strings.TrimSpace(budget.Name)
+
+	if err := validations.ValidateBudget(budget); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	existingBudget, err := s.budgetStore.GetByName(libraryID, budget.Name, budget.FiscalYear)
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar el nombre del presupuesto: %w", err)
+	}
+
+	if existingBudget != nil {
+		return nil, fmt.Errorf("Ya existe el presupuesto %s para el año fiscal %d", budget.Name, budget.FiscalYear)
+	}
+
+	createdBudget, err := s.budgetStore.Create(libraryID, budget)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear el presupuesto: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "budget", createdBudget.ID, "create", nil, createdBudget); err != nil {
+		return nil, err
+	}
+
+	return s.GetBudgetByID(libraryID, createdBudget.ID)
+}
+
+func (s *BudgetService) UpdateBudget(ctx context.Context, libraryID, id int64, budget *models.Budget) (*models.Budget, error) {
+	previousBudget, err := s.GetBudgetByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.Name = strings.TrimSpace(budget.Name)
+
+	if err := validations.ValidateBudget(budget); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	existingBudget, err := s.budgetStore.GetByName(libraryID, budget.Name, budget.FiscalYear)
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar el nombre del presupuesto: %w", err)
+	}
+
+	if existingBudget != nil && existingBudget.ID != id {
+		return nil, fmt.Errorf("Ya existe el presupuesto %s para el año fiscal %d", budget.Name, budget.FiscalYear)
+	}
+
+	if _, err := s.budgetStore.Update(libraryID, id, budget); err != nil {
+		return nil, fmt.Errorf("Error al actualizar el presupuesto con ID %d: %w", id, err)
+	}
+
+	updatedBudget, err := s.GetBudgetByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "budget", id, "update", previousBudget, updatedBudget); err != nil {
+		return nil, err
+	}
+
+	return updatedBudget, nil
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, libraryID, id int64) error {
+	budget, err := s.GetBudgetByID(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	orders, err := s.orderStore.GetAll(libraryID, store.PurchaseOrderFilter{BudgetID: &id})
+	if err != nil {
+		return fmt.Errorf("Error al verificar las órdenes del presupuesto: %w", err)
+	}
+
+	if len(orders) > 0 {
+		return fmt.Errorf("No se puede eliminar el presupuesto porque tiene %d orden(es) de compra", len(orders))
+	}
+
+	if err := s.budgetStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar el presupuesto con ID %d: %w", id, err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "budget", id, "delete", budget, nil)
+}
+
+func (s *PurchaseOrderService) GetAllOrders(libraryID int64, filter store.PurchaseOrderFilter) ([]*models.PurchaseOrder, error) {
+	filter.Status = strings.TrimSpace(filter.Status)
+
+	if filter.Status != "" && !validOrderStatuses[filter.Status] {
+		return nil, errors.New("El estado debe ser: Draft, Ordered, PartiallyReceived, Received, Closed o Cancelled")
+	}
+
+	orders, err := s.orderStore.GetAll(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las órdenes de compra: %w", err)
+	}
+
+	for _, order := range orders {
+		if err := s.attachLines(libraryID, order); err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
+}
+
+func (s *PurchaseOrderService) GetOrderByID(libraryID, id int64) (*models.PurchaseOrder, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la orden es inválido")
+	}
+
+	order, err := s.orderStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la orden con ID %d: %w", id, err)
+	}
+
+	if err := s.attachLines(libraryID, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *PurchaseOrderService) CreateOrder(ctx context.Context, libraryID int64, order *models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	if err := s.checkOrder(libraryID, 0, order); err != nil {
+		return nil, err
+	}
+
+	order.Status = "Draft"
+	order.CreatedAt = time.Now()
+
+	createdOrder, err := s.orderStore.Create(libraryID, order)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear la orden de compra: %w", err)
+	}
+
+	return s.recordOrder(ctx, libraryID, createdOrder.ID, "create", nil)
+}
+
+// UpdateOrder rewrites a draft order, lines included.
+func (s *PurchaseOrderService) UpdateOrder(ctx context.Context, libraryID, id int64, order *models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	existingOrder, err := s.GetOrderByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingOrder.Status != "Draft" {
+		return nil, errors.New("Solo se pueden modificar órdenes en borrador")
+	}
+
+	if err := s.checkOrder(libraryID, id, order); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.orderStore.Update(libraryID, id, order); err != nil {
+		return nil, fmt.Errorf("Error al actualizar la orden con ID %d: %w", id, err)
+	}
+
+	return s.recordOrder(ctx, libraryID, id, "update", existingOrder)
+}
+
+func (s *PurchaseOrderService) DeleteOrder(ctx context.Context, libraryID, id int64) error {
+	order, err := s.GetOrderByID(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != "Draft" {
+		return errors.New("Solo se pueden eliminar órdenes en borrador; cancele la orden en su lugar")
+	}
+
+	if err := s.orderStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar la orden con ID %d: %w", id, err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "purchase_order", id, "delete", order, nil)
+}
+
+// SubmitOrder sends a draft order to the vendor, committing its total against
+// the budget.
+func (s *PurchaseOrderService) SubmitOrder(ctx context.Context, libraryID, id int64) (*models.PurchaseOrder, error) {
+	order, err := s.GetOrderByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != "Draft" {
+		return nil, errors.New("Solo se pueden enviar órdenes en borrador")
+	}
+
+	budget, err := s.budgetStore.GetByID(libraryID, order.BudgetID)
+	if err != nil {
+		return nil, fmt.Errorf("El presupuesto con ID %d no existe: %w", order.BudgetID, err)
+	}
+
+	if err := attachBudgetTotals(s.budgetStore, libraryID, budget); err != nil {
+		return nil, err
+	}
+
+	if order.Total > budget.Available {
+		return nil, fmt.Errorf("%w: total %.2f, disponible %.2f", ErrBudgetExceeded, order.Total, budget.Available)
+	}
+
+	if err := s.orderStore.UpdateStatus(libraryID, id, "Ordered"); err != nil {
+		return nil, fmt.Errorf("Error al enviar la orden con ID %d: %w", id, err)
+	}
+
+	return s.recordOrder(ctx, libraryID, id, "submit", order)
+}
+
+// CancelOrder cancels an order that has not received anything, or closes one
+// that did, releasing what is still pending from the budget.
+func (s *PurchaseOrderService) CancelOrder(ctx context.Context, libraryID, id int64) (*models.PurchaseOrder, error) {
+	order, err := s.GetOrderByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "Cancelled"
+
+	switch order.Status {
+		case "Draft", "Ordered":
+		case "PartiallyReceived":
+			status = "Closed"
+		default:
+			return nil, fmt.Errorf("No se puede cancelar una orden en estado %s", order.Status)
+	}
+
+	if err := s.orderStore.UpdateStatus(libraryID, id, status); err != nil {
+		return nil, fmt.Errorf("Error al cancelar la orden con ID %d: %w", id, err)
+	}
+
+	return s.recordOrder(ctx, libraryID, id, "cancel", order)
+}
+
+// ReceiveLine creates one copy per unit received with the price of the line
+// and the receipt date, and records them against the line in the same
+// transaction. Lines ordered by ISBN need the book to be cataloged first.
+func (s *PurchaseOrderService) ReceiveLine(ctx context.Context, libraryID, orderID, lineID int64, receipt *models.LineReceipt, librarianID *int64) ([]*models.Copy, error) {
+	order, err := s.GetOrderByID(libraryID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != "Ordered" && order.Status != "PartiallyReceived" {
+		return nil, fmt.Errorf("No se puede recibir una orden en estado %s", order.Status)
+	}
+
+	var line *models.PurchaseOrderLine
+
+	for _, orderLine := range order.Lines {
+		if orderLine.ID == lineID {
+			line = orderLine
+		}
+	}
+
+	if line == nil {
+		return nil, fmt.Errorf("La línea con ID %d no pertenece a la orden", lineID)
+	}
+
+	if receipt.Quantity == 0 {
+		receipt.Quantity = len(receipt.Codes)
+	}
+
+	for i, code := range receipt.Codes {
+		receipt.Codes[i] = strings.TrimSpace(strings.ToUpper(code))
+	}
+
+	if err := validations.ValidateLineReceipt(receipt); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	pending := line.Quantity - line.QuantityReceived
+	if receipt.Quantity > pending {
+		return nil, fmt.Errorf("Solo quedan %d ejemplar(es) pendientes en la línea", pending)
+	}
+
+	bookID, err := s.lineBookID(libraryID, line)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.bookStore.GetByID(libraryID, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("El libro con ID %d no existe: %w", bookID, err)
+	}
+
+	codes := receipt.Codes
+	if len(codes) == 0 {
+		for i := 1; i <= receipt.Quantity; i++ {
+			codes = append(codes, fmt.Sprintf("PO%06d%03d", line.ID, line.QuantityReceived+i))
+		}
+	}
+
+	seen := make(map[string]bool, len(codes))
+
+	for _, code := range codes {
+		if seen[code] {
+			return nil, fmt.Errorf("El código %s está repetido", code)
+		}
+
+		seen[code] = true
+
+		existingCopy, err := s.copyStore.GetByCode(libraryID, code)
+		if err != nil {
+			return nil, fmt.Errorf("Error al verificar el código %s: %w", code, err)
+		}
+
+		if existingCopy != nil {
+			return nil, fmt.Errorf("Ya existe una copia con el código %s", code)
+		}
+	}
+
+	receivedAt := time.Now()
+	if receipt.ReceivedAt.Valid {
+		receivedAt = receipt.ReceivedAt.Time
+	}
+
+	condition := receipt.Condition
+	if condition == "" {
+		condition = "New"
+	}
+
+	shelfID := receipt.ShelfID
+	if !shelfID.Valid {
+		shelfID = book.ShelfID
+	}
+
+	copies := make([]*models.Copy, 0, len(codes))
+
+	for _, code := range codes {
+		copy := &models.Copy{
+			Code:      code,
+			BookID:    bookID,
+			Status:    "Available",
+			Condition: condition,
+			ShelfID:   shelfID,
+		}
+
+		copy.AcquisitionDate.Valid = true
+		copy.AcquisitionDate.Time = receivedAt
+		copy.PurchasePrice.Valid = true
+		copy.PurchasePrice.Float64 = line.UnitPrice
+		copy.Notes.Valid = true
+		copy.Notes.String = "Orden de compra " + order.OrderNumber
+
+		if err := validations.ValidateCopy(copy); err != nil {
+			return nil, fmt.Errorf("Validación fallida: %w", err)
+		}
+
+		if err := s.copyService.checkCopyLocation(libraryID, copy); err != nil {
+			return nil, err
+		}
+
+		copies = append(copies, copy)
+	}
+
+	err = s.orderStore.ReceiveLine(libraryID, orderID, lineID, copies, receivedAt, librarianID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("La línea ya no tiene suficientes ejemplares pendientes; consulte la orden e intente de nuevo")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error al registrar la recepción: %w", err)
+	}
+
+	for _, copy := range copies {
+		if err := s.auditService.Record(ctx, libraryID, "copy", copy.ID, "create", nil, copy); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.recordOrder(ctx, libraryID, orderID, "receive", order); err != nil {
+		return nil, err
+	}
+
+	return copies, nil
+}
+
+func (s *PurchaseOrderService) GetReceipts(libraryID, orderID int64) ([]*models.PurchaseReceipt, error) {
+	if _, err := s.GetOrderByID(libraryID, orderID); err != nil {
+		return nil, err
+	}
+
+	receipts, err := s.orderStore.GetReceipts(libraryID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las recepciones de la orden: %w", err)
+	}
+
+	return receipts, nil
+}
+
+func (s *PurchaseOrderService) GetInvoices(libraryID, orderID int64) ([]*models.Invoice, error) {
+	if _, err := s.GetOrderByID(libraryID, orderID); err != nil {
+		return nil, err
+	}
+
+	invoices, err := s.orderStore.GetInvoices(libraryID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las facturas de la orden: %w", err)
+	}
+
+	return invoices, nil
+}
+
+func (s *PurchaseOrderService) AddInvoice(ctx context.Context, libraryID, orderID int64, invoice *models.Invoice) (*models.Invoice, error) {
+	order, err := s.GetOrderByID(libraryID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status == "Draft" || order.Status == "Cancelled" {
+		return nil, fmt.Errorf("No se pueden registrar facturas de una orden en estado %s", order.Status)
+	}
+
+	invoice.InvoiceNumber = strings.TrimSpace(invoice.InvoiceNumber)
+
+	if err := validations.ValidateInvoice(invoice); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	existingInvoice, err := s.orderStore.GetInvoiceByNumber(libraryID, order.VendorID, invoice.InvoiceNumber)
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar el número de factura: %w", err)
+	}
+
+	if existingInvoice != nil {
+		return nil, fmt.Errorf("La factura %s del proveedor ya fue registrada", invoice.InvoiceNumber)
+	}
+
+	invoice.OrderID = orderID
+	invoice.VendorID = order.VendorID
+	invoice.Amount = roundAmount(invoice.Amount)
+	invoice.CreatedAt = time.Now()
+
+	if !invoice.InvoiceDate.Valid {
+		invoice.InvoiceDate.Valid = true
+		invoice.InvoiceDate.Time = invoice.CreatedAt
+	}
+
+	createdInvoice, err := s.orderStore.CreateInvoice(libraryID, invoice)
+	if err != nil {
+		return nil, fmt.Errorf("Error al registrar la factura: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "invoice", createdInvoice.ID, "create", nil, createdInvoice); err != nil {
+		return nil, err
+	}
+
+	return createdInvoice, nil
+}
+
+// recordOrder reads the order as it is after the change, lines included, and
+// records the change.
+func (s *PurchaseOrderService) recordOrder(ctx context.Context, libraryID, id int64, action string, previousOrder *models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	order, err := s.GetOrderByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "purchase_order", id, action, previousOrder, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetReconciliation compares the invoices of the order with the value of what
+// was received.
+func (s *PurchaseOrderService) GetReconciliation(libraryID, orderID int64) (*models.OrderReconciliation, error) {
+	order, err := s.GetOrderByID(libraryID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices, err := s.orderStore.GetInvoices(libraryID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las facturas de la orden: %w", err)
+	}
+
+	reconciliation := &models.OrderReconciliation{
+		OrderID:  order.ID,
+		Ordered:  order.Total,
+		Invoices: invoices,
+	}
+
+	for _, line := range order.Lines {
+		reconciliation.Received += float64(line.QuantityReceived) * line.UnitPrice
+	}
+
+	for _, invoice := range invoices {
+		reconciliation.Invoiced += invoice.Amount
+	}
+
+	reconciliation.Received = roundAmount(reconciliation.Received)
+	reconciliation.Invoiced = roundAmount(reconciliation.Invoiced)
+	reconciliation.Difference = roundAmount(reconciliation.Invoiced - reconciliation.Received)
+
+	switch {
+		case reconciliation.Difference > 0:
+			reconciliation.Status = "OverInvoiced"
+		case reconciliation.Difference < 0:
+			reconciliation.Status = "UnderInvoiced"
+		default:
+			reconciliation.Status = "Balanced"
+	}
+
+	return reconciliation, nil
+}
+
+func (s *PurchaseOrderService) checkOrder(libraryID, id int64, order *models.PurchaseOrder) error {
+	order.OrderNumber = strings.TrimSpace(strings.ToUpper(order.OrderNumber))
+
+	for _, line := range order.Lines {
+		if line != nil && line.ISBN.Valid {
+			line.ISBN.String = metadata.NormalizeISBN(line.ISBN.String)
+		}
+	}
+
+	if err := validations.ValidatePurchaseOrder(order); err != nil {
+		return fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	existingOrder, err := s.orderStore.GetByNumber(libraryID, order.OrderNumber)
+	if err != nil {
+		return fmt.Errorf("Error al verificar el número de orden: %w", err)
+	}
+
+	if existingOrder != nil && existingOrder.ID != id {
+		return fmt.Errorf("Ya existe una orden con el número %s", order.OrderNumber)
+	}
+
+	if _, err := s.vendorStore.GetByID(libraryID, order.VendorID); err != nil {
+		return fmt.Errorf("El proveedor con ID %d no existe: %w", order.VendorID, err)
+	}
+
+	if _, err := s.budgetStore.GetByID(libraryID, order.BudgetID); err != nil {
+		return fmt.Errorf("El presupuesto con ID %d no existe: %w", order.BudgetID, err)
+	}
+
+	for _, line := range order.Lines {
+		line.UnitPrice = roundAmount(line.UnitPrice)
+
+		if line.BookID.Valid {
+			book, err := s.bookStore.GetByID(libraryID, line.BookID.Int64)
+			if err != nil {
+				return fmt.Errorf("El libro con ID %d no existe: %w", line.BookID.Int64, err)
+			}
+
+			line.Title.Valid = true
+			line.Title.String = book.Title
+
+			if !line.ISBN.Valid {
+				line.ISBN.Valid = true
+				line.ISBN.String = metadata.NormalizeISBN(book.ISBN)
+			}
+
+			continue
+		}
+
+		book, err := s.bookStore.GetByISBN(libraryID, line.ISBN.String)
+		if err != nil {
+			return fmt.Errorf("Error al buscar el libro con ISBN %s: %w", line.ISBN.String, err)
+		}
+
+		if book != nil {
+			line.BookID.Valid = true
+			line.BookID.Int64 = book.ID
+			line.Title.Valid = true
+			line.Title.String = book.Title
+			continue
+		}
+
+		if !line.Title.Valid || strings.TrimSpace(line.Title.String) == "" {
+			return fmt.Errorf("El ISBN %s no está en el catálogo; indique el título de la línea", line.ISBN.String)
+		}
+
+		line.Title.String = strings.TrimSpace(line.Title.String)
+	}
+
+	return nil
+}
+
+// lineBookID returns the book the copies of the line belong to, looking it up
+// by ISBN when the line was ordered before the book was cataloged.
+func (s *PurchaseOrderService) lineBookID(libraryID int64, line *models.PurchaseOrderLine) (int64, error) {
+	if line.BookID.Valid {
+		return line.BookID.Int64, nil
+	}
+
+	book, err := s.bookStore.GetByISBN(libraryID, line.ISBN.String)
+	if err != nil {
+		return 0, fmt.Errorf("Error al buscar el libro con ISBN %s: %w", line.ISBN.String, err)
+	}
+
+	if book == nil {
+		return 0, fmt.Errorf("El libro con ISBN %s aún no está en el catálogo; regístrelo antes de recibirlo", line.ISBN.String)
+	}
+
+	return book.ID, nil
+}
+
+func (s *PurchaseOrderService) attachLines(libraryID int64, order *models.PurchaseOrder) error {
+	lines, err := s.orderStore.GetLines(libraryID, order.ID)
+	if err != nil {
+		return fmt.Errorf("Error al obtener las líneas de la orden: %w", err)
+	}
+
+	order.Lines = lines
+	order.Total = 0
+
+	for _, line := range lines {
+		order.Total += float64(line.Quantity) * line.UnitPrice
+	}
+
+	order.Total = roundAmount(order.Total)
+
+	return nil
+}
+
+func attachBudgetTotals(budgetStore store.IBudgetStore, libraryID int64, budget *models.Budget) error {
+	committed, spent, invoiced, err := budgetStore.GetTotals(libraryID, budget.ID)
+	if err != nil {
+		return fmt.Errorf("Error al calcular el ejercicio del presupuesto: %w", err)
+	}
+
+	budget.Committed = roundAmount(committed)
+	budget.Spent = roundAmount(spent)
+	budget.Invoiced = roundAmount(invoiced)
+	budget.Available = roundAmount(budget.Amount - budget.Committed - budget.Spent)
+
+	return nil
+}
+
+func trimVendor(vendor *models.Vendor) {
+	vendor.Name = strings.TrimSpace(vendor.Name)
+
+	for _, field := range []*database.NullString{&vendor.ContactName, &vendor.Email, &vendor.Phone, &vendor.AccountNumber, &vendor.Notes} {
+		if field.Valid {
+			field.String = strings.TrimSpace(field.String)
+		}
+	}
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+func TestReceiveLineIsAtomic(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+
+	seed := []string{
+		`INSERT INTO books (id, isbn, title, library_id) VALUES (1, '9780000000001', 'Pedro Páramo', 1)`,
+		`INSERT INTO vendors (id, name, library_id) VALUES (1, 'Distribuidora Norte', 1)`,
+		`INSERT INTO budgets (id, name, fiscal_year, amount, library_id) VALUES (1, 'General', 2026, 1000, 1)`,
+		`INSERT INTO purchase_orders (id, order_number, vendor_id, budget_id, status, library_id) VALUES (1, 'PO-1', 1, 1, 'Ordered', 1)`,
+		`INSERT INTO purchase_order_lines (id, order_id, isbn, quantity, unit_price, library_id) VALUES (1, 1, '9780000000001', 2, 150, 1)`,
+		`INSERT INTO copies (code, book_id, status, library_id) VALUES ('TAKEN', 1, 'Available', 1)`,
+	}
+
+	for _, query := range seed {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	orderStore := store.NewPurchaseOrderStore(db)
+
+	receive := func(codes ...string) error {
+		copies := make([]*models.Copy, 0, len(codes))
+		for _, code := range codes {
+			copies = append(copies, &models.Copy{Code: code, BookID: 1, Status: "Available", Condition: "New"})
+		}
+
+		return orderStore.ReceiveLine(1, 1, 1, copies, time.Now(), nil)
+	}
+
+	state := func() (received, copies, receipts, events int) {
+		t.Helper()
+
+		err := db.QueryRow(`
+			SELECT
+				(SELECT quantity_received FROM purchase_order_lines WHERE id = 1),
+				(SELECT COUNT(*) FROM copies),
+				(SELECT COUNT(*) FROM purchase_receipts),
+				(SELECT COUNT(*) FROM copy_events WHERE event_type = 'Created')
+		`).Scan(&received, &copies, &receipts, &events)
+
+		if err != nil {
+			t.Fatalf("state: %v", err)
+		}
+
+		return received, copies, receipts, events
+	}
+
+	if err := receive("PO1", "TAKEN"); err == nil {
+		t.Fatal("receiving a code already in use succeeded")
+	}
+
+	if received, copies, receipts, events := state(); received != 0 || copies != 1 || receipts != 0 || events != 0 {
+		t.Fatalf("after failed receipt: received %d, copies %d, receipts %d, events %d", received, copies, receipts, events)
+	}
+
+	if err := receive("PO1", "PO2", "PO3"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("over-receiving: err = %v, want sql.ErrNoRows", err)
+	}
+
+	if err := receive("PO1"); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+
+	if err := receive("PO2", "PO3"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("over-receiving the rest: err = %v, want sql.ErrNoRows", err)
+	}
+
+	if err := receive("PO2"); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+
+	if received, copies, receipts, events := state(); received != 2 || copies != 3 || receipts != 2 || events != 2 {
+		t.Fatalf("after receipts: received %d, copies %d, receipts %d, events %d", received, copies, receipts, events)
+	}
+
+	var status string
+	if err := db.QueryRow(`SELECT status FROM purchase_orders WHERE id = 1`).Scan(&status); err != nil {
+		t.Fatalf("order: %v", err)
+	}
+
+	if status != "Received" {
+		t.Errorf("order status = %s, want Received", status)
+	}
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -11,10 +13,14 @@ import (
 )
 
 type LoanService struct {
-	loanStore store.ILoanStore
-	userStore store.IUserStore
-	copyStore store.ICopyStore
-	fineStore store.IFineStore
+	loanStore        store.ILoanStore
+	userStore        store.IUserStore
+	copyStore        store.ICopyStore
+	fineStore        store.IFineStore
+	reservationStore store.IReservationStore
+	bookStore        store.IBookStore
+	copyEventStore   store.ICopyEventStore
+	auditService     *AuditService
 }
 
 type ReservationService struct {
@@ -23,38 +29,46 @@ type ReservationService struct {
 	bookStore        store.IBookStore
 	copyStore        store.ICopyStore
 	fineStore        store.IFineStore
+	auditService     *AuditService
 }
 
 type FineService struct {
-	fineStore store.IFineStore
-	userStore store.IUserStore
-	loanStore store.ILoanStore
+	fineStore    store.IFineStore
+	userStore    store.IUserStore
+	loanStore    store.ILoanStore
+	auditService *AuditService
 }
 
-func NewLoanService(loanStore store.ILoanStore, userStore store.IUserStore, copyStore store.ICopyStore, fineStore store.IFineStore) *LoanService {
+func NewLoanService(loanStore store.ILoanStore, userStore store.IUserStore, copyStore store.ICopyStore, fineStore store.IFineStore, reservationStore store.IReservationStore, bookStore store.IBookStore, copyEventStore store.ICopyEventStore, auditService *AuditService) *LoanService {
 	return &LoanService{
-		loanStore: loanStore,
-		userStore: userStore,
-		copyStore: copyStore,
-		fineStore: fineStore,
+		loanStore:        loanStore,
+		userStore:        userStore,
+		copyStore:        copyStore,
+		fineStore:        fineStore,
+		reservationStore: reservationStore,
+		bookStore:        bookStore,
+		copyEventStore:   copyEventStore,
+		auditService:     auditService,
 	}
 }
 
-func NewReservationService(reservationStore store.IReservationStore, userStore store.IUserStore, bookStore store.IBookStore, copyStore store.ICopyStore, fineStore store.IFineStore) *ReservationService {
+func NewReservationService(reservationStore store.IReservationStore, userStore store.IUserStore, bookStore store.IBookStore, copyStore store.ICopyStore, fineStore store.IFineStore, auditService *AuditService) *ReservationService {
 	return &ReservationService{
 		reservationStore: reservationStore,
 		userStore:        userStore,
 		bookStore:        bookStore,
 		copyStore:        copyStore,
 		fineStore:        fineStore,
+		auditService:     auditService,
 	}
 }
 
-func NewFineService(fineStore store.IFineStore, userStore store.IUserStore, loanStore store.ILoanStore) *FineService {
+func NewFineService(fineStore store.IFineStore, userStore store.IUserStore, loanStore store.ILoanStore, auditService *AuditService) *FineService {
 	return &FineService{
-		fineStore: fineStore,
-		userStore: userStore,
-		loanStore: loanStore,
+		fineStore:    fineStore,
+		userStore:    userStore,
+		loanStore:    loanStore,
+		auditService: auditService,
 	}
 }
 
@@ -87,7 +101,7 @@ func (s *LoanService) GetLoansFiltered(libraryID int64, filter store.LoanFilter)
 	return loans, nil
 }
 
-func (s *LoanService) CreateLoan(libraryID int64, loan *models.Loan) (*models.Loan, error) {
+func (s *LoanService) CreateLoan(ctx context.Context, libraryID int64, loan *models.Loan) (*models.Loan, error) {
 	if err := validations.ValidateLoan(loan); err != nil {
 		return nil, err
 	}
@@ -122,6 +136,11 @@ func (s *LoanService) CreateLoan(libraryID int64, loan *models.Loan) (*models.Lo
 		return nil, fmt.Errorf("La copia no está en condiciones para préstamo")
 	}
 
+	heldReservation, err := s.checkHolds(libraryID, copy.BookID, loan.UserID)
+	if err != nil {
+		return nil, err
+	}
+
 	overdueLoans, err := s.loanStore.GetLoansFiltered(libraryID, store.LoanFilter{Overdue: true})
 	if err != nil {
 		return nil, fmt.Errorf("Error al verificar préstamos vencidos: %v", err)
@@ -158,16 +177,74 @@ func (s *LoanService) CreateLoan(libraryID int64, loan *models.Loan) (*models.Lo
 		return nil, fmt.Errorf("Error al crear préstamo: %v", err)
 	}
 
-	copy.Status = "Loaned"
-	_, err = s.copyStore.Update(libraryID, copy.ID, copy)
+	if err := s.auditService.Record(ctx, libraryID, "loan", createdLoan.ID, "create", nil, createdLoan); err != nil {
+		return nil, err
+	}
+
+	previousCopy := *copy
+	copy.Status = "Borrowed"
+	updatedCopy, err := s.copyStore.Update(libraryID, copy.ID, copy)
 	if err != nil {
 		return nil, fmt.Errorf("Error al actualizar estado de copia: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "copy", copy.ID, "update", &previousCopy, updatedCopy); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordLoanEvent(libraryID, createdLoan, "Loaned", previousCopy.Status, copy.Status, nil); err != nil {
+		return nil, err
+	}
+
+	if heldReservation != nil {
+		previousReservation := *heldReservation
+		heldReservation.Status = "Completed"
+
+		completedReservation, err := s.reservationStore.Update(libraryID, heldReservation.ID, heldReservation)
+		if err != nil {
+			return nil, fmt.Errorf("Error al completar la reservación con ID %d: %v", heldReservation.ID, err)
+		}
+
+		if err := s.auditService.Record(ctx, libraryID, "reservation", heldReservation.ID, "process", &previousReservation, completedReservation); err != nil {
+			return nil, err
+		}
+	}
+
 	return createdLoan, nil
 }
 
-func (s *LoanService) RenewLoan(libraryID, id int64, librarianID *int64) (*models.Loan, error) {
+// checkHolds keeps the shelf copies of a book held for its active reservations
+// away from other users. It returns the user's own active reservation, which
+// the loan fulfills, or nil.
+func (s *LoanService) checkHolds(libraryID, bookID, userID int64) (*models.Reservation, error) {
+	holds, err := s.reservationStore.GetReservationsFiltered(libraryID, store.ReservationFilter{BookID: &bookID, Status: "Active"})
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar reservaciones activas: %v", err)
+	}
+
+	for _, hold := range holds {
+		if hold.UserID == userID {
+			return hold, nil
+		}
+	}
+
+	if len(holds) == 0 {
+		return nil, nil
+	}
+
+	availability, err := s.bookStore.GetAvailability(libraryID, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar la disponibilidad del libro: %v", err)
+	}
+
+	if availability.AvailableCopies == 0 {
+		return nil, fmt.Errorf("La copia está apartada para una reservación activa de otro usuario")
+	}
+
+	return nil, nil
+}
+
+func (s *LoanService) RenewLoan(ctx context.Context, libraryID, id int64, librarianID *int64) (*models.Loan, error) {
 	loan, err := s.loanStore.GetByID(libraryID, id)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener préstamo: %v", err)
@@ -191,6 +268,7 @@ func (s *LoanService) RenewLoan(libraryID, id int64, librarianID *int64) (*model
 		return nil, fmt.Errorf("El usuario tiene multas pendientes, debe pagarlas antes de renovar el préstamo")
 	}
 
+	previousLoan := *loan
 	loan.Renewals++
 	loan.DueDate = loan.DueDate.Add(time.Duration(loan.LoanDays) * 24 * time.Hour)
 
@@ -204,10 +282,18 @@ func (s *LoanService) RenewLoan(libraryID, id int64, librarianID *int64) (*model
 		return nil, fmt.Errorf("Error al renovar préstamo: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "loan", id, "renew", &previousLoan, updatedLoan); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordLoanEvent(libraryID, updatedLoan, "Renewed", "", updatedLoan.DueDate.Format("2006-01-02"), librarianID); err != nil {
+		return nil, err
+	}
+
 	return updatedLoan, nil
 }
 
-func (s *LoanService) ReturnLoan(libraryID, id int64, notes *string) (*models.Loan, error) {
+func (s *LoanService) ReturnLoan(ctx context.Context, libraryID, id int64, notes *string, librarianID *int64) (*models.Loan, error) {
 	loan, err := s.loanStore.GetByID(libraryID, id)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener préstamo: %v", err)
@@ -222,6 +308,7 @@ func (s *LoanService) ReturnLoan(libraryID, id int64, notes *string) (*models.Lo
 	}
 
 	now := time.Now()
+	previousLoan := *loan
 	loan.Status = "Returned"
 	loan.ReturnDate.Valid = true
 	loan.ReturnDate.Time = now
@@ -236,17 +323,41 @@ func (s *LoanService) ReturnLoan(libraryID, id int64, notes *string) (*models.Lo
 		return nil, fmt.Errorf("Error al actualizar préstamo: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "loan", id, "return", &previousLoan, updatedLoan); err != nil {
+		return nil, err
+	}
+
 	copy, err := s.copyStore.GetByID(libraryID, loan.CopyID)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener copia: %v", err)
 	}
 
+	previousCopy := *copy
 	copy.Status = "Available"
-	_, err = s.copyStore.Update(libraryID, copy.ID, copy)
+	updatedCopy, err := s.copyStore.Update(libraryID, copy.ID, copy)
 	if err != nil {
 		return nil, fmt.Errorf("Error al actualizar estado de copia: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "copy", copy.ID, "update", &previousCopy, updatedCopy); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordLoanEvent(libraryID, updatedLoan, "Returned", previousCopy.Status, copy.Status, librarianID); err != nil {
+		return nil, err
+	}
+
+	previousReservation, activatedReservation, err := s.activateNextReservation(libraryID, copy.BookID)
+	if err != nil {
+		slog.WarnContext(ctx, "Error al activar la siguiente reservación", "library_id", libraryID, "book_id", copy.BookID, "error", err)
+	}
+
+	if activatedReservation != nil {
+		if err := s.auditService.Record(ctx, libraryID, "reservation", activatedReservation.ID, "activate", previousReservation, activatedReservation); err != nil {
+			return nil, err
+		}
+	}
+
 	if now.After(loan.DueDate) {
 		daysLate := int(now.Sub(loan.DueDate).Hours() / 24)
 		fineAmount := float64(daysLate) * 5.0
@@ -264,9 +375,11 @@ func (s *LoanService) ReturnLoan(libraryID, id int64, notes *string) (*models.Lo
 			fine.LoanID.Valid = true
 			fine.LoanID.Int64 = loan.ID
 
-			_, err = s.fineStore.Create(libraryID, fine)
+			createdFine, err := s.fineStore.Create(libraryID, fine)
 			if err != nil {
-				fmt.Printf("Advertencia: Error al crear multa automática: %v\n", err)
+				slog.WarnContext(ctx, "Error al crear multa automática", "library_id", libraryID, "loan_id", loan.ID, "error", err)
+			} else if err := s.auditService.Record(ctx, libraryID, "fine", createdFine.ID, "create", nil, createdFine); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -274,7 +387,56 @@ func (s *LoanService) ReturnLoan(libraryID, id int64, notes *string) (*models.Lo
 	return updatedLoan, nil
 }
 
-func (s *LoanService) Update(libraryID, id int64, loan *models.Loan) (*models.Loan, error) {
+// recordLoanEvent appends a loan event to the copy history; without an explicit
+// librarian the one registered on the loan is used.
+func (s *LoanService) recordLoanEvent(libraryID int64, loan *models.Loan, eventType, fromValue, toValue string, librarianID *int64) error {
+	if librarianID == nil && loan.LibrarianID.Valid {
+		librarianID = &loan.LibrarianID.Int64
+	}
+
+	event := newCopyEvent(loan.CopyID, eventType, fromValue, toValue, librarianID)
+	event.LoanID.Valid = true
+	event.LoanID.Int64 = loan.ID
+
+	return recordCopyEvents(s.copyEventStore, libraryID, event)
+}
+
+// activateNextReservation makes the next pending hold on the book Active and
+// returns it as it was and as it is now, nil when nobody was waiting.
+func (s *LoanService) activateNextReservation(libraryID, bookID int64) (*models.Reservation, *models.Reservation, error) {
+	book, err := s.bookStore.GetByID(libraryID, bookID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error al obtener el libro con ID %d: %w", bookID, err)
+	}
+
+	var workID *int64
+	if book.WorkID.Valid {
+		workID = &book.WorkID.Int64
+	}
+
+	reservation, err := s.reservationStore.GetNextPending(libraryID, book.ID, workID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error al obtener la siguiente reservación pendiente: %w", err)
+	}
+
+	if reservation == nil {
+		return nil, nil, nil
+	}
+
+	previousReservation := *reservation
+	reservation.BookID = book.ID
+	reservation.Status = "Active"
+	reservation.Notified = false
+
+	activatedReservation, err := s.reservationStore.Update(libraryID, reservation.ID, reservation)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error al activar la reservación con ID %d: %w", reservation.ID, err)
+	}
+
+	return &previousReservation, activatedReservation, nil
+}
+
+func (s *LoanService) Update(ctx context.Context, libraryID, id int64, loan *models.Loan) (*models.Loan, error) {
 	if err := validations.ValidateLoan(loan); err != nil {
 		return nil, err
 	}
@@ -288,10 +450,19 @@ func (s *LoanService) Update(libraryID, id int64, loan *models.Loan) (*models.Lo
 		return nil, fmt.Errorf("Préstamo con ID %d no encontrado", id)
 	}
 
-	return s.loanStore.Update(libraryID, id, loan)
+	updatedLoan, err := s.loanStore.Update(libraryID, id, loan)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "loan", id, "update", existingLoan, updatedLoan); err != nil {
+		return nil, err
+	}
+
+	return updatedLoan, nil
 }
 
-func (s *LoanService) Delete(libraryID, id int64) error {
+func (s *LoanService) Delete(ctx context.Context, libraryID, id int64) error {
 	loan, err := s.loanStore.GetByID(libraryID, id)
 	if err != nil {
 		return fmt.Errorf("Error al obtener préstamo: %v", err)
@@ -301,7 +472,11 @@ func (s *LoanService) Delete(libraryID, id int64) error {
 		return fmt.Errorf("Préstamo con ID %d no encontrado", id)
 	}
 
-	return s.loanStore.Delete(libraryID, id)
+	if err := s.loanStore.Delete(libraryID, id); err != nil {
+		return err
+	}
+
+	return s.auditService.Record(ctx, libraryID, "loan", id, "delete", loan, nil)
 }
 
 func (s *ReservationService) GetAll(libraryID int64) ([]*models.Reservation, error) {
@@ -325,7 +500,7 @@ func (s *ReservationService) GetReservationsFiltered(libraryID int64, filter sto
 	return reservations, nil
 }
 
-func (s *ReservationService) CreateReservation(libraryID int64, reservation *models.Reservation) (*models.Reservation, error) {
+func (s *ReservationService) CreateReservation(ctx context.Context, libraryID int64, reservation *models.Reservation) (*models.Reservation, error) {
 	if err := validations.ValidateReservation(reservation); err != nil {
 		return nil, err
 	}
@@ -352,28 +527,52 @@ func (s *ReservationService) CreateReservation(libraryID int64, reservation *mod
 		return nil, fmt.Errorf("El usuario tiene %d multa(s) pendiente(s) y no puede hacer reservaciones", len(pendingFines))
 	}
 
+	if reservation.WorkID.Valid {
+		if err := s.prepareWorkReservation(libraryID, reservation); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.prepareBookReservation(libraryID, reservation); err != nil {
+			return nil, err
+		}
+	}
+
+	reservation.LibraryID = libraryID
+	createdReservation, err := s.reservationStore.Create(libraryID, reservation)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear reservación: %v", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "reservation", createdReservation.ID, "create", nil, createdReservation); err != nil {
+		return nil, err
+	}
+
+	return createdReservation, nil
+}
+
+func (s *ReservationService) prepareBookReservation(libraryID int64, reservation *models.Reservation) error {
 	book, err := s.bookStore.GetByID(libraryID, reservation.BookID)
 	if err != nil {
-		return nil, fmt.Errorf("Error al verificar libro: %v", err)
+		return fmt.Errorf("Error al verificar libro: %v", err)
 	}
 
 	if book == nil {
-		return nil, fmt.Errorf("El libro con ID %d no existe", reservation.BookID)
+		return fmt.Errorf("El libro con ID %d no existe", reservation.BookID)
 	}
 
 	existingReservation, err := s.reservationStore.GetActiveByUserAndBook(libraryID, reservation.UserID, reservation.BookID)
 	if err != nil {
-		return nil, fmt.Errorf("Error al verificar reservaciones existentes: %v", err)
+		return fmt.Errorf("Error al verificar reservaciones existentes: %v", err)
 	}
 
 	if existingReservation != nil {
-		return nil, fmt.Errorf("El usuario ya tiene una reservación activa para este libro")
+		return fmt.Errorf("El usuario ya tiene una reservación activa para este libro")
 	}
 
 	bookID := reservation.BookID
 	copies, err := s.copyStore.GetCopiesFiltered(libraryID, store.CopyFilter{BookID: &bookID})
 	if err != nil {
-		return nil, fmt.Errorf("Error al verificar copias del libro: %v", err)
+		return fmt.Errorf("Error al verificar copias del libro: %v", err)
 	}
 
 	availableCopies := 0
@@ -384,19 +583,53 @@ func (s *ReservationService) CreateReservation(libraryID int64, reservation *mod
 	}
 
 	if availableCopies > 0 {
-		return nil, fmt.Errorf("Hay copias disponibles del libro, no es necesario realizar una reservación")
+		return fmt.Errorf("Hay copias disponibles del libro, no es necesario realizar una reservación")
 	}
 
-	reservation.LibraryID = libraryID
-	createdReservation, err := s.reservationStore.Create(libraryID, reservation)
+	return nil
+}
+
+func (s *ReservationService) prepareWorkReservation(libraryID int64, reservation *models.Reservation) error {
+	workID := reservation.WorkID.Int64
+
+	editions, err := s.bookStore.GetBooksFiltered(libraryID, store.BookFilter{WorkID: &workID})
 	if err != nil {
-		return nil, fmt.Errorf("Error al crear reservación: %v", err)
+		return fmt.Errorf("Error al verificar las ediciones de la obra: %v", err)
 	}
 
-	return createdReservation, nil
+	if len(editions) == 0 {
+		return fmt.Errorf("La obra con ID %d no existe o no tiene ediciones", workID)
+	}
+
+	existingReservation, err := s.reservationStore.GetActiveByUserAndWork(libraryID, reservation.UserID, workID)
+	if err != nil {
+		return fmt.Errorf("Error al verificar reservaciones existentes: %v", err)
+	}
+
+	if existingReservation != nil {
+		return fmt.Errorf("El usuario ya tiene una reservación activa para esta obra")
+	}
+
+	for _, edition := range editions {
+		bookID := edition.ID
+		copies, err := s.copyStore.GetCopiesFiltered(libraryID, store.CopyFilter{BookID: &bookID})
+		if err != nil {
+			return fmt.Errorf("Error al verificar copias de la edición %d: %v", edition.ID, err)
+		}
+
+		for _, copy := range copies {
+			if copy.Status == "Available" {
+				return fmt.Errorf("Hay copias disponibles de la edición %d, no es necesario realizar una reservación", edition.ID)
+			}
+		}
+	}
+
+	reservation.BookID = editions[0].ID
+
+	return nil
 }
 
-func (s *ReservationService) CancelReservation(libraryID, id int64) (*models.Reservation, error) {
+func (s *ReservationService) CancelReservation(ctx context.Context, libraryID, id int64) (*models.Reservation, error) {
 	reservation, err := s.reservationStore.GetByID(libraryID, id)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener reservación: %v", err)
@@ -410,6 +643,7 @@ func (s *ReservationService) CancelReservation(libraryID, id int64) (*models.Res
 		return nil, fmt.Errorf("La reservación no se puede cancelar (estado actual: %s)", reservation.Status)
 	}
 
+	previousReservation := *reservation
 	reservation.Status = "Cancelled"
 
 	updatedReservation, err := s.reservationStore.Update(libraryID, id, reservation)
@@ -417,10 +651,14 @@ func (s *ReservationService) CancelReservation(libraryID, id int64) (*models.Res
 		return nil, fmt.Errorf("Error al cancelar reservación: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "reservation", id, "cancel", &previousReservation, updatedReservation); err != nil {
+		return nil, err
+	}
+
 	return updatedReservation, nil
 }
 
-func (s *ReservationService) ProcessReservation(libraryID, id int64) (*models.Reservation, error) {
+func (s *ReservationService) ProcessReservation(ctx context.Context, libraryID, id int64) (*models.Reservation, error) {
 	reservation, err := s.reservationStore.GetByID(libraryID, id)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener reservación: %v", err)
@@ -434,6 +672,7 @@ func (s *ReservationService) ProcessReservation(libraryID, id int64) (*models.Re
 		return nil, fmt.Errorf("La reservación no está activa")
 	}
 
+	previousReservation := *reservation
 	reservation.Status = "Completed"
 
 	updatedReservation, err := s.reservationStore.Update(libraryID, id, reservation)
@@ -441,10 +680,14 @@ func (s *ReservationService) ProcessReservation(libraryID, id int64) (*models.Re
 		return nil, fmt.Errorf("Error al procesar reservación: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "reservation", id, "process", &previousReservation, updatedReservation); err != nil {
+		return nil, err
+	}
+
 	return updatedReservation, nil
 }
 
-func (s *ReservationService) Update(libraryID, id int64, reservation *models.Reservation) (*models.Reservation, error) {
+func (s *ReservationService) Update(ctx context.Context, libraryID, id int64, reservation *models.Reservation) (*models.Reservation, error) {
 	if err := validations.ValidateReservation(reservation); err != nil {
 		return nil, err
 	}
@@ -458,10 +701,19 @@ func (s *ReservationService) Update(libraryID, id int64, reservation *models.Res
 		return nil, fmt.Errorf("Reservación con ID %d no encontrada", id)
 	}
 
-	return s.reservationStore.Update(libraryID, id, reservation)
+	updatedReservation, err := s.reservationStore.Update(libraryID, id, reservation)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "reservation", id, "update", existingReservation, updatedReservation); err != nil {
+		return nil, err
+	}
+
+	return updatedReservation, nil
 }
 
-func (s *ReservationService) Delete(libraryID, id int64) error {
+func (s *ReservationService) Delete(ctx context.Context, libraryID, id int64) error {
 	reservation, err := s.reservationStore.GetByID(libraryID, id)
 	if err != nil {
 		return fmt.Errorf("Error al obtener reservación: %v", err)
@@ -471,7 +723,11 @@ func (s *ReservationService) Delete(libraryID, id int64) error {
 		return fmt.Errorf("Reservación con ID %d no encontrada", id)
 	}
 
-	return s.reservationStore.Delete(libraryID, id)
+	if err := s.reservationStore.Delete(libraryID, id); err != nil {
+		return err
+	}
+
+	return s.auditService.Record(ctx, libraryID, "reservation", id, "delete", reservation, nil)
 }
 
 func (s *FineService) GetAll(libraryID int64) ([]*models.Fine, error) {
@@ -495,7 +751,7 @@ func (s *FineService) GetFinesFiltered(libraryID int64, filter store.FineFilter)
 	return fines, nil
 }
 
-func (s *FineService) CreateFine(libraryID int64, fine *models.Fine) (*models.Fine, error) {
+func (s *FineService) CreateFine(ctx context.Context, libraryID int64, fine *models.Fine) (*models.Fine, error) {
 	if err := validations.ValidateFine(fine); err != nil {
 		return nil, err
 	}
@@ -526,10 +782,14 @@ func (s *FineService) CreateFine(libraryID int64, fine *models.Fine) (*models.Fi
 		return nil, fmt.Errorf("Error al crear multa: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "fine", createdFine.ID, "create", nil, createdFine); err != nil {
+		return nil, err
+	}
+
 	return createdFine, nil
 }
 
-func (s *FineService) PayFine(libraryID, id int64, notes *string) (*models.Fine, error) {
+func (s *FineService) PayFine(ctx context.Context, libraryID, id int64, notes *string) (*models.Fine, error) {
 	fine, err := s.fineStore.GetByID(libraryID, id)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener multa: %v", err)
@@ -544,6 +804,7 @@ func (s *FineService) PayFine(libraryID, id int64, notes *string) (*models.Fine,
 	}
 
 	now := time.Now()
+	previousFine := *fine
 	fine.Status = "Paid"
 	fine.PaymentDate.Valid = true
 	fine.PaymentDate.Time = now
@@ -558,10 +819,14 @@ func (s *FineService) PayFine(libraryID, id int64, notes *string) (*models.Fine,
 		return nil, fmt.Errorf("Error al marcar multa como pagada: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "fine", id, "pay", &previousFine, updatedFine); err != nil {
+		return nil, err
+	}
+
 	return updatedFine, nil
 }
 
-func (s *FineService) WaiveFine(libraryID, id int64, notes *string) (*models.Fine, error) {
+func (s *FineService) WaiveFine(ctx context.Context, libraryID, id int64, notes *string) (*models.Fine, error) {
 	fine, err := s.fineStore.GetByID(libraryID, id)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener multa: %v", err)
@@ -575,6 +840,7 @@ func (s *FineService) WaiveFine(libraryID, id int64, notes *string) (*models.Fin
 		return nil, fmt.Errorf("La multa no está pendiente")
 	}
 
+	previousFine := *fine
 	fine.Status = "Waived"
 
 	if notes != nil {
@@ -587,10 +853,14 @@ func (s *FineService) WaiveFine(libraryID, id int64, notes *string) (*models.Fin
 		return nil, fmt.Errorf("Error al condonar multa: %v", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "fine", id, "waive", &previousFine, updatedFine); err != nil {
+		return nil, err
+	}
+
 	return updatedFine, nil
 }
 
-func (s *FineService) Update(libraryID, id int64, fine *models.Fine) (*models.Fine, error) {
+func (s *FineService) Update(ctx context.Context, libraryID, id int64, fine *models.Fine) (*models.Fine, error) {
 	if err := validations.ValidateFine(fine); err != nil {
 		return nil, err
 	}
@@ -604,10 +874,19 @@ func (s *FineService) Update(libraryID, id int64, fine *models.Fine) (*models.Fi
 		return nil, fmt.Errorf("Multa con ID %d no encontrada", id)
 	}
 
-	return s.fineStore.Update(libraryID, id, fine)
+	updatedFine, err := s.fineStore.Update(libraryID, id, fine)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "fine", id, "update", existingFine, updatedFine); err != nil {
+		return nil, err
+	}
+
+	return updatedFine, nil
 }
 
-func (s *FineService) Delete(libraryID, id int64) error {
+func (s *FineService) Delete(ctx context.Context, libraryID, id int64) error {
 	fine, err := s.fineStore.GetByID(libraryID, id)
 	if err != nil {
 		return fmt.Errorf("Error al obtener multa: %v", err)
@@ -617,5 +896,9 @@ func (s *FineService) Delete(libraryID, id int64) error {
 		return fmt.Errorf("Multa con ID %d no encontrada", id)
 	}
 
-	return s.fineStore.Delete(libraryID, id)
+	if err := s.fineStore.Delete(libraryID, id); err != nil {
+		return err
+	}
+
+	return s.auditService.Record(ctx, libraryID, "fine", id, "delete", fine, nil)
 }
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+func TestCreateLoanKeepsHeldCopies(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+
+	seed := []string{
+		`INSERT INTO books (id, isbn, title, library_id) VALUES (1, '9780000000001', 'Pedro Páramo', 1)`,
+		`INSERT INTO copies (id, code, book_id, status, condition, library_id) VALUES (1, 'C1', 1, 'Available', 'Good', 1), (2, 'C2', 1, 'Available', 'Good', 1)`,
+		`INSERT INTO users (id, code, dni, first_name, last_name, user_type, status, library_id) VALUES
+			(1, 'U1', 'D1', 'Ana', 'Ruiz', 'Student', 'Active', 1),
+			(2, 'U2', 'D2', 'Luis', 'Soto', 'Student', 'Active', 1),
+			(3, 'U3', 'D3', 'Eva', 'Mora', 'Student', 'Active', 1)`,
+		`INSERT INTO reservations (id, user_id, book_id, expiration_date, status, library_id) VALUES (1, 1, 1, '2099-01-01', 'Active', 1)`,
+	}
+
+	for _, query := range seed {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	reservationStore := store.NewReservationStore(db)
+
+	service := NewLoanService(
+		store.NewLoanStore(db),
+		store.NewUserStore(db),
+		store.NewCopyStore(db),
+		store.NewFineStore(db),
+		reservationStore,
+		store.NewBookStore(db),
+		store.NewCopyEventStore(db),
+		NewAuditService(store.NewAuditStore(db)),
+	)
+
+	borrow := func(number int, userID, copyID int64) error {
+		now := time.Now()
+
+		_, err := service.CreateLoan(context.Background(), 1, &models.Loan{
+			LoanCode: fmt.Sprintf("LOAN-2026-%04d", number),
+			UserID:   userID,
+			CopyID:   copyID,
+			LoanDate: now,
+			DueDate:  now.AddDate(0, 0, 14),
+			Status:   "Active",
+			LoanDays: 14,
+		})
+
+		return err
+	}
+
+	// One of the two shelf copies is held for user 1, the other is free
+	if err := borrow(1, 2, 1); err != nil {
+		t.Fatalf("loan of the free copy: %v", err)
+	}
+
+	if err := borrow(2, 3, 2); err == nil || !strings.Contains(err.Error(), "apartada") {
+		t.Fatalf("loan of the held copy to another user: err = %v", err)
+	}
+
+	if err := borrow(3, 1, 2); err != nil {
+		t.Fatalf("loan of the held copy to its holder: %v", err)
+	}
+
+	reservation, err := reservationStore.GetByID(1, 1)
+	if err != nil {
+		t.Fatalf("reservation: %v", err)
+	}
+
+	if reservation.Status != "Completed" {
+		t.Errorf("reservation status = %s, want Completed", reservation.Status)
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+// The first entry of every library is chained to auditGenesisHash.
+const auditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+	auditVerifyBatch  = 500
+)
+
+type AuditQuery struct {
+	EntityType string
+	EntityID   *int64
+	Action     string
+	ActorID    *int64
+	RequestID  string
+	From       string
+	To         string
+	BeforeID   *int64
+	Limit      int
+}
+
+// AuditOrigin is the API call on whose behalf the services change entities.
+type AuditOrigin struct {
+	RequestID  string
+	ActorID    *int64 // From X-Librarian-ID
+	RemoteAddr string
+	Method     string
+	Path       string
+}
+
+type auditOriginKey struct{}
+
+type AuditService struct {
+	auditStore store.IAuditStore
+}
+
+func NewAuditService(auditStore store.IAuditStore) *AuditService {
+	return &AuditService{
+		auditStore: auditStore,
+	}
+}
+
+func (s *AuditService) GetEntries(libraryID int64, query AuditQuery) ([]*models.AuditEntry, error) {
+	filter := store.AuditFilter{
+		EntityType: query.EntityType,
+		EntityID:   query.EntityID,
+		Action:     query.Action,
+		ActorID:    query.ActorID,
+		RequestID:  query.RequestID,
+		BeforeID:   query.BeforeID,
+		Limit:      query.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+
+	if filter.Limit < 0 || filter.Limit > MaxAuditLimit {
+		return nil, fmt.Errorf("Validación fallida: El límite debe estar entre 1 y %d", MaxAuditLimit)
+	}
+
+	if query.From != "" {
+		from, err := time.Parse(time.DateOnly, query.From)
+		if err != nil {
+			return nil, errors.New("Validación fallida: La fecha inicial debe tener el formato AAAA-MM-DD")
+		}
+
+		filter.From = &from
+	}
+
+	if query.To != "" {
+		to, err := time.Parse(time.DateOnly, query.To)
+		if err != nil {
+			return nil, errors.New("Validación fallida: La fecha final debe tener el formato AAAA-MM-DD")
+		}
+
+		// The whole last day is included.
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	entries, err := s.auditStore.GetAll(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el registro de auditoría: %w", err)
+	}
+
+	return entries, nil
+}
+
+// WithAuditOrigin keeps in ctx the call that changes the entities, the
+// services attribute their audit entries to it.
+func WithAuditOrigin(ctx context.Context, origin AuditOrigin) context.Context {
+	return context.WithValue(ctx, auditOriginKey{}, origin)
+}
+
+// Record keeps a change of an entity made within the call of ctx, with only
+// the fields that changed between before and after (nil when it was created
+// or deleted), the sensitive ones redacted, chained to the last entry of the
+// library. A change that can't be recorded must fail the call, so the error
+// is returned to the service that made it.
+func (s *AuditService) Record(ctx context.Context, libraryID int64, entityType string, entityID int64, action string, before, after any) error {
+	origin, _ := ctx.Value(auditOriginKey{}).(AuditOrigin)
+
+	entry := &models.AuditEntry{
+		RequestID:  origin.RequestID,
+		Method:     origin.Method,
+		Path:       origin.Path,
+		EntityType: entityType,
+		Action:     action,
+		LibraryID:  libraryID,
+	}
+
+	entry.EntityID.Int64 = entityID
+	entry.EntityID.Valid = entityID > 0
+
+	if origin.ActorID != nil {
+		entry.ActorID.Int64 = *origin.ActorID
+		entry.ActorID.Valid = true
+	}
+
+	if origin.RemoteAddr != "" {
+		entry.RemoteAddr.String = origin.RemoteAddr
+		entry.RemoteAddr.Valid = true
+	}
+
+	return s.record(entry, auditState(before), auditState(after))
+}
+
+func (s *AuditService) record(entry *models.AuditEntry, before, after json.RawMessage) error {
+	entry.Before, entry.After = auditDiff(before, after)
+	entry.Before, entry.After = auditRedact(entry.Before), auditRedact(entry.After)
+	entry.OccurredAt = time.Now().UTC()
+
+	seal := func(prevHash string) {
+		entry.PrevHash = auditGenesisHash
+		if prevHash != "" {
+			entry.PrevHash = prevHash
+		}
+
+		entry.Hash = auditHash(entry)
+	}
+
+	if _, err := s.auditStore.Append(entry, seal); err != nil {
+		return fmt.Errorf("Error al guardar la entrada de auditoría: %w", err)
+	}
+
+	return nil
+}
+
+// Verify walks the chain of the library from the first entry and reports the
+// first one that was changed, or that follows one removed.
+func (s *AuditService) Verify(libraryID int64) (*models.AuditVerification, error) {
+	verification := &models.AuditVerification{
+		Valid:    true,
+		LastHash: auditGenesisHash,
+	}
+
+	var afterID int64
+
+	for {
+		entries, err := s.auditStore.GetAfter(libraryID, afterID, auditVerifyBatch)
+		if err != nil {
+			return nil, fmt.Errorf("Error al obtener el registro de auditoría: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.PrevHash != verification.LastHash || auditHash(entry) != entry.Hash {
+				verification.Valid = false
+				verification.BrokenAt = &entry.ID
+				return verification, nil
+			}
+
+			verification.Checked++
+			verification.LastHash = entry.Hash
+			afterID = entry.ID
+		}
+
+		if len(entries) < auditVerifyBatch {
+			return verification, nil
+		}
+	}
+}
+
+func auditHash(entry *models.AuditEntry) string {
+	fields := []string{
+		entry.PrevHash,
+		strconv.FormatInt(entry.LibraryID, 10),
+		entry.RequestID,
+		auditNullable(entry.ActorID.Valid, strconv.FormatInt(entry.ActorID.Int64, 10)),
+		auditNullable(entry.RemoteAddr.Valid, entry.RemoteAddr.String),
+		entry.Method,
+		entry.Path,
+		entry.EntityType,
+		auditNullable(entry.EntityID.Valid, strconv.FormatInt(entry.EntityID.Int64, 10)),
+		entry.Action,
+		auditNullable(entry.Before != nil, string(entry.Before)),
+		auditNullable(entry.After != nil, string(entry.After)),
+		entry.OccurredAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func auditNullable(valid bool, value string) string {
+	if !valid {
+		return "null"
+	}
+
+	return strconv.Quote(value)
+}
+
+// auditState is the JSON of the state of an entity, nil for no entity.
+func auditState(state any) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+
+	object, err := json.Marshal(state)
+	if err != nil || string(object) == "null" {
+		return nil
+	}
+
+	return object
+}
+
+// auditSensitiveKeys are parts of the names of the fields whose value is never
+// kept in the log, as the secret of a webhook or the password of a library.
+var auditSensitiveKeys = []string{"secret", "password", "token", "api_key", "apikey", "authorization", "credential"}
+
+const auditRedacted = "***"
+
+// auditRedact replaces the value of the sensitive fields at any depth. A field
+// that changed still shows up in the diff, without its value.
+func auditRedact(object json.RawMessage) json.RawMessage {
+	if object == nil {
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(object, &value); err != nil {
+		return object
+	}
+
+	redacted, err := json.Marshal(auditRedactValue(value))
+	if err != nil {
+		return object
+	}
+
+	return redacted
+}
+
+func auditRedactValue(value any) any {
+	switch v := value.(type) {
+		case map[string]any:
+			for key, field := range v {
+				if isAuditSensitive(key) {
+					v[key] = auditRedacted
+					continue
+				}
+
+				v[key] = auditRedactValue(field)
+			}
+		case []any:
+			for i, item := range v {
+				v[i] = auditRedactValue(item)
+			}
+	}
+
+	return value
+}
+
+func isAuditSensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, sensitive := range auditSensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// auditDiff keeps the top level fields whose value changed. Snapshots that are
+// not JSON objects are kept whole.
+func auditDiff(before, after json.RawMessage) (json.RawMessage, json.RawMessage) {
+	if before == nil || after == nil {
+		return before, after
+	}
+
+	var beforeFields, afterFields map[string]any
+
+	if json.Unmarshal(before, &beforeFields) != nil || json.Unmarshal(after, &afterFields) != nil {
+		return before, after
+	}
+
+	changedBefore := map[string]any{}
+	changedAfter := map[string]any{}
+
+	for field, value := range beforeFields {
+		if afterValue, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changedBefore[field] = value
+		}
+	}
+
+	for field, value := range afterFields {
+		if beforeValue, ok := beforeFields[field]; !ok || !reflect.DeepEqual(value, beforeValue) {
+			changedAfter[field] = value
+		}
+	}
+
+	diffBefore, _ := json.Marshal(changedBefore)
+	diffAfter, _ := json.Marshal(changedAfter)
+
+	return diffBefore, diffAfter
+}
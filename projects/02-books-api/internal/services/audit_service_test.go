@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+func TestAuditRedact(t *testing.T) {
+	tests := []struct {
+		name   string
+		object string
+		want   string
+	}{
+		{"webhook secret", `{"id":3,"secret":"whsec_abc","url":"https://example.com"}`, `{"id":3,"secret":"***","url":"https://example.com"}`},
+		{"any case and suffix", `{"Password":"x","access_token":"y","apiKey":"z"}`, `{"Password":"***","access_token":"***","apiKey":"***"}`},
+		{"nested", `{"items":[{"token":"t","name":"a"}],"auth":{"client_secret":"s"}}`, `{"auth":{"client_secret":"***"},"items":[{"name":"a","token":"***"}]}`},
+		{"nothing sensitive", `{"status":"Waived"}`, `{"status":"Waived"}`},
+		{"not an object", `[1,2]`, `[1,2]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(auditRedact(json.RawMessage(tt.object))); got != tt.want {
+				t.Errorf("auditRedact = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if auditRedact(nil) != nil {
+		t.Error("auditRedact(nil) is not nil")
+	}
+}
+
+func TestAuditRedactKeepsChangedField(t *testing.T) {
+	before, after := auditDiff(json.RawMessage(`{"id":1,"secret":"old","url":"a"}`), json.RawMessage(`{"id":1,"secret":"new","url":"a"}`))
+
+	if got := string(auditRedact(before)); got != `{"secret":"***"}` {
+		t.Errorf("before = %s", got)
+	}
+
+	if got := string(auditRedact(after)); got != `{"secret":"***"}` {
+		t.Errorf("after = %s", got)
+	}
+}
+
+// openTestDB opens the database at path, migrated and with one library.
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db := database.Open(path)
+	t.Cleanup(func() { db.Close() })
+
+	if path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
+	if _, err := db.Exec(database.GetMigrationSchema()); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+
+	if err := database.ApplyMigrationAlterations(db); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	_, err := db.Exec(
+		`INSERT OR IGNORE INTO libraries (id, name, address, city, state, zip_code, country, phone, email, website, username, password)
+		VALUES (1, 'Biblioteca Central', 'Av. Juárez 10', 'Aguascalientes', 'Ags.', '20000', 'MX', '4490000000', 'mostrador@example.com', 'https://example.com', 'central', 'x')`,
+	)
+
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	return db
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   string
+		brokenAt int64
+	}{
+		{"untouched", "", 0},
+		{"modified entry", `UPDATE audit_log SET after = '{"status":"Paid"}' WHERE id = 2`, 2},
+		{"modified last entry", `UPDATE audit_log SET actor_id = 9 WHERE id = 3`, 3},
+		{"removed entry", `DELETE FROM audit_log WHERE id = 2`, 3},
+		{"rechained entry", `UPDATE audit_log SET prev_hash = (SELECT prev_hash FROM audit_log WHERE id = 2) WHERE id = 3`, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t, ":memory:")
+			audit := NewAuditService(store.NewAuditStore(db))
+
+			for i := 1; i <= 3; i++ {
+				before := map[string]any{"id": i, "status": "Pending"}
+				after := map[string]any{"id": i, "status": "Waived"}
+
+				if err := audit.Record(context.Background(), 1, "fine", int64(i), "waive", before, after); err != nil {
+					t.Fatalf("Record: %v", err)
+				}
+			}
+
+			if tt.tamper != "" {
+				// Whoever edits the file directly doesn't go through the triggers.
+				if _, err := db.Exec(`DROP TRIGGER trg_audit_log_no_update; DROP TRIGGER trg_audit_log_no_delete`); err != nil {
+					t.Fatalf("drop triggers: %v", err)
+				}
+
+				if _, err := db.Exec(tt.tamper); err != nil {
+					t.Fatalf("tamper: %v", err)
+				}
+			}
+
+			verification, err := audit.Verify(1)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+
+			if tt.brokenAt == 0 {
+				if !verification.Valid || verification.Checked != 3 {
+					t.Errorf("valid %v checked %d, want valid and 3", verification.Valid, verification.Checked)
+				}
+
+				return
+			}
+
+			if verification.Valid || verification.BrokenAt == nil || *verification.BrokenAt != tt.brokenAt {
+				t.Errorf("valid %v broken_at %v, want broken at %d", verification.Valid, verification.BrokenAt, tt.brokenAt)
+			}
+		})
+	}
+}
+
+// Two servers sharing the database must not chain two entries to the same one.
+func TestAuditRecordChainsAcrossConnections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.db")
+	services := []*AuditService{
+		NewAuditService(store.NewAuditStore(openTestDB(t, path))),
+		NewAuditService(store.NewAuditStore(openTestDB(t, path))),
+	}
+
+	const perService = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*perService)
+
+	for _, audit := range services {
+		for i := 0; i < perService; i++ {
+			wg.Go(func() {
+				errs <- audit.Record(context.Background(), 1, "book", int64(i+1), "update", nil, map[string]any{"title": fmt.Sprint(i)})
+			})
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	verification, err := services[0].Verify(1)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if !verification.Valid || verification.Checked != 2*perService {
+		t.Errorf("valid %v checked %d broken_at %v, want valid and %d", verification.Valid, verification.Checked, verification.BrokenAt, 2*perService)
+	}
+}
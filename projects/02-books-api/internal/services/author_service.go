@@ -1,22 +1,31 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/chicho69-cesar/backend-go/books/internal/authority"
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
 	"github.com/chicho69-cesar/backend-go/books/internal/store"
 	"github.com/chicho69-cesar/backend-go/books/internal/validations"
 )
 
+const DefaultDuplicateThreshold = 0.85
+
 type AuthorService struct {
-	authorStore store.IAuthorStore
+	authorStore  store.IAuthorStore
+	bookStore    store.IBookStore
+	auditService *AuditService
 }
 
-func NewAuthorService(authorStore store.IAuthorStore) *AuthorService {
+func NewAuthorService(authorStore store.IAuthorStore, bookStore store.IBookStore, auditService *AuditService) *AuthorService {
 	return &AuthorService{
-		authorStore: authorStore,
+		authorStore:  authorStore,
+		bookStore:    bookStore,
+		auditService: auditService,
 	}
 }
 
@@ -37,7 +46,7 @@ func (s *AuthorService) GetAuthorByID(libraryID, id int64) (*models.Author, erro
 	return author, nil
 }
 
-func (s *AuthorService) CreateAuthor(libraryID int64, author *models.Author) (*models.Author, error) {
+func (s *AuthorService) CreateAuthor(ctx context.Context, libraryID int64, author *models.Author) (*models.Author, error) {
 	if err := validations.ValidateAuthor(author); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -54,15 +63,21 @@ func (s *AuthorService) CreateAuthor(libraryID int64, author *models.Author) (*m
 		author.Nationality.String = strings.TrimSpace(author.Nationality.String)
 	}
 
+	author.Aliases = trimAliases(author.Aliases)
+
 	createdAuthor, err := s.authorStore.Create(libraryID, author)
 	if err != nil {
 		return nil, fmt.Errorf("Error al crear el autor: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "author", createdAuthor.ID, "create", nil, createdAuthor); err != nil {
+		return nil, err
+	}
+
 	return createdAuthor, nil
 }
 
-func (s *AuthorService) UpdateAuthor(libraryID, id int64, author *models.Author) (*models.Author, error) {
+func (s *AuthorService) UpdateAuthor(ctx context.Context, libraryID, id int64, author *models.Author) (*models.Author, error) {
 	if id <= 0 {
 		return nil, errors.New("El ID del autor es invalido")
 	}
@@ -91,15 +106,27 @@ func (s *AuthorService) UpdateAuthor(libraryID, id int64, author *models.Author)
 		author.Nationality.String = strings.TrimSpace(author.Nationality.String)
 	}
 
+	author.Aliases = trimAliases(author.Aliases)
+
 	updatedAuthor, err := s.authorStore.Update(libraryID, id, author)
 	if err != nil {
 		return nil, fmt.Errorf("Error al actualizar el autor con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "author", id, "update", existingAuthor, updatedAuthor); err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(existingAuthor.LastName, updatedAuthor.LastName) {
+		if err := s.refreshAuthorCallNumbers(ctx, libraryID, id); err != nil {
+			return nil, err
+		}
+	}
+
 	return updatedAuthor, nil
 }
 
-func (s *AuthorService) DeleteAuthor(libraryID, id int64) error {
+func (s *AuthorService) DeleteAuthor(ctx context.Context, libraryID, id int64) error {
 	if id <= 0 {
 		return errors.New("El ID del autor es invalido")
 	}
@@ -117,5 +144,225 @@ func (s *AuthorService) DeleteAuthor(libraryID, id int64) error {
 		return fmt.Errorf("Error al eliminar el autor con ID %d: %w", id, err)
 	}
 
+	return s.auditService.Record(ctx, libraryID, "author", id, "delete", existingAuthor, nil)
+}
+
+// FindDuplicates groups the authors whose names, or aliases, share the same
+// normalized form or are at least threshold similar.
+func (s *AuthorService) FindDuplicates(libraryID int64, threshold float64) ([]*models.AuthorDuplicateGroup, error) {
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+
+	if threshold < 0.5 || threshold > 1 {
+		return nil, errors.New("El umbral de similitud debe estar entre 0.5 y 1")
+	}
+
+	authors, err := s.authorStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los autores: %w", err)
+	}
+
+	keys := make([][]string, len(authors))
+	for i, author := range authors {
+		keys[i] = authorNameKeys(author)
+	}
+
+	parent := make([]int, len(authors))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+
+		return parent[i]
+	}
+
+	scores := make(map[int]float64)
+
+	for i := range authors {
+		for j := i + 1; j < len(authors); j++ {
+			score := bestSimilarity(keys[i], keys[j])
+			if score < threshold {
+				continue
+			}
+
+			rootI, rootJ := find(i), find(j)
+
+			if rootI == rootJ {
+				scores[rootI] = min(scores[rootI], score)
+				continue
+			}
+
+			lowest := score
+			for _, root := range []int{rootI, rootJ} {
+				if current, ok := scores[root]; ok && current < lowest {
+					lowest = current
+				}
+			}
+
+			parent[rootJ] = rootI
+			scores[rootI] = lowest
+			delete(scores, rootJ)
+		}
+	}
+
+	groupsByRoot := make(map[int]*models.AuthorDuplicateGroup)
+	var groups []*models.AuthorDuplicateGroup
+
+	for i, author := range authors {
+		root := find(i)
+
+		score, ok := scores[root]
+		if !ok {
+			continue
+		}
+
+		group, exists := groupsByRoot[root]
+		if !exists {
+			group = &models.AuthorDuplicateGroup{MatchType: "Fuzzy", Score: score}
+			if score == 1 {
+				group.MatchType = "Normalized"
+			}
+
+			groupsByRoot[root] = group
+			groups = append(groups, group)
+		}
+
+		group.Authors = append(group.Authors, author)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Score > groups[j].Score
+	})
+
+	return groups, nil
+}
+
+// MergeAuthors folds the duplicates into the author with ID id: their books,
+// names and missing details move to it and the duplicates are deleted.
+func (s *AuthorService) MergeAuthors(ctx context.Context, libraryID, id int64, duplicateIDs []int64) (*models.Author, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID del autor es invalido")
+	}
+
+	if len(duplicateIDs) == 0 {
+		return nil, errors.New("Se requiere al menos un autor duplicado")
+	}
+
+	previousAuthor, err := s.authorStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("El autor con ID %d no existe: %w", id, err)
+	}
+
+	duplicates := make(map[int64]*models.Author, len(duplicateIDs))
+
+	for _, duplicateID := range duplicateIDs {
+		if duplicateID == id {
+			return nil, errors.New("Un autor no puede fusionarse consigo mismo")
+		}
+
+		if duplicates[duplicateID] != nil {
+			return nil, fmt.Errorf("El autor con ID %d está repetido", duplicateID)
+		}
+
+		duplicate, err := s.authorStore.GetByID(libraryID, duplicateID)
+		if err != nil {
+			return nil, fmt.Errorf("El autor con ID %d no existe: %w", duplicateID, err)
+		}
+
+		duplicates[duplicateID] = duplicate
+	}
+
+	if err := s.authorStore.Merge(libraryID, id, duplicateIDs); err != nil {
+		return nil, fmt.Errorf("Error al fusionar los autores: %w", err)
+	}
+
+	if err := s.refreshAuthorCallNumbers(ctx, libraryID, id); err != nil {
+		return nil, err
+	}
+
+	mergedAuthor, err := s.GetAuthorByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, duplicateID := range duplicateIDs {
+		if err := s.auditService.Record(ctx, libraryID, "author", duplicateID, "merge", duplicates[duplicateID], nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "author", id, "merge", previousAuthor, mergedAuthor); err != nil {
+		return nil, err
+	}
+
+	return mergedAuthor, nil
+}
+
+func (s *AuthorService) refreshAuthorCallNumbers(ctx context.Context, libraryID, authorID int64) error {
+	books, err := s.bookStore.GetBooksFiltered(libraryID, store.BookFilter{AuthorID: &authorID})
+	if err != nil {
+		return fmt.Errorf("Error al obtener los libros del autor: %w", err)
+	}
+
+	for _, book := range books {
+		previousBook := *book
+
+		if err := refreshCallNumber(s.bookStore, libraryID, book); err != nil {
+			return err
+		}
+
+		if book.CallNumber != previousBook.CallNumber {
+			if err := s.auditService.Record(ctx, libraryID, "book", book.ID, "update", &previousBook, book); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
+
+func authorNameKeys(author *models.Author) []string {
+	keys := []string{authority.NormalizeName(author.FirstName + " " + author.LastName)}
+
+	for _, alias := range author.Aliases {
+		keys = append(keys, authority.NormalizeName(alias))
+	}
+
+	return keys
+}
+
+func bestSimilarity(a, b []string) float64 {
+	var best float64
+
+	for _, keyA := range a {
+		for _, keyB := range b {
+			if score := authority.Similarity(keyA, keyB); score > best {
+				best = score
+			}
+		}
+	}
+
+	return best
+}
+
+func trimAliases(aliases []string) []string {
+	if aliases == nil {
+		return nil
+	}
+
+	trimmed := make([]string, 0, len(aliases))
+
+	for _, alias := range aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			trimmed = append(trimmed, alias)
+		}
+	}
+
+	return trimmed
+}
@@ -1,12 +1,16 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/chicho69-cesar/backend-go/books/internal/classification"
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/storage"
 	"github.com/chicho69-cesar/backend-go/books/internal/store"
 	"github.com/chicho69-cesar/backend-go/books/internal/validations"
 )
@@ -16,14 +20,20 @@ type BookService struct {
 	authorStore      store.IAuthorStore
 	copyStore        store.ICopyStore
 	reservationStore store.IReservationStore
+	coverStore       store.ICoverStore
+	blobStore        storage.BlobStore
+	auditService     *AuditService
 }
 
-func NewBookService(bookStore store.IBookStore, authorStore store.IAuthorStore, copyStore store.ICopyStore, reservationStore store.IReservationStore) *BookService {
+func NewBookService(bookStore store.IBookStore, authorStore store.IAuthorStore, copyStore store.ICopyStore, reservationStore store.IReservationStore, coverStore store.ICoverStore, blobStore storage.BlobStore, auditService *AuditService) *BookService {
 	return &BookService{
 		bookStore:        bookStore,
 		authorStore:      authorStore,
 		copyStore:        copyStore,
 		reservationStore: reservationStore,
+		coverStore:       coverStore,
+		blobStore:        blobStore,
+		auditService:     auditService,
 	}
 }
 
@@ -33,6 +43,14 @@ func (s *BookService) GetAllBooks(libraryID int64) ([]*models.Book, error) {
 		return nil, fmt.Errorf("Error al obtener los libros: %w", err)
 	}
 
+	if err := s.attachCovers(libraryID, books...); err != nil {
+		return nil, err
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, books...); err != nil {
+		return nil, err
+	}
+
 	return books, nil
 }
 
@@ -46,6 +64,14 @@ func (s *BookService) GetBookByID(libraryID, id int64) (*models.Book, error) {
 		return nil, fmt.Errorf("Error al obtener el libro con ID %d: %w", id, err)
 	}
 
+	if err := s.attachCovers(libraryID, book); err != nil {
+		return nil, err
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, book); err != nil {
+		return nil, err
+	}
+
 	return book, nil
 }
 
@@ -63,6 +89,14 @@ func (s *BookService) GetBookByISBN(libraryID int64, isbn string) (*models.Book,
 		return nil, fmt.Errorf("No se encontró un libro con ISBN %s", isbn)
 	}
 
+	if err := s.attachCovers(libraryID, book); err != nil {
+		return nil, err
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, book); err != nil {
+		return nil, err
+	}
+
 	return book, nil
 }
 
@@ -90,6 +124,12 @@ func (s *BookService) GetBooksFiltered(libraryID int64, filter store.BookFilter)
 		}
 	}
 
+	if filter.Availability != "" {
+		if err := validations.ValidateBookStatus(filter.Availability); err != nil {
+			return nil, err
+		}
+	}
+
 	if filter.ISBN != "" {
 		filter.ISBN = strings.TrimSpace(filter.ISBN)
 
@@ -103,10 +143,18 @@ func (s *BookService) GetBooksFiltered(libraryID int64, filter store.BookFilter)
 		return nil, fmt.Errorf("Error al obtener los libros filtrados: %w", err)
 	}
 
+	if err := s.attachCovers(libraryID, books...); err != nil {
+		return nil, err
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, books...); err != nil {
+		return nil, err
+	}
+
 	return books, nil
 }
 
-func (s *BookService) CreateBook(libraryID int64, book *models.Book) (*models.Book, error) {
+func (s *BookService) CreateBook(ctx context.Context, libraryID int64, book *models.Book) (*models.Book, error) {
 	if err := validations.ValidateBook(book); err != nil {
 		return nil, fmt.Errorf("validación fallida: %w", err)
 	}
@@ -136,12 +184,16 @@ func (s *BookService) CreateBook(libraryID int64, book *models.Book) (*models.Bo
 		book.Synopsis.String = strings.TrimSpace(book.Synopsis.String)
 	}
 
-	if book.RegistrationDate.IsZero() {
-		book.RegistrationDate = time.Now()
+	if book.ClassNumber.Valid {
+		book.ClassNumber.String = strings.TrimSpace(book.ClassNumber.String)
+	}
+
+	if book.CutterNumber.Valid {
+		book.CutterNumber.String = strings.TrimSpace(book.CutterNumber.String)
 	}
 
-	if strings.TrimSpace(book.Status) == "" {
-		book.Status = "Available"
+	if book.RegistrationDate.IsZero() {
+		book.RegistrationDate = time.Now()
 	}
 
 	createdBook, err := s.bookStore.Create(libraryID, book)
@@ -149,10 +201,26 @@ func (s *BookService) CreateBook(libraryID int64, book *models.Book) (*models.Bo
 		return nil, fmt.Errorf("Error al crear el libro: %w", err)
 	}
 
+	if err := s.bookStore.MarkFieldsEdited(libraryID, createdBook.ID, editedBookFields(nil, createdBook)); err != nil {
+		return nil, fmt.Errorf("Error al registrar los campos editados del libro: %w", err)
+	}
+
+	if err := refreshCallNumber(s.bookStore, libraryID, createdBook); err != nil {
+		return nil, err
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, createdBook); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "book", createdBook.ID, "create", nil, createdBook); err != nil {
+		return nil, err
+	}
+
 	return createdBook, nil
 }
 
-func (s *BookService) UpdateBook(libraryID, id int64, book *models.Book) (*models.Book, error) {
+func (s *BookService) UpdateBook(ctx context.Context, libraryID, id int64, book *models.Book) (*models.Book, error) {
 	if id <= 0 {
 		return nil, errors.New("El ID del libro es inválido")
 	}
@@ -194,6 +262,14 @@ func (s *BookService) UpdateBook(libraryID, id int64, book *models.Book) (*model
 		book.Synopsis.String = strings.TrimSpace(book.Synopsis.String)
 	}
 
+	if book.ClassNumber.Valid {
+		book.ClassNumber.String = strings.TrimSpace(book.ClassNumber.String)
+	}
+
+	if book.CutterNumber.Valid {
+		book.CutterNumber.String = strings.TrimSpace(book.CutterNumber.String)
+	}
+
 	book.RegistrationDate = existingBook.RegistrationDate
 
 	updatedBook, err := s.bookStore.Update(libraryID, id, book)
@@ -201,10 +277,32 @@ func (s *BookService) UpdateBook(libraryID, id int64, book *models.Book) (*model
 		return nil, fmt.Errorf("Error al actualizar el libro con ID %d: %w", id, err)
 	}
 
+	if err := s.bookStore.MarkFieldsEdited(libraryID, id, editedBookFields(existingBook, updatedBook)); err != nil {
+		return nil, fmt.Errorf("Error al registrar los campos editados del libro: %w", err)
+	}
+
+	if existingBook.ShelfID != updatedBook.ShelfID && updatedBook.ShelfID.Valid {
+		if err := s.moveShelvedCopies(ctx, libraryID, id, existingBook.ShelfID, updatedBook.ShelfID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := refreshCallNumber(s.bookStore, libraryID, updatedBook); err != nil {
+		return nil, err
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, updatedBook); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "book", id, "update", existingBook, updatedBook); err != nil {
+		return nil, err
+	}
+
 	return updatedBook, nil
 }
 
-func (s *BookService) DeleteBook(libraryID, id int64) error {
+func (s *BookService) DeleteBook(ctx context.Context, libraryID, id int64) error {
 	if id <= 0 {
 		return errors.New("El ID del libro es inválido")
 	}
@@ -242,11 +340,30 @@ func (s *BookService) DeleteBook(libraryID, id int64) error {
 		return fmt.Errorf("No se puede eliminar el libro porque tiene %d reservación(es) en proceso", len(processingReservations))
 	}
 
+	cover, err := s.coverStore.GetByBookID(libraryID, id)
+	if err != nil {
+		return fmt.Errorf("Error al obtener la portada del libro con ID %d: %w", id, err)
+	}
+
+	if cover != nil {
+		for _, size := range []string{CoverSizeOriginal, CoverSizeMedium, CoverSizeSmall} {
+			if err := s.blobStore.Delete(coverKey(libraryID, id, size)); err != nil {
+				return fmt.Errorf("Error al eliminar el archivo de portada %s: %w", size, err)
+			}
+		}
+	}
+
 	if err := s.bookStore.Delete(libraryID, id); err != nil {
 		return fmt.Errorf("Error al eliminar el libro con ID %d: %w", id, err)
 	}
 
-	return nil
+	if cover != nil {
+		if err := s.auditService.Record(ctx, libraryID, "book_cover", id, "delete", cover, nil); err != nil {
+			return err
+		}
+	}
+
+	return s.auditService.Record(ctx, libraryID, "book", id, "delete", existingBook, nil)
 }
 
 func (s *BookService) GetBookAuthors(libraryID, bookID int64) ([]*models.Author, error) {
@@ -267,7 +384,7 @@ func (s *BookService) GetBookAuthors(libraryID, bookID int64) ([]*models.Author,
 	return authors, nil
 }
 
-func (s *BookService) AddAuthorToBook(libraryID int64, bookAuthor *models.BookAuthor) error {
+func (s *BookService) AddAuthorToBook(ctx context.Context, libraryID int64, bookAuthor *models.BookAuthor) error {
 	if err := validations.ValidateBookAuthor(bookAuthor); err != nil {
 		return fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -286,10 +403,14 @@ func (s *BookService) AddAuthorToBook(libraryID int64, bookAuthor *models.BookAu
 		return fmt.Errorf("Error al agregar el autor al libro: %w", err)
 	}
 
-	return nil
+	if err := s.auditService.Record(ctx, libraryID, "book", bookAuthor.BookID, "add_author", nil, bookAuthor); err != nil {
+		return err
+	}
+
+	return refreshCallNumberByID(s.bookStore, libraryID, bookAuthor.BookID)
 }
 
-func (s *BookService) RemoveAuthorFromBook(libraryID, bookID, authorID int64) error {
+func (s *BookService) RemoveAuthorFromBook(ctx context.Context, libraryID, bookID, authorID int64) error {
 	if bookID <= 0 {
 		return errors.New("El ID del libro es inválido")
 	}
@@ -307,10 +428,14 @@ func (s *BookService) RemoveAuthorFromBook(libraryID, bookID, authorID int64) er
 		return fmt.Errorf("Error al eliminar el autor del libro: %w", err)
 	}
 
-	return nil
+	if err := s.auditService.Record(ctx, libraryID, "book", bookID, "remove_author", map[string]int64{"author_id": authorID}, nil); err != nil {
+		return err
+	}
+
+	return refreshCallNumberByID(s.bookStore, libraryID, bookID)
 }
 
-func (s *BookService) UpdateAuthorPosition(libraryID, bookID, authorID int64, position int) error {
+func (s *BookService) UpdateAuthorPosition(ctx context.Context, libraryID, bookID, authorID int64, position int) error {
 	if bookID <= 0 {
 		return errors.New("El ID del libro es inválido")
 	}
@@ -332,7 +457,11 @@ func (s *BookService) UpdateAuthorPosition(libraryID, bookID, authorID int64, po
 		return fmt.Errorf("Error al actualizar la posición del autor: %w", err)
 	}
 
-	return nil
+	if err := s.auditService.Record(ctx, libraryID, "book", bookID, "move_author", nil, map[string]int64{"author_id": authorID, "position": int64(position)}); err != nil {
+		return err
+	}
+
+	return refreshCallNumberByID(s.bookStore, libraryID, bookID)
 }
 
 func (s *BookService) GetBookCategories(libraryID, bookID int64) ([]*models.Category, error) {
@@ -353,7 +482,7 @@ func (s *BookService) GetBookCategories(libraryID, bookID int64) ([]*models.Cate
 	return categories, nil
 }
 
-func (s *BookService) AddCategoryToBook(libraryID int64, bookCategory *models.BookCategory) error {
+func (s *BookService) AddCategoryToBook(ctx context.Context, libraryID int64, bookCategory *models.BookCategory) error {
 	if err := validations.ValidateBookCategory(bookCategory); err != nil {
 		return fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -367,10 +496,10 @@ func (s *BookService) AddCategoryToBook(libraryID int64, bookCategory *models.Bo
 		return fmt.Errorf("Error al agregar la categoría al libro: %w", err)
 	}
 
-	return nil
+	return s.auditService.Record(ctx, libraryID, "book", bookCategory.BookID, "add_category", nil, bookCategory)
 }
 
-func (s *BookService) RemoveCategoryFromBook(libraryID, bookID, categoryID int64) error {
+func (s *BookService) RemoveCategoryFromBook(ctx context.Context, libraryID, bookID, categoryID int64) error {
 	if bookID <= 0 {
 		return errors.New("El ID del libro es inválido")
 	}
@@ -388,5 +517,181 @@ func (s *BookService) RemoveCategoryFromBook(libraryID, bookID, categoryID int64
 		return fmt.Errorf("Error al eliminar la categoría del libro: %w", err)
 	}
 
+	return s.auditService.Record(ctx, libraryID, "book", bookID, "remove_category", map[string]int64{"category_id": categoryID}, nil)
+}
+
+func (s *BookService) attachCovers(libraryID int64, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	if len(books) == 1 {
+		cover, err := s.coverStore.GetByBookID(libraryID, books[0].ID)
+		if err != nil {
+			return fmt.Errorf("Error al obtener la portada del libro: %w", err)
+		}
+
+		if cover != nil {
+			books[0].Cover = CoverURLsFor(libraryID, books[0].ID)
+		}
+
+		return nil
+	}
+
+	covers, err := s.coverStore.GetAll(libraryID)
+	if err != nil {
+		return fmt.Errorf("Error al obtener las portadas: %w", err)
+	}
+
+	withCover := make(map[int64]bool, len(covers))
+	for _, cover := range covers {
+		withCover[cover.BookID] = true
+	}
+
+	for _, book := range books {
+		if withCover[book.ID] {
+			book.Cover = CoverURLsFor(libraryID, book.ID)
+		}
+	}
+
+	return nil
+}
+
+// moveShelvedCopies takes the copies of a book still on its previous shelf
+// to the new one; copies placed elsewhere stay where they are.
+func (s *BookService) moveShelvedCopies(ctx context.Context, libraryID, bookID int64, from, to database.NullInt64) error {
+	copies, err := s.copyStore.GetCopiesFiltered(libraryID, store.CopyFilter{BookID: &bookID})
+	if err != nil {
+		return fmt.Errorf("Error al obtener las copias del libro: %w", err)
+	}
+
+	var shelved []*models.Copy
+
+	for _, copy := range copies {
+		if copy.ShelfID == from {
+			shelved = append(shelved, copy)
+		}
+	}
+
+	return relocateCopies(ctx, s.copyStore, s.auditService, libraryID, shelved, to, nil)
+}
+
+// attachAvailability fills the availability derived from copies and
+// reservations; the book status always follows it.
+func attachAvailability(bookStore store.IBookStore, libraryID int64, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	if len(books) == 1 {
+		availability, err := bookStore.GetAvailability(libraryID, books[0].ID)
+		if err != nil {
+			return fmt.Errorf("Error al obtener la disponibilidad del libro: %w", err)
+		}
+
+		books[0].Availability = availability
+		books[0].Status = availability.Status
+
+		return nil
+	}
+
+	bookIDs := make([]int64, len(books))
+	for i, book := range books {
+		bookIDs[i] = book.ID
+	}
+
+	availabilities, err := bookStore.GetAvailabilityByBookIDs(libraryID, bookIDs)
+	if err != nil {
+		return fmt.Errorf("Error al obtener la disponibilidad de los libros: %w", err)
+	}
+
+	byBook := make(map[int64]*models.BookAvailability, len(availabilities))
+	for _, availability := range availabilities {
+		byBook[availability.BookID] = availability
+	}
+
+	for _, book := range books {
+		if availability, ok := byBook[book.ID]; ok {
+			book.Availability = availability
+			book.Status = availability.Status
+		}
+	}
+
+	return nil
+}
+
+func refreshCallNumberByID(bookStore store.IBookStore, libraryID, bookID int64) error {
+	book, err := bookStore.GetByID(libraryID, bookID)
+	if err != nil {
+		return fmt.Errorf("Error al obtener el libro con ID %d: %w", bookID, err)
+	}
+
+	return refreshCallNumber(bookStore, libraryID, book)
+}
+
+func refreshCallNumber(bookStore store.IBookStore, libraryID int64, book *models.Book) error {
+	var callNumber string
+
+	if book.ClassNumber.Valid {
+		cutter := book.CutterNumber.String
+
+		if !book.CutterNumber.Valid {
+			authors, err := bookStore.GetBookAuthors(libraryID, book.ID)
+			if err != nil {
+				return fmt.Errorf("Error al obtener los autores del libro: %w", err)
+			}
+
+			entry := book.Title
+			if len(authors) > 0 {
+				entry = authors[0].LastName
+			}
+
+			cutter = classification.Cutter(entry)
+		}
+
+		callNumber = classification.CallNumber(book.ClassNumber.String, cutter, book.PublicationYear.Int64)
+	}
+
+	if err := bookStore.UpdateCallNumber(libraryID, book.ID, callNumber); err != nil {
+		return fmt.Errorf("Error al actualizar la signatura topográfica del libro: %w", err)
+	}
+
+	book.CallNumber.Valid = callNumber != ""
+	book.CallNumber.String = callNumber
+
 	return nil
 }
+
+func editedBookFields(before, after *models.Book) []string {
+	if before == nil {
+		before = &models.Book{}
+	}
+
+	var fields []string
+
+	if before.Title != after.Title {
+		fields = append(fields, "title")
+	}
+
+	if before.Subtitle != after.Subtitle {
+		fields = append(fields, "subtitle")
+	}
+
+	if before.PublicationYear != after.PublicationYear {
+		fields = append(fields, "publication_year")
+	}
+
+	if before.Pages != after.Pages {
+		fields = append(fields, "pages")
+	}
+
+	if before.Synopsis != after.Synopsis {
+		fields = append(fields, "synopsis")
+	}
+
+	if before.PublisherID != after.PublisherID {
+		fields = append(fields, "publisher_id")
+	}
+
+	return fields
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/storage"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+func TestDeleteBookRemovesCover(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+
+	seed := []string{
+		`INSERT INTO books (id, isbn, title, library_id) VALUES (1, '9780000000001', 'Pedro Páramo', 1)`,
+		`INSERT INTO book_covers (book_id, content_type, size, width, height, etag, library_id) VALUES (1, 'image/png', 3, 1, 1, 'abc', 1)`,
+	}
+
+	for _, query := range seed {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("blob store: %v", err)
+	}
+
+	sizes := []string{CoverSizeOriginal, CoverSizeMedium, CoverSizeSmall}
+
+	for _, size := range sizes {
+		if err := blobStore.Put(coverKey(1, 1, size), []byte("img")); err != nil {
+			t.Fatalf("put %s: %v", size, err)
+		}
+	}
+
+	service := NewBookService(
+		store.NewBookStore(db),
+		store.NewAuthorStore(db),
+		store.NewCopyStore(db),
+		store.NewReservationStore(db),
+		store.NewCoverStore(db),
+		blobStore,
+		NewAuditService(store.NewAuditStore(db)),
+	)
+
+	if err := service.DeleteBook(context.Background(), 1, 1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	for _, size := range sizes {
+		if _, err := blobStore.Get(coverKey(1, 1, size)); !errors.Is(err, storage.ErrBlobNotFound) {
+			t.Errorf("cover %s: err = %v, want ErrBlobNotFound", size, err)
+		}
+	}
+}
+
+func TestGetBookCategoriesIncludesTree(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+
+	seed := []string{
+		`INSERT INTO books (id, isbn, title, library_id) VALUES (1, '9780000000001', 'Pedro Páramo', 1)`,
+		`INSERT INTO categories (id, name, parent_id, path, depth, library_id) VALUES (1, 'Literatura', NULL, '/1/', 0, 1), (2, 'Novela', 1, '/1/2/', 1, 1)`,
+		`INSERT INTO book_categories (book_id, category_id, library_id) VALUES (1, 2, 1)`,
+	}
+
+	for _, query := range seed {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	categories, err := store.NewBookStore(db).GetBookCategories(1, 1)
+	if err != nil {
+		t.Fatalf("book categories: %v", err)
+	}
+
+	want, err := store.NewCategoryStore(db).GetByID(1, 2)
+	if err != nil {
+		t.Fatalf("category: %v", err)
+	}
+
+	if len(categories) != 1 || *categories[0] != *want {
+		t.Fatalf("book categories = %+v, want [%+v]", categories, want)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,11 +13,13 @@ import (
 
 type CategoryService struct {
 	categoryStore store.ICategoryStore
+	auditService  *AuditService
 }
 
-func NewCategoryService(categoryStore store.ICategoryStore) *CategoryService {
+func NewCategoryService(categoryStore store.ICategoryStore, auditService *AuditService) *CategoryService {
 	return &CategoryService{
 		categoryStore: categoryStore,
+		auditService:  auditService,
 	}
 }
 
@@ -24,6 +27,34 @@ func (s *CategoryService) GetAllCategories(libraryID int64) ([]*models.Category,
 	return s.categoryStore.GetAll(libraryID)
 }
 
+func (s *CategoryService) GetCategoryTree(libraryID int64) ([]*models.CategoryNode, error) {
+	categories, err := s.categoryStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las categorías: %w", err)
+	}
+
+	nodes := make(map[int64]*models.CategoryNode, len(categories))
+	roots := []*models.CategoryNode{}
+
+	for _, category := range categories {
+		nodes[category.ID] = &models.CategoryNode{Category: category, Children: []*models.CategoryNode{}}
+	}
+
+	for _, category := range categories {
+		node := nodes[category.ID]
+
+		parent, ok := nodes[category.ParentID.Int64]
+		if !category.ParentID.Valid || !ok {
+			roots = append(roots, node)
+			continue
+		}
+
+		parent.Children = append(parent.Children, node)
+	}
+
+	return roots, nil
+}
+
 func (s *CategoryService) GetCategoryByID(libraryID, id int64) (*models.Category, error) {
 	if id <= 0 {
 		return nil, errors.New("El id de la categoría es invalido")
@@ -37,7 +68,7 @@ func (s *CategoryService) GetCategoryByID(libraryID, id int64) (*models.Category
 	return category, nil
 }
 
-func (s *CategoryService) CreateCategory(libraryID int64, category *models.Category) (*models.Category, error) {
+func (s *CategoryService) CreateCategory(ctx context.Context, libraryID int64, category *models.Category) (*models.Category, error) {
 	if err := validations.ValidateCategory(category); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -49,15 +80,33 @@ func (s *CategoryService) CreateCategory(libraryID int64, category *models.Categ
 		category.Description.String = strings.TrimSpace(category.Description.String)
 	}
 
+	var parentID *int64
+
+	if category.ParentID.Valid {
+		parentID = &category.ParentID.Int64
+
+		if _, err := s.categoryStore.GetByID(libraryID, *parentID); err != nil {
+			return nil, fmt.Errorf("La categoría padre con ID %d no existe", *parentID)
+		}
+	}
+
+	if err := s.ensureUniqueName(libraryID, parentID, category.Name, 0); err != nil {
+		return nil, err
+	}
+
 	createdCategory, err := s.categoryStore.Create(libraryID, category)
 	if err != nil {
 		return nil, fmt.Errorf("Error al crear la categoría: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "category", createdCategory.ID, "create", nil, createdCategory); err != nil {
+		return nil, err
+	}
+
 	return createdCategory, nil
 }
 
-func (s *CategoryService) UpdateCategory(libraryID, id int64, category *models.Category) (*models.Category, error) {
+func (s *CategoryService) UpdateCategory(ctx context.Context, libraryID, id int64, category *models.Category) (*models.Category, error) {
 	if id <= 0 {
 		return nil, errors.New("El id de la categoría es invalido")
 	}
@@ -81,15 +130,28 @@ func (s *CategoryService) UpdateCategory(libraryID, id int64, category *models.C
 		category.Description.String = strings.TrimSpace(category.Description.String)
 	}
 
+	var parentID *int64
+	if existingCategory.ParentID.Valid {
+		parentID = &existingCategory.ParentID.Int64
+	}
+
+	if err := s.ensureUniqueName(libraryID, parentID, category.Name, id); err != nil {
+		return nil, err
+	}
+
 	updatedCategory, err := s.categoryStore.Update(libraryID, id, category)
 	if err != nil {
 		return nil, fmt.Errorf("Error al actualizar la categoría con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "category", id, "update", existingCategory, updatedCategory); err != nil {
+		return nil, err
+	}
+
 	return updatedCategory, nil
 }
 
-func (s *CategoryService) DeleteCategory(libraryID, id int64) error {
+func (s *CategoryService) DeleteCategory(ctx context.Context, libraryID, id int64) error {
 	if id <= 0 {
 		return errors.New("El id de la categoría es invalido")
 	}
@@ -103,9 +165,126 @@ func (s *CategoryService) DeleteCategory(libraryID, id int64) error {
 		return fmt.Errorf("La categoría con ID %d no existe", id)
 	}
 
+	children, err := s.categoryStore.GetChildren(libraryID, id)
+	if err != nil {
+		return fmt.Errorf("Error al obtener las subcategorías: %w", err)
+	}
+
+	if len(children) > 0 {
+		return fmt.Errorf("La categoría tiene %d subcategoría(s), muévalas o fusione la categoría antes de eliminarla", len(children))
+	}
+
 	if err := s.categoryStore.Delete(libraryID, id); err != nil {
 		return fmt.Errorf("Error al eliminar la categoría con ID %d: %w", id, err)
 	}
 
+	return s.auditService.Record(ctx, libraryID, "category", id, "delete", existingCategory, nil)
+}
+
+func (s *CategoryService) MoveCategory(ctx context.Context, libraryID, id int64, parentID *int64) (*models.Category, error) {
+	category, err := s.GetCategoryByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		parent, err := s.categoryStore.GetByID(libraryID, *parentID)
+		if err != nil {
+			return nil, fmt.Errorf("La categoría padre con ID %d no existe", *parentID)
+		}
+
+		if strings.HasPrefix(parent.Path, category.Path) {
+			return nil, errors.New("No se puede mover una categoría dentro de sí misma o de una de sus subcategorías")
+		}
+	}
+
+	if err := s.ensureUniqueName(libraryID, parentID, category.Name, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryStore.Move(libraryID, id, parentID); err != nil {
+		return nil, fmt.Errorf("Error al mover la categoría con ID %d: %w", id, err)
+	}
+
+	movedCategory, err := s.GetCategoryByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "category", id, "move", category, movedCategory); err != nil {
+		return nil, err
+	}
+
+	return movedCategory, nil
+}
+
+func (s *CategoryService) MergeCategories(ctx context.Context, libraryID, sourceID, targetID int64) (*models.Category, error) {
+	if sourceID == targetID {
+		return nil, errors.New("No se puede fusionar una categoría consigo misma")
+	}
+
+	source, err := s.GetCategoryByID(libraryID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.GetCategoryByID(libraryID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(target.Path, source.Path) {
+		return nil, errors.New("No se puede fusionar una categoría con una de sus subcategorías")
+	}
+
+	children, err := s.categoryStore.GetChildren(libraryID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las subcategorías: %w", err)
+	}
+
+	for _, child := range children {
+		if err := s.ensureUniqueName(libraryID, &targetID, child.Name, child.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.categoryStore.Merge(libraryID, sourceID, targetID); err != nil {
+		return nil, fmt.Errorf("Error al fusionar la categoría %d en %d: %w", sourceID, targetID, err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "category", sourceID, "merge", source, nil); err != nil {
+		return nil, err
+	}
+
+	for _, child := range children {
+		if movedChild, err := s.categoryStore.GetByID(libraryID, child.ID); err == nil {
+			if err := s.auditService.Record(ctx, libraryID, "category", child.ID, "move", child, movedChild); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	mergedCategory, err := s.GetCategoryByID(libraryID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "category", targetID, "merge", target, mergedCategory); err != nil {
+		return nil, err
+	}
+
+	return mergedCategory, nil
+}
+
+func (s *CategoryService) ensureUniqueName(libraryID int64, parentID *int64, name string, excludeID int64) error {
+	sibling, err := s.categoryStore.GetByName(libraryID, parentID, name)
+	if err != nil {
+		return fmt.Errorf("Error al verificar el nombre de la categoría: %w", err)
+	}
+
+	if sibling != nil && sibling.ID != excludeID {
+		return fmt.Errorf("Ya existe una categoría llamada %s en el mismo nivel", sibling.Name)
+	}
+
 	return nil
 }
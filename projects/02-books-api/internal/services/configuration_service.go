@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
@@ -8,12 +9,14 @@ import (
 )
 
 type ConfigurationService struct {
-	configStore store.IConfigStore
+	configStore  store.IConfigStore
+	auditService *AuditService
 }
 
-func NewConfigurationService(configStore store.IConfigStore) *ConfigurationService {
+func NewConfigurationService(configStore store.IConfigStore, auditService *AuditService) *ConfigurationService {
 	return &ConfigurationService{
-		configStore: configStore,
+		configStore:  configStore,
+		auditService: auditService,
 	}
 }
 
@@ -26,12 +29,14 @@ func (s *ConfigurationService) GetConfiguration(libraryID int64) (*models.Config
 	return config, nil
 }
 
-func (s *ConfigurationService) UpdateConfiguration(libraryID int64, updates map[string]any) (*models.Configuration, error) {
+func (s *ConfigurationService) UpdateConfiguration(ctx context.Context, libraryID int64, updates map[string]any) (*models.Configuration, error) {
 	currentConfig, err := s.configStore.GetByLibraryID(libraryID)
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener la configuración actual: %w", err)
 	}
 
+	previousConfig := *currentConfig
+
 	if val, ok := updates["student_loan_days"]; ok {
 		if intVal, ok := val.(float64); ok {
 			currentConfig.StudentLoanDays = int(intVal)
@@ -79,5 +84,9 @@ func (s *ConfigurationService) UpdateConfiguration(libraryID int64, updates map[
 		return nil, fmt.Errorf("Error al actualizar la configuración: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "configuration", updatedConfig.ID, "update", &previousConfig, updatedConfig); err != nil {
+		return nil, err
+	}
+
 	return updatedConfig, nil
 }
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+// GetCopyHistory returns the timeline of a copy; it stays available after the
+// copy is deleted.
+func (s *CopyService) GetCopyHistory(libraryID, id int64) ([]*models.CopyEvent, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la copia es inválido")
+	}
+
+	events, err := s.copyEventStore.GetByCopyID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el historial de la copia con ID %d: %w", id, err)
+	}
+
+	if len(events) == 0 {
+		if _, err := s.copyStore.GetByID(libraryID, id); err != nil {
+			return nil, fmt.Errorf("La copia con ID %d no existe: %w", id, err)
+		}
+
+		return []*models.CopyEvent{}, nil
+	}
+
+	return events, nil
+}
+
+func newCopyEvent(copyID int64, eventType, fromValue, toValue string, librarianID *int64) *models.CopyEvent {
+	event := &models.CopyEvent{
+		CopyID:     copyID,
+		EventType:  eventType,
+		OccurredAt: time.Now(),
+	}
+
+	event.FromValue.Valid = fromValue != ""
+	event.FromValue.String = fromValue
+	event.ToValue.Valid = toValue != ""
+	event.ToValue.String = toValue
+
+	if librarianID != nil {
+		event.LibrarianID.Valid = true
+		event.LibrarianID.Int64 = *librarianID
+	}
+
+	return event
+}
+
+func recordCopyEvents(copyEventStore store.ICopyEventStore, libraryID int64, events ...*models.CopyEvent) error {
+	for _, event := range events {
+		if _, err := copyEventStore.Create(libraryID, event); err != nil {
+			return fmt.Errorf("Error al registrar el historial de la copia con ID %d: %w", event.CopyID, err)
+		}
+	}
+
+	return nil
+}
+
+func copyChangeEvents(before, after *models.Copy, librarianID *int64) []*models.CopyEvent {
+	var events []*models.CopyEvent
+
+	if before.Status != after.Status {
+		events = append(events, newCopyEvent(after.ID, "StatusChanged", before.Status, after.Status, librarianID))
+	}
+
+	if before.Condition != after.Condition {
+		events = append(events, newCopyEvent(after.ID, "ConditionChanged", before.Condition, after.Condition, librarianID))
+	}
+
+	if before.Notes != after.Notes {
+		events = append(events, newCopyEvent(after.ID, "NotesChanged", before.Notes.String, after.Notes.String, librarianID))
+	}
+
+	if before.ShelfID != after.ShelfID {
+		events = append(events, newCopyEvent(after.ID, "Moved", nullInt64Value(before.ShelfID), nullInt64Value(after.ShelfID), librarianID))
+	}
+
+	if before.TemporaryLocation != after.TemporaryLocation {
+		events = append(events, newCopyEvent(after.ID, "TemporaryLocationChanged", before.TemporaryLocation.String, after.TemporaryLocation.String, librarianID))
+	}
+
+	if before.BookID != after.BookID {
+		events = append(events, newCopyEvent(after.ID, "BookChanged", strconv.FormatInt(before.BookID, 10), strconv.FormatInt(after.BookID, 10), librarianID))
+	}
+
+	return events
+}
+
+func nullInt64Value(value database.NullInt64) string {
+	if !value.Valid {
+		return ""
+	}
+
+	return strconv.FormatInt(value.Int64, 10)
+}
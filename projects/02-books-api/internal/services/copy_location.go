@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+var ErrLocationInUse = errors.New("La ubicación todavía tiene copias o libros asignados")
+
+func (s *CopyService) MoveCopy(ctx context.Context, libraryID, id int64, move *models.CopyMove, librarianID *int64) (*models.Copy, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la copia es inválido")
+	}
+
+	if move == nil {
+		move = &models.CopyMove{}
+	}
+
+	move.CopyIDs = []int64{id}
+
+	copies, err := s.MoveCopies(ctx, libraryID, move, librarianID)
+	if err != nil {
+		return nil, err
+	}
+
+	return copies[0], nil
+}
+
+// MoveCopies puts the copies on the target shelf, at the temporary location,
+// or both. Moving to a shelf without a temporary location brings the copies
+// back from wherever they were temporarily.
+func (s *CopyService) MoveCopies(ctx context.Context, libraryID int64, move *models.CopyMove, librarianID *int64) ([]*models.Copy, error) {
+	if err := validations.ValidateCopyMove(move); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	if len(move.CopyIDs) == 0 {
+		return nil, errors.New("Se requiere al menos una copia")
+	}
+
+	if move.ShelfID.Valid {
+		if _, err := s.shelfStore.GetByID(libraryID, move.ShelfID.Int64); err != nil {
+			return nil, fmt.Errorf("El estante con ID %d no existe: %w", move.ShelfID.Int64, err)
+		}
+	}
+
+	if move.TemporaryLocation.Valid {
+		move.TemporaryLocation.String = strings.TrimSpace(move.TemporaryLocation.String)
+	}
+
+	previous := make(map[int64]*models.Copy, len(move.CopyIDs))
+
+	for _, copyID := range move.CopyIDs {
+		if previous[copyID] != nil {
+			return nil, fmt.Errorf("La copia con ID %d está repetida", copyID)
+		}
+
+		copy, err := s.copyStore.GetByID(libraryID, copyID)
+		if err != nil {
+			return nil, fmt.Errorf("La copia con ID %d no existe: %w", copyID, err)
+		}
+
+		previous[copyID] = copy
+	}
+
+	if err := s.copyStore.Move(libraryID, move.CopyIDs, move, librarianID); err != nil {
+		return nil, fmt.Errorf("Error al mover las copias: %w", err)
+	}
+
+	copies := make([]*models.Copy, 0, len(move.CopyIDs))
+
+	for _, copyID := range move.CopyIDs {
+		copy, err := s.copyStore.GetByID(libraryID, copyID)
+		if err != nil {
+			return nil, fmt.Errorf("Error al obtener la copia con ID %d: %w", copyID, err)
+		}
+
+		if err := s.auditService.Record(ctx, libraryID, "copy", copyID, "move", previous[copyID], copy); err != nil {
+			return nil, err
+		}
+
+		copies = append(copies, copy)
+	}
+
+	return copies, nil
+}
+
+// checkCopyLocation validates the shelf of a copy and trims its temporary
+// location.
+func (s *CopyService) checkCopyLocation(libraryID int64, copy *models.Copy) error {
+	if copy.ShelfID.Valid {
+		if _, err := s.shelfStore.GetByID(libraryID, copy.ShelfID.Int64); err != nil {
+			return fmt.Errorf("El estante con ID %d no existe: %w", copy.ShelfID.Int64, err)
+		}
+	}
+
+	if copy.TemporaryLocation.Valid {
+		copy.TemporaryLocation.String = strings.TrimSpace(copy.TemporaryLocation.String)
+	}
+
+	return nil
+}
+
+// checkLocationInUse fails with ErrLocationInUse when copies or books still
+// point at the shelves and there is no shelf to rehome them to.
+func checkLocationInUse(shelfStore store.IShelfStore, libraryID int64, shelfIDs []int64, rehomeTo *int64) error {
+	if rehomeTo != nil {
+		return nil
+	}
+
+	copyCount, bookCount, err := shelfStore.CountContents(libraryID, shelfIDs)
+	if err != nil {
+		return fmt.Errorf("Error al contar las copias y libros de la ubicación: %w", err)
+	}
+
+	if copyCount > 0 || bookCount > 0 {
+		return fmt.Errorf("%w: %d copia(s) y %d libro(s), indique rehome_to para reubicarlos", ErrLocationInUse, copyCount, bookCount)
+	}
+
+	return nil
+}
+
+// shelfContents returns the copies and books on the shelves, as they are
+// before a rehome, for the audit log.
+func shelfContents(copyStore store.ICopyStore, bookStore store.IBookStore, libraryID int64, shelfIDs []int64) ([]*models.Copy, []*models.Book, error) {
+	var copies []*models.Copy
+	var books []*models.Book
+
+	for _, shelfID := range shelfIDs {
+		shelfCopies, err := copyStore.GetCopiesFiltered(libraryID, store.CopyFilter{ShelfID: &shelfID})
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error al obtener las copias del estante: %w", err)
+		}
+
+		shelfBooks, err := bookStore.GetBooksFiltered(libraryID, store.BookFilter{ShelfID: &shelfID})
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error al obtener los libros del estante: %w", err)
+		}
+
+		copies = append(copies, shelfCopies...)
+		books = append(books, shelfBooks...)
+	}
+
+	return copies, books, nil
+}
+
+// recordRehome audits the move of the copies and books to the shelf they were
+// rehomed to.
+func recordRehome(ctx context.Context, auditService *AuditService, libraryID int64, copies []*models.Copy, books []*models.Book, toShelfID int64) error {
+	target := database.NullInt64{}
+	target.Valid = true
+	target.Int64 = toShelfID
+
+	for _, copy := range copies {
+		moved := *copy
+		moved.ShelfID = target
+
+		if err := auditService.Record(ctx, libraryID, "copy", copy.ID, "move", copy, &moved); err != nil {
+			return err
+		}
+	}
+
+	for _, book := range books {
+		moved := *book
+		moved.ShelfID = target
+
+		if err := auditService.Record(ctx, libraryID, "book", book.ID, "move", book, &moved); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relocateCopies changes the shelf of the copies keeping their temporary
+// location.
+func relocateCopies(ctx context.Context, copyStore store.ICopyStore, auditService *AuditService, libraryID int64, copies []*models.Copy, shelfID database.NullInt64, librarianID *int64) error {
+	for _, copy := range copies {
+		move := &models.CopyMove{
+			ShelfID:           shelfID,
+			TemporaryLocation: copy.TemporaryLocation,
+		}
+
+		if err := copyStore.Move(libraryID, []int64{copy.ID}, move, librarianID); err != nil {
+			return fmt.Errorf("Error al mover la copia con ID %d: %w", copy.ID, err)
+		}
+
+		moved := *copy
+		moved.ShelfID = shelfID
+
+		if err := auditService.Record(ctx, libraryID, "copy", copy.ID, "move", copy, &moved); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+func (s *CopyService) WithdrawCopy(ctx context.Context, libraryID, id int64, withdrawal *models.CopyWithdrawal, librarianID *int64) (*models.Copy, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la copia es inválido")
+	}
+
+	if withdrawal == nil {
+		withdrawal = &models.CopyWithdrawal{}
+	}
+
+	withdrawal.CopyIDs = []int64{id}
+
+	copies, err := s.WithdrawCopies(ctx, libraryID, withdrawal, librarianID)
+	if err != nil {
+		return nil, err
+	}
+
+	return copies[0], nil
+}
+
+// WithdrawCopies takes weeded copies out of the collection. They keep their
+// row and history but stop counting as copies of the book.
+func (s *CopyService) WithdrawCopies(ctx context.Context, libraryID int64, withdrawal *models.CopyWithdrawal, librarianID *int64) ([]*models.Copy, error) {
+	if err := validations.ValidateCopyWithdrawal(withdrawal); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	if len(withdrawal.CopyIDs) == 0 {
+		return nil, errors.New("Se requiere al menos una copia")
+	}
+
+	if withdrawal.Reason.Valid {
+		withdrawal.Reason.String = strings.TrimSpace(withdrawal.Reason.String)
+		withdrawal.Reason.Valid = withdrawal.Reason.String != ""
+	}
+
+	previous := make(map[int64]*models.Copy, len(withdrawal.CopyIDs))
+
+	for _, copyID := range withdrawal.CopyIDs {
+		if previous[copyID] != nil {
+			return nil, fmt.Errorf("La copia con ID %d está repetida", copyID)
+		}
+
+		copy, err := s.copyStore.GetByID(libraryID, copyID)
+		if err != nil {
+			return nil, fmt.Errorf("La copia con ID %d no existe: %w", copyID, err)
+		}
+
+		previous[copyID] = copy
+
+		switch copy.Status {
+			case "Borrowed", "Reserved":
+				return nil, fmt.Errorf("La copia %s está prestada o apartada y no se puede dar de baja", copy.Code)
+			case "Withdrawn":
+				return nil, fmt.Errorf("La copia %s ya fue dada de baja", copy.Code)
+		}
+	}
+
+	if err := s.copyStore.Withdraw(libraryID, withdrawal.CopyIDs, withdrawal.Reason, librarianID); err != nil {
+		return nil, fmt.Errorf("Error al dar de baja las copias: %w", err)
+	}
+
+	copies := make([]*models.Copy, 0, len(withdrawal.CopyIDs))
+
+	for _, copyID := range withdrawal.CopyIDs {
+		copy, err := s.copyStore.GetByID(libraryID, copyID)
+		if err != nil {
+			return nil, fmt.Errorf("Error al obtener la copia con ID %d: %w", copyID, err)
+		}
+
+		if err := s.auditService.Record(ctx, libraryID, "copy", copyID, "withdraw", previous[copyID], copy); err != nil {
+			return nil, err
+		}
+
+		copies = append(copies, copy)
+	}
+
+	return copies, nil
+}
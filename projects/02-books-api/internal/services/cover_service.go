@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/storage"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+const (
+	CoverSizeOriginal = "original"
+	CoverSizeMedium   = "medium"
+	CoverSizeSmall    = "small"
+
+	MaxCoverBytes     = 5 << 20
+	maxCoverDimension = 6000
+)
+
+var (
+	ErrCoverNotFound = errors.New("El libro no tiene portada")
+
+	coverThumbnailSizes = map[string]int{
+		CoverSizeMedium: 320,
+		CoverSizeSmall:  120,
+	}
+
+	allowedCoverTypes = map[string]bool{
+		"image/jpeg": true,
+		"image/png":  true,
+	}
+)
+
+type CoverService struct {
+	coverStore   store.ICoverStore
+	bookStore    store.IBookStore
+	blobStore    storage.BlobStore
+	auditService *AuditService
+}
+
+func NewCoverService(coverStore store.ICoverStore, bookStore store.IBookStore, blobStore storage.BlobStore, auditService *AuditService) *CoverService {
+	return &CoverService{
+		coverStore:   coverStore,
+		bookStore:    bookStore,
+		blobStore:    blobStore,
+		auditService: auditService,
+	}
+}
+
+func (s *CoverService) UploadCover(ctx context.Context, libraryID, bookID int64, data []byte) (*models.BookCover, error) {
+	if bookID <= 0 {
+		return nil, errors.New("El ID del libro es inválido")
+	}
+
+	_, err := s.bookStore.GetByID(libraryID, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("El libro con ID %d no existe: %w", bookID, err)
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("La imagen de portada es requerida")
+	}
+
+	if len(data) > MaxCoverBytes {
+		return nil, fmt.Errorf("La imagen no puede exceder %d MB", MaxCoverBytes>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedCoverTypes[contentType] {
+		return nil, fmt.Errorf("Tipo de imagen no permitido (%s), solo se aceptan JPEG o PNG", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("La imagen está dañada o no es válida: %w", err)
+	}
+
+	if config.Width > maxCoverDimension || config.Height > maxCoverDimension {
+		return nil, fmt.Errorf("Las dimensiones de la imagen no pueden exceder %dx%d píxeles", maxCoverDimension, maxCoverDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("La imagen está dañada o no es válida: %w", err)
+	}
+
+	thumbnails := make(map[string][]byte, len(coverThumbnailSizes))
+
+	for size, maxSide := range coverThumbnailSizes {
+		var buffer bytes.Buffer
+
+		err := jpeg.Encode(&buffer, resizeImage(img, maxSide), &jpeg.Options{Quality: 85})
+		if err != nil {
+			return nil, fmt.Errorf("Error al generar la miniatura %s: %w", size, err)
+		}
+
+		thumbnails[size] = buffer.Bytes()
+	}
+
+	if err := s.blobStore.Put(coverKey(libraryID, bookID, CoverSizeOriginal), data); err != nil {
+		return nil, fmt.Errorf("Error al guardar la portada: %w", err)
+	}
+
+	for size, thumbnail := range thumbnails {
+		if err := s.blobStore.Put(coverKey(libraryID, bookID, size), thumbnail); err != nil {
+			return nil, fmt.Errorf("Error al guardar la miniatura %s: %w", size, err)
+		}
+	}
+
+	hash := sha256.Sum256(data)
+
+	cover := &models.BookCover{
+		BookID:      bookID,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       config.Width,
+		Height:      config.Height,
+		ETag:        hex.EncodeToString(hash[:16]),
+		UpdatedAt:   time.Now(),
+	}
+
+	previousCover, err := s.coverStore.GetByBookID(libraryID, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la portada del libro con ID %d: %w", bookID, err)
+	}
+
+	savedCover, err := s.coverStore.Save(libraryID, cover)
+	if err != nil {
+		return nil, fmt.Errorf("Error al registrar la portada: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "book_cover", bookID, "upload", previousCover, savedCover); err != nil {
+		return nil, err
+	}
+
+	return savedCover, nil
+}
+
+func (s *CoverService) GetCover(libraryID, bookID int64, size string) (*models.BookCover, []byte, error) {
+	if size == "" {
+		size = CoverSizeOriginal
+	}
+
+	if size != CoverSizeOriginal && coverThumbnailSizes[size] == 0 {
+		return nil, nil, fmt.Errorf("Tamaño de portada inválido: %s", size)
+	}
+
+	cover, err := s.coverStore.GetByBookID(libraryID, bookID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error al obtener la portada del libro con ID %d: %w", bookID, err)
+	}
+
+	if cover == nil {
+		return nil, nil, ErrCoverNotFound
+	}
+
+	data, err := s.blobStore.Get(coverKey(libraryID, bookID, size))
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, nil, ErrCoverNotFound
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error al leer la portada: %w", err)
+	}
+
+	if size != CoverSizeOriginal {
+		cover.ContentType = "image/jpeg"
+		cover.Size = int64(len(data))
+	}
+
+	cover.ETag = fmt.Sprintf("%s-%s", cover.ETag, size)
+
+	return cover, data, nil
+}
+
+func (s *CoverService) DeleteCover(ctx context.Context, libraryID, bookID int64) error {
+	cover, err := s.coverStore.GetByBookID(libraryID, bookID)
+	if err != nil {
+		return fmt.Errorf("Error al obtener la portada del libro con ID %d: %w", bookID, err)
+	}
+
+	if cover == nil {
+		return ErrCoverNotFound
+	}
+
+	if err := s.coverStore.Delete(libraryID, bookID); err != nil {
+		return fmt.Errorf("Error al eliminar la portada: %w", err)
+	}
+
+	for _, size := range []string{CoverSizeOriginal, CoverSizeMedium, CoverSizeSmall} {
+		if err := s.blobStore.Delete(coverKey(libraryID, bookID, size)); err != nil {
+			return fmt.Errorf("Error al eliminar el archivo de portada %s: %w", size, err)
+		}
+	}
+
+	return s.auditService.Record(ctx, libraryID, "book_cover", bookID, "delete", cover, nil)
+}
+
+func CoverURLsFor(libraryID, bookID int64) *models.CoverURLs {
+	base := fmt.Sprintf("/%d/books/%d/cover", libraryID, bookID)
+
+	return &models.CoverURLs{
+		Original: base,
+		Medium:   base + "?size=" + CoverSizeMedium,
+		Small:    base + "?size=" + CoverSizeSmall,
+	}
+}
+
+func coverKey(libraryID, bookID int64, size string) string {
+	return fmt.Sprintf("covers/%d/%d/%s", libraryID, bookID, size)
+}
+
+func resizeImage(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), src, bounds.Min, draw.Over)
+
+	if width <= maxSide && height <= maxSide {
+		return canvas
+	}
+
+	dstWidth, dstHeight := maxSide, maxSide
+	if width > height {
+		dstHeight = max(1, height*maxSide/width)
+	} else {
+		dstWidth = max(1, width*maxSide/height)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		y0 := y * height / dstHeight
+		y1 := max((y+1)*height/dstHeight, y0+1)
+
+		for x := 0; x < dstWidth; x++ {
+			x0 := x * width / dstWidth
+			x1 := max((x+1)*width/dstWidth, x0+1)
+
+			var r, g, b, count int
+
+			for sy := y0; sy < y1; sy++ {
+				offset := sy*canvas.Stride + x0*4
+
+				for sx := x0; sx < x1; sx++ {
+					r += int(canvas.Pix[offset])
+					g += int(canvas.Pix[offset+1])
+					b += int(canvas.Pix[offset+2])
+					offset += 4
+					count++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / count),
+				G: uint8(g / count),
+				B: uint8(b / count),
+				A: 255,
+			})
+		}
+	}
+
+	return dst
+}
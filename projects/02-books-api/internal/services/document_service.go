@@ -0,0 +1,802 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/documents"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+const (
+	DocumentLoanSlip        = "loan_slip"
+	DocumentOverdueNotice   = "overdue_notice"
+	DocumentFineReceipt     = "fine_receipt"
+	DocumentPatronStatement = "patron_statement"
+)
+
+// documentType is an entry of the registry with the template used while the
+// library has not customized it.
+type documentType struct {
+	name        string
+	title       string
+	description string
+	body        string
+}
+
+var documentTypes = []documentType{
+	{
+		name:        DocumentLoanSlip,
+		title:       "Comprobante de préstamo",
+		description: "Comprobante que se entrega al prestar un ejemplar",
+		body: `# Comprobante de préstamo
+
+**Usuario:** {{.Patron.Name}} ({{.Patron.Code}})
+**Fecha:** {{datetime .GeneratedAt}}
+
+{{with .Loan}}
+| Título | Autor | Ejemplar | Signatura | Préstamo | Devolver antes de |
+| {{.Title}} | {{.Authors}} | {{.CopyCode}} | {{.CallNumber}} | {{date .LoanDate}} | {{date .DueDate}} |
+
+{{if .Renewals}}Renovaciones realizadas: {{.Renewals}}{{end}}
+Por favor devuelva el material a más tardar el **{{date .DueDate}}**. Después de esa fecha se genera una multa por cada día de retraso.
+{{end}}
+{{if .TotalDue}}
+Tiene multas pendientes por **{{money .TotalDue}}**.
+{{end}}
+
+~ Conserve este comprobante hasta devolver el material.
+`,
+	},
+	{
+		name:        DocumentOverdueNotice,
+		title:       "Aviso de préstamo vencido",
+		description: "Aviso al usuario de los préstamos que no devolvió a tiempo",
+		body: `# Aviso de préstamo vencido
+
+Estimado(a) **{{.Patron.Name}}** ({{.Patron.Code}}):
+
+Nuestros registros indican que {{if eq (len .Loans) 1}}el siguiente material no ha sido devuelto{{else}}los siguientes materiales no han sido devueltos{{end}} en la fecha acordada:
+
+| Título | Ejemplar | Vencimiento | Días de atraso |
+{{range .Loans}}
+| {{.Title}} | {{.CopyCode}} | {{date .DueDate}} | {{.DaysOverdue}} |
+{{end}}
+
+Le pedimos devolverlo a la brevedad. Mientras tanto se sigue generando una multa por cada día de retraso.
+{{if .TotalDue}}
+Adeudo actual por multas: **{{money .TotalDue}}**
+{{end}}
+
+Atentamente,
+{{.Library.Name}}
+
+~ Aviso generado el {{datetime .GeneratedAt}}. Si ya devolvió el material, ignore este aviso.
+`,
+	},
+	{
+		name:        DocumentFineReceipt,
+		title:       "Recibo de multa",
+		description: "Recibo de una multa pagada o condonada",
+		body: `# Recibo de multa
+
+{{with .Fine}}
+**Folio:** {{.ID}}
+**Usuario:** {{$.Patron.Name}} ({{$.Patron.Code}})
+**Fecha de emisión:** {{datetime $.GeneratedAt}}
+
+| Concepto | Préstamo | Generada | Estado | Importe |
+| {{.Reason}} | {{.LoanCode}} | {{date .GeneratedDate}} | {{.Status}} | {{money .Amount}} |
+
+{{if .PaymentDate}}**Fecha de pago:** {{date .PaymentDate}}{{end}}
+{{if .Notes}}**Notas:** {{.Notes}}{{end}}
+
+>> **Total: {{money .Amount}}**
+{{end}}
+{{if .TotalDue}}
+Saldo pendiente de otras multas: {{money .TotalDue}}
+{{end}}
+
+
+
+______________________________
+Firma y sello de la biblioteca
+`,
+	},
+	{
+		name:        DocumentPatronStatement,
+		title:       "Estado de cuenta",
+		description: "Préstamos activos, multas pendientes y reservaciones del usuario",
+		body: `# Estado de cuenta
+
+**Usuario:** {{.Patron.Name}} ({{.Patron.Code}})
+**Tipo:** {{.Patron.UserType}}{{if .Patron.Email}}   **Correo:** {{.Patron.Email}}{{end}}
+**Fecha:** {{datetime .GeneratedAt}}
+
+## Préstamos activos
+{{if .Loans}}
+| Título | Ejemplar | Préstamo | Vencimiento | Estado |
+{{range .Loans}}
+| {{.Title}} | {{.CopyCode}} | {{date .LoanDate}} | {{date .DueDate}} | {{.Status}}{{if .DaysOverdue}} ({{.DaysOverdue}} {{plural .DaysOverdue "día" "días"}}){{end}} |
+{{end}}
+{{else}}
+Sin préstamos activos.
+{{end}}
+
+## Multas pendientes
+{{if .Fines}}
+| Folio | Concepto | Préstamo | Generada | Importe |
+{{range .Fines}}
+| {{.ID}} | {{.Reason}} | {{.LoanCode}} | {{date .GeneratedDate}} | {{money .Amount}} |
+{{end}}
+>> **Total adeudado: {{money .TotalDue}}**
+{{else}}
+Sin multas pendientes.
+{{end}}
+
+## Reservaciones
+{{if .Reservations}}
+| Título | Reservada | Vence | Estado |
+{{range .Reservations}}
+| {{.Title}} | {{date .ReservationDate}} | {{date .ExpirationDate}} | {{.Status}} |
+{{end}}
+{{else}}
+Sin reservaciones vigentes.
+{{end}}
+`,
+	},
+}
+
+var (
+	userTypeLabels          = map[string]string{"Student": "Estudiante", "Teacher": "Docente", "Staff": "Personal", "External": "Externo"}
+	loanStatusLabels        = map[string]string{"Active": "Activo", "Overdue": "Vencido", "Returned": "Devuelto", "Lost": "Perdido"}
+	fineReasonLabels        = map[string]string{"Overdue": "Retraso", "Damage": "Daño", "Loss": "Pérdida"}
+	fineStatusLabels        = map[string]string{"Pending": "Pendiente", "Paid": "Pagada", "Waived": "Condonada"}
+	reservationStatusLabels = map[string]string{"Pending": "En espera", "Active": "Lista para recoger"}
+)
+
+type DocumentService struct {
+	templateStore    store.IDocumentTemplateStore
+	libraryService   *LibraryService
+	loanStore        store.ILoanStore
+	fineStore        store.IFineStore
+	reservationStore store.IReservationStore
+	userStore        store.IUserStore
+	copyStore        store.ICopyStore
+	bookStore        store.IBookStore
+	auditService     *AuditService
+}
+
+func NewDocumentService(templateStore store.IDocumentTemplateStore, libraryService *LibraryService, loanStore store.ILoanStore, fineStore store.IFineStore, reservationStore store.IReservationStore, userStore store.IUserStore, copyStore store.ICopyStore, bookStore store.IBookStore, auditService *AuditService) *DocumentService {
+	return &DocumentService{
+		templateStore:    templateStore,
+		libraryService:   libraryService,
+		loanStore:        loanStore,
+		fineStore:        fineStore,
+		reservationStore: reservationStore,
+		userStore:        userStore,
+		copyStore:        copyStore,
+		bookStore:        bookStore,
+		auditService:     auditService,
+	}
+}
+
+// GetTemplates returns the template of every document, the library's own or
+// the default one.
+func (s *DocumentService) GetTemplates(libraryID int64) ([]*models.DocumentTemplate, error) {
+	customized, err := s.templateStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las plantillas: %w", err)
+	}
+
+	byType := make(map[string]*models.DocumentTemplate, len(customized))
+	for _, tmpl := range customized {
+		byType[tmpl.DocumentType] = tmpl
+	}
+
+	templates := make([]*models.DocumentTemplate, 0, len(documentTypes))
+
+	for _, t := range documentTypes {
+		tmpl, ok := byType[t.name]
+		if !ok {
+			tmpl = &models.DocumentTemplate{DocumentType: t.name, Body: t.body, LibraryID: libraryID}
+		}
+
+		tmpl.Description = t.description
+		templates = append(templates, tmpl)
+	}
+
+	return templates, nil
+}
+
+func (s *DocumentService) GetTemplate(libraryID int64, documentType string) (*models.DocumentTemplate, error) {
+	t, err := findDocumentType(documentType)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := s.templateStore.GetByType(libraryID, t.name)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la plantilla %s: %w", t.name, err)
+	}
+
+	if tmpl == nil {
+		tmpl = &models.DocumentTemplate{DocumentType: t.name, Body: t.body, LibraryID: libraryID}
+	}
+
+	tmpl.Description = t.description
+
+	return tmpl, nil
+}
+
+// SaveTemplate replaces the template of a document for the library. The
+// template is tried against sample data so mistakes show up now and not when
+// the desk prints.
+func (s *DocumentService) SaveTemplate(ctx context.Context, libraryID int64, documentType string, tmpl *models.DocumentTemplate) (*models.DocumentTemplate, error) {
+	t, err := findDocumentType(documentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validations.ValidateDocumentTemplate(tmpl); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	parsed, err := documents.Parse(t.name, tmpl.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	markup, err := documents.Execute(parsed, sampleDocumentData())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := documents.Render(t.title, markup, documents.Branding{Name: "Biblioteca"}, time.Now()); err != nil {
+		return nil, fmt.Errorf("Error al generar el documento de prueba: %w", err)
+	}
+
+	tmpl.DocumentType = t.name
+
+	previousTemplate, err := s.templateStore.GetByType(libraryID, t.name)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la plantilla %s: %w", t.name, err)
+	}
+
+	savedTemplate, err := s.templateStore.Save(libraryID, tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("Error al guardar la plantilla: %w", err)
+	}
+
+	action := "update"
+	if previousTemplate == nil {
+		action = "create"
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "document_template", 0, action, previousTemplate, savedTemplate); err != nil {
+		return nil, err
+	}
+
+	savedTemplate.Description = t.description
+
+	return savedTemplate, nil
+}
+
+// ResetTemplate drops the library's template so the default one is used again.
+func (s *DocumentService) ResetTemplate(ctx context.Context, libraryID int64, documentType string) error {
+	t, err := findDocumentType(documentType)
+	if err != nil {
+		return err
+	}
+
+	previousTemplate, err := s.templateStore.GetByType(libraryID, t.name)
+	if err != nil {
+		return fmt.Errorf("Error al obtener la plantilla %s: %w", t.name, err)
+	}
+
+	if err := s.templateStore.Delete(libraryID, t.name); err != nil {
+		return fmt.Errorf("Error al restablecer la plantilla: %w", err)
+	}
+
+	if previousTemplate != nil {
+		if err := s.auditService.Record(ctx, libraryID, "document_template", 0, "delete", previousTemplate, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *DocumentService) RenderLoanSlip(libraryID, loanID int64) ([]byte, string, error) {
+	now := time.Now()
+
+	loan, err := s.loanStore.GetByID(libraryID, loanID)
+	if err != nil {
+		return nil, "", fmt.Errorf("Error al obtener el préstamo con ID %d: %w", loanID, err)
+	}
+
+	data, err := s.patronData(libraryID, loan.UserID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	documentLoan, err := s.loanData(libraryID, loan, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data.Loan = &documentLoan
+	data.Loans = []models.DocumentLoan{documentLoan}
+
+	return s.render(libraryID, DocumentLoanSlip, data, "comprobante-"+loan.LoanCode)
+}
+
+// RenderOverdueNotice builds the notice for a single overdue loan.
+func (s *DocumentService) RenderOverdueNotice(libraryID, loanID int64) ([]byte, string, error) {
+	now := time.Now()
+
+	loan, err := s.loanStore.GetByID(libraryID, loanID)
+	if err != nil {
+		return nil, "", fmt.Errorf("Error al obtener el préstamo con ID %d: %w", loanID, err)
+	}
+
+	if loan.ReturnDate.Valid || !loan.DueDate.Before(now) {
+		return nil, "", errors.New("El préstamo no está vencido")
+	}
+
+	data, err := s.patronData(libraryID, loan.UserID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	documentLoan, err := s.loanData(libraryID, loan, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data.Loan = &documentLoan
+	data.Loans = []models.DocumentLoan{documentLoan}
+
+	return s.render(libraryID, DocumentOverdueNotice, data, "aviso-"+loan.LoanCode)
+}
+
+// RenderPatronOverdueNotice builds one notice with every overdue loan of the
+// patron.
+func (s *DocumentService) RenderPatronOverdueNotice(libraryID, userID int64) ([]byte, string, error) {
+	now := time.Now()
+
+	data, err := s.patronData(libraryID, userID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	loans, err := s.openLoans(libraryID, userID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, loan := range loans {
+		if loan.DaysOverdue > 0 {
+			data.Loans = append(data.Loans, loan)
+		}
+	}
+
+	if len(data.Loans) == 0 {
+		return nil, "", errors.New("El usuario no tiene préstamos vencidos")
+	}
+
+	return s.render(libraryID, DocumentOverdueNotice, data, "aviso-"+data.Patron.Code)
+}
+
+// RenderFineReceipt builds the receipt of a paid or waived fine.
+func (s *DocumentService) RenderFineReceipt(libraryID, fineID int64) ([]byte, string, error) {
+	now := time.Now()
+
+	fine, err := s.fineStore.GetByID(libraryID, fineID)
+	if err != nil {
+		return nil, "", fmt.Errorf("Error al obtener la multa con ID %d: %w", fineID, err)
+	}
+
+	if fine.Status == "Pending" {
+		return nil, "", errors.New("La multa no ha sido pagada ni condonada")
+	}
+
+	data, err := s.patronData(libraryID, fine.UserID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	documentFine, err := s.fineData(libraryID, fine)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data.Fine = &documentFine
+	data.Fines = []models.DocumentFine{documentFine}
+
+	return s.render(libraryID, DocumentFineReceipt, data, fmt.Sprintf("recibo-multa-%d", fine.ID))
+}
+
+// RenderPatronStatement builds the statement with the open loans, pending
+// fines and current reservations of the patron.
+func (s *DocumentService) RenderPatronStatement(libraryID, userID int64) ([]byte, string, error) {
+	now := time.Now()
+
+	data, err := s.patronData(libraryID, userID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data.Loans, err = s.openLoans(libraryID, userID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fines, err := s.fineStore.GetFinesFiltered(libraryID, store.FineFilter{UserID: &userID, Status: "Pending"})
+	if err != nil {
+		return nil, "", fmt.Errorf("Error al obtener las multas del usuario: %w", err)
+	}
+
+	for _, fine := range fines {
+		documentFine, err := s.fineData(libraryID, fine)
+		if err != nil {
+			return nil, "", err
+		}
+
+		data.Fines = append(data.Fines, documentFine)
+	}
+
+	reservations, err := s.reservationStore.GetReservationsFiltered(libraryID, store.ReservationFilter{UserID: &userID})
+	if err != nil {
+		return nil, "", fmt.Errorf("Error al obtener las reservaciones del usuario: %w", err)
+	}
+
+	for _, reservation := range reservations {
+		if reservation.Status != "Pending" && reservation.Status != "Active" {
+			continue
+		}
+
+		book, err := s.bookStore.GetByID(libraryID, reservation.BookID)
+		if err != nil {
+			return nil, "", fmt.Errorf("Error al obtener el libro con ID %d: %w", reservation.BookID, err)
+		}
+
+		data.Reservations = append(data.Reservations, models.DocumentReservation{
+			Title:           documents.Escape(book.Title),
+			ReservationDate: reservation.ReservationDate,
+			ExpirationDate:  reservation.ExpirationDate,
+			Status:          documentLabel(reservationStatusLabels, reservation.Status),
+			Priority:        reservation.Priority,
+		})
+	}
+
+	return s.render(libraryID, DocumentPatronStatement, data, "estado-de-cuenta-"+data.Patron.Code)
+}
+
+// render applies the library's template and prints the result on its
+// letterhead.
+func (s *DocumentService) render(libraryID int64, documentType string, data *models.DocumentData, fileName string) ([]byte, string, error) {
+	t, err := findDocumentType(documentType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tmpl, err := s.GetTemplate(libraryID, documentType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	parsed, err := documents.Parse(t.name, tmpl.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	markup, err := documents.Execute(parsed, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	branding, err := s.branding(libraryID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdf, err := documents.Render(t.title, markup, branding, data.GeneratedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("Error al generar el documento: %w", err)
+	}
+
+	return pdf, reportFileNameRegex.ReplaceAllString(strings.ToLower(fileName), "-") + ".pdf", nil
+}
+
+func (s *DocumentService) branding(libraryID int64) (documents.Branding, error) {
+	library, err := s.libraryService.GetLibraryByID(libraryID)
+	if err != nil {
+		return documents.Branding{}, err
+	}
+
+	branding := documents.Branding{Name: library.Name}
+
+	if address := libraryAddress(library); address != "" {
+		branding.Lines = append(branding.Lines, address)
+	}
+
+	if contact := joinNonEmpty("   ", prefixed("Tel. ", library.Phone), library.Email, library.Website); contact != "" {
+		branding.Lines = append(branding.Lines, contact)
+	}
+
+	logo, err := s.libraryService.GetLogo(libraryID)
+	if errors.Is(err, ErrLogoNotFound) {
+		return branding, nil
+	}
+
+	if err != nil {
+		return documents.Branding{}, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(logo))
+	if err != nil {
+		return documents.Branding{}, fmt.Errorf("El logotipo de la biblioteca está dañado: %w", err)
+	}
+
+	branding.Logo = img
+
+	return branding, nil
+}
+
+func (s *DocumentService) patronData(libraryID, userID int64, now time.Time) (*models.DocumentData, error) {
+	library, err := s.libraryService.GetLibraryByID(libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userStore.GetByID(libraryID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el usuario con ID %d: %w", userID, err)
+	}
+
+	pending, err := s.fineStore.GetFinesFiltered(libraryID, store.FineFilter{UserID: &userID, Status: "Pending"})
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las multas del usuario: %w", err)
+	}
+
+	data := &models.DocumentData{
+		Library: models.DocumentLibrary{
+			Name:    documents.Escape(library.Name),
+			Address: documents.Escape(libraryAddress(library)),
+			Phone:   documents.Escape(library.Phone),
+			Email:   documents.Escape(library.Email),
+			Website: documents.Escape(library.Website),
+		},
+		Patron: models.DocumentPatron{
+			Code:     documents.Escape(user.Code),
+			Name:     documents.Escape(user.FirstName + " " + user.LastName),
+			UserType: documentLabel(userTypeLabels, user.UserType),
+			Email:    documents.Escape(user.Email.String),
+			Phone:    documents.Escape(user.Phone.String),
+		},
+		GeneratedAt: now,
+	}
+
+	for _, fine := range pending {
+		data.TotalDue += fine.Amount
+	}
+
+	return data, nil
+}
+
+// openLoans returns the loans of the patron that were not returned yet.
+func (s *DocumentService) openLoans(libraryID, userID int64, now time.Time) ([]models.DocumentLoan, error) {
+	loans, err := s.loanStore.GetLoansFiltered(libraryID, store.LoanFilter{UserID: &userID})
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los préstamos del usuario: %w", err)
+	}
+
+	var open []models.DocumentLoan
+
+	for _, loan := range loans {
+		if loan.Status != "Active" && loan.Status != "Overdue" {
+			continue
+		}
+
+		documentLoan, err := s.loanData(libraryID, loan, now)
+		if err != nil {
+			return nil, err
+		}
+
+		open = append(open, documentLoan)
+	}
+
+	return open, nil
+}
+
+func (s *DocumentService) loanData(libraryID int64, loan *models.Loan, now time.Time) (models.DocumentLoan, error) {
+	copy, err := s.copyStore.GetByID(libraryID, loan.CopyID)
+	if err != nil {
+		return models.DocumentLoan{}, fmt.Errorf("Error al obtener la copia con ID %d: %w", loan.CopyID, err)
+	}
+
+	book, err := s.bookStore.GetByID(libraryID, copy.BookID)
+	if err != nil {
+		return models.DocumentLoan{}, fmt.Errorf("Error al obtener el libro con ID %d: %w", copy.BookID, err)
+	}
+
+	authors, err := s.bookStore.GetBookAuthors(libraryID, book.ID)
+	if err != nil {
+		return models.DocumentLoan{}, fmt.Errorf("Error al obtener los autores del libro con ID %d: %w", book.ID, err)
+	}
+
+	names := make([]string, 0, len(authors))
+	for _, author := range authors {
+		names = append(names, author.FirstName+" "+author.LastName)
+	}
+
+	documentLoan := models.DocumentLoan{
+		Code:       documents.Escape(loan.LoanCode),
+		Title:      documents.Escape(book.Title),
+		Authors:    documents.Escape(strings.Join(names, ", ")),
+		CopyCode:   documents.Escape(copy.Code),
+		CallNumber: documents.Escape(book.CallNumber.String),
+		LoanDate:   loan.LoanDate,
+		DueDate:    loan.DueDate,
+		Status:     documentLabel(loanStatusLabels, loan.Status),
+		Renewals:   loan.Renewals,
+	}
+
+	if loan.ReturnDate.Valid {
+		documentLoan.ReturnDate = &loan.ReturnDate.Time
+	} else if loan.DueDate.Before(now) {
+		documentLoan.DaysOverdue = max(1, int(now.Sub(loan.DueDate).Hours()/24))
+	}
+
+	return documentLoan, nil
+}
+
+func (s *DocumentService) fineData(libraryID int64, fine *models.Fine) (models.DocumentFine, error) {
+	documentFine := models.DocumentFine{
+		ID:            fine.ID,
+		Reason:        documentLabel(fineReasonLabels, fine.Reason),
+		Amount:        fine.Amount,
+		Status:        documentLabel(fineStatusLabels, fine.Status),
+		GeneratedDate: fine.GeneratedDate,
+		Notes:         documents.Escape(fine.Notes.String),
+	}
+
+	if fine.PaymentDate.Valid {
+		documentFine.PaymentDate = &fine.PaymentDate.Time
+	}
+
+	if fine.LoanID.Valid {
+		loan, err := s.loanStore.GetByID(libraryID, fine.LoanID.Int64)
+		if err != nil {
+			return models.DocumentFine{}, fmt.Errorf("Error al obtener el préstamo con ID %d: %w", fine.LoanID.Int64, err)
+		}
+
+		documentFine.LoanCode = documents.Escape(loan.LoanCode)
+	}
+
+	return documentFine, nil
+}
+
+// sampleDocumentData fills every field so a template can be tried before it
+// is saved.
+func sampleDocumentData() *models.DocumentData {
+	now := time.Now()
+	paid := now.AddDate(0, 0, -1)
+
+	loan := models.DocumentLoan{
+		Code:        "L-0001",
+		Title:       "Cien años de soledad",
+		Authors:     "Gabriel García Márquez",
+		CopyCode:    "C00001",
+		CallNumber:  "863 G216c",
+		LoanDate:    now.AddDate(0, 0, -20),
+		DueDate:     now.AddDate(0, 0, -6),
+		Status:      "Vencido",
+		Renewals:    1,
+		DaysOverdue: 6,
+	}
+
+	fine := models.DocumentFine{
+		ID:            1,
+		Reason:        "Retraso",
+		Amount:        30,
+		Status:        "Pagada",
+		GeneratedDate: now.AddDate(0, 0, -2),
+		PaymentDate:   &paid,
+		LoanCode:      loan.Code,
+		Notes:         "Pago en caja",
+	}
+
+	return &models.DocumentData{
+		Library: models.DocumentLibrary{
+			Name:    "Biblioteca",
+			Address: "Av. Principal 100, Centro",
+			Phone:   "555 000 0000",
+			Email:   "biblioteca@example.com",
+			Website: "https://example.com",
+		},
+		Patron: models.DocumentPatron{
+			Code:     "U0001",
+			Name:     "Ana Pérez",
+			UserType: "Estudiante",
+			Email:    "ana@example.com",
+			Phone:    "555 111 1111",
+		},
+		Loan:  &loan,
+		Loans: []models.DocumentLoan{loan},
+		Fine:  &fine,
+		Fines: []models.DocumentFine{fine},
+		Reservations: []models.DocumentReservation{
+			{
+				Title:           "Pedro Páramo",
+				ReservationDate: now.AddDate(0, 0, -3),
+				ExpirationDate:  now.AddDate(0, 0, 4),
+				Status:          "En espera",
+				Priority:        1,
+			},
+		},
+		TotalDue:    30,
+		GeneratedAt: now,
+	}
+}
+
+func findDocumentType(name string) (documentType, error) {
+	names := make([]string, 0, len(documentTypes))
+
+	for _, t := range documentTypes {
+		if t.name == name {
+			return t, nil
+		}
+
+		names = append(names, t.name)
+	}
+
+	return documentType{}, fmt.Errorf("El tipo de documento debe ser: %s", strings.Join(names, ", "))
+}
+
+func documentLabel(labels map[string]string, value string) string {
+	if label, ok := labels[value]; ok {
+		return label
+	}
+
+	return documents.Escape(value)
+}
+
+func libraryAddress(library *models.Library) string {
+	return joinNonEmpty(
+		", ",
+		library.Address,
+		library.City,
+		joinNonEmpty(" ", library.State, library.ZipCode),
+		library.Country,
+	)
+}
+
+func joinNonEmpty(separator string, values ...string) string {
+	var parts []string
+
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, value)
+		}
+	}
+
+	return strings.Join(parts, separator)
+}
+
+func prefixed(prefix, value string) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+
+	return prefix + value
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/metrics"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+// eventBrokerBatchSize bounds both the events read from the table on every
+// check and the ones a client that is behind the buffer gets at once.
+const eventBrokerBatchSize = 200
+
+// EventBroker feeds the event stream in process. It reads the events the
+// triggers record every interval, keeps the last ones of every library in a
+// ring buffer and wakes up the clients listening. A client resuming from an
+// event older than the buffer reads the table until it catches up.
+type EventBroker struct {
+	eventStore   store.IEventStore
+	eventService *EventService
+	interval     time.Duration
+
+	mu        sync.Mutex
+	buffer    []*models.Event
+	next      int
+	size      int
+	floorID   int64 // Every event after it and up to lastID is in the buffer
+	lastID    int64
+	listeners map[chan struct{}]struct{}
+	stopped   chan struct{}
+}
+
+func NewEventBroker(eventStore store.IEventStore, eventService *EventService, capacity int, interval time.Duration) *EventBroker {
+	return &EventBroker{
+		eventStore:   eventStore,
+		eventService: eventService,
+		interval:     interval,
+		buffer:       make([]*models.Event, capacity),
+		listeners:    make(map[chan struct{}]struct{}),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// Start blocks until the context is cancelled. Only the events recorded from
+// now on go into the buffer.
+func (b *EventBroker) Start(ctx context.Context) error {
+	lastID, err := b.eventStore.GetLastID()
+	if err != nil {
+		return fmt.Errorf("Error al obtener el último evento: %w", err)
+	}
+
+	b.mu.Lock()
+	b.floorID = lastID
+	b.lastID = lastID
+	b.mu.Unlock()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+			case <-ctx.Done():
+				close(b.stopped)
+				return nil
+			case now := <-ticker.C:
+				err := b.Poll()
+				metrics.ObserveJob("event_broker", now, err)
+
+				if err != nil {
+					slog.WarnContext(ctx, "Error al leer los eventos", "error", err)
+				}
+		}
+	}
+}
+
+// Poll reads the events recorded since the last check, adds them to the buffer
+// and wakes up the listeners.
+func (b *EventBroker) Poll() error {
+	published := 0
+
+	for {
+		events, err := b.eventStore.GetAfter(b.LastID(), eventBrokerBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := b.describe(event); err != nil {
+				return err
+			}
+
+			b.publish(event)
+			published++
+		}
+
+		if len(events) < eventBrokerBatchSize {
+			break
+		}
+	}
+
+	if published > 0 {
+		b.notify()
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel that receives a signal when new events come and
+// the function that stops listening.
+func (b *EventBroker) Subscribe() (<-chan struct{}, func()) {
+	listener := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	b.listeners[listener] = struct{}{}
+	b.mu.Unlock()
+
+	return listener, func() {
+		b.mu.Lock()
+		delete(b.listeners, listener)
+		b.mu.Unlock()
+	}
+}
+
+// Stopped is closed when the broker is stopped, so the streams end and their
+// clients reconnect to another instance.
+func (b *EventBroker) Stopped() <-chan struct{} {
+	return b.stopped
+}
+
+// LastID is the last event added to the buffer, where a new client starts.
+func (b *EventBroker) LastID() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.lastID
+}
+
+// Since returns the events of the library after the given one, oldest first,
+// from the buffer or from the table when it is older than the buffer, and the
+// event to ask from next time. A client is caught up once it reaches LastID.
+func (b *EventBroker) Since(libraryID, afterID int64) ([]*models.Event, int64, error) {
+	b.mu.Lock()
+
+	lastID := b.lastID
+
+	if afterID >= b.floorID {
+		var events []*models.Event
+
+		for i := 0; i < b.size; i++ {
+			event := b.buffer[(b.next-b.size+i+len(b.buffer))%len(b.buffer)]
+
+			if event.LibraryID == libraryID && event.ID > afterID {
+				events = append(events, event)
+			}
+		}
+
+		b.mu.Unlock()
+		return events, max(afterID, lastID), nil
+	}
+
+	b.mu.Unlock()
+
+	events, err := b.eventStore.GetAfterByLibrary(libraryID, afterID, eventBrokerBatchSize)
+	if err != nil {
+		return nil, afterID, fmt.Errorf("Error al obtener los eventos: %w", err)
+	}
+
+	var described []*models.Event
+	next := lastID
+
+	for _, event := range events {
+		// The ones after lastID are not in the buffer yet, they are sent from
+		// it after the next check.
+		if event.ID > lastID {
+			break
+		}
+
+		if err := b.describe(event); err != nil {
+			return nil, afterID, err
+		}
+
+		if event.Payload.Valid {
+			described = append(described, event)
+		}
+	}
+
+	if len(events) == eventBrokerBatchSize && events[len(events)-1].ID < lastID {
+		next = events[len(events)-1].ID
+	}
+
+	return described, next, nil
+}
+
+// describe sets the payload of the event; it is left empty when the subject
+// no longer exists and the event is not sent.
+func (b *EventBroker) describe(event *models.Event) error {
+	payload, found, err := b.eventService.Payload(event)
+	if err != nil {
+		return err
+	}
+
+	event.Payload.String = string(payload)
+	event.Payload.Valid = found
+
+	return nil
+}
+
+func (b *EventBroker) publish(event *models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID = event.ID
+
+	if !event.Payload.Valid {
+		return
+	}
+
+	if b.size == len(b.buffer) {
+		b.floorID = b.buffer[b.next].ID
+	} else {
+		b.size++
+	}
+
+	b.buffer[b.next] = event
+	b.next = (b.next + 1) % len(b.buffer)
+}
+
+func (b *EventBroker) notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for listener := range b.listeners {
+		select {
+			case listener <- struct{}{}:
+			default:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+const (
+	EventLoanCreated      = "loan.created"
+	EventLoanReturned     = "loan.returned"
+	EventFineCreated      = "fine.created"
+	EventReservationReady = "reservation.ready"
+	EventUserSuspended    = "user.suspended"
+)
+
+var circulationEvents = []string{
+	EventLoanCreated,
+	EventLoanReturned,
+	EventFineCreated,
+	EventReservationReady,
+	EventUserSuspended,
+}
+
+// EventService describes the circulation events the triggers record, the same
+// way for the webhooks and the event stream.
+type EventService struct {
+	loanStore        store.ILoanStore
+	fineStore        store.IFineStore
+	reservationStore store.IReservationStore
+	userStore        store.IUserStore
+}
+
+func NewEventService(loanStore store.ILoanStore, fineStore store.IFineStore, reservationStore store.IReservationStore, userStore store.IUserStore) *EventService {
+	return &EventService{
+		loanStore:        loanStore,
+		fineStore:        fineStore,
+		reservationStore: reservationStore,
+		userStore:        userStore,
+	}
+}
+
+// Payload takes the payload of the event from the current state of its
+// subject. It returns false when the subject was deleted before the event went
+// out and there is nothing left to describe.
+func (s *EventService) Payload(event *models.Event) ([]byte, bool, error) {
+	data, err := s.eventData(event)
+	if err != nil {
+		return nil, false, err
+	}
+
+	payload, err := json.Marshal(models.EventPayload{
+		ID:         event.ID,
+		Event:      event.EventType,
+		OccurredAt: event.OccurredAt,
+		LibraryID:  event.LibraryID,
+		Data:       data,
+	})
+
+	if err != nil {
+		return nil, false, fmt.Errorf("Error al generar el contenido del evento %d: %w", event.ID, err)
+	}
+
+	return payload, data != nil, nil
+}
+
+func (s *EventService) eventData(event *models.Event) (any, error) {
+	var data any
+	var err error
+
+	switch event.EventType {
+		case EventLoanCreated, EventLoanReturned:
+			data, err = s.loanStore.GetByID(event.LibraryID, event.SubjectID)
+		case EventFineCreated:
+			data, err = s.fineStore.GetByID(event.LibraryID, event.SubjectID)
+		case EventReservationReady:
+			data, err = s.reservationStore.GetByID(event.LibraryID, event.SubjectID)
+		case EventUserSuspended:
+			data, err = s.userStore.GetByID(event.LibraryID, event.SubjectID)
+		default:
+			return nil, nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el contenido del evento %d: %w", event.ID, err)
+	}
+
+	return data, nil
+}
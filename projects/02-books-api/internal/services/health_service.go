@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+type HealthService struct {
+	healthStore store.IHealthStore
+	draining    atomic.Bool
+}
+
+func NewHealthService(healthStore store.IHealthStore) *HealthService {
+	return &HealthService{
+		healthStore: healthStore,
+	}
+}
+
+// Drain makes the instance unready while the server shuts down, so the load
+// balancer stops sending it requests.
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Readiness checks that the database answers, that it was not migrated by a
+// newer build and that every object the migrations create is there.
+func (s *HealthService) Readiness(ctx context.Context) *models.Readiness {
+	readiness := &models.Readiness{Status: "ok"}
+
+	check := func(name string, err error) {
+		healthCheck := &models.HealthCheck{Name: name, Status: "ok"}
+
+		if err != nil {
+			healthCheck.Status = "failed"
+			healthCheck.Error = err.Error()
+			readiness.Status = "unavailable"
+		}
+
+		readiness.Checks = append(readiness.Checks, healthCheck)
+	}
+
+	if s.draining.Load() {
+		check("shutdown", fmt.Errorf("El servidor se está deteniendo"))
+	}
+
+	if err := s.healthStore.Ping(ctx); err != nil {
+		check("database", fmt.Errorf("La base de datos no responde: %w", err))
+		return readiness
+	}
+
+	check("database", nil)
+
+	check("migrations", s.checkMigrations(ctx))
+
+	return readiness
+}
+
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	version, err := s.healthStore.GetSchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("Error al obtener la versión del esquema: %w", err)
+	}
+
+	if version > database.SchemaVersion {
+		return fmt.Errorf("La base de datos fue migrada por una versión más nueva del servidor (esquema %d, se esperaba %d)", version, database.SchemaVersion)
+	}
+
+	objects, err := s.healthStore.GetSchemaObjects(ctx)
+	if err != nil {
+		return fmt.Errorf("Error al obtener los objetos del esquema: %w", err)
+	}
+
+	var missing []string
+
+	for name, objectType := range database.SchemaObjects() {
+		if objects[name] != objectType {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("Faltan objetos del esquema: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+func TestReadinessMigrations(t *testing.T) {
+	tests := []struct {
+		name  string
+		alter string
+		want  string // Part of the error of the migrations check, "" when ready
+	}{
+		{"migrated", "", ""},
+		{"newer schema", fmt.Sprintf("PRAGMA user_version = %d", database.SchemaVersion+1), "versión más nueva"},
+		{"missing trigger", "DROP TRIGGER trg_audit_log_no_update", "trg_audit_log_no_update"},
+		{"missing view", "DROP VIEW book_availability", "book_availability"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t, ":memory:")
+
+			if tt.alter != "" {
+				if _, err := db.Exec(tt.alter); err != nil {
+					t.Fatalf("alter: %v", err)
+				}
+			}
+
+			readiness := NewHealthService(store.NewHealthStore(db)).Readiness(context.Background())
+
+			var migrations string
+			for _, check := range readiness.Checks {
+				if check.Name == "migrations" {
+					migrations = check.Error
+				}
+			}
+
+			if tt.want == "" {
+				if readiness.Status != "ok" {
+					t.Errorf("status %s, migrations %q", readiness.Status, migrations)
+				}
+
+				return
+			}
+
+			if readiness.Status != "unavailable" || !strings.Contains(migrations, tt.want) {
+				t.Errorf("status %s, migrations %q, want unavailable with %q", readiness.Status, migrations, tt.want)
+			}
+		})
+	}
+}
+
+// Starting an older build must not hide that a newer one migrated the database.
+func TestMigrationsKeepNewerVersion(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", database.SchemaVersion+1)); err != nil {
+		t.Fatalf("user_version: %v", err)
+	}
+
+	if err := database.ApplyMigrationAlterations(db); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("user_version: %v", err)
+	}
+
+	if version != database.SchemaVersion+1 {
+		t.Errorf("user_version = %d, want %d", version, database.SchemaVersion+1)
+	}
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+type InventoryService struct {
+	inventoryStore store.IInventoryStore
+	zoneStore      store.ILibraryZoneStore
+	shelfStore     store.IShelfStore
+	copyStore      store.ICopyStore
+	copyEventStore store.ICopyEventStore
+	auditService   *AuditService
+}
+
+func NewInventoryService(inventoryStore store.IInventoryStore, zoneStore store.ILibraryZoneStore, shelfStore store.IShelfStore, copyStore store.ICopyStore, copyEventStore store.ICopyEventStore, auditService *AuditService) *InventoryService {
+	return &InventoryService{
+		inventoryStore: inventoryStore,
+		zoneStore:      zoneStore,
+		shelfStore:     shelfStore,
+		copyStore:      copyStore,
+		copyEventStore: copyEventStore,
+		auditService:   auditService,
+	}
+}
+
+func (s *InventoryService) GetAudits(libraryID int64, filter store.InventoryAuditFilter) ([]*models.InventoryAudit, error) {
+	filter.Status = strings.TrimSpace(filter.Status)
+
+	if filter.Status != "" && filter.Status != "Open" && filter.Status != "Closed" {
+		return nil, errors.New("El estado debe ser: Open o Closed")
+	}
+
+	audits, err := s.inventoryStore.GetAudits(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las auditorías de inventario: %w", err)
+	}
+
+	return audits, nil
+}
+
+func (s *InventoryService) GetAuditByID(libraryID, id int64) (*models.InventoryAudit, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la auditoría es inválido")
+	}
+
+	audit, err := s.inventoryStore.GetAuditByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la auditoría con ID %d: %w", id, err)
+	}
+
+	return audit, nil
+}
+
+func (s *InventoryService) StartAudit(ctx context.Context, libraryID int64, audit *models.InventoryAudit) (*models.InventoryAudit, error) {
+	if err := validations.ValidateInventoryAudit(audit); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	filter := store.InventoryAuditFilter{Status: "Open"}
+
+	if audit.ZoneID.Valid {
+		if _, err := s.zoneStore.GetByID(libraryID, audit.ZoneID.Int64); err != nil {
+			return nil, fmt.Errorf("La zona con ID %d no existe: %w", audit.ZoneID.Int64, err)
+		}
+
+		filter.ZoneID = &audit.ZoneID.Int64
+	} else {
+		if _, err := s.shelfStore.GetByID(libraryID, audit.ShelfID.Int64); err != nil {
+			return nil, fmt.Errorf("El estante con ID %d no existe: %w", audit.ShelfID.Int64, err)
+		}
+
+		filter.ShelfID = &audit.ShelfID.Int64
+	}
+
+	openAudits, err := s.inventoryStore.GetAudits(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al verificar las auditorías abiertas: %w", err)
+	}
+
+	if len(openAudits) > 0 {
+		return nil, fmt.Errorf("Ya existe una auditoría abierta (ID %d) para esta ubicación", openAudits[0].ID)
+	}
+
+	if audit.Notes.Valid {
+		audit.Notes.String = strings.TrimSpace(audit.Notes.String)
+	}
+
+	audit.Status = "Open"
+	audit.StartedAt = time.Now()
+	audit.ClosedAt.Valid = false
+
+	createdAudit, err := s.inventoryStore.CreateAudit(libraryID, audit)
+	if err != nil {
+		return nil, fmt.Errorf("Error al iniciar la auditoría: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "inventory_audit", createdAudit.ID, "create", nil, createdAudit); err != nil {
+		return nil, err
+	}
+
+	return createdAudit, nil
+}
+
+// AddScans records the barcodes read on a shelf. Shelf audits default to the
+// audited shelf; zone audits must say which shelf of the zone was scanned.
+func (s *InventoryService) AddScans(ctx context.Context, libraryID, auditID int64, barcodes []string, shelfID, librarianID *int64) ([]*models.InventoryScan, error) {
+	audit, err := s.GetAuditByID(libraryID, auditID)
+	if err != nil {
+		return nil, err
+	}
+
+	if audit.Status != "Open" {
+		return nil, errors.New("La auditoría ya está cerrada")
+	}
+
+	if err := validations.ValidateInventoryScans(barcodes); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	if audit.ShelfID.Valid {
+		if shelfID != nil && *shelfID != audit.ShelfID.Int64 {
+			return nil, fmt.Errorf("La auditoría solo cubre el estante con ID %d", audit.ShelfID.Int64)
+		}
+
+		shelfID = &audit.ShelfID.Int64
+	} else {
+		if shelfID == nil {
+			return nil, errors.New("El ID del estante escaneado es requerido en una auditoría por zona")
+		}
+
+		shelf, err := s.shelfStore.GetByID(libraryID, *shelfID)
+		if err != nil {
+			return nil, fmt.Errorf("El estante con ID %d no existe: %w", *shelfID, err)
+		}
+
+		if shelf.ZoneID != audit.ZoneID.Int64 {
+			return nil, fmt.Errorf("El estante %s no pertenece a la zona auditada", shelf.Code)
+		}
+	}
+
+	now := time.Now()
+	var scans []*models.InventoryScan
+
+	for _, barcode := range barcodes {
+		barcode = strings.TrimSpace(barcode)
+		if barcode == "" {
+			continue
+		}
+
+		scan := &models.InventoryScan{Barcode: barcode, ScannedAt: now}
+		scan.ShelfID.Valid = true
+		scan.ShelfID.Int64 = *shelfID
+
+		copy, err := s.copyStore.GetByCode(libraryID, barcode)
+		if err != nil {
+			return nil, fmt.Errorf("Error al buscar la copia con código %s: %w", barcode, err)
+		}
+
+		if copy != nil {
+			scan.CopyID.Valid = true
+			scan.CopyID.Int64 = copy.ID
+		}
+
+		scans = append(scans, scan)
+	}
+
+	if len(scans) == 0 {
+		return nil, errors.New("Se requiere al menos un código de barras")
+	}
+
+	if err := s.inventoryStore.AddScans(libraryID, auditID, scans); err != nil {
+		return nil, fmt.Errorf("Error al registrar los escaneos: %w", err)
+	}
+
+	for _, scan := range scans {
+		if !scan.CopyID.Valid {
+			continue
+		}
+
+		event := newCopyEvent(scan.CopyID.Int64, "AuditScanned", "", strconv.FormatInt(*shelfID, 10), librarianID)
+		event.AuditID.Valid = true
+		event.AuditID.Int64 = auditID
+
+		if err := recordCopyEvents(s.copyEventStore, libraryID, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "inventory_audit", auditID, "scan", nil, scans); err != nil {
+		return nil, err
+	}
+
+	return scans, nil
+}
+
+func (s *InventoryService) GetReport(libraryID, auditID int64) (*models.InventoryReport, error) {
+	audit, err := s.GetAuditByID(libraryID, auditID)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := s.inventoryStore.GetAuditItems(libraryID, audit)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las copias esperadas: %w", err)
+	}
+
+	scans, err := s.inventoryStore.GetScans(libraryID, auditID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los escaneos: %w", err)
+	}
+
+	report := &models.InventoryReport{
+		Audit:              audit,
+		Missing:            []*models.InventoryItem{},
+		WrongShelf:         []*models.InventoryItem{},
+		ScannedWhileLoaned: []*models.InventoryItem{},
+		UnknownBarcodes:    []string{},
+	}
+
+	expectedByCopy := make(map[int64]*models.InventoryItem, len(expected))
+	for _, item := range expected {
+		expectedByCopy[item.Copy.ID] = item
+
+		if expectedOnShelf(item.Copy) {
+			report.ExpectedCount++
+		}
+	}
+
+	// The last scan of a copy wins, so a rescan on the right shelf fixes it
+	lastScan := make(map[int64]*models.InventoryScan)
+	var scannedOrder []int64
+	unknown := make(map[string]bool)
+
+	for _, scan := range scans {
+		if !scan.CopyID.Valid {
+			if !unknown[scan.Barcode] {
+				unknown[scan.Barcode] = true
+				report.UnknownBarcodes = append(report.UnknownBarcodes, scan.Barcode)
+			}
+
+			continue
+		}
+
+		if _, seen := lastScan[scan.CopyID.Int64]; !seen {
+			scannedOrder = append(scannedOrder, scan.CopyID.Int64)
+		}
+
+		lastScan[scan.CopyID.Int64] = scan
+	}
+
+	report.ScannedCount = len(scannedOrder) + len(report.UnknownBarcodes)
+
+	for _, copyID := range scannedOrder {
+		scan := lastScan[copyID]
+
+		item, ok := expectedByCopy[copyID]
+		if !ok {
+			item, err = s.inventoryStore.GetItemByCopyID(libraryID, copyID)
+			if err != nil {
+				return nil, fmt.Errorf("Error al obtener la copia con ID %d: %w", copyID, err)
+			}
+
+			if item == nil {
+				report.UnknownBarcodes = append(report.UnknownBarcodes, scan.Barcode)
+				continue
+			}
+		}
+
+		item.ScannedShelfID = scan.ShelfID
+
+		if item.Copy.Status == "Borrowed" {
+			report.ScannedWhileLoaned = append(report.ScannedWhileLoaned, item)
+		}
+
+		if item.ExpectedShelfID != scan.ShelfID {
+			report.WrongShelf = append(report.WrongShelf, item)
+		}
+	}
+
+	for _, item := range expected {
+		if _, scanned := lastScan[item.Copy.ID]; scanned {
+			continue
+		}
+
+		if !expectedOnShelf(item.Copy) {
+			continue
+		}
+
+		report.Missing = append(report.Missing, item)
+	}
+
+	return report, nil
+}
+
+// CloseAudit closes the audit and, when markMissingLost is set, marks the
+// missing copies as Lost in the same transaction.
+func (s *InventoryService) CloseAudit(ctx context.Context, libraryID, auditID int64, markMissingLost bool, librarianID *int64) (*models.InventoryReport, error) {
+	report, err := s.GetReport(libraryID, auditID)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Audit.Status != "Open" {
+		return nil, errors.New("La auditoría ya está cerrada")
+	}
+
+	var lostCopyIDs []int64
+
+	if markMissingLost {
+		for _, item := range report.Missing {
+			lostCopyIDs = append(lostCopyIDs, item.Copy.ID)
+		}
+	}
+
+	marked, err := s.inventoryStore.CloseAudit(libraryID, auditID, lostCopyIDs, librarianID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("La auditoría ya está cerrada")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error al cerrar la auditoría: %w", err)
+	}
+
+	report.MarkedLost = marked
+
+	if markMissingLost {
+		for _, item := range report.Missing {
+			if item.Copy.Status == "Lost" {
+				continue
+			}
+
+			lostCopy, err := s.copyStore.GetByID(libraryID, item.Copy.ID)
+			if err != nil {
+				return nil, fmt.Errorf("Error al obtener la copia con ID %d: %w", item.Copy.ID, err)
+			}
+
+			if lostCopy.Status == "Lost" {
+				if err := s.auditService.Record(ctx, libraryID, "copy", lostCopy.ID, "mark_lost", item.Copy, lostCopy); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	audit, err := s.GetAuditByID(libraryID, auditID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "inventory_audit", auditID, "close", report.Audit, audit); err != nil {
+		return nil, err
+	}
+
+	report.Audit = audit
+
+	return report, nil
+}
+
+// expectedOnShelf tells whether a copy should be found on its shelf: it is not
+// loaned, lost, withdrawn or away at a temporary location.
+func expectedOnShelf(copy *models.Copy) bool {
+	if copy.TemporaryLocation.Valid {
+		return false
+	}
+
+	return copy.Status != "Borrowed" && copy.Status != "Lost" && copy.Status != "Withdrawn"
+}
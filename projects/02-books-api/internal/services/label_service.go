@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/labels"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+const (
+	DefaultLabelLayout = "avery-l7160"
+
+	labelDPI = 300
+)
+
+var builtInLabelLayouts = []models.LabelLayout{
+	{
+		Code: "avery-l7160", Name: "Avery L7160 (A4, 3 x 7)",
+		PageWidth: 210, PageHeight: 297, Columns: 3, Rows: 7, LabelWidth: 63.5, LabelHeight: 38.1,
+		MarginTop: 15.15, MarginLeft: 7.25, HorizontalGap: 2.5,
+	},
+	{
+		Code: "avery-l7163", Name: "Avery L7163 (A4, 2 x 7)",
+		PageWidth: 210, PageHeight: 297, Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1,
+		MarginTop: 15.15, MarginLeft: 4.65, HorizontalGap: 2.5,
+	},
+	{
+		Code: "avery-5160", Name: "Avery 5160 (Carta, 3 x 10)",
+		PageWidth: 215.9, PageHeight: 279.4, Columns: 3, Rows: 10, LabelWidth: 66.675, LabelHeight: 25.4,
+		MarginTop: 12.7, MarginLeft: 4.7625, HorizontalGap: 3.175,
+	},
+	{
+		Code: "single-50x25", Name: "Etiqueta individual 50 x 25 mm",
+		PageWidth: 50, PageHeight: 25, Columns: 1, Rows: 1, LabelWidth: 50, LabelHeight: 25,
+	},
+}
+
+type LabelService struct {
+	labelLayoutStore store.ILabelLayoutStore
+	copyStore        store.ICopyStore
+	bookStore        store.IBookStore
+	auditService     *AuditService
+}
+
+func NewLabelService(labelLayoutStore store.ILabelLayoutStore, copyStore store.ICopyStore, bookStore store.IBookStore, auditService *AuditService) *LabelService {
+	return &LabelService{
+		labelLayoutStore: labelLayoutStore,
+		copyStore:        copyStore,
+		bookStore:        bookStore,
+		auditService:     auditService,
+	}
+}
+
+// GetLayouts returns the built-in layouts followed by the library ones.
+func (s *LabelService) GetLayouts(libraryID int64) ([]*models.LabelLayout, error) {
+	layouts, err := s.labelLayoutStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los formatos de etiquetas: %w", err)
+	}
+
+	all := make([]*models.LabelLayout, 0, len(builtInLabelLayouts)+len(layouts))
+
+	for _, layout := range builtInLabelLayouts {
+		all = append(all, builtInLabelLayout(layout))
+	}
+
+	return append(all, layouts...), nil
+}
+
+func (s *LabelService) GetLayoutByID(libraryID, id int64) (*models.LabelLayout, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID del formato es inválido")
+	}
+
+	layout, err := s.labelLayoutStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el formato con ID %d: %w", id, err)
+	}
+
+	return layout, nil
+}
+
+func (s *LabelService) CreateLayout(ctx context.Context, libraryID int64, layout *models.LabelLayout) (*models.LabelLayout, error) {
+	layout.Code = strings.TrimSpace(strings.ToLower(layout.Code))
+	layout.Name = strings.TrimSpace(layout.Name)
+
+	if err := s.checkLayout(libraryID, 0, layout); err != nil {
+		return nil, err
+	}
+
+	createdLayout, err := s.labelLayoutStore.Create(libraryID, layout)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear el formato de etiquetas: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "label_layout", createdLayout.ID, "create", nil, createdLayout); err != nil {
+		return nil, err
+	}
+
+	return createdLayout, nil
+}
+
+func (s *LabelService) UpdateLayout(ctx context.Context, libraryID, id int64, layout *models.LabelLayout) (*models.LabelLayout, error) {
+	existingLayout, err := s.GetLayoutByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	layout.Code = strings.TrimSpace(strings.ToLower(layout.Code))
+	layout.Name = strings.TrimSpace(layout.Name)
+
+	if err := s.checkLayout(libraryID, id, layout); err != nil {
+		return nil, err
+	}
+
+	updatedLayout, err := s.labelLayoutStore.Update(libraryID, id, layout)
+	if err != nil {
+		return nil, fmt.Errorf("Error al actualizar el formato con ID %d: %w", id, err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "label_layout", id, "update", existingLayout, updatedLayout); err != nil {
+		return nil, err
+	}
+
+	return updatedLayout, nil
+}
+
+func (s *LabelService) DeleteLayout(ctx context.Context, libraryID, id int64) error {
+	existingLayout, err := s.GetLayoutByID(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.labelLayoutStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar el formato con ID %d: %w", id, err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "label_layout", id, "delete", existingLayout, nil)
+}
+
+// RenderCopyLabel renders the label of one copy at the label size of the
+// layout.
+func (s *LabelService) RenderCopyLabel(libraryID, copyID int64, layoutCode, format string) ([]byte, string, error) {
+	if copyID <= 0 {
+		return nil, "", errors.New("El ID de la copia es inválido")
+	}
+
+	copy, err := s.copyStore.GetByID(libraryID, copyID)
+	if err != nil {
+		return nil, "", fmt.Errorf("La copia con ID %d no existe: %w", copyID, err)
+	}
+
+	layout, err := s.resolveLayout(libraryID, layoutCode)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items, err := s.labelsFor(libraryID, []*models.Copy{copy})
+	if err != nil {
+		return nil, "", err
+	}
+
+	pages, contentType, err := renderLabels(labels.SingleLabel(layout.LabelWidth, layout.LabelHeight), items, 0, format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return pages[0], contentType, nil
+}
+
+// RenderLabels renders the labels of the requested copies, or of every copy
+// acquired since a date, as sheets of the layout; one document per page.
+func (s *LabelService) RenderLabels(libraryID int64, batch *models.LabelBatch) ([][]byte, string, error) {
+	if err := validations.ValidateLabelBatch(batch); err != nil {
+		return nil, "", fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	layout, err := s.resolveLayout(libraryID, batch.Layout)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var copies []*models.Copy
+
+	if batch.AcquiredSince.Valid {
+		copies, err = s.copyStore.GetCopiesFiltered(libraryID, store.CopyFilter{AcquiredSince: &batch.AcquiredSince.Time})
+		if err != nil {
+			return nil, "", fmt.Errorf("Error al obtener las copias: %w", err)
+		}
+
+		if len(copies) == 0 {
+			return nil, "", errors.New("No hay copias adquiridas desde esa fecha")
+		}
+	}
+
+	for _, copyID := range batch.CopyIDs {
+		copy, err := s.copyStore.GetByID(libraryID, copyID)
+		if err != nil {
+			return nil, "", fmt.Errorf("La copia con ID %d no existe: %w", copyID, err)
+		}
+
+		copies = append(copies, copy)
+	}
+
+	items, err := s.labelsFor(libraryID, copies)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return renderLabels(labelSheet(layout), items, batch.Skip, batch.Format)
+}
+
+func (s *LabelService) resolveLayout(libraryID int64, code string) (*models.LabelLayout, error) {
+	code = strings.TrimSpace(strings.ToLower(code))
+	if code == "" {
+		code = DefaultLabelLayout
+	}
+
+	layout, err := s.labelLayoutStore.GetByCode(libraryID, code)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el formato %s: %w", code, err)
+	}
+
+	if layout != nil {
+		return layout, nil
+	}
+
+	for _, builtIn := range builtInLabelLayouts {
+		if builtIn.Code == code {
+			return builtInLabelLayout(builtIn), nil
+		}
+	}
+
+	return nil, fmt.Errorf("El formato de etiquetas %s no existe", code)
+}
+
+func (s *LabelService) checkLayout(libraryID, id int64, layout *models.LabelLayout) error {
+	if err := validations.ValidateLabelLayout(layout); err != nil {
+		return fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	for _, builtIn := range builtInLabelLayouts {
+		if builtIn.Code == layout.Code {
+			return fmt.Errorf("El código %s pertenece a un formato predefinido", layout.Code)
+		}
+	}
+
+	existingLayout, err := s.labelLayoutStore.GetByCode(libraryID, layout.Code)
+	if err != nil {
+		return fmt.Errorf("Error al verificar el código del formato: %w", err)
+	}
+
+	if existingLayout != nil && existingLayout.ID != id {
+		return fmt.Errorf("Ya existe un formato con el código %s", layout.Code)
+	}
+
+	return nil
+}
+
+func (s *LabelService) labelsFor(libraryID int64, copies []*models.Copy) ([]labels.Label, error) {
+	books := make(map[int64]*models.Book)
+	items := make([]labels.Label, 0, len(copies))
+
+	for _, copy := range copies {
+		book, ok := books[copy.BookID]
+		if !ok {
+			var err error
+
+			book, err = s.bookStore.GetByID(libraryID, copy.BookID)
+			if err != nil {
+				return nil, fmt.Errorf("Error al obtener el libro con ID %d: %w", copy.BookID, err)
+			}
+
+			books[copy.BookID] = book
+		}
+
+		items = append(items, labels.Label{
+			Barcode:    copy.Code,
+			Title:      book.Title,
+			CallNumber: book.CallNumber.String,
+		})
+	}
+
+	return items, nil
+}
+
+func renderLabels(layout labels.Layout, items []labels.Label, skip int, format string) ([][]byte, string, error) {
+	var pages [][]byte
+	var contentType string
+	var err error
+
+	switch format {
+		case "", "svg":
+			pages, err = labels.RenderSVG(layout, items, skip)
+			contentType = "image/svg+xml"
+		case "png":
+			pages, err = labels.RenderPNG(layout, items, skip, labelDPI)
+			contentType = "image/png"
+		default:
+			return nil, "", errors.New("El formato debe ser: svg o png")
+	}
+
+	if err != nil {
+		return nil, "", fmt.Errorf("Error al generar las etiquetas: %w", err)
+	}
+
+	return pages, contentType, nil
+}
+
+func labelSheet(layout *models.LabelLayout) labels.Layout {
+	return labels.Layout{
+		PageWidth:     layout.PageWidth,
+		PageHeight:    layout.PageHeight,
+		Columns:       layout.Columns,
+		Rows:          layout.Rows,
+		LabelWidth:    layout.LabelWidth,
+		LabelHeight:   layout.LabelHeight,
+		MarginTop:     layout.MarginTop,
+		MarginLeft:    layout.MarginLeft,
+		HorizontalGap: layout.HorizontalGap,
+		VerticalGap:   layout.VerticalGap,
+	}
+}
+
+func builtInLabelLayout(layout models.LabelLayout) *models.LabelLayout {
+	layout.BuiltIn = true
+	return &layout
+}
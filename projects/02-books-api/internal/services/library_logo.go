@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/storage"
+)
+
+// Logos are kept small and flattened over white since they are only printed
+// on the letterhead of the documents.
+const maxLogoSide = 400
+
+var ErrLogoNotFound = errors.New("La biblioteca no tiene logotipo")
+
+// UploadLogo stores the logo of the library in the cover storage as PNG.
+func (s *LibraryService) UploadLogo(ctx context.Context, id int64, data []byte) error {
+	if _, err := s.GetLibraryByID(id); err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return errors.New("La imagen del logotipo es requerida")
+	}
+
+	if len(data) > MaxCoverBytes {
+		return fmt.Errorf("La imagen no puede exceder %d MB", MaxCoverBytes>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedCoverTypes[contentType] {
+		return fmt.Errorf("Tipo de imagen no permitido (%s), solo se aceptan JPEG o PNG", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("La imagen está dañada o no es válida: %w", err)
+	}
+
+	if config.Width > maxCoverDimension || config.Height > maxCoverDimension {
+		return fmt.Errorf("Las dimensiones de la imagen no pueden exceder %dx%d píxeles", maxCoverDimension, maxCoverDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("La imagen está dañada o no es válida: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, resizeImage(img, maxLogoSide)); err != nil {
+		return fmt.Errorf("Error al procesar el logotipo: %w", err)
+	}
+
+	if err := s.blobStore.Put(logoKey(id), buffer.Bytes()); err != nil {
+		return fmt.Errorf("Error al guardar el logotipo: %w", err)
+	}
+
+	return s.auditService.Record(ctx, id, "library_logo", id, "upload", nil, map[string]any{"key": logoKey(id), "size": buffer.Len()})
+}
+
+func (s *LibraryService) GetLogo(id int64) ([]byte, error) {
+	data, err := s.blobStore.Get(logoKey(id))
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, ErrLogoNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error al leer el logotipo: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *LibraryService) DeleteLogo(ctx context.Context, id int64) error {
+	data, err := s.GetLogo(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.blobStore.Delete(logoKey(id)); err != nil {
+		return fmt.Errorf("Error al eliminar el logotipo: %w", err)
+	}
+
+	return s.auditService.Record(ctx, id, "library_logo", id, "delete", map[string]any{"key": logoKey(id), "size": len(data)}, nil)
+}
+
+func logoKey(libraryID int64) string {
+	return fmt.Sprintf("logos/%d", libraryID)
+}
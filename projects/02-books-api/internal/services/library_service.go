@@ -1,55 +1,85 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/storage"
 	"github.com/chicho69-cesar/backend-go/books/internal/store"
 	"github.com/chicho69-cesar/backend-go/books/internal/validations"
 )
 
 type LibraryService struct {
 	libraryStore store.ILibraryStore
+	blobStore    storage.BlobStore
+	auditService *AuditService
 }
 
 type LibraryZoneService struct {
-	zoneStore store.ILibraryZoneStore
+	zoneStore    store.ILibraryZoneStore
+	shelfStore   store.IShelfStore
+	copyStore    store.ICopyStore
+	bookStore    store.IBookStore
+	auditService *AuditService
 }
 
 type ShelfService struct {
-	shelfStore store.IShelfStore
-	zoneStore  store.ILibraryZoneStore
+	shelfStore   store.IShelfStore
+	zoneStore    store.ILibraryZoneStore
+	bookStore    store.IBookStore
+	copyStore    store.ICopyStore
+	auditService *AuditService
 }
 
 type CopyService struct {
-	copyStore store.ICopyStore
-	bookStore store.IBookStore
-	loanStore store.ILoanStore
+	copyStore      store.ICopyStore
+	bookStore      store.IBookStore
+	loanStore      store.ILoanStore
+	shelfStore     store.IShelfStore
+	copyEventStore store.ICopyEventStore
+	auditService   *AuditService
 }
 
-func NewLibraryService(libraryStore store.ILibraryStore) *LibraryService {
-	return &LibraryService{libraryStore: libraryStore}
+func NewLibraryService(libraryStore store.ILibraryStore, blobStore storage.BlobStore, auditService *AuditService) *LibraryService {
+	return &LibraryService{
+		libraryStore: libraryStore,
+		blobStore:    blobStore,
+		auditService: auditService,
+	}
 }
 
-func NewLibraryZoneService(zoneStore store.ILibraryZoneStore) *LibraryZoneService {
-	return &LibraryZoneService{zoneStore: zoneStore}
+func NewLibraryZoneService(zoneStore store.ILibraryZoneStore, shelfStore store.IShelfStore, copyStore store.ICopyStore, bookStore store.IBookStore, auditService *AuditService) *LibraryZoneService {
+	return &LibraryZoneService{
+		zoneStore:    zoneStore,
+		shelfStore:   shelfStore,
+		copyStore:    copyStore,
+		bookStore:    bookStore,
+		auditService: auditService,
+	}
 }
 
-func NewShelfService(shelfStore store.IShelfStore, zoneStore store.ILibraryZoneStore) *ShelfService {
+func NewShelfService(shelfStore store.IShelfStore, zoneStore store.ILibraryZoneStore, bookStore store.IBookStore, copyStore store.ICopyStore, auditService *AuditService) *ShelfService {
 	return &ShelfService{
-		shelfStore: shelfStore,
-		zoneStore:  zoneStore,
+		shelfStore:   shelfStore,
+		zoneStore:    zoneStore,
+		bookStore:    bookStore,
+		copyStore:    copyStore,
+		auditService: auditService,
 	}
 }
 
-func NewCopyService(copyStore store.ICopyStore, bookStore store.IBookStore, loanStore store.ILoanStore) *CopyService {
+func NewCopyService(copyStore store.ICopyStore, bookStore store.IBookStore, loanStore store.ILoanStore, shelfStore store.IShelfStore, copyEventStore store.ICopyEventStore, auditService *AuditService) *CopyService {
 	return &CopyService{
-		copyStore: copyStore,
-		bookStore: bookStore,
-		loanStore: loanStore,
+		copyStore:      copyStore,
+		bookStore:      bookStore,
+		loanStore:      loanStore,
+		shelfStore:     shelfStore,
+		copyEventStore: copyEventStore,
+		auditService:   auditService,
 	}
 }
 
@@ -97,7 +127,7 @@ func (s *LibraryService) EnterLibraryCredentials(username, password string) (*mo
 	return library, nil
 }
 
-func (s *LibraryService) CreateLibrary(library *models.Library) (*models.Library, error) {
+func (s *LibraryService) CreateLibrary(ctx context.Context, library *models.Library) (*models.Library, error) {
 	if err := validations.ValidateLibrary(library); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -112,10 +142,14 @@ func (s *LibraryService) CreateLibrary(library *models.Library) (*models.Library
 		return nil, fmt.Errorf("Error al crear la biblioteca: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, createdLibrary.ID, "library", createdLibrary.ID, "create", nil, createdLibrary); err != nil {
+		return nil, err
+	}
+
 	return createdLibrary, nil
 }
 
-func (s *LibraryService) UpdateLibrary(id int64, library *models.Library) (*models.Library, error) {
+func (s *LibraryService) UpdateLibrary(ctx context.Context, id int64, library *models.Library) (*models.Library, error) {
 	if id <= 0 {
 		return nil, errors.New("El ID de la biblioteca es inválido")
 	}
@@ -138,10 +172,14 @@ func (s *LibraryService) UpdateLibrary(id int64, library *models.Library) (*mode
 		return nil, fmt.Errorf("Error al actualizar la biblioteca con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, id, "library", id, "update", existingLibrary, updatedLibrary); err != nil {
+		return nil, err
+	}
+
 	return updatedLibrary, nil
 }
 
-func (s *LibraryService) DeleteLibrary(id int64) error {
+func (s *LibraryService) DeleteLibrary(ctx context.Context, id int64) error {
 	if id <= 0 {
 		return errors.New("El ID de la biblioteca es inválido")
 	}
@@ -159,7 +197,7 @@ func (s *LibraryService) DeleteLibrary(id int64) error {
 		return fmt.Errorf("Error al eliminar la biblioteca con ID %d: %w", id, err)
 	}
 
-	return nil
+	return s.auditService.Record(ctx, id, "library", id, "delete", existingLibrary, nil)
 }
 
 func (s *LibraryZoneService) GetAllZones(libraryID int64) ([]*models.LibraryZone, error) {
@@ -203,7 +241,7 @@ func (s *LibraryZoneService) GetZonesFiltered(libraryID int64, filter store.Libr
 	return zones, nil
 }
 
-func (s *LibraryZoneService) CreateZone(libraryID int64, zone *models.LibraryZone) (*models.LibraryZone, error) {
+func (s *LibraryZoneService) CreateZone(ctx context.Context, libraryID int64, zone *models.LibraryZone) (*models.LibraryZone, error) {
 	if err := validations.ValidateLibraryZone(zone); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -226,10 +264,14 @@ func (s *LibraryZoneService) CreateZone(libraryID int64, zone *models.LibraryZon
 		return nil, fmt.Errorf("Error al crear la zona: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "zone", createdZone.ID, "create", nil, createdZone); err != nil {
+		return nil, err
+	}
+
 	return createdZone, nil
 }
 
-func (s *LibraryZoneService) UpdateZone(libraryID, id int64, zone *models.LibraryZone) (*models.LibraryZone, error) {
+func (s *LibraryZoneService) UpdateZone(ctx context.Context, libraryID, id int64, zone *models.LibraryZone) (*models.LibraryZone, error) {
 	if id <= 0 {
 		return nil, errors.New("El ID de la zona es inválido")
 	}
@@ -264,10 +306,17 @@ func (s *LibraryZoneService) UpdateZone(libraryID, id int64, zone *models.Librar
 		return nil, fmt.Errorf("Error al actualizar la zona con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "zone", id, "update", existingZone, updatedZone); err != nil {
+		return nil, err
+	}
+
 	return updatedZone, nil
 }
 
-func (s *LibraryZoneService) DeleteZone(libraryID, id int64) error {
+// DeleteZone deletes the zone along with its shelves. While copies or books
+// are still on them it fails, unless rehomeTo names a shelf in another zone
+// to move them to first.
+func (s *LibraryZoneService) DeleteZone(ctx context.Context, libraryID, id int64, rehomeTo, librarianID *int64) error {
 	if id <= 0 {
 		return errors.New("El ID de la zona es inválido")
 	}
@@ -281,11 +330,56 @@ func (s *LibraryZoneService) DeleteZone(libraryID, id int64) error {
 		return fmt.Errorf("La zona con ID %d no fue encontrada", id)
 	}
 
-	if err := s.zoneStore.Delete(libraryID, id); err != nil {
+	shelves, err := s.shelfStore.GetShelvesFiltered(libraryID, store.ShelfFilter{ZoneID: &id})
+	if err != nil {
+		return fmt.Errorf("Error al obtener los estantes de la zona: %w", err)
+	}
+
+	shelfIDs := make([]int64, 0, len(shelves))
+	for _, shelf := range shelves {
+		shelfIDs = append(shelfIDs, shelf.ID)
+	}
+
+	if err := checkLocationInUse(s.shelfStore, libraryID, shelfIDs, rehomeTo); err != nil {
+		return err
+	}
+
+	var copies []*models.Copy
+	var books []*models.Book
+
+	if rehomeTo != nil {
+		target, err := s.shelfStore.GetByID(libraryID, *rehomeTo)
+		if err != nil {
+			return fmt.Errorf("El estante con ID %d no existe: %w", *rehomeTo, err)
+		}
+
+		if target.ZoneID == id {
+			return errors.New("El estante destino pertenece a la zona que se va a eliminar")
+		}
+
+		copies, books, err = shelfContents(s.copyStore, s.bookStore, libraryID, shelfIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.zoneStore.DeleteRehoming(libraryID, id, shelfIDs, rehomeTo, librarianID); err != nil {
 		return fmt.Errorf("Error al eliminar la zona con ID %d: %w", id, err)
 	}
 
-	return nil
+	if rehomeTo != nil {
+		if err := recordRehome(ctx, s.auditService, libraryID, copies, books, *rehomeTo); err != nil {
+			return err
+		}
+	}
+
+	for _, shelf := range shelves {
+		if err := s.auditService.Record(ctx, libraryID, "shelf", shelf.ID, "delete", shelf, nil); err != nil {
+			return err
+		}
+	}
+
+	return s.auditService.Record(ctx, libraryID, "zone", id, "delete", existingZone, nil)
 }
 
 func (s *ShelfService) GetAllShelves(libraryID int64) ([]*models.Shelf, error) {
@@ -310,6 +404,23 @@ func (s *ShelfService) GetShelfByID(libraryID, id int64) (*models.Shelf, error)
 	return shelf, nil
 }
 
+func (s *ShelfService) GetShelfBooks(libraryID, id int64) ([]*models.Book, error) {
+	if _, err := s.GetShelfByID(libraryID, id); err != nil {
+		return nil, err
+	}
+
+	books, err := s.bookStore.GetBooksFiltered(libraryID, store.BookFilter{ShelfID: &id})
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los libros del estante: %w", err)
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, books...); err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
 func (s *ShelfService) GetShelvesFiltered(libraryID int64, filter store.ShelfFilter) ([]*models.Shelf, error) {
 	if filter.Code != "" {
 		filter.Code = strings.TrimSpace(strings.ToUpper(filter.Code))
@@ -334,7 +445,7 @@ func (s *ShelfService) GetShelvesFiltered(libraryID int64, filter store.ShelfFil
 	return shelves, nil
 }
 
-func (s *ShelfService) CreateShelf(libraryID int64, shelf *models.Shelf) (*models.Shelf, error) {
+func (s *ShelfService) CreateShelf(ctx context.Context, libraryID int64, shelf *models.Shelf) (*models.Shelf, error) {
 	if err := validations.ValidateShelf(shelf); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -361,10 +472,14 @@ func (s *ShelfService) CreateShelf(libraryID int64, shelf *models.Shelf) (*model
 		return nil, fmt.Errorf("Error al crear el estante: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "shelf", createdShelf.ID, "create", nil, createdShelf); err != nil {
+		return nil, err
+	}
+
 	return createdShelf, nil
 }
 
-func (s *ShelfService) UpdateShelf(libraryID, id int64, shelf *models.Shelf) (*models.Shelf, error) {
+func (s *ShelfService) UpdateShelf(ctx context.Context, libraryID, id int64, shelf *models.Shelf) (*models.Shelf, error) {
 	if id <= 0 {
 		return nil, errors.New("El ID del estante es inválido")
 	}
@@ -403,10 +518,16 @@ func (s *ShelfService) UpdateShelf(libraryID, id int64, shelf *models.Shelf) (*m
 		return nil, fmt.Errorf("Error al actualizar el estante con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "shelf", id, "update", existingShelf, updatedShelf); err != nil {
+		return nil, err
+	}
+
 	return updatedShelf, nil
 }
 
-func (s *ShelfService) DeleteShelf(libraryID, id int64) error {
+// DeleteShelf fails while copies or books are still on the shelf, unless
+// rehomeTo names the shelf to move them to first.
+func (s *ShelfService) DeleteShelf(ctx context.Context, libraryID, id int64, rehomeTo, librarianID *int64) error {
 	if id <= 0 {
 		return errors.New("El ID del estante es inválido")
 	}
@@ -420,11 +541,39 @@ func (s *ShelfService) DeleteShelf(libraryID, id int64) error {
 		return fmt.Errorf("El estante con ID %d no fue encontrado", id)
 	}
 
-	if err := s.shelfStore.Delete(libraryID, id); err != nil {
+	if err := checkLocationInUse(s.shelfStore, libraryID, []int64{id}, rehomeTo); err != nil {
+		return err
+	}
+
+	var copies []*models.Copy
+	var books []*models.Book
+
+	if rehomeTo != nil {
+		if *rehomeTo == id {
+			return errors.New("El estante destino debe ser distinto del que se va a eliminar")
+		}
+
+		if _, err := s.shelfStore.GetByID(libraryID, *rehomeTo); err != nil {
+			return fmt.Errorf("El estante con ID %d no existe: %w", *rehomeTo, err)
+		}
+
+		copies, books, err = shelfContents(s.copyStore, s.bookStore, libraryID, []int64{id})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.shelfStore.DeleteRehoming(libraryID, []int64{id}, rehomeTo, librarianID); err != nil {
 		return fmt.Errorf("Error al eliminar el estante con ID %d: %w", id, err)
 	}
 
-	return nil
+	if rehomeTo != nil {
+		if err := recordRehome(ctx, s.auditService, libraryID, copies, books, *rehomeTo); err != nil {
+			return err
+		}
+	}
+
+	return s.auditService.Record(ctx, libraryID, "shelf", id, "delete", existingShelf, nil)
 }
 
 func (s *CopyService) GetAllCopies(libraryID int64) ([]*models.Copy, error) {
@@ -465,6 +614,14 @@ func (s *CopyService) GetCopiesFiltered(libraryID int64, filter store.CopyFilter
 		}
 	}
 
+	if filter.ShelfID != nil && *filter.ShelfID <= 0 {
+		return nil, errors.New("El ID del estante es inválido")
+	}
+
+	if filter.ZoneID != nil && *filter.ZoneID <= 0 {
+		return nil, errors.New("El ID de la zona es inválido")
+	}
+
 	if filter.Status != "" {
 		filter.Status = strings.TrimSpace(filter.Status)
 	}
@@ -481,12 +638,12 @@ func (s *CopyService) GetCopiesFiltered(libraryID int64, filter store.CopyFilter
 	return copies, nil
 }
 
-func (s *CopyService) CreateCopy(libraryID int64, copy *models.Copy) (*models.Copy, error) {
+func (s *CopyService) CreateCopy(ctx context.Context, libraryID int64, copy *models.Copy, librarianID *int64) (*models.Copy, error) {
 	if err := validations.ValidateCopy(copy); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
 
-	_, err := s.bookStore.GetByID(libraryID, copy.BookID)
+	book, err := s.bookStore.GetByID(libraryID, copy.BookID)
 	if err != nil {
 		return nil, fmt.Errorf("El libro con ID %d no existe: %w", copy.BookID, err)
 	}
@@ -496,6 +653,14 @@ func (s *CopyService) CreateCopy(libraryID int64, copy *models.Copy) (*models.Co
 		return nil, fmt.Errorf("Ya existe una copia con el código %s", copy.Code)
 	}
 
+	if !copy.ShelfID.Valid {
+		copy.ShelfID = book.ShelfID
+	}
+
+	if err := s.checkCopyLocation(libraryID, copy); err != nil {
+		return nil, err
+	}
+
 	copy.LibraryID = libraryID
 	copy.Code = strings.TrimSpace(strings.ToUpper(copy.Code))
 
@@ -513,10 +678,19 @@ func (s *CopyService) CreateCopy(libraryID int64, copy *models.Copy) (*models.Co
 		return nil, fmt.Errorf("Error al crear la copia: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "copy", createdCopy.ID, "create", nil, createdCopy); err != nil {
+		return nil, err
+	}
+
+	event := newCopyEvent(createdCopy.ID, "Created", "", createdCopy.Status, librarianID)
+	if err := recordCopyEvents(s.copyEventStore, libraryID, event); err != nil {
+		return nil, err
+	}
+
 	return createdCopy, nil
 }
 
-func (s *CopyService) UpdateCopy(libraryID, id int64, copy *models.Copy) (*models.Copy, error) {
+func (s *CopyService) UpdateCopy(ctx context.Context, libraryID, id int64, copy *models.Copy, librarianID *int64) (*models.Copy, error) {
 	if id <= 0 {
 		return nil, errors.New("El ID de la copia es inválido")
 	}
@@ -544,6 +718,10 @@ func (s *CopyService) UpdateCopy(libraryID, id int64, copy *models.Copy) (*model
 		return nil, fmt.Errorf("Ya existe otra copia con el código %s", copy.Code)
 	}
 
+	if err := s.checkCopyLocation(libraryID, copy); err != nil {
+		return nil, err
+	}
+
 	if copy.Status != "Borrowed" && existingCopy.Status == "Borrowed" {
 		activeLoans, err := s.loanStore.GetLoansFiltered(libraryID, store.LoanFilter{
 			CopyID: &id,
@@ -565,10 +743,18 @@ func (s *CopyService) UpdateCopy(libraryID, id int64, copy *models.Copy) (*model
 		return nil, fmt.Errorf("Error al actualizar la copia con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "copy", id, "update", existingCopy, updatedCopy); err != nil {
+		return nil, err
+	}
+
+	if err := recordCopyEvents(s.copyEventStore, libraryID, copyChangeEvents(existingCopy, updatedCopy, librarianID)...); err != nil {
+		return nil, err
+	}
+
 	return updatedCopy, nil
 }
 
-func (s *CopyService) DeleteCopy(libraryID, id int64) error {
+func (s *CopyService) DeleteCopy(ctx context.Context, libraryID, id int64, librarianID *int64) error {
 	if id <= 0 {
 		return errors.New("El ID de la copia es inválido")
 	}
@@ -606,5 +792,14 @@ func (s *CopyService) DeleteCopy(libraryID, id int64) error {
 		return fmt.Errorf("Error al eliminar la copia con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "copy", id, "delete", existingCopy, nil); err != nil {
+		return err
+	}
+
+	event := newCopyEvent(id, "Deleted", existingCopy.Status, "", librarianID)
+	if err := recordCopyEvents(s.copyEventStore, libraryID, event); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+func TestDeleteZoneRehomesAtomically(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+
+	seed := []string{
+		`INSERT INTO library_zones (id, code, name, floor, library_id) VALUES (1, 'Z1', 'Infantil', 1, 1), (2, 'Z2', 'General', 1, 1)`,
+		`INSERT INTO shelves (id, code, zone_id, library_id) VALUES (1, 'A1', 1, 1), (2, 'A2', 1, 1), (3, 'B1', 2, 1)`,
+		`INSERT INTO books (id, isbn, title, shelf_id, library_id) VALUES (1, '9780000000001', 'Pedro Páramo', 1, 1), (2, '9780000000002', 'Aura', 2, 1)`,
+		`INSERT INTO copies (code, book_id, status, shelf_id, library_id) VALUES ('C1', 1, 'Available', 1, 1), ('C2', 2, 'Available', 2, 1)`,
+		// Makes the second shelf fail to delete after the first one is gone
+		`CREATE TRIGGER trg_test_keep_shelf BEFORE DELETE ON shelves WHEN OLD.id = 2
+		BEGIN
+			SELECT RAISE(ABORT, 'estante bloqueado');
+		END`,
+	}
+
+	for _, query := range seed {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	shelfStore := store.NewShelfStore(db)
+	audit := NewAuditService(store.NewAuditStore(db))
+	service := NewLibraryZoneService(store.NewLibraryZoneStore(db), shelfStore, store.NewCopyStore(db), store.NewBookStore(db), audit)
+
+	state := func() (shelves, onTarget, moved int) {
+		t.Helper()
+
+		err := db.QueryRow(`
+			SELECT
+				(SELECT COUNT(*) FROM shelves WHERE zone_id = 1),
+				(SELECT COUNT(*) FROM copies WHERE shelf_id = 3) + (SELECT COUNT(*) FROM books WHERE shelf_id = 3),
+				(SELECT COUNT(*) FROM copy_events WHERE event_type = 'Moved')
+		`).Scan(&shelves, &onTarget, &moved)
+
+		if err != nil {
+			t.Fatalf("state: %v", err)
+		}
+
+		return shelves, onTarget, moved
+	}
+
+	if err := service.DeleteZone(context.Background(), 1, 1, nil, nil); !errors.Is(err, ErrLocationInUse) {
+		t.Fatalf("delete without rehome: err = %v, want ErrLocationInUse", err)
+	}
+
+	target := int64(3)
+
+	if err := service.DeleteZone(context.Background(), 1, 1, &target, nil); err == nil {
+		t.Fatal("delete with a shelf that can't be deleted succeeded")
+	}
+
+	if shelves, onTarget, moved := state(); shelves != 2 || onTarget != 0 || moved != 0 {
+		t.Fatalf("after failed delete: %d shelves, %d on target, %d moved", shelves, onTarget, moved)
+	}
+
+	if _, err := db.Exec(`DROP TRIGGER trg_test_keep_shelf`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+
+	if err := service.DeleteZone(context.Background(), 1, 1, &target, nil); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if shelves, onTarget, moved := state(); shelves != 0 || onTarget != 4 || moved != 2 {
+		t.Fatalf("after delete: %d shelves, %d on target, %d moved", shelves, onTarget, moved)
+	}
+
+	copies, books, err := shelfStore.CountContents(1, []int64{3})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+
+	if copies != 2 || books != 2 {
+		t.Errorf("CountContents = %d copies, %d books, want 2 and 2", copies, books)
+	}
+
+	var entries int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE action IN ('move', 'delete')`).Scan(&entries); err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+
+	// Two copies and two books moved, two shelves and the zone deleted
+	if entries != 7 {
+		t.Errorf("audit entries = %d, want 7", entries)
+	}
+}
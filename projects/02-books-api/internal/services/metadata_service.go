@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/metadata"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+type MetadataService struct {
+	provider       metadata.MetadataProvider
+	metadataStore  store.IMetadataStore
+	bookStore      store.IBookStore
+	authorStore    store.IAuthorStore
+	publisherStore store.IPublisherStore
+	auditService   *AuditService
+}
+
+func NewMetadataService(provider metadata.MetadataProvider, metadataStore store.IMetadataStore, bookStore store.IBookStore, authorStore store.IAuthorStore, publisherStore store.IPublisherStore, auditService *AuditService) *MetadataService {
+	return &MetadataService{
+		provider:       provider,
+		metadataStore:  metadataStore,
+		bookStore:      bookStore,
+		authorStore:    authorStore,
+		publisherStore: publisherStore,
+		auditService:   auditService,
+	}
+}
+
+// EnrichBook creates or completes the book of the ISBN with the metadata of
+// the provider. Everything is looked up first and written at once, so a
+// failed enrichment creates no publisher or author.
+func (s *MetadataService) EnrichBook(ctx context.Context, libraryID int64, isbn string, refresh bool) (*models.BookEnrichment, error) {
+	normalizedISBN := metadata.NormalizeISBN(isbn)
+	if len(normalizedISBN) != 10 && len(normalizedISBN) != 13 {
+		return nil, errors.New("El ISBN debe tener 10 o 13 dígitos")
+	}
+
+	bookMetadata, fromCache, err := s.lookupMetadata(normalizedISBN, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	enrichment := &models.BookEnrichment{
+		Metadata:  bookMetadata,
+		FromCache: fromCache,
+	}
+
+	publisher, err := s.resolvePublisher(libraryID, bookMetadata.Publisher)
+	if err != nil {
+		return nil, err
+	}
+
+	authors, skippedAuthors, err := s.resolveAuthors(libraryID, bookMetadata.Authors)
+	if err != nil {
+		return nil, err
+	}
+
+	enrichment.SkippedAuthors = skippedAuthors
+
+	book, err := s.bookStore.GetByISBN(libraryID, strings.TrimSpace(isbn))
+	if err != nil {
+		return nil, fmt.Errorf("Error al buscar el libro con ISBN %s: %w", isbn, err)
+	}
+
+	if book == nil && normalizedISBN != strings.TrimSpace(isbn) {
+		book, err = s.bookStore.GetByISBN(libraryID, normalizedISBN)
+		if err != nil {
+			return nil, fmt.Errorf("Error al buscar el libro con ISBN %s: %w", normalizedISBN, err)
+		}
+	}
+
+	changes := &store.EnrichmentChanges{Publisher: publisher}
+	var previousBook models.Book
+
+	if book == nil {
+		book, err = newBookFromMetadata(libraryID, normalizedISBN, bookMetadata)
+		if err != nil {
+			return nil, err
+		}
+
+		changes.SetPublisher = publisher != nil
+		changes.Authors = authors
+		enrichment.Created = true
+	} else {
+		previousBook = *book
+
+		updated, skipped, err := s.applyMetadata(libraryID, book, bookMetadata, publisher)
+		if err != nil {
+			return nil, err
+		}
+
+		changes.UpdateBook = len(updated) > 0
+		changes.SetPublisher = slices.Contains(updated, "publisher_id")
+		enrichment.UpdatedFields = updated
+		enrichment.SkippedFields = skipped
+
+		// The authors a cataloguer already linked are kept.
+		currentAuthors, err := s.bookStore.GetBookAuthors(libraryID, book.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Error al obtener los autores del libro: %w", err)
+		}
+
+		if len(currentAuthors) > 0 {
+			authors = currentAuthors
+		} else {
+			changes.Authors = authors
+		}
+	}
+
+	changes.Book = book
+
+	createdPublisher := changes.SetPublisher && publisher.ID == 0
+
+	var createdAuthors []*models.Author
+	for _, author := range changes.Authors {
+		if author.ID == 0 {
+			createdAuthors = append(createdAuthors, author)
+		}
+	}
+
+	if err := s.metadataStore.SaveEnrichment(libraryID, changes); err != nil {
+		return nil, fmt.Errorf("Error al guardar los metadatos del libro: %w", err)
+	}
+
+	if createdPublisher {
+		if err := s.auditService.Record(ctx, libraryID, "publisher", publisher.ID, "create", nil, publisher); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, author := range createdAuthors {
+		if err := s.auditService.Record(ctx, libraryID, "author", author.ID, "create", nil, author); err != nil {
+			return nil, err
+		}
+	}
+
+	if enrichment.Created {
+		enrichment.UpdatedFields = editedBookFields(nil, book)
+	} else if changes.UpdateBook {
+		book, err = s.bookStore.GetByID(libraryID, book.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Error al obtener el libro con ID %d: %w", changes.Book.ID, err)
+		}
+	}
+
+	if publisher != nil && publisher.ID != 0 {
+		enrichment.Publisher = publisher
+	}
+
+	enrichment.Book = book
+	enrichment.Authors = authors
+
+	if enrichment.Created {
+		if err := s.auditService.Record(ctx, libraryID, "book", book.ID, "create", nil, book); err != nil {
+			return nil, err
+		}
+	} else if changes.UpdateBook || changes.SetPublisher {
+		if err := s.auditService.Record(ctx, libraryID, "book", book.ID, "enrich", &previousBook, book); err != nil {
+			return nil, err
+		}
+	}
+
+	return enrichment, nil
+}
+
+func (s *MetadataService) lookupMetadata(isbn string, refresh bool) (*models.BookMetadata, bool, error) {
+	if !refresh {
+		cached, err := s.metadataStore.GetCached(s.provider.Name(), isbn)
+		if err != nil {
+			return nil, false, fmt.Errorf("Error al leer la caché de metadatos: %w", err)
+		}
+
+		if cached != nil {
+			return cached, true, nil
+		}
+	}
+
+	bookMetadata, err := s.provider.Lookup(isbn)
+	if err != nil {
+		if errors.Is(err, metadata.ErrMetadataNotFound) {
+			return nil, false, fmt.Errorf("%w %s", err, isbn)
+		}
+
+		return nil, false, err
+	}
+
+	if err := s.metadataStore.SaveCached(s.provider.Name(), isbn, bookMetadata); err != nil {
+		return nil, false, fmt.Errorf("Error al guardar la caché de metadatos: %w", err)
+	}
+
+	return bookMetadata, false, nil
+}
+
+// resolvePublisher returns the publisher of the library with the name, or a
+// new one without ID to be created with the book.
+func (s *MetadataService) resolvePublisher(libraryID int64, name string) (*models.Publisher, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+
+	publisher, err := s.publisherStore.GetByName(libraryID, name)
+	if err != nil {
+		return nil, fmt.Errorf("Error al buscar la editorial %s: %w", name, err)
+	}
+
+	if publisher != nil {
+		return publisher, nil
+	}
+
+	publisher = &models.Publisher{Name: name}
+	if err := validations.ValidatePublisher(publisher); err != nil {
+		return nil, nil
+	}
+
+	return publisher, nil
+}
+
+// resolveAuthors returns the authors of the library with the names, or new
+// ones without ID, and the names that are not a valid author, as a single
+// word one.
+func (s *MetadataService) resolveAuthors(libraryID int64, names []string) ([]*models.Author, []string, error) {
+	var (
+		authors []*models.Author
+		skipped []string
+	)
+
+	for _, name := range names {
+		firstName, lastName := splitAuthorName(name)
+
+		author := &models.Author{FirstName: firstName, LastName: lastName}
+		if err := validations.ValidateAuthor(author); err != nil {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		existingAuthor, err := s.authorStore.GetByName(libraryID, firstName, lastName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error al buscar el autor %s: %w", name, err)
+		}
+
+		if existingAuthor != nil {
+			author = existingAuthor
+		}
+
+		authors = append(authors, author)
+	}
+
+	return authors, skipped, nil
+}
+
+func newBookFromMetadata(libraryID int64, isbn string, bookMetadata *models.BookMetadata) (*models.Book, error) {
+	book := &models.Book{
+		ISBN:             isbn,
+		Title:            bookMetadata.Title,
+		RegistrationDate: time.Now(),
+		LibraryID:        libraryID,
+	}
+
+	setNullString(&book.Subtitle, bookMetadata.Subtitle)
+	setNullString(&book.Synopsis, bookMetadata.Synopsis)
+	setNullInt64(&book.PublicationYear, bookMetadata.PublicationYear)
+	setNullInt64(&book.Pages, bookMetadata.Pages)
+
+	if err := validations.ValidateBook(book); err != nil {
+		return nil, fmt.Errorf("Los metadatos obtenidos no son válidos: %w", err)
+	}
+
+	return book, nil
+}
+
+// applyMetadata sets on the book the fields the metadata changes and no
+// cataloguer edited. The publisher is set when the book is saved, since it
+// may not exist yet.
+func (s *MetadataService) applyMetadata(libraryID int64, book *models.Book, bookMetadata *models.BookMetadata, publisher *models.Publisher) ([]string, []string, error) {
+	editedFields, err := s.bookStore.GetEditedFields(libraryID, book.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error al obtener los campos editados del libro: %w", err)
+	}
+
+	edited := make(map[string]bool, len(editedFields))
+	for _, field := range editedFields {
+		edited[field] = true
+	}
+
+	before := *book
+	candidate := *book
+
+	if bookMetadata.Title != "" {
+		candidate.Title = bookMetadata.Title
+	}
+
+	setNullString(&candidate.Subtitle, bookMetadata.Subtitle)
+	setNullString(&candidate.Synopsis, bookMetadata.Synopsis)
+	setNullInt64(&candidate.PublicationYear, bookMetadata.PublicationYear)
+	setNullInt64(&candidate.Pages, bookMetadata.Pages)
+
+	changedFields := editedBookFields(&before, &candidate)
+
+	if publisher != nil && (publisher.ID == 0 || !book.PublisherID.Valid || book.PublisherID.Int64 != publisher.ID) {
+		changedFields = append(changedFields, "publisher_id")
+	}
+
+	var updated, skipped []string
+
+	for _, field := range changedFields {
+		if edited[field] {
+			skipped = append(skipped, field)
+			continue
+		}
+
+		switch field {
+			case "title":
+				book.Title = candidate.Title
+			case "subtitle":
+				book.Subtitle = candidate.Subtitle
+			case "publication_year":
+				book.PublicationYear = candidate.PublicationYear
+			case "pages":
+				book.Pages = candidate.Pages
+			case "synopsis":
+				book.Synopsis = candidate.Synopsis
+		}
+
+		updated = append(updated, field)
+	}
+
+	if len(updated) == 0 {
+		return updated, skipped, nil
+	}
+
+	if err := validations.ValidateBook(book); err != nil {
+		return nil, nil, fmt.Errorf("Los metadatos obtenidos no son válidos: %w", err)
+	}
+
+	return updated, skipped, nil
+}
+
+func splitAuthorName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+
+	if last, first, found := strings.Cut(name, ","); found {
+		return strings.TrimSpace(first), strings.TrimSpace(last)
+	}
+
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name, ""
+	}
+
+	return strings.Join(parts[:len(parts)-1], " "), parts[len(parts)-1]
+}
+
+func setNullString(target *database.NullString, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	target.Valid = true
+	target.String = value
+}
+
+func setNullInt64(target *database.NullInt64, value int64) {
+	if value <= 0 {
+		return
+	}
+
+	target.Valid = true
+	target.Int64 = value
+}
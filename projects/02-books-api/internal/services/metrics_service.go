@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/metrics"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+type MetricsService struct {
+	metricsStore store.IMetricsStore
+}
+
+func NewMetricsService(metricsStore store.IMetricsStore) *MetricsService {
+	return &MetricsService{
+		metricsStore: metricsStore,
+	}
+}
+
+// Collect reads the circulation gauges of every library; it is registered as
+// a collector so they are taken from the database on every scrape.
+func (s *MetricsService) Collect() ([]metrics.Family, error) {
+	gauges, err := s.metricsStore.GetLibraryGauges(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las métricas de circulación: %w", err)
+	}
+
+	families := []metrics.Family{
+		{Name: "library_active_loans", Help: "Préstamos sin devolver por biblioteca.", Type: "gauge"},
+		{Name: "library_overdue_loans", Help: "Préstamos vencidos sin devolver por biblioteca.", Type: "gauge"},
+		{Name: "library_pending_fines", Help: "Multas pendientes de pago por biblioteca.", Type: "gauge"},
+		{Name: "library_pending_fines_amount", Help: "Importe de las multas pendientes de pago por biblioteca.", Type: "gauge"},
+	}
+
+	for _, gauge := range gauges {
+		labels := map[string]string{"library_id": strconv.FormatInt(gauge.LibraryID, 10)}
+
+		families[0].Samples = append(families[0].Samples, metrics.Sample{Labels: labels, Value: float64(gauge.ActiveLoans)})
+		families[1].Samples = append(families[1].Samples, metrics.Sample{Labels: labels, Value: float64(gauge.OverdueLoans)})
+		families[2].Samples = append(families[2].Samples, metrics.Sample{Labels: labels, Value: float64(gauge.PendingFines)})
+		families[3].Samples = append(families[3].Samples, metrics.Sample{Labels: labels, Value: gauge.PendingFinesAmount})
+	}
+
+	return families, nil
+}
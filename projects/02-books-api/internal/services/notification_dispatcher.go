@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/metrics"
+)
+
+// NotificationDispatcher delivers the outbox in process, checking every
+// interval for notifications whose next attempt has come.
+type NotificationDispatcher struct {
+	notificationService *NotificationService
+	interval            time.Duration
+}
+
+func NewNotificationDispatcher(notificationService *NotificationService, interval time.Duration) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		notificationService: notificationService,
+		interval:            interval,
+	}
+}
+
+// Start blocks until the context is cancelled.
+func (d *NotificationDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				_, err := d.notificationService.Dispatch(ctx, now)
+				metrics.ObserveJob("notification_dispatcher", now, err)
+
+				if err != nil {
+					slog.WarnContext(ctx, "Error al enviar las notificaciones", "error", err)
+				}
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/notify"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+// fakeNotifier records every message and answers with err.
+type fakeNotifier struct {
+	mu   sync.Mutex
+	err  error
+	sent []notify.Message
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.sent = append(n.sent, msg)
+
+	return n.err
+}
+
+func (n *fakeNotifier) calls() []notify.Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]notify.Message(nil), n.sent...)
+}
+
+type notificationTest struct {
+	service  *NotificationService
+	store    store.INotificationStore
+	audit    *AuditService
+	notifier *fakeNotifier
+	now      time.Time
+}
+
+// newNotificationTest opens an in-memory database with one library and a
+// patron whose card expires in ten days, so the first Dispatch enqueues a
+// card_expiring notice.
+func newNotificationTest(t *testing.T, notifyErr error) *notificationTest {
+	t.Helper()
+
+	db := database.Open(":memory:")
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(database.GetMigrationSchema()); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+
+	if err := database.ApplyMigrationAlterations(db); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	// The outbox defaults next_attempt_at to CURRENT_TIMESTAMP, the clock of
+	// the test must not be behind it.
+	now := time.Now().UTC().Add(time.Minute).Truncate(time.Second)
+
+	seed := []struct {
+		query string
+		args  []any
+	}{
+		{
+			`INSERT INTO libraries (name, address, city, state, zip_code, country, phone, email, website, username, password)
+			VALUES ('Biblioteca Central', 'Av. Juárez 10', 'Aguascalientes', 'Ags.', '20000', 'MX', '4490000000', 'mostrador@example.com', 'https://example.com', 'central', 'x')`,
+			nil,
+		},
+		{
+			`INSERT INTO users (code, dni, first_name, last_name, email, user_type, status, card_expiration, library_id)
+			VALUES ('U0001', 'D0001', 'Ana', 'Pérez', 'ana@example.com', 'Student', 'Active', ?, 1)`,
+			[]any{now.AddDate(0, 0, 10)},
+		},
+	}
+
+	for _, s := range seed {
+		if _, err := db.Exec(s.query, s.args...); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	notifier := &fakeNotifier{err: notifyErr}
+	notificationStore := store.NewNotificationStore(db)
+	audit := NewAuditService(store.NewAuditStore(db))
+
+	service := NewNotificationService(
+		notificationStore,
+		store.NewNotificationTemplateStore(db),
+		store.NewNotificationPreferenceStore(db),
+		NewLibraryService(store.NewLibraryStore(db), nil, audit),
+		store.NewUserStore(db),
+		store.NewLoanStore(db),
+		store.NewFineStore(db),
+		store.NewReservationStore(db),
+		store.NewCopyStore(db),
+		store.NewBookStore(db),
+		map[string]notify.Notifier{notificationChannelEmail: notifier},
+		audit,
+	)
+
+	return &notificationTest{service: service, store: notificationStore, audit: audit, notifier: notifier, now: now}
+}
+
+func (nt *notificationTest) dispatch(t *testing.T, now time.Time) int {
+	t.Helper()
+
+	sent, err := nt.service.Dispatch(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	return sent
+}
+
+// notifications returns the whole outbox of the library.
+func (nt *notificationTest) notifications(t *testing.T) []*models.Notification {
+	t.Helper()
+
+	notifications, err := nt.store.GetAll(1, store.NotificationFilter{})
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+
+	return notifications
+}
+
+func (nt *notificationTest) only(t *testing.T) *models.Notification {
+	t.Helper()
+
+	notifications := nt.notifications(t)
+	if len(notifications) != 1 {
+		t.Fatalf("outbox has %d notifications, want 1", len(notifications))
+	}
+
+	return notifications[0]
+}
+
+func TestDispatchSendsAndDeduplicates(t *testing.T) {
+	nt := newNotificationTest(t, nil)
+
+	if sent := nt.dispatch(t, nt.now); sent != 1 {
+		t.Fatalf("sent = %d, want 1", sent)
+	}
+
+	// Later runs enqueue the same event again; the dedup key keeps it once.
+	for _, later := range []time.Duration{time.Minute, time.Hour, 24 * time.Hour} {
+		if sent := nt.dispatch(t, nt.now.Add(later)); sent != 0 {
+			t.Errorf("sent = %d after %v, want 0", sent, later)
+		}
+	}
+
+	calls := nt.notifier.calls()
+	if len(calls) != 1 {
+		t.Fatalf("notifier called %d times, want 1", len(calls))
+	}
+
+	notification := nt.only(t)
+
+	if notification.EventType != NotificationCardExpiring || notification.Status != "Sent" || notification.Attempts != 1 {
+		t.Errorf("notification = %s %s attempts %d", notification.EventType, notification.Status, notification.Attempts)
+	}
+
+	if notification.Recipient.String != "ana@example.com" || notification.Channel.String != notificationChannelEmail {
+		t.Errorf("recipient/channel = %q/%q", notification.Recipient.String, notification.Channel.String)
+	}
+
+	msg := calls[0]
+
+	if want := fmt.Sprintf("notification-1-%d", notification.ID); msg.ID != want {
+		t.Errorf("message ID = %q, want %q", msg.ID, want)
+	}
+
+	if msg.To != "ana@example.com" || msg.FromName != "Biblioteca Central" || msg.ReplyTo != "mostrador@example.com" {
+		t.Errorf("message = %+v", msg)
+	}
+}
+
+func TestDispatchRetriesWithDelays(t *testing.T) {
+	nt := newNotificationTest(t, errors.New("conexión rechazada"))
+
+	at := nt.now
+
+	for i, delay := range notificationRetryDelays {
+		if sent := nt.dispatch(t, at); sent != 0 {
+			t.Fatalf("attempt %d: sent = %d, want 0", i+1, sent)
+		}
+
+		notification := nt.only(t)
+
+		if notification.Status != "Pending" || notification.Attempts != i+1 {
+			t.Fatalf("attempt %d: status %s attempts %d", i+1, notification.Status, notification.Attempts)
+		}
+
+		if notification.LastError.String != "conexión rechazada" {
+			t.Errorf("attempt %d: last_error = %q", i+1, notification.LastError.String)
+		}
+
+		next := notification.NextAttemptAt.Time
+		if !next.Equal(at.Add(delay)) {
+			t.Fatalf("attempt %d: next attempt at %v, want %v", i+1, next, at.Add(delay))
+		}
+
+		// Nothing goes out before the delay is over.
+		calls := len(nt.notifier.calls())
+		nt.dispatch(t, next.Add(-time.Second))
+
+		if got := len(nt.notifier.calls()); got != calls {
+			t.Fatalf("attempt %d: sent again %v before the delay", i+1, delay)
+		}
+
+		at = next
+	}
+
+	var ids []string
+	for _, msg := range nt.notifier.calls() {
+		ids = append(ids, msg.ID)
+	}
+
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Errorf("message IDs changed between attempts: %v", ids)
+			break
+		}
+	}
+
+	nt.dispatch(t, at)
+
+	notification := nt.only(t)
+	if notification.Status != "Failed" || notification.Attempts != len(notificationRetryDelays)+1 {
+		t.Fatalf("final: status %s attempts %d", notification.Status, notification.Attempts)
+	}
+
+	calls := len(nt.notifier.calls())
+	nt.dispatch(t, at.Add(24*time.Hour))
+
+	if got := len(nt.notifier.calls()); got != calls {
+		t.Errorf("a failed notification was sent again")
+	}
+}
+
+func TestDispatchPermanentFailure(t *testing.T) {
+	nt := newNotificationTest(t, fmt.Errorf("%w: ana@example", notify.ErrInvalidRecipient))
+
+	nt.dispatch(t, nt.now)
+
+	notification := nt.only(t)
+	if notification.Status != "Failed" || notification.Attempts != 1 {
+		t.Fatalf("status %s attempts %d, want Failed after one attempt", notification.Status, notification.Attempts)
+	}
+
+	nt.dispatch(t, nt.now.Add(time.Hour))
+
+	if calls := len(nt.notifier.calls()); calls != 1 {
+		t.Errorf("notifier called %d times, want 1", calls)
+	}
+}
+
+func TestRetryNotificationStartsOver(t *testing.T) {
+	nt := newNotificationTest(t, fmt.Errorf("%w: ana@example", notify.ErrInvalidRecipient))
+
+	nt.dispatch(t, nt.now)
+	failed := nt.only(t)
+
+	nt.notifier.mu.Lock()
+	nt.notifier.err = nil
+	nt.notifier.mu.Unlock()
+
+	librarianID := int64(7)
+	ctx := WithAuditOrigin(context.Background(), AuditOrigin{RequestID: "req-1", ActorID: &librarianID, Method: "POST"})
+
+	if _, err := nt.service.RetryNotification(ctx, 1, failed.ID, false); err != nil {
+		t.Fatalf("RetryNotification: %v", err)
+	}
+
+	entries, err := nt.audit.GetEntries(1, AuditQuery{EntityType: "notification"})
+	if err != nil {
+		t.Fatalf("GetEntries: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d audit entries, want 1", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Action != "retry" || entry.EntityID.Int64 != failed.ID || entry.ActorID.Int64 != librarianID || entry.RequestID != "req-1" {
+		t.Errorf("entry = %s %d by %d in %q", entry.Action, entry.EntityID.Int64, entry.ActorID.Int64, entry.RequestID)
+	}
+
+	if !strings.Contains(string(entry.Before), `"status":"Failed"`) || !strings.Contains(string(entry.After), `"status":"Pending"`) {
+		t.Errorf("before %s after %s", entry.Before, entry.After)
+	}
+
+	if sent := nt.dispatch(t, time.Now().UTC().Add(time.Minute)); sent != 1 {
+		t.Fatalf("sent = %d, want 1", sent)
+	}
+
+	notification := nt.only(t)
+	if notification.Status != "Sent" || notification.Attempts != 1 || notification.LastError.Valid {
+		t.Errorf("status %s attempts %d last_error %q", notification.Status, notification.Attempts, notification.LastError.String)
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+// GetPreferences returns how the user wants to be notified, the defaults
+// while they have not chosen: every notice by email in Spanish.
+func (s *NotificationService) GetPreferences(libraryID, userID int64) (*models.NotificationPreferences, error) {
+	if _, err := s.userStore.GetByID(libraryID, userID); err != nil {
+		return nil, fmt.Errorf("Error al obtener el usuario con ID %d: %w", userID, err)
+	}
+
+	return s.preferencesOf(libraryID, userID)
+}
+
+func (s *NotificationService) SavePreferences(ctx context.Context, libraryID, userID int64, preferences *models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	user, err := s.userStore.GetByID(libraryID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el usuario con ID %d: %w", userID, err)
+	}
+
+	if preferences.Channel == "" {
+		preferences.Channel = notificationChannelEmail
+	}
+
+	if preferences.Language == "" {
+		preferences.Language = defaultNotificationLanguage
+	}
+
+	if err := validations.ValidateNotificationPreferences(preferences); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	eventTypes := make([]string, 0, len(preferences.EventTypes))
+
+	for _, eventType := range preferences.EventTypes {
+		e, err := findNotificationEvent(eventType)
+		if err != nil {
+			return nil, fmt.Errorf("Validación fallida: %w", err)
+		}
+
+		if !slices.Contains(eventTypes, e.name) {
+			eventTypes = append(eventTypes, e.name)
+		}
+	}
+
+	if preferences.Channel != notificationChannelNone && !canReach(preferences.Channel, user) {
+		return nil, fmt.Errorf("Validación fallida: %s", unreachableReason(preferences.Channel))
+	}
+
+	preferences.UserID = userID
+	preferences.EventTypes = eventTypes
+
+	previousPreferences, err := s.preferenceStore.GetByUserID(libraryID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las preferencias del usuario con ID %d: %w", userID, err)
+	}
+
+	savedPreferences, err := s.preferenceStore.Save(libraryID, preferences)
+	if err != nil {
+		return nil, fmt.Errorf("Error al guardar las preferencias: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "notification_preferences", userID, "update", previousPreferences, savedPreferences); err != nil {
+		return nil, err
+	}
+
+	return savedPreferences, nil
+}
+
+// ResetPreferences drops the user's preferences so the defaults apply again.
+func (s *NotificationService) ResetPreferences(ctx context.Context, libraryID, userID int64) error {
+	if _, err := s.userStore.GetByID(libraryID, userID); err != nil {
+		return fmt.Errorf("Error al obtener el usuario con ID %d: %w", userID, err)
+	}
+
+	previousPreferences, err := s.preferenceStore.GetByUserID(libraryID, userID)
+	if err != nil {
+		return fmt.Errorf("Error al obtener las preferencias del usuario con ID %d: %w", userID, err)
+	}
+
+	if err := s.preferenceStore.Delete(libraryID, userID); err != nil {
+		return fmt.Errorf("Error al restablecer las preferencias: %w", err)
+	}
+
+	if previousPreferences != nil {
+		if err := s.auditService.Record(ctx, libraryID, "notification_preferences", userID, "delete", previousPreferences, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *NotificationService) preferencesOf(libraryID, userID int64) (*models.NotificationPreferences, error) {
+	preferences, err := s.preferenceStore.GetByUserID(libraryID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las preferencias del usuario con ID %d: %w", userID, err)
+	}
+
+	if preferences == nil {
+		preferences = &models.NotificationPreferences{
+			UserID:     userID,
+			Channel:    notificationChannelEmail,
+			EventTypes: []string{},
+			Language:   defaultNotificationLanguage,
+			LibraryID:  libraryID,
+		}
+	}
+
+	return preferences, nil
+}
+
+// channelFor picks the channel a notification goes through, or the reason to
+// skip it. Mandatory notices ignore the opt-outs: they use the patron's channel
+// when it works and otherwise any other that reaches them.
+func (s *NotificationService) channelFor(notification *models.Notification, user *models.User, preferences *models.NotificationPreferences) (string, string) {
+	if notification.Mandatory {
+		for _, channel := range []string{preferences.Channel, notificationChannelEmail, notificationChannelSMS} {
+			if s.notifiers[channel] != nil && canReach(channel, user) {
+				return channel, ""
+			}
+		}
+
+		return "", "No hay un medio para hacerle llegar el aviso obligatorio al usuario"
+	}
+
+	if preferences.Channel == notificationChannelNone {
+		return "", "El usuario desactivó las notificaciones"
+	}
+
+	if len(preferences.EventTypes) > 0 && !slices.Contains(preferences.EventTypes, notification.EventType) {
+		return "", "El usuario no desea recibir este tipo de aviso"
+	}
+
+	if !canReach(preferences.Channel, user) {
+		return "", unreachableReason(preferences.Channel)
+	}
+
+	if s.notifiers[preferences.Channel] == nil {
+		return "", fmt.Sprintf("El envío por %s no está configurado", preferences.Channel)
+	}
+
+	return preferences.Channel, ""
+}
+
+func canReach(channel string, user *models.User) bool {
+	switch channel {
+		case notificationChannelEmail:
+			return user.Email.Valid && strings.TrimSpace(user.Email.String) != ""
+		case notificationChannelSMS:
+			return user.Phone.Valid && strings.TrimSpace(user.Phone.String) != ""
+		default:
+			return false
+	}
+}
+
+func unreachableReason(channel string) string {
+	if channel == notificationChannelSMS {
+		return "El usuario no tiene teléfono"
+	}
+
+	return "El usuario no tiene correo electrónico"
+}
+
+// quietHoursEnd reports whether now falls in the patron's quiet hours and when
+// they end. The hours are read in the patron's timezone, and a window like
+// 22:00 to 07:00 runs past midnight.
+func quietHoursEnd(preferences *models.NotificationPreferences, now time.Time) (time.Time, bool) {
+	if !preferences.QuietHoursStart.Valid || !preferences.QuietHoursEnd.Valid || !preferences.Timezone.Valid {
+		return time.Time{}, false
+	}
+
+	location, err := time.LoadLocation(preferences.Timezone.String)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	now = now.In(location)
+
+	start, err := time.Parse("15:04", preferences.QuietHoursStart.String)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	end, err := time.Parse("15:04", preferences.QuietHoursEnd.String)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	quiet := minute >= startMinute && minute < endMinute
+	if startMinute > endMinute {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(now.Year(), now.Month(), now.Day(), end.Hour(), end.Minute(), 0, 0, now.Location())
+	if !until.After(now) {
+		until = until.AddDate(0, 0, 1)
+	}
+
+	return until, true
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	preferences := func(start, end, timezone string) *models.NotificationPreferences {
+		p := &models.NotificationPreferences{}
+		p.QuietHoursStart.String, p.QuietHoursStart.Valid = start, true
+		p.QuietHoursEnd.String, p.QuietHoursEnd.Valid = end, true
+		p.Timezone.String, p.Timezone.Valid = timezone, timezone != ""
+
+		return p
+	}
+
+	utc := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return parsed
+	}
+
+	tests := []struct {
+		name        string
+		preferences *models.NotificationPreferences
+		now         time.Time
+		quiet       bool
+		until       time.Time
+	}{
+		// 03:00 UTC is 21:00 of the day before in Mexico City (UTC-6).
+		{"before the window in local time", preferences("22:00", "07:00", "America/Mexico_City"), utc("2026-03-10T03:00:00Z"), false, time.Time{}},
+		{"past midnight in local time", preferences("22:00", "07:00", "America/Mexico_City"), utc("2026-03-10T06:30:00Z"), true, utc("2026-03-10T13:00:00Z")},
+		{"before midnight in local time", preferences("22:00", "07:00", "America/Mexico_City"), utc("2026-03-10T05:00:00Z"), true, utc("2026-03-10T13:00:00Z")},
+		{"after the window in local time", preferences("22:00", "07:00", "America/Mexico_City"), utc("2026-03-10T13:00:00Z"), false, time.Time{}},
+		{"window within the day", preferences("13:00", "15:00", "Europe/Madrid"), utc("2026-07-01T11:30:00Z"), true, utc("2026-07-01T13:00:00Z")},
+		{"no timezone", preferences("00:00", "23:59", ""), utc("2026-03-10T12:00:00Z"), false, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := quietHoursEnd(tt.preferences, tt.now)
+
+			if quiet != tt.quiet || !until.Equal(tt.until) {
+				t.Errorf("quietHoursEnd = %v, %v; want %v, %v", until, quiet, tt.until, tt.quiet)
+			}
+		})
+	}
+}
@@ -0,0 +1,964 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/documents"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/notify"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+const (
+	NotificationHoldAvailable = "hold_available"
+	NotificationDueSoon       = "due_soon"
+	NotificationOverdue       = "overdue"
+	NotificationFineCreated   = "fine_created"
+	NotificationCardExpiring  = "card_expiring"
+)
+
+const (
+	notificationChannelEmail = "email"
+	notificationChannelSMS   = "sms"
+	notificationChannelNone  = "none"
+
+	defaultNotificationLanguage = "es"
+)
+
+var notificationLanguages = []string{"es", "en"}
+
+const (
+	notificationBatchSize = 50
+	dueSoonWindow         = 2 * 24 * time.Hour
+	cardExpiringWindow    = 30 * 24 * time.Hour
+)
+
+// Wait before each new attempt of a notification that could not be delivered;
+// when they run out the notification is left as Failed.
+var notificationRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+var blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+
+// notificationEvent is an entry of the registry with the templates, one per
+// language, used while the library has not customized them.
+type notificationEvent struct {
+	name        string
+	description string
+	texts       map[string]notificationText
+}
+
+type notificationText struct {
+	subject string
+	body    string
+}
+
+const notificationSignature = `
+{{.Library.Name}}
+{{with .Library.Address}}{{.}}
+{{end}}{{with .Library.Phone}}Tel. {{.}}
+{{end}}{{with .Library.Website}}{{.}}
+{{end}}`
+
+const notificationSignatureEnglish = `
+{{.Library.Name}}
+{{with .Library.Address}}{{.}}
+{{end}}{{with .Library.Phone}}Phone: {{.}}
+{{end}}{{with .Library.Website}}{{.}}
+{{end}}`
+
+var notificationEvents = []notificationEvent{
+	{
+		name:        NotificationHoldAvailable,
+		description: "El material reservado ya está disponible para recoger",
+		texts: map[string]notificationText{
+			"es": {
+				subject: `Su reservación de «{{.Reservation.Title}}» está lista`,
+				body: `Hola, {{.Patron.Name}}:
+
+El material que reservó ya está disponible y lo apartamos a su nombre:
+
+    {{.Reservation.Title}}
+
+Puede recogerlo en el mostrador de préstamos presentando su credencial ({{.Patron.Code}}). La reservación vence el {{date .Reservation.ExpirationDate}}; después de esa fecha el ejemplar pasa al siguiente usuario en espera.
+` + notificationSignature,
+			},
+			"en": {
+				subject: `Your hold on "{{.Reservation.Title}}" is ready`,
+				body: `Hello {{.Patron.Name}},
+
+The item you placed on hold is now available and has been set aside for you:
+
+    {{.Reservation.Title}}
+
+You can pick it up at the circulation desk with your library card ({{.Patron.Code}}). The hold expires on {{date .Reservation.ExpirationDate}}; after that date the copy goes to the next patron in line.
+` + notificationSignatureEnglish,
+			},
+		},
+	},
+	{
+		name:        NotificationDueSoon,
+		description: "Un préstamo vence en los próximos dos días",
+		texts: map[string]notificationText{
+			"es": {
+				subject: `Su préstamo de «{{.Loan.Title}}» vence el {{date .Loan.DueDate}}`,
+				body: `Hola, {{.Patron.Name}}:
+
+Le recordamos que el siguiente préstamo vence pronto:
+
+    {{.Loan.Title}}{{with .Loan.Authors}} - {{.}}{{end}}
+    Ejemplar: {{.Loan.CopyCode}}
+    Devolver antes de: {{datetime .Loan.DueDate}}
+
+Puede devolverlo en el mostrador o solicitar una renovación si no hay otros usuarios esperándolo. Después de la fecha de vencimiento se genera una multa por cada día de retraso.
+` + notificationSignature,
+			},
+			"en": {
+				subject: `Your loan of "{{.Loan.Title}}" is due on {{date .Loan.DueDate}}`,
+				body: `Hello {{.Patron.Name}},
+
+This is a reminder that the following loan is due soon:
+
+    {{.Loan.Title}}{{with .Loan.Authors}} - {{.}}{{end}}
+    Copy: {{.Loan.CopyCode}}
+    Return by: {{datetime .Loan.DueDate}}
+
+You can return it at the desk or ask for a renewal if no other patron is waiting for it. After the due date a fine is charged for every day it is late.
+` + notificationSignatureEnglish,
+			},
+		},
+	},
+	{
+		name:        NotificationOverdue,
+		description: "Un préstamo no se devolvió a tiempo",
+		texts: map[string]notificationText{
+			"es": {
+				subject: `Préstamo vencido: «{{.Loan.Title}}»`,
+				body: `Hola, {{.Patron.Name}}:
+
+Nuestros registros indican que el siguiente préstamo no ha sido devuelto:
+
+    {{.Loan.Title}}{{with .Loan.Authors}} - {{.}}{{end}}
+    Ejemplar: {{.Loan.CopyCode}}
+    Venció el: {{date .Loan.DueDate}}
+
+Le pedimos devolverlo a la brevedad. Mientras tanto se sigue generando una multa por cada día de retraso y no podrá realizar nuevos préstamos.
+{{- if .TotalDue}}
+
+Adeudo actual por multas: {{money .TotalDue}}
+{{- end}}
+
+Si ya devolvió el material, ignore este mensaje.
+` + notificationSignature,
+			},
+			"en": {
+				subject: `Overdue loan: "{{.Loan.Title}}"`,
+				body: `Hello {{.Patron.Name}},
+
+Our records show that the following loan has not been returned:
+
+    {{.Loan.Title}}{{with .Loan.Authors}} - {{.}}{{end}}
+    Copy: {{.Loan.CopyCode}}
+    Due on: {{date .Loan.DueDate}}
+
+Please return it as soon as possible. Until then a fine is charged for every day it is late and you cannot borrow other items.
+{{- if .TotalDue}}
+
+Current fines due: {{money .TotalDue}}
+{{- end}}
+
+If you have already returned it, please disregard this message.
+` + notificationSignatureEnglish,
+			},
+		},
+	},
+	{
+		name:        NotificationFineCreated,
+		description: "Se generó una multa al usuario",
+		texts: map[string]notificationText{
+			"es": {
+				subject: `Se generó una multa de {{money .Fine.Amount}} a su cuenta`,
+				body: `Hola, {{.Patron.Name}}:
+
+Se registró una multa a su cuenta:
+
+    Folio: {{.Fine.ID}}
+    Concepto: {{.Fine.Reason}}{{with .Fine.LoanCode}} (préstamo {{.}}){{end}}
+    Importe: {{money .Fine.Amount}}
+    Fecha: {{date .Fine.GeneratedDate}}
+
+Adeudo total por multas: {{money .TotalDue}}
+
+Mientras tenga multas pendientes no podrá realizar préstamos, renovaciones ni reservaciones. Puede pagarlas en el mostrador de la biblioteca.
+` + notificationSignature,
+			},
+			"en": {
+				subject: `A fine of {{money .Fine.Amount}} was charged to your account`,
+				body: `Hello {{.Patron.Name}},
+
+A fine was charged to your account:
+
+    Number: {{.Fine.ID}}
+    Reason: {{.Fine.Reason}}{{with .Fine.LoanCode}} (loan {{.}}){{end}}
+    Amount: {{money .Fine.Amount}}
+    Date: {{date .Fine.GeneratedDate}}
+
+Total fines due: {{money .TotalDue}}
+
+While you have pending fines you cannot borrow, renew or place holds. You can pay them at the library desk.
+` + notificationSignatureEnglish,
+			},
+		},
+	},
+	{
+		name:        NotificationCardExpiring,
+		description: "La credencial del usuario vence en los próximos 30 días",
+		texts: map[string]notificationText{
+			"es": {
+				subject: `Su credencial de la biblioteca vence el {{date .CardExpiration}}`,
+				body: `Hola, {{.Patron.Name}}:
+
+Su credencial de {{.Library.Name}} ({{.Patron.Code}}) vence el {{date .CardExpiration}}.
+
+Para seguir usando los servicios de la biblioteca, acuda al mostrador con una identificación vigente para renovarla.
+` + notificationSignature,
+			},
+			"en": {
+				subject: `Your library card expires on {{date .CardExpiration}}`,
+				body: `Hello {{.Patron.Name}},
+
+Your {{.Library.Name}} card ({{.Patron.Code}}) expires on {{date .CardExpiration}}.
+
+To keep using the library services, please come to the desk with a valid ID to renew it.
+` + notificationSignatureEnglish,
+			},
+		},
+	},
+}
+
+type NotificationService struct {
+	notificationStore store.INotificationStore
+	templateStore     store.INotificationTemplateStore
+	preferenceStore   store.INotificationPreferenceStore
+	libraryService    *LibraryService
+	userStore         store.IUserStore
+	loanStore         store.ILoanStore
+	fineStore         store.IFineStore
+	reservationStore  store.IReservationStore
+	copyStore         store.ICopyStore
+	bookStore         store.IBookStore
+	notifiers         map[string]notify.Notifier // By channel, only the configured ones
+	auditService      *AuditService
+}
+
+// delivery is what to do with a notification: send the message through the
+// channel, skip it or leave it for later.
+type delivery struct {
+	msg        *notify.Message
+	channel    string
+	skipReason string
+	deferUntil time.Time
+}
+
+func NewNotificationService(notificationStore store.INotificationStore, templateStore store.INotificationTemplateStore, preferenceStore store.INotificationPreferenceStore, libraryService *LibraryService, userStore store.IUserStore, loanStore store.ILoanStore, fineStore store.IFineStore, reservationStore store.IReservationStore, copyStore store.ICopyStore, bookStore store.IBookStore, notifiers map[string]notify.Notifier, auditService *AuditService) *NotificationService {
+	return &NotificationService{
+		notificationStore: notificationStore,
+		templateStore:     templateStore,
+		preferenceStore:   preferenceStore,
+		libraryService:    libraryService,
+		userStore:         userStore,
+		loanStore:         loanStore,
+		fineStore:         fineStore,
+		reservationStore:  reservationStore,
+		copyStore:         copyStore,
+		bookStore:         bookStore,
+		notifiers:         notifiers,
+		auditService:      auditService,
+	}
+}
+
+func (s *NotificationService) GetNotifications(libraryID int64, filter store.NotificationFilter) ([]*models.Notification, error) {
+	notifications, err := s.notificationStore.GetAll(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las notificaciones: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (s *NotificationService) GetNotification(libraryID, id int64) (*models.Notification, error) {
+	notification, err := s.notificationStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Notificación con ID %d no encontrada", id)
+	}
+
+	return notification, nil
+}
+
+// RetryNotification puts a failed notification back in the outbox, with its
+// attempts starting over. Marking it mandatory sends it regardless of the
+// patron's preferences, which also allows retrying one that was skipped.
+func (s *NotificationService) RetryNotification(ctx context.Context, libraryID, id int64, mandatory bool) (*models.Notification, error) {
+	notification, err := s.GetNotification(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if notification.Status != "Failed" && (notification.Status != "Skipped" || !mandatory) {
+		return nil, fmt.Errorf("Solo se pueden reintentar las notificaciones fallidas, o las omitidas si se marcan como obligatorias")
+	}
+
+	previousNotification := *notification
+
+	notification.Status = "Pending"
+	notification.Attempts = 0
+	notification.NextAttemptAt.Time = time.Now()
+	notification.NextAttemptAt.Valid = true
+	notification.Mandatory = notification.Mandatory || mandatory
+
+	if err := s.notificationStore.UpdateDelivery(libraryID, notification); err != nil {
+		return nil, fmt.Errorf("Error al reintentar la notificación: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "notification", id, "retry", &previousNotification, notification); err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+// GetTemplates returns the template of every event, the library's own or the
+// default one, in the language given or in all of them.
+func (s *NotificationService) GetTemplates(libraryID int64, language string) ([]*models.NotificationTemplate, error) {
+	languages := notificationLanguages
+	if language != "" {
+		if err := validateNotificationLanguage(language); err != nil {
+			return nil, err
+		}
+
+		languages = []string{language}
+	}
+
+	customized, err := s.templateStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las plantillas: %w", err)
+	}
+
+	byType := make(map[string]*models.NotificationTemplate, len(customized))
+	for _, tmpl := range customized {
+		byType[tmpl.EventType+":"+tmpl.Language] = tmpl
+	}
+
+	templates := make([]*models.NotificationTemplate, 0, len(notificationEvents)*len(languages))
+
+	for _, e := range notificationEvents {
+		for _, language := range languages {
+			tmpl, ok := byType[e.name+":"+language]
+			if !ok {
+				tmpl = defaultNotificationTemplate(libraryID, e, language)
+			}
+
+			tmpl.Description = e.description
+			templates = append(templates, tmpl)
+		}
+	}
+
+	return templates, nil
+}
+
+func (s *NotificationService) GetTemplate(libraryID int64, eventType, language string) (*models.NotificationTemplate, error) {
+	e, err := findNotificationEvent(eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	if language == "" {
+		language = defaultNotificationLanguage
+	}
+
+	if err := validateNotificationLanguage(language); err != nil {
+		return nil, err
+	}
+
+	tmpl, err := s.templateStore.GetByType(libraryID, e.name, language)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la plantilla %s: %w", e.name, err)
+	}
+
+	if tmpl == nil {
+		tmpl = defaultNotificationTemplate(libraryID, e, language)
+	}
+
+	tmpl.Description = e.description
+
+	return tmpl, nil
+}
+
+// SaveTemplate replaces the template of an event for the library, after
+// trying it against sample data.
+func (s *NotificationService) SaveTemplate(ctx context.Context, libraryID int64, eventType, language string, tmpl *models.NotificationTemplate) (*models.NotificationTemplate, error) {
+	e, err := findNotificationEvent(eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	if language == "" {
+		language = defaultNotificationLanguage
+	}
+
+	tmpl.Language = language
+
+	if err := validations.ValidateNotificationTemplate(tmpl); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	tmpl.EventType = e.name
+
+	if _, _, err := renderNotification(tmpl, sampleNotificationData()); err != nil {
+		return nil, err
+	}
+
+	previousTemplate, err := s.templateStore.GetByType(libraryID, e.name, language)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la plantilla %s: %w", e.name, err)
+	}
+
+	savedTemplate, err := s.templateStore.Save(libraryID, tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("Error al guardar la plantilla: %w", err)
+	}
+
+	action := "update"
+	if previousTemplate == nil {
+		action = "create"
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "notification_template", 0, action, previousTemplate, savedTemplate); err != nil {
+		return nil, err
+	}
+
+	savedTemplate.Description = e.description
+
+	return savedTemplate, nil
+}
+
+// ResetTemplate drops the library's template so the default one is used again.
+func (s *NotificationService) ResetTemplate(ctx context.Context, libraryID int64, eventType, language string) error {
+	e, err := findNotificationEvent(eventType)
+	if err != nil {
+		return err
+	}
+
+	if language == "" {
+		language = defaultNotificationLanguage
+	}
+
+	if err := validateNotificationLanguage(language); err != nil {
+		return err
+	}
+
+	previousTemplate, err := s.templateStore.GetByType(libraryID, e.name, language)
+	if err != nil {
+		return fmt.Errorf("Error al obtener la plantilla %s: %w", e.name, err)
+	}
+
+	if err := s.templateStore.Delete(libraryID, e.name, language); err != nil {
+		return fmt.Errorf("Error al restablecer la plantilla: %w", err)
+	}
+
+	if previousTemplate != nil {
+		if err := s.auditService.Record(ctx, libraryID, "notification_template", 0, "delete", previousTemplate, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Dispatch writes the events due by now to the outbox and delivers the
+// pending notifications of every library. It returns how many were sent.
+func (s *NotificationService) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	if len(s.notifiers) == 0 {
+		return 0, fmt.Errorf("No hay un medio configurado para enviar las notificaciones")
+	}
+
+	if _, err := s.notificationStore.EnqueueScheduled(now, dueSoonWindow, cardExpiringWindow); err != nil {
+		return 0, fmt.Errorf("Error al registrar las notificaciones programadas: %w", err)
+	}
+
+	notifications, err := s.notificationStore.GetReady(now, notificationBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("Error al obtener las notificaciones pendientes: %w", err)
+	}
+
+	sent := 0
+
+	for _, notification := range notifications {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := s.deliver(ctx, notification, now); err != nil {
+			return sent, err
+		}
+
+		if notification.Status == "Sent" {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// deliver sends one notification and records the outcome. Only errors saving
+// that outcome are returned; delivery errors are kept in the notification.
+func (s *NotificationService) deliver(ctx context.Context, notification *models.Notification, now time.Time) error {
+	d, err := s.message(notification, now)
+	if err == nil && d.skipReason == "" && d.deferUntil.IsZero() {
+		err = s.notifiers[d.channel].Send(ctx, *d.msg)
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if d.channel != "" {
+		notification.Channel.String = d.channel
+		notification.Channel.Valid = true
+	}
+
+	switch {
+		case d.skipReason != "":
+			notification.Status = "Skipped"
+			notification.LastError.String = d.skipReason
+			notification.LastError.Valid = true
+		case !d.deferUntil.IsZero():
+			notification.NextAttemptAt.Time = d.deferUntil
+			notification.NextAttemptAt.Valid = true
+		case err != nil:
+			notification.Attempts++
+			notification.LastError.String = err.Error()
+			notification.LastError.Valid = true
+
+			if notify.IsPermanent(err) || notification.Attempts > len(notificationRetryDelays) {
+				notification.Status = "Failed"
+			} else {
+				notification.NextAttemptAt.Time = now.Add(notificationRetryDelays[notification.Attempts-1])
+				notification.NextAttemptAt.Valid = true
+			}
+		default:
+			notification.Status = "Sent"
+			notification.Attempts++
+			notification.Recipient.String = d.msg.To
+			notification.Recipient.Valid = true
+			notification.LastError.Valid = false
+			notification.SentAt.Time = now
+			notification.SentAt.Valid = true
+	}
+
+	if err := s.notificationStore.UpdateDelivery(notification.LibraryID, notification); err != nil {
+		return fmt.Errorf("Error al guardar el resultado de la notificación %d: %w", notification.ID, err)
+	}
+
+	if notification.Status == "Sent" && notification.EventType == NotificationHoldAvailable {
+		s.markReservationNotified(notification)
+	}
+
+	return nil
+}
+
+// message builds the message of a notification from the current state of its
+// subject and the preferences of the patron. When the event no longer applies,
+// like a loan returned before the notice went out, or the patron opted out of
+// it, it returns the reason to skip it instead; during the patron's quiet
+// hours it returns when they end. Mandatory notices wait for the quiet hours
+// too: they must reach the patron, not wake them up.
+func (s *NotificationService) message(notification *models.Notification, now time.Time) (delivery, error) {
+	libraryID := notification.LibraryID
+
+	library, err := s.libraryService.GetLibraryByID(libraryID)
+	if err != nil {
+		return delivery{}, err
+	}
+
+	user, err := s.userStore.GetByID(libraryID, notification.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return delivery{skipReason: "El usuario ya no existe"}, nil
+	}
+
+	if err != nil {
+		return delivery{}, fmt.Errorf("Error al obtener el usuario con ID %d: %w", notification.UserID, err)
+	}
+
+	preferences, err := s.preferencesOf(libraryID, user.ID)
+	if err != nil {
+		return delivery{}, err
+	}
+
+	channel, skipReason := s.channelFor(notification, user, preferences)
+	if skipReason != "" {
+		return delivery{skipReason: skipReason}, nil
+	}
+
+	if until, quiet := quietHoursEnd(preferences, now); quiet {
+		return delivery{deferUntil: until}, nil
+	}
+
+	language := preferences.Language
+
+	userID := user.ID
+	pending, err := s.fineStore.GetFinesFiltered(libraryID, store.FineFilter{UserID: &userID, Status: "Pending"})
+	if err != nil {
+		return delivery{}, fmt.Errorf("Error al obtener las multas del usuario: %w", err)
+	}
+
+	data := &models.NotificationData{
+		Library: models.DocumentLibrary{
+			Name:    library.Name,
+			Address: libraryAddress(library),
+			Phone:   library.Phone,
+			Email:   library.Email,
+			Website: library.Website,
+		},
+		Patron: models.DocumentPatron{
+			Code:     user.Code,
+			Name:     user.FirstName + " " + user.LastName,
+			UserType: notificationLabel(userTypeLabels, user.UserType, language),
+			Email:    user.Email.String,
+			Phone:    user.Phone.String,
+		},
+		GeneratedAt: now,
+	}
+
+	for _, fine := range pending {
+		data.TotalDue += fine.Amount
+	}
+
+	skipReason, err = s.subjectData(notification, data, user, language, now)
+	if err != nil || skipReason != "" {
+		return delivery{skipReason: skipReason}, err
+	}
+
+	tmpl, err := s.GetTemplate(libraryID, notification.EventType, language)
+	if err != nil {
+		return delivery{}, err
+	}
+
+	subject, body, err := renderNotification(tmpl, data)
+	if err != nil {
+		return delivery{}, err
+	}
+
+	msg := &notify.Message{
+		ID:       fmt.Sprintf("notification-%d-%d", libraryID, notification.ID),
+		FromName: library.Name,
+		ReplyTo:  library.Email,
+		To:       strings.TrimSpace(user.Email.String),
+		Subject:  subject,
+		Body:     body,
+	}
+
+	if channel == notificationChannelSMS {
+		msg.To = strings.TrimSpace(user.Phone.String)
+		msg.Body = library.Name + ": " + subject
+	}
+
+	return delivery{msg: msg, channel: channel}, nil
+}
+
+func (s *NotificationService) subjectData(notification *models.Notification, data *models.NotificationData, user *models.User, language string, now time.Time) (string, error) {
+	libraryID := notification.LibraryID
+	subjectID := notification.SubjectID.Int64
+
+	switch notification.EventType {
+		case NotificationHoldAvailable:
+			reservation, err := s.reservationStore.GetByID(libraryID, subjectID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return "La reservación ya no existe", nil
+			}
+
+			if err != nil {
+				return "", fmt.Errorf("Error al obtener la reservación con ID %d: %w", subjectID, err)
+			}
+
+			if reservation.Status != "Active" {
+				return "La reservación ya no está lista para recoger", nil
+			}
+
+			book, err := s.bookStore.GetByID(libraryID, reservation.BookID)
+			if err != nil {
+				return "", fmt.Errorf("Error al obtener el libro con ID %d: %w", reservation.BookID, err)
+			}
+
+			data.Reservation = &models.DocumentReservation{
+				Title:           book.Title,
+				ReservationDate: reservation.ReservationDate,
+				ExpirationDate:  reservation.ExpirationDate,
+				Status:          notificationLabel(reservationStatusLabels, reservation.Status, language),
+				Priority:        reservation.Priority,
+			}
+
+		case NotificationDueSoon, NotificationOverdue:
+			loan, err := s.loanStore.GetByID(libraryID, subjectID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return "El préstamo ya no existe", nil
+			}
+
+			if err != nil {
+				return "", fmt.Errorf("Error al obtener el préstamo con ID %d: %w", subjectID, err)
+			}
+
+			if loan.ReturnDate.Valid || (loan.Status != "Active" && loan.Status != "Overdue") {
+				return "El préstamo ya fue devuelto", nil
+			}
+
+			if notification.EventType == NotificationDueSoon && !loan.DueDate.After(now) {
+				return "El préstamo ya venció", nil
+			}
+
+			documentLoan, err := s.loanData(libraryID, loan, language, now)
+			if err != nil {
+				return "", err
+			}
+
+			data.Loan = &documentLoan
+
+		case NotificationFineCreated:
+			fine, err := s.fineStore.GetByID(libraryID, subjectID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return "La multa ya no existe", nil
+			}
+
+			if err != nil {
+				return "", fmt.Errorf("Error al obtener la multa con ID %d: %w", subjectID, err)
+			}
+
+			if fine.Status != "Pending" {
+				return "La multa ya no está pendiente", nil
+			}
+
+			data.Fine = &models.DocumentFine{
+				ID:            fine.ID,
+				Reason:        notificationLabel(fineReasonLabels, fine.Reason, language),
+				Amount:        fine.Amount,
+				Status:        notificationLabel(fineStatusLabels, fine.Status, language),
+				GeneratedDate: fine.GeneratedDate,
+				Notes:         fine.Notes.String,
+			}
+
+			if fine.LoanID.Valid {
+				loan, err := s.loanStore.GetByID(libraryID, fine.LoanID.Int64)
+				if err == nil {
+					data.Fine.LoanCode = loan.LoanCode
+				}
+			}
+
+		case NotificationCardExpiring:
+			if !user.CardExpiration.Valid || !user.CardExpiration.Time.After(now) {
+				return "La credencial ya no está por vencer", nil
+			}
+
+			data.CardExpiration = &user.CardExpiration.Time
+	}
+
+	return "", nil
+}
+
+func (s *NotificationService) loanData(libraryID int64, loan *models.Loan, language string, now time.Time) (models.DocumentLoan, error) {
+	copy, err := s.copyStore.GetByID(libraryID, loan.CopyID)
+	if err != nil {
+		return models.DocumentLoan{}, fmt.Errorf("Error al obtener la copia con ID %d: %w", loan.CopyID, err)
+	}
+
+	book, err := s.bookStore.GetByID(libraryID, copy.BookID)
+	if err != nil {
+		return models.DocumentLoan{}, fmt.Errorf("Error al obtener el libro con ID %d: %w", copy.BookID, err)
+	}
+
+	authors, err := s.bookStore.GetBookAuthors(libraryID, book.ID)
+	if err != nil {
+		return models.DocumentLoan{}, fmt.Errorf("Error al obtener los autores del libro con ID %d: %w", book.ID, err)
+	}
+
+	names := make([]string, 0, len(authors))
+	for _, author := range authors {
+		names = append(names, author.FirstName+" "+author.LastName)
+	}
+
+	documentLoan := models.DocumentLoan{
+		Code:       loan.LoanCode,
+		Title:      book.Title,
+		Authors:    strings.Join(names, ", "),
+		CopyCode:   copy.Code,
+		CallNumber: book.CallNumber.String,
+		LoanDate:   loan.LoanDate,
+		DueDate:    loan.DueDate,
+		Status:     notificationLabel(loanStatusLabels, loan.Status, language),
+		Renewals:   loan.Renewals,
+	}
+
+	if loan.DueDate.Before(now) {
+		documentLoan.DaysOverdue = max(1, int(now.Sub(loan.DueDate).Hours()/24))
+	}
+
+	return documentLoan, nil
+}
+
+// markReservationNotified keeps reservations.notified in step with the
+// outbox. The notification is already out, so a failure here is not retried.
+func (s *NotificationService) markReservationNotified(notification *models.Notification) {
+	reservation, err := s.reservationStore.GetByID(notification.LibraryID, notification.SubjectID.Int64)
+	if err != nil {
+		return
+	}
+
+	reservation.Notified = true
+	s.reservationStore.Update(notification.LibraryID, reservation.ID, reservation)
+}
+
+// renderNotification applies a template and returns the subject, on a single
+// line, and the body.
+func renderNotification(tmpl *models.NotificationTemplate, data *models.NotificationData) (string, string, error) {
+	subjectTemplate, err := documents.Parse(tmpl.EventType+"_subject", tmpl.Subject)
+	if err != nil {
+		return "", "", err
+	}
+
+	bodyTemplate, err := documents.Parse(tmpl.EventType, tmpl.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	subject, err := documents.Execute(subjectTemplate, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	body, err := documents.Execute(bodyTemplate, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	subject = strings.Join(strings.Fields(subject), " ")
+	if subject == "" {
+		return "", "", fmt.Errorf("El asunto del mensaje quedó vacío")
+	}
+
+	body = blankLinesRegex.ReplaceAllString(strings.TrimSpace(body), "\n\n") + "\n"
+
+	return subject, body, nil
+}
+
+// sampleNotificationData fills every field so a template can be tried before
+// it is saved.
+func sampleNotificationData() *models.NotificationData {
+	now := time.Now()
+	expiration := now.AddDate(0, 0, 20)
+
+	return &models.NotificationData{
+		Library: models.DocumentLibrary{
+			Name:    "Biblioteca",
+			Address: "Av. Principal 100, Centro",
+			Phone:   "555 000 0000",
+			Email:   "biblioteca@example.com",
+			Website: "https://example.com",
+		},
+		Patron: models.DocumentPatron{
+			Code:     "U0001",
+			Name:     "Ana Pérez",
+			UserType: "Estudiante",
+			Email:    "ana@example.com",
+			Phone:    "555 111 1111",
+		},
+		Loan: &models.DocumentLoan{
+			Code:       "L-0001",
+			Title:      "Cien años de soledad",
+			Authors:    "Gabriel García Márquez",
+			CopyCode:   "C00001",
+			CallNumber: "863 G216c",
+			LoanDate:   now.AddDate(0, 0, -13),
+			DueDate:    now.AddDate(0, 0, 2),
+			Status:     "Activo",
+		},
+		Fine: &models.DocumentFine{
+			ID:            1,
+			Reason:        "Retraso",
+			Amount:        30,
+			Status:        "Pendiente",
+			GeneratedDate: now,
+			LoanCode:      "L-0001",
+		},
+		Reservation: &models.DocumentReservation{
+			Title:           "Pedro Páramo",
+			ReservationDate: now.AddDate(0, 0, -3),
+			ExpirationDate:  now.AddDate(0, 0, 4),
+			Status:          "Lista para recoger",
+			Priority:        1,
+		},
+		CardExpiration: &expiration,
+		TotalDue:       30,
+		GeneratedAt:    now,
+	}
+}
+
+// notificationLabel is documentLabel without the escaping, the messages are
+// plain text. The labels are Spanish, in English the values are already
+// readable.
+func notificationLabel(labels map[string]string, value, language string) string {
+	if language != defaultNotificationLanguage {
+		return value
+	}
+
+	if label, ok := labels[value]; ok {
+		return label
+	}
+
+	return value
+}
+
+func defaultNotificationTemplate(libraryID int64, e notificationEvent, language string) *models.NotificationTemplate {
+	text := e.texts[language]
+
+	return &models.NotificationTemplate{
+		EventType: e.name,
+		Language:  language,
+		Subject:   text.subject,
+		Body:      text.body,
+		LibraryID: libraryID,
+	}
+}
+
+func validateNotificationLanguage(language string) error {
+	if !slices.Contains(notificationLanguages, language) {
+		return fmt.Errorf("El idioma debe ser: %s", strings.Join(notificationLanguages, ", "))
+	}
+
+	return nil
+}
+
+func findNotificationEvent(name string) (notificationEvent, error) {
+	names := make([]string, 0, len(notificationEvents))
+
+	for _, e := range notificationEvents {
+		if e.name == name {
+			return e, nil
+		}
+
+		names = append(names, e.name)
+	}
+
+	return notificationEvent{}, fmt.Errorf("El tipo de notificación debe ser: %s", strings.Join(names, ", "))
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,11 +13,13 @@ import (
 
 type PublisherService struct {
 	publisherStore store.IPublisherStore
+	auditService   *AuditService
 }
 
-func NewPublisherService(publisherStore store.IPublisherStore) *PublisherService {
+func NewPublisherService(publisherStore store.IPublisherStore, auditService *AuditService) *PublisherService {
 	return &PublisherService{
 		publisherStore: publisherStore,
+		auditService:   auditService,
 	}
 }
 
@@ -37,7 +40,7 @@ func (s *PublisherService) GetPublisherByID(libraryID, id int64) (*models.Publis
 	return publisher, nil
 }
 
-func (s *PublisherService) CreatePublisher(libraryID int64, publisher *models.Publisher) (*models.Publisher, error) {
+func (s *PublisherService) CreatePublisher(ctx context.Context, libraryID int64, publisher *models.Publisher) (*models.Publisher, error) {
 	if err := validations.ValidatePublisher(publisher); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -54,10 +57,14 @@ func (s *PublisherService) CreatePublisher(libraryID int64, publisher *models.Pu
 		return nil, fmt.Errorf("Error al crear la editorial: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "publisher", createdPublisher.ID, "create", nil, createdPublisher); err != nil {
+		return nil, err
+	}
+
 	return createdPublisher, nil
 }
 
-func (s *PublisherService) UpdatePublisher(libraryID, id int64, publisher *models.Publisher) (*models.Publisher, error) {
+func (s *PublisherService) UpdatePublisher(ctx context.Context, libraryID, id int64, publisher *models.Publisher) (*models.Publisher, error) {
 	if id <= 0 {
 		return nil, errors.New("El id de la editorial es invalido")
 	}
@@ -86,10 +93,14 @@ func (s *PublisherService) UpdatePublisher(libraryID, id int64, publisher *model
 		return nil, fmt.Errorf("Error al actualizar la editorial con ID %d: %w", id, err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "publisher", id, "update", existingPublisher, updatedPublisher); err != nil {
+		return nil, err
+	}
+
 	return updatedPublisher, nil
 }
 
-func (s *PublisherService) DeletePublisher(libraryID, id int64) error {
+func (s *PublisherService) DeletePublisher(ctx context.Context, libraryID, id int64) error {
 	if id <= 0 {
 		return errors.New("El id de la editorial es invalido")
 	}
@@ -107,5 +118,5 @@ func (s *PublisherService) DeletePublisher(libraryID, id int64) error {
 		return fmt.Errorf("Error al eliminar la editorial con ID %d: %w", id, err)
 	}
 
-	return nil
+	return s.auditService.Record(ctx, libraryID, "publisher", id, "delete", existingPublisher, nil)
 }
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/metrics"
+)
+
+// ReportScheduler runs the scheduled reports in process, checking every
+// interval for definitions whose next run has come.
+type ReportScheduler struct {
+	reportService *ScheduledReportService
+	interval      time.Duration
+}
+
+func NewReportScheduler(reportService *ScheduledReportService, interval time.Duration) *ReportScheduler {
+	return &ReportScheduler{
+		reportService: reportService,
+		interval:      interval,
+	}
+}
+
+// Start blocks until the context is cancelled.
+func (s *ReportScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				_, err := s.reportService.RunDue(now)
+				metrics.ObserveJob("report_scheduler", now, err)
+
+				if err != nil {
+					slog.WarnContext(ctx, "Error al ejecutar los reportes programados", "error", err)
+				}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+const (
+	DefaultWeedingIdleMonths = 24
+	DefaultWeedingMaxAge     = 15
+	DefaultWeedingHoldsRatio = 3.0
+)
+
+type ReportService struct {
+	reportStore store.IReportStore
+	zoneStore   store.ILibraryZoneStore
+}
+
+func NewReportService(reportStore store.IReportStore, zoneStore store.ILibraryZoneStore) *ReportService {
+	return &ReportService{
+		reportStore: reportStore,
+		zoneStore:   zoneStore,
+	}
+}
+
+// GetWeedingReport gathers the collection-health lists used to decide which
+// copies to weed and which titles to buy. Zero values take the defaults.
+func (s *ReportService) GetWeedingReport(libraryID int64, zoneID *int64, months, maxAge int, holdsRatio float64) (*models.WeedingReport, error) {
+	if months == 0 {
+		months = DefaultWeedingIdleMonths
+	}
+
+	if maxAge == 0 {
+		maxAge = DefaultWeedingMaxAge
+	}
+
+	if holdsRatio == 0 {
+		holdsRatio = DefaultWeedingHoldsRatio
+	}
+
+	if months < 1 || months > 240 {
+		return nil, errors.New("Los meses sin préstamo deben estar entre 1 y 240")
+	}
+
+	if maxAge < 1 || maxAge > 500 {
+		return nil, errors.New("La antigüedad máxima debe estar entre 1 y 500 años")
+	}
+
+	if holdsRatio < 0.1 || holdsRatio > 100 {
+		return nil, errors.New("La proporción de reservas por copia debe estar entre 0.1 y 100")
+	}
+
+	report := &models.WeedingReport{
+		GeneratedAt: time.Now(),
+		IdleMonths:  months,
+		MaxAge:      maxAge,
+		HoldsRatio:  holdsRatio,
+	}
+
+	if zoneID != nil {
+		if _, err := s.zoneStore.GetByID(libraryID, *zoneID); err != nil {
+			return nil, fmt.Errorf("La zona con ID %d no existe: %w", *zoneID, err)
+		}
+
+		report.ZoneID.Int64 = *zoneID
+		report.ZoneID.Valid = true
+	}
+
+	filter := store.WeedingFilter{
+		ZoneID:    zoneID,
+		IdleSince: report.GeneratedAt.AddDate(0, -months, 0),
+		OldBefore: report.GeneratedAt.Year() - maxAge,
+	}
+
+	var err error
+
+	report.NotLoaned, err = s.reportStore.GetIdleCopies(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las copias sin préstamo: %w", err)
+	}
+
+	report.PoorCondition, err = s.reportStore.GetPoorCopies(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las copias en mal estado: %w", err)
+	}
+
+	titles, err := s.reportStore.GetTitleDemand(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la demanda de los títulos: %w", err)
+	}
+
+	for _, title := range titles {
+		if title.PendingReservations > 0 {
+			if float64(title.PendingReservations) < holdsRatio*float64(title.Copies) {
+				continue
+			}
+
+			title.SuggestedChange = int(math.Ceil(float64(title.PendingReservations)/holdsRatio)) - title.Copies
+			if title.SuggestedChange < 1 {
+				title.SuggestedChange = 1
+			}
+
+			report.Understocked = append(report.Understocked, title)
+			continue
+		}
+
+		// Without a queue one copy per recent loan is enough, and at least one
+		surplus := title.Copies - max(1, title.RecentLoans)
+		if surplus > 0 {
+			title.SuggestedChange = -surplus
+			report.Overstocked = append(report.Overstocked, title)
+		}
+	}
+
+	categories, err := s.reportStore.GetCategoryAges(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la antigüedad de las categorías: %w", err)
+	}
+
+	for _, category := range categories {
+		if category.OldBooks == 0 {
+			continue
+		}
+
+		category.OldShare = math.Round(float64(category.OldBooks)/float64(category.Books)*10000) / 10000
+		report.AgingCategories = append(report.AgingCategories, category)
+	}
+
+	return report, nil
+}
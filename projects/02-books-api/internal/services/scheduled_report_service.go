@@ -0,0 +1,599 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/export"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/schedule"
+	"github.com/chicho69-cesar/backend-go/books/internal/storage"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+const DefaultReportFormat = "csv"
+
+var reportRanges = []string{"previous_month", "current_month", "last_7_days", "last_30_days"}
+
+// reportType is an entry of the registry: its description and how to build the
+// table for a library with the given parameters at a moment.
+type reportType struct {
+	models.ReportType
+	build func(s *ScheduledReportService, libraryID int64, params map[string]string, now time.Time) (*export.Table, error)
+}
+
+var reportTypes = []reportType{
+	{
+		ReportType: models.ReportType{
+			Name:        "overdue",
+			Description: "Préstamos vencidos sin devolver con los días de atraso",
+			Parameters: []models.ReportParameter{
+				{Name: "min_days", Description: "Días mínimos de atraso", Default: "1"},
+			},
+		},
+		build: buildOverdueReport,
+	},
+	{
+		ReportType: models.ReportType{
+			Name:        "fines",
+			Description: "Multas generadas en el periodo",
+			Parameters: []models.ReportParameter{
+				{Name: "range", Description: "Periodo del reporte", Default: "previous_month", Options: reportRanges},
+				{Name: "status", Description: "Estado de las multas (todas si se omite)", Options: []string{"Pending", "Paid", "Waived"}},
+			},
+		},
+		build: buildFinesReport,
+	},
+	{
+		ReportType: models.ReportType{
+			Name:        "circulation",
+			Description: "Préstamos, devoluciones, renovaciones, usuarios nuevos y multas cobradas por periodo",
+			Parameters: []models.ReportParameter{
+				{Name: "range", Description: "Periodo del reporte", Default: "previous_month", Options: reportRanges},
+				{Name: "interval", Description: "Agrupación", Default: "day", Options: []string{"day", "week", "month"}},
+			},
+		},
+		build: buildCirculationReport,
+	},
+}
+
+var reportFileNameRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+var reportFileNameAccents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+
+type ScheduledReportService struct {
+	definitionStore store.IReportDefinitionStore
+	runStore        store.IReportRunStore
+	reportStore     store.IReportStore
+	statsService    *StatsService
+	blobStore       storage.BlobStore
+	auditService    *AuditService
+}
+
+func NewScheduledReportService(definitionStore store.IReportDefinitionStore, runStore store.IReportRunStore, reportStore store.IReportStore, statsService *StatsService, blobStore storage.BlobStore, auditService *AuditService) *ScheduledReportService {
+	return &ScheduledReportService{
+		definitionStore: definitionStore,
+		runStore:        runStore,
+		reportStore:     reportStore,
+		statsService:    statsService,
+		blobStore:       blobStore,
+		auditService:    auditService,
+	}
+}
+
+func (s *ScheduledReportService) GetReportTypes() []models.ReportType {
+	types := make([]models.ReportType, 0, len(reportTypes))
+	for _, t := range reportTypes {
+		types = append(types, t.ReportType)
+	}
+
+	return types
+}
+
+func (s *ScheduledReportService) GetDefinitions(libraryID int64) ([]*models.ReportDefinition, error) {
+	definitions, err := s.definitionStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los reportes: %w", err)
+	}
+
+	return definitions, nil
+}
+
+func (s *ScheduledReportService) GetDefinitionByID(libraryID, id int64) (*models.ReportDefinition, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID del reporte es inválido")
+	}
+
+	definition, err := s.definitionStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener el reporte con ID %d: %w", id, err)
+	}
+
+	return definition, nil
+}
+
+func (s *ScheduledReportService) CreateDefinition(ctx context.Context, libraryID int64, definition *models.ReportDefinition) (*models.ReportDefinition, error) {
+	if err := s.checkDefinition(libraryID, 0, definition); err != nil {
+		return nil, err
+	}
+
+	createdDefinition, err := s.definitionStore.Create(libraryID, definition)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear el reporte: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "report_definition", createdDefinition.ID, "create", nil, createdDefinition); err != nil {
+		return nil, err
+	}
+
+	return createdDefinition, nil
+}
+
+func (s *ScheduledReportService) UpdateDefinition(ctx context.Context, libraryID, id int64, definition *models.ReportDefinition) (*models.ReportDefinition, error) {
+	existingDefinition, err := s.GetDefinitionByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkDefinition(libraryID, id, definition); err != nil {
+		return nil, err
+	}
+
+	updatedDefinition, err := s.definitionStore.Update(libraryID, id, definition)
+	if err != nil {
+		return nil, fmt.Errorf("Error al actualizar el reporte: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "report_definition", id, "update", existingDefinition, updatedDefinition); err != nil {
+		return nil, err
+	}
+
+	return updatedDefinition, nil
+}
+
+// DeleteDefinition removes the definition, its runs and their files.
+func (s *ScheduledReportService) DeleteDefinition(ctx context.Context, libraryID, id int64) error {
+	existingDefinition, err := s.GetDefinitionByID(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	runs, err := s.runStore.GetAll(libraryID, store.ReportRunFilter{DefinitionID: &id})
+	if err != nil {
+		return fmt.Errorf("Error al obtener las ejecuciones del reporte: %w", err)
+	}
+
+	for _, run := range runs {
+		if !run.FileKey.Valid {
+			continue
+		}
+
+		if err := s.blobStore.Delete(run.FileKey.String); err != nil {
+			return fmt.Errorf("Error al eliminar el archivo %s: %w", run.FileName.String, err)
+		}
+	}
+
+	if err := s.definitionStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar el reporte: %w", err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "report_definition", id, "delete", existingDefinition, nil)
+}
+
+// checkDefinition validates the definition against the registry and computes
+// its next run from the schedule.
+func (s *ScheduledReportService) checkDefinition(libraryID, id int64, definition *models.ReportDefinition) error {
+	if err := validations.ValidateReportDefinition(definition); err != nil {
+		return fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	definition.Name = strings.TrimSpace(definition.Name)
+
+	if definition.Format == "" {
+		definition.Format = DefaultReportFormat
+	}
+
+	reportType, ok := findReportType(definition.ReportType)
+	if !ok {
+		names := make([]string, 0, len(reportTypes))
+		for _, t := range reportTypes {
+			names = append(names, t.Name)
+		}
+
+		return fmt.Errorf("El tipo de reporte debe ser: %s", strings.Join(names, ", "))
+	}
+
+	if definition.Parameters == nil {
+		definition.Parameters = map[string]string{}
+	}
+
+	for name, value := range definition.Parameters {
+		parameter, ok := findReportParameter(reportType, name)
+		if !ok {
+			return fmt.Errorf("El reporte %s no tiene el parámetro %s", reportType.Name, name)
+		}
+
+		if len(parameter.Options) > 0 && !containsString(parameter.Options, value) {
+			return fmt.Errorf("El parámetro %s debe ser: %s", name, strings.Join(parameter.Options, ", "))
+		}
+	}
+
+	if value, ok := definition.Parameters["min_days"]; ok {
+		if days, err := strconv.Atoi(value); err != nil || days < 1 {
+			return errors.New("El parámetro min_days debe ser un número mayor a cero")
+		}
+	}
+
+	existing, err := s.definitionStore.GetByName(libraryID, definition.Name)
+	if err != nil {
+		return fmt.Errorf("Error al verificar el nombre del reporte: %w", err)
+	}
+
+	if existing != nil && existing.ID != id {
+		return fmt.Errorf("Ya existe un reporte con el nombre %s", definition.Name)
+	}
+
+	definition.NextRunAt = database.NullTime{}
+
+	if definition.Schedule.Valid {
+		definition.Schedule.String = strings.TrimSpace(definition.Schedule.String)
+		definition.Schedule.Valid = definition.Schedule.String != ""
+	}
+
+	if definition.Schedule.Valid {
+		cron, err := schedule.Parse(definition.Schedule.String)
+		if err != nil {
+			return fmt.Errorf("Programación inválida: %w", err)
+		}
+
+		next := cron.Next(time.Now())
+		if next.IsZero() {
+			return errors.New("La programación nunca se cumple")
+		}
+
+		definition.NextRunAt.Time = next
+		definition.NextRunAt.Valid = true
+	}
+
+	return nil
+}
+
+// RunDefinition generates the report now, outside of its schedule.
+func (s *ScheduledReportService) RunDefinition(ctx context.Context, libraryID, id int64) (*models.ReportRun, error) {
+	definition, err := s.GetDefinitionByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.run(definition, "Manual", time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "report_run", run.ID, "create", nil, run); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// RunDue generates the reports whose scheduled time has come and moves them to
+// their next run. It returns the number of reports generated.
+func (s *ScheduledReportService) RunDue(now time.Time) (int, error) {
+	definitions, err := s.definitionStore.GetDue(now)
+	if err != nil {
+		return 0, fmt.Errorf("Error al obtener los reportes programados: %w", err)
+	}
+
+	count := 0
+
+	for _, definition := range definitions {
+		if _, err := s.run(definition, "Scheduled", now); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+func (s *ScheduledReportService) run(definition *models.ReportDefinition, trigger string, now time.Time) (*models.ReportRun, error) {
+	libraryID := definition.LibraryID
+
+	run, err := s.runStore.Create(libraryID, &models.ReportRun{
+		DefinitionID: definition.ID,
+		Name:         definition.Name,
+		ReportType:   definition.ReportType,
+		Format:       definition.Format,
+		Trigger:      trigger,
+		Status:       "Running",
+		StartedAt:    now,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Error al registrar la ejecución del reporte: %w", err)
+	}
+
+	// A failed report is recorded in its run; the schedule moves on anyway
+	if err := s.generate(definition, run, now); err != nil {
+		run.Status = "Failed"
+		run.Error.String = err.Error()
+		run.Error.Valid = true
+	} else {
+		run.Status = "Succeeded"
+	}
+
+	run.FinishedAt.Time = time.Now()
+	run.FinishedAt.Valid = true
+
+	if err := s.runStore.Finish(libraryID, run); err != nil {
+		return nil, fmt.Errorf("Error al registrar el resultado del reporte: %w", err)
+	}
+
+	nextRunAt := definition.NextRunAt
+
+	if trigger == "Scheduled" {
+		nextRunAt = database.NullTime{}
+
+		if cron, err := schedule.Parse(definition.Schedule.String); err == nil {
+			if next := cron.Next(now); !next.IsZero() {
+				nextRunAt.Time = next
+				nextRunAt.Valid = true
+			}
+		}
+	}
+
+	var lastRunAt database.NullTime
+	lastRunAt.Time = now
+	lastRunAt.Valid = true
+
+	if err := s.definitionStore.UpdateRunTimes(libraryID, definition.ID, lastRunAt, nextRunAt); err != nil {
+		return nil, fmt.Errorf("Error al actualizar la programación del reporte: %w", err)
+	}
+
+	setDownloadURL(run)
+
+	return run, nil
+}
+
+func (s *ScheduledReportService) generate(definition *models.ReportDefinition, run *models.ReportRun, now time.Time) error {
+	reportType, ok := findReportType(definition.ReportType)
+	if !ok {
+		return fmt.Errorf("Tipo de reporte desconocido: %s", definition.ReportType)
+	}
+
+	params := make(map[string]string)
+	for _, parameter := range reportType.Parameters {
+		params[parameter.Name] = parameter.Default
+	}
+
+	for name, value := range definition.Parameters {
+		params[name] = value
+	}
+
+	table, err := reportType.build(s, definition.LibraryID, params, now)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, definition.Format, table); err != nil {
+		return fmt.Errorf("Error al escribir el reporte: %w", err)
+	}
+
+	base := strings.Trim(reportFileNameRegex.ReplaceAllString(reportFileNameAccents.Replace(strings.ToLower(definition.Name)), "-"), "-")
+	if base == "" {
+		base = definition.ReportType
+	}
+
+	fileName := fmt.Sprintf("%s-%s.%s", base, now.Format("20060102-150405"), export.Formats[definition.Format].Extension)
+	key := fmt.Sprintf("%d/%d-%s", definition.LibraryID, run.ID, fileName)
+
+	if err := s.blobStore.Put(key, buf.Bytes()); err != nil {
+		return fmt.Errorf("Error al guardar el archivo del reporte: %w", err)
+	}
+
+	run.FileKey.String = key
+	run.FileKey.Valid = true
+	run.FileName.String = fileName
+	run.FileName.Valid = true
+	run.Size = int64(buf.Len())
+	run.Rows = len(table.Rows)
+
+	return nil
+}
+
+func (s *ScheduledReportService) GetRuns(libraryID int64, filter store.ReportRunFilter) ([]*models.ReportRun, error) {
+	if filter.Status != "" && filter.Status != "Running" && filter.Status != "Succeeded" && filter.Status != "Failed" {
+		return nil, errors.New("El estado debe ser: Running, Succeeded o Failed")
+	}
+
+	runs, err := s.runStore.GetAll(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las ejecuciones de reportes: %w", err)
+	}
+
+	for _, run := range runs {
+		setDownloadURL(run)
+	}
+
+	return runs, nil
+}
+
+func (s *ScheduledReportService) GetRunByID(libraryID, id int64) (*models.ReportRun, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la ejecución es inválido")
+	}
+
+	run, err := s.runStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la ejecución con ID %d: %w", id, err)
+	}
+
+	setDownloadURL(run)
+
+	return run, nil
+}
+
+// GetRunFile returns the generated file of a run with its content type.
+func (s *ScheduledReportService) GetRunFile(libraryID, id int64) (*models.ReportRun, []byte, string, error) {
+	run, err := s.GetRunByID(libraryID, id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if run.Status != "Succeeded" || !run.FileKey.Valid {
+		return nil, nil, "", errors.New("La ejecución no generó ningún archivo")
+	}
+
+	data, err := s.blobStore.Get(run.FileKey.String)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("Error al leer el archivo del reporte: %w", err)
+	}
+
+	return run, data, export.Formats[run.Format].ContentType, nil
+}
+
+func setDownloadURL(run *models.ReportRun) {
+	if run.Status == "Succeeded" && run.FileKey.Valid {
+		run.DownloadURL = fmt.Sprintf("/%d/reports/runs/%d/download", run.LibraryID, run.ID)
+	}
+}
+
+func findReportType(name string) (reportType, bool) {
+	for _, t := range reportTypes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return reportType{}, false
+}
+
+func findReportParameter(t reportType, name string) (models.ReportParameter, bool) {
+	for _, parameter := range t.Parameters {
+		if parameter.Name == name {
+			return parameter, true
+		}
+	}
+
+	return models.ReportParameter{}, false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reportRange resolves a named range to its first and last day.
+func reportRange(name string, now time.Time) (string, string) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var from, to time.Time
+
+	switch name {
+		case "current_month":
+			from, to = monthStart, today
+		case "last_7_days":
+			from, to = today.AddDate(0, 0, -6), today
+		case "last_30_days":
+			from, to = today.AddDate(0, 0, -29), today
+		default:
+			from, to = monthStart.AddDate(0, -1, 0), monthStart.AddDate(0, 0, -1)
+	}
+
+	return from.Format(time.DateOnly), to.Format(time.DateOnly)
+}
+
+func buildOverdueReport(s *ScheduledReportService, libraryID int64, params map[string]string, now time.Time) (*export.Table, error) {
+	minDays, _ := strconv.Atoi(params["min_days"])
+
+	loans, err := s.reportStore.GetOverdueLoans(libraryID, now)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los préstamos vencidos: %w", err)
+	}
+
+	table := &export.Table{
+		Columns: []string{"loan_code", "user_code", "user_name", "user_type", "email", "title", "copy_code", "loan_date", "due_date", "days_overdue"},
+	}
+
+	for _, loan := range loans {
+		if loan.DaysOverdue < minDays {
+			continue
+		}
+
+		var email any
+		if loan.Email.Valid {
+			email = loan.Email.String
+		}
+
+		table.Rows = append(table.Rows, []any{
+			loan.LoanCode, loan.UserCode, loan.UserName, loan.UserType, email,
+			loan.Title, loan.CopyCode, loan.LoanDate, loan.DueDate, loan.DaysOverdue,
+		})
+	}
+
+	return table, nil
+}
+
+func buildFinesReport(s *ScheduledReportService, libraryID int64, params map[string]string, now time.Time) (*export.Table, error) {
+	from, to := reportRange(params["range"], now)
+
+	fines, err := s.reportStore.GetFines(libraryID, store.StatsFilter{From: from, To: to}, params["status"])
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las multas: %w", err)
+	}
+
+	table := &export.Table{
+		Columns: []string{"fine_id", "user_code", "user_name", "reason", "amount", "status", "generated_date", "payment_date"},
+	}
+
+	for _, fine := range fines {
+		var paymentDate any
+		if fine.PaymentDate.Valid {
+			paymentDate = fine.PaymentDate.Time
+		}
+
+		table.Rows = append(table.Rows, []any{
+			fine.FineID, fine.UserCode, fine.UserName, fine.Reason, fine.Amount, fine.Status, fine.GeneratedDate, paymentDate,
+		})
+	}
+
+	return table, nil
+}
+
+func buildCirculationReport(s *ScheduledReportService, libraryID int64, params map[string]string, now time.Time) (*export.Table, error) {
+	from, to := reportRange(params["range"], now)
+
+	stats, err := s.statsService.GetCirculationStats(libraryID, CirculationQuery{From: from, To: to, Interval: params["interval"]})
+	if err != nil {
+		return nil, err
+	}
+
+	table := &export.Table{
+		Columns: []string{"period", "loans", "returns", "renewals", "new_patrons", "fines_collected"},
+	}
+
+	for _, bucket := range stats.Buckets {
+		table.Rows = append(table.Rows, []any{
+			bucket.Period, bucket.Loans, bucket.Returns, bucket.Renewals, bucket.NewPatrons, bucket.FinesCollected,
+		})
+	}
+
+	return table, nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+const (
+	DefaultStatsRangeDays = 30
+	DefaultStatsTop       = 10
+	maxStatsBuckets       = 731
+	maxCachedStats        = 50
+)
+
+type CirculationQuery struct {
+	From       string
+	To         string
+	Interval   string
+	Breakdowns []string
+	Top        int
+}
+
+// statsCache keeps the computed statistics of a library while its stats
+// version does not change.
+type statsCache struct {
+	version int64
+	results map[string]*models.CirculationStats
+}
+
+type StatsService struct {
+	statsStore store.IStatsStore
+
+	mu    sync.Mutex
+	cache map[int64]*statsCache
+}
+
+func NewStatsService(statsStore store.IStatsStore) *StatsService {
+	return &StatsService{
+		statsStore: statsStore,
+		cache:      make(map[int64]*statsCache),
+	}
+}
+
+func (s *StatsService) GetCirculationStats(libraryID int64, query CirculationQuery) (*models.CirculationStats, error) {
+	from, to, err := statsRange(query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	query.From = from.Format(time.DateOnly)
+	query.To = to.Format(time.DateOnly)
+
+	if query.Interval == "" {
+		query.Interval = "day"
+	}
+
+	periods, err := statsPeriods(from, to, query.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var breakdowns []string
+
+	for _, dimension := range query.Breakdowns {
+		dimension = strings.TrimSpace(dimension)
+
+		if dimension == "" || seen[dimension] {
+			continue
+		}
+
+		if dimension != "user_type" && dimension != "category" && dimension != "zone" {
+			return nil, errors.New("El desglose debe ser: user_type, category o zone")
+		}
+
+		seen[dimension] = true
+		breakdowns = append(breakdowns, dimension)
+	}
+
+	sort.Strings(breakdowns)
+	query.Breakdowns = breakdowns
+
+	if query.Top == 0 {
+		query.Top = DefaultStatsTop
+	}
+
+	if query.Top < 1 || query.Top > 100 {
+		return nil, errors.New("El top debe estar entre 1 y 100")
+	}
+
+	version, err := s.statsStore.GetVersion(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la versión de las estadísticas: %w", err)
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s|%d", query.From, query.To, query.Interval, strings.Join(query.Breakdowns, ","), query.Top)
+
+	if stats := s.cachedStats(libraryID, version, key); stats != nil {
+		return stats, nil
+	}
+
+	stats, err := s.computeCirculationStats(libraryID, query, periods)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheStats(libraryID, version, key, stats)
+
+	return stats, nil
+}
+
+func (s *StatsService) computeCirculationStats(libraryID int64, query CirculationQuery, periods []string) (*models.CirculationStats, error) {
+	filter := store.StatsFilter{From: query.From, To: query.To}
+
+	stats := &models.CirculationStats{
+		From:        query.From,
+		To:          query.To,
+		Interval:    query.Interval,
+		GeneratedAt: time.Now(),
+	}
+
+	buckets, err := s.statsStore.GetCirculationBuckets(libraryID, filter, query.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la circulación por periodo: %w", err)
+	}
+
+	byPeriod := make(map[string]*models.CirculationBucket, len(buckets))
+	for _, bucket := range buckets {
+		byPeriod[bucket.Period] = bucket
+	}
+
+	// Periods without activity are returned with zeros so the series is continuous
+	for _, period := range periods {
+		bucket, ok := byPeriod[period]
+		if !ok {
+			bucket = &models.CirculationBucket{Period: period}
+		}
+
+		stats.Totals.Loans += bucket.Loans
+		stats.Totals.Returns += bucket.Returns
+		stats.Totals.Renewals += bucket.Renewals
+		stats.Totals.NewPatrons += bucket.NewPatrons
+		stats.Totals.FinesCollected += bucket.FinesCollected
+
+		stats.Buckets = append(stats.Buckets, bucket)
+	}
+
+	stats.Totals.FinesCollected = math.Round(stats.Totals.FinesCollected*100) / 100
+
+	if len(query.Breakdowns) > 0 {
+		stats.Breakdowns = make(map[string][]*models.CirculationBreakdown)
+	}
+
+	for _, dimension := range query.Breakdowns {
+		breakdown, err := s.statsStore.GetCirculationBreakdown(libraryID, filter, dimension)
+		if err != nil {
+			return nil, fmt.Errorf("Error al obtener el desglose por %s: %w", dimension, err)
+		}
+
+		if breakdown == nil {
+			breakdown = []*models.CirculationBreakdown{}
+		}
+
+		stats.Breakdowns[dimension] = breakdown
+	}
+
+	stats.TopBooks, err = s.statsStore.GetTopBooks(libraryID, filter, query.Top)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los libros más prestados: %w", err)
+	}
+
+	stats.TopPatrons, err = s.statsStore.GetTopPatrons(libraryID, filter, query.Top)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los usuarios más activos: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (s *StatsService) cachedStats(libraryID, version int64, key string) *models.CirculationStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[libraryID]
+	if !ok || entry.version != version {
+		return nil
+	}
+
+	return entry.results[key]
+}
+
+func (s *StatsService) cacheStats(libraryID, version int64, key string, stats *models.CirculationStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[libraryID]
+	if !ok || entry.version != version || len(entry.results) >= maxCachedStats {
+		entry = &statsCache{
+			version: version,
+			results: make(map[string]*models.CirculationStats),
+		}
+
+		s.cache[libraryID] = entry
+	}
+
+	entry.results[key] = stats
+}
+
+// statsRange parses the range, by default the last 30 days up to today.
+func statsRange(fromStr, toStr string) (time.Time, time.Time, error) {
+	to := time.Now()
+
+	if toStr != "" {
+		parsed, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("La fecha final debe tener el formato AAAA-MM-DD")
+		}
+
+		to = parsed
+	}
+
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -(DefaultStatsRangeDays - 1))
+
+	if fromStr != "" {
+		parsed, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("La fecha inicial debe tener el formato AAAA-MM-DD")
+		}
+
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("La fecha inicial no puede ser posterior a la fecha final")
+	}
+
+	return from, to, nil
+}
+
+// statsPeriods lists the first day of every bucket in the range, matching the
+// periods computed by the store.
+func statsPeriods(from, to time.Time, interval string) ([]string, error) {
+	var start time.Time
+	var next func(time.Time) time.Time
+
+	switch interval {
+		case "day":
+			start = from
+			next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		case "week":
+			start = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+			next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+		case "month":
+			start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+			next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		default:
+			return nil, errors.New("El intervalo debe ser: day, week o month")
+	}
+
+	var periods []string
+
+	for t := start; !t.After(to); t = next(t) {
+		if len(periods) == maxStatsBuckets {
+			return nil, fmt.Errorf("El rango no puede tener más de %d periodos", maxStatsBuckets)
+		}
+
+		periods = append(periods, t.Format(time.DateOnly))
+	}
+
+	return periods, nil
+}
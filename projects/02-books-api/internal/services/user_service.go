@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -16,14 +17,16 @@ type UserService struct {
 	loanStore        store.ILoanStore
 	reservationStore store.IReservationStore
 	fineStore        store.IFineStore
+	auditService     *AuditService
 }
 
-func NewUserService(userStore store.IUserStore, loanStore store.ILoanStore, reservationStore store.IReservationStore, fineStore store.IFineStore) *UserService {
+func NewUserService(userStore store.IUserStore, loanStore store.ILoanStore, reservationStore store.IReservationStore, fineStore store.IFineStore, auditService *AuditService) *UserService {
 	return &UserService{
 		userStore:        userStore,
 		loanStore:        loanStore,
 		reservationStore: reservationStore,
 		fineStore:        fineStore,
+		auditService:     auditService,
 	}
 }
 
@@ -74,7 +77,7 @@ func (s *UserService) GetUsersFiltered(libraryID int64, filter store.UserFilter)
 	return users, nil
 }
 
-func (s *UserService) CreateUser(libraryID int64, user *models.User) (*models.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, libraryID int64, user *models.User) (*models.User, error) {
 	if err := validations.ValidateUser(user); err != nil {
 		return nil, fmt.Errorf("Validación fallida: %w", err)
 	}
@@ -120,10 +123,14 @@ func (s *UserService) CreateUser(libraryID int64, user *models.User) (*models.Us
 		return nil, fmt.Errorf("Error al crear el usuario: %w", err)
 	}
 
+	if err := s.auditService.Record(ctx, libraryID, "user", createdUser.ID, "create", nil, createdUser); err != nil {
+		return nil, err
+	}
+
 	return createdUser, nil
 }
 
-func (s *UserService) UpdateUser(libraryID, id int64, user *models.User) (*models.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, libraryID, id int64, user *models.User) (*models.User, error) {
 	if id <= 0 {
 		return nil, errors.New("El ID del usuario es inválido")
 	}
@@ -177,8 +184,14 @@ func (s *UserService) UpdateUser(libraryID, id int64, user *models.User) (*model
 		})
 		if err == nil && len(reservations) > 0 {
 			for _, reservation := range reservations {
+				previousReservation := *reservation
 				reservation.Status = "Cancelled"
-				s.reservationStore.Update(libraryID, reservation.ID, reservation)
+
+				if cancelled, err := s.reservationStore.Update(libraryID, reservation.ID, reservation); err == nil {
+					if err := s.auditService.Record(ctx, libraryID, "reservation", reservation.ID, "cancel", &previousReservation, cancelled); err != nil {
+						return nil, err
+					}
+				}
 			}
 		}
 	}
@@ -188,10 +201,19 @@ func (s *UserService) UpdateUser(libraryID, id int64, user *models.User) (*model
 		return nil, fmt.Errorf("Error al actualizar el usuario con ID %d: %w", id, err)
 	}
 
+	action := "update"
+	if updatedUser.Status == "Suspended" && existingUser.Status != "Suspended" {
+		action = "suspend"
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "user", id, action, existingUser, updatedUser); err != nil {
+		return nil, err
+	}
+
 	return updatedUser, nil
 }
 
-func (s *UserService) DeleteUser(libraryID, id int64) error {
+func (s *UserService) DeleteUser(ctx context.Context, libraryID, id int64) error {
 	if id <= 0 {
 		return errors.New("El ID del usuario es inválido")
 	}
@@ -234,7 +256,11 @@ func (s *UserService) DeleteUser(libraryID, id int64) error {
 	})
 	if err == nil && len(userReservations) > 0 {
 		for _, reservation := range userReservations {
-			s.reservationStore.Delete(libraryID, reservation.ID)
+			if err := s.reservationStore.Delete(libraryID, reservation.ID); err == nil {
+				if err := s.auditService.Record(ctx, libraryID, "reservation", reservation.ID, "delete", reservation, nil); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -242,5 +268,5 @@ func (s *UserService) DeleteUser(libraryID, id int64) error {
 		return fmt.Errorf("Error al eliminar el usuario con ID %d: %w", id, err)
 	}
 
-	return nil
+	return s.auditService.Record(ctx, libraryID, "user", id, "delete", existingUser, nil)
 }
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/metrics"
+)
+
+// WebhookDispatcher posts the webhooks in process, checking every interval
+// for new events and for deliveries whose next attempt has come.
+type WebhookDispatcher struct {
+	webhookService *WebhookService
+	interval       time.Duration
+}
+
+func NewWebhookDispatcher(webhookService *WebhookService, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookService: webhookService,
+		interval:       interval,
+	}
+}
+
+// Start blocks until the context is cancelled.
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				_, err := d.webhookService.Dispatch(ctx, now)
+				metrics.ObserveJob("webhook_dispatcher", now, err)
+
+				if err != nil {
+					slog.WarnContext(ctx, "Error al enviar los webhooks", "error", err)
+				}
+		}
+	}
+}
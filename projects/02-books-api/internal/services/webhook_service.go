@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+	"github.com/chicho69-cesar/backend-go/books/internal/webhook"
+)
+
+// A failed delivery waits webhookBaseDelay and then twice as long after every
+// new failure, from one minute to a little over four hours; when the attempts
+// run out it is left as Failed until it is replayed.
+const (
+	webhookBatchSize   = 50
+	webhookBaseDelay   = time.Minute
+	webhookMaxAttempts = 9
+)
+
+type WebhookService struct {
+	subscriptionStore store.IWebhookSubscriptionStore
+	deliveryStore     store.IWebhookDeliveryStore
+	eventService      *EventService
+	sender            *webhook.Sender
+	auditService      *AuditService
+}
+
+func NewWebhookService(subscriptionStore store.IWebhookSubscriptionStore, deliveryStore store.IWebhookDeliveryStore, eventService *EventService, sender *webhook.Sender, auditService *AuditService) *WebhookService {
+	return &WebhookService{
+		subscriptionStore: subscriptionStore,
+		deliveryStore:     deliveryStore,
+		eventService:      eventService,
+		sender:            sender,
+		auditService:      auditService,
+	}
+}
+
+func (s *WebhookService) GetSubscriptions(libraryID int64) ([]*models.WebhookSubscription, error) {
+	subscriptions, err := s.subscriptionStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los webhooks: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (s *WebhookService) GetSubscription(libraryID, id int64) (*models.WebhookSubscription, error) {
+	subscription, err := s.subscriptionStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Webhook con ID %d no encontrado", id)
+	}
+
+	return subscription, nil
+}
+
+// CreateSubscription registers an active subscription. When no secret comes
+// one is generated; either way it is only returned now.
+func (s *WebhookService) CreateSubscription(ctx context.Context, libraryID int64, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if err := s.prepareSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	if subscription.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, fmt.Errorf("Error al generar el secreto del webhook: %w", err)
+		}
+
+		subscription.Secret = secret
+	}
+
+	subscription.Active = true
+
+	createdSubscription, err := s.subscriptionStore.Create(libraryID, subscription)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear el webhook: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "webhook", createdSubscription.ID, "create", nil, createdSubscription); err != nil {
+		return nil, err
+	}
+
+	return createdSubscription, nil
+}
+
+// UpdateSubscription keeps the secret unless a new one comes.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, libraryID, id int64, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	existing, err := s.GetSubscription(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.prepareSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	updatedSubscription, err := s.subscriptionStore.Update(libraryID, id, subscription)
+	if err != nil {
+		return nil, fmt.Errorf("Error al actualizar el webhook: %w", err)
+	}
+
+	updatedSubscription.CreatedAt = existing.CreatedAt
+
+	if err := s.auditService.Record(ctx, libraryID, "webhook", id, "update", existing, updatedSubscription); err != nil {
+		return nil, err
+	}
+
+	return updatedSubscription, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, libraryID, id int64) error {
+	existing, err := s.GetSubscription(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.subscriptionStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar el webhook: %w", err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "webhook", id, "delete", existing, nil)
+}
+
+func (s *WebhookService) GetDeliveries(libraryID, subscriptionID int64, filter store.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(libraryID, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	filter.SubscriptionID = &subscriptionID
+
+	deliveries, err := s.deliveryStore.GetAll(libraryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las entregas del webhook: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// GetDelivery returns a delivery with its payload and every attempt made.
+func (s *WebhookService) GetDelivery(libraryID, subscriptionID, id int64) (*models.WebhookDelivery, error) {
+	delivery, err := s.deliveryStore.GetByID(libraryID, id)
+	if err != nil || delivery.SubscriptionID != subscriptionID {
+		return nil, fmt.Errorf("Entrega con ID %d no encontrada", id)
+	}
+
+	delivery.AttemptLog, err = s.deliveryStore.GetAttempts(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los intentos de la entrega: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ReplayDelivery sends a delivery again with the same payload, whether it
+// failed or the receiver lost it.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, libraryID, subscriptionID, id int64) (*models.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(libraryID, subscriptionID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.Status == "Pending" {
+		return nil, fmt.Errorf("La entrega ya está pendiente de envío")
+	}
+
+	if _, err := s.deliveryStore.Replay(libraryID, []int64{id}, time.Now()); err != nil {
+		return nil, fmt.Errorf("Error al reenviar la entrega: %w", err)
+	}
+
+	replayedDelivery, err := s.GetDelivery(libraryID, subscriptionID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "webhook_delivery", id, "replay", delivery, replayedDelivery); err != nil {
+		return nil, err
+	}
+
+	return replayedDelivery, nil
+}
+
+// ReplayFailed sends again every failed delivery of the subscription and
+// returns how many were queued.
+func (s *WebhookService) ReplayFailed(ctx context.Context, libraryID, subscriptionID int64) (int64, error) {
+	deliveries, err := s.GetDeliveries(libraryID, subscriptionID, store.WebhookDeliveryFilter{Status: "Failed"})
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+
+	now := time.Now()
+
+	replayed, err := s.deliveryStore.Replay(libraryID, ids, now)
+	if err != nil {
+		return 0, fmt.Errorf("Error al reenviar las entregas fallidas: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		replayedDelivery := *delivery
+		replayedDelivery.Status = "Pending"
+		replayedDelivery.Attempts = 0
+		replayedDelivery.NextAttemptAt.Time = now
+		replayedDelivery.NextAttemptAt.Valid = true
+		replayedDelivery.DeliveredAt.Valid = false
+
+		if err := s.auditService.Record(ctx, libraryID, "webhook_delivery", delivery.ID, "replay", delivery, &replayedDelivery); err != nil {
+			return 0, err
+		}
+	}
+
+	return replayed, nil
+}
+
+// Dispatch fans the new events out to the subscriptions and posts the
+// deliveries of every library whose next attempt has come. It returns how
+// many were delivered.
+func (s *WebhookService) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	if err := s.fanOut(); err != nil {
+		return 0, err
+	}
+
+	deliveries, err := s.deliveryStore.GetReady(now, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("Error al obtener las entregas pendientes: %w", err)
+	}
+
+	delivered := 0
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := s.deliver(ctx, delivery, now); err != nil {
+			return delivered, err
+		}
+
+		if delivery.Status == "Delivered" {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// fanOut takes the payload of each new event from the current state of its
+// subject and creates a delivery for every subscription that wants it.
+func (s *WebhookService) fanOut() error {
+	events, err := s.deliveryStore.GetPendingEvents(webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("Error al obtener los eventos de los webhooks: %w", err)
+	}
+
+	subscriptionsByLibrary := make(map[int64][]*models.WebhookSubscription)
+
+	for _, event := range events {
+		subscriptions, ok := subscriptionsByLibrary[event.LibraryID]
+		if !ok {
+			subscriptions, err = s.subscriptionStore.GetActive(event.LibraryID)
+			if err != nil {
+				return fmt.Errorf("Error al obtener los webhooks activos: %w", err)
+			}
+
+			subscriptionsByLibrary[event.LibraryID] = subscriptions
+		}
+
+		var subscriptionIDs []int64
+
+		for _, subscription := range subscriptions {
+			if len(subscription.EventTypes) == 0 || slices.Contains(subscription.EventTypes, event.EventType) {
+				subscriptionIDs = append(subscriptionIDs, subscription.ID)
+			}
+		}
+
+		payload, found, err := s.eventService.Payload(event)
+		if err != nil {
+			return err
+		}
+
+		// The subject was deleted before the event went out, there is nothing
+		// left to describe.
+		if !found {
+			subscriptionIDs = nil
+		}
+
+		event.Payload.String = string(payload)
+		event.Payload.Valid = true
+
+		if err := s.deliveryStore.FanOut(event, subscriptionIDs); err != nil {
+			return fmt.Errorf("Error al registrar las entregas del evento %d: %w", event.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// deliver posts one delivery and logs the attempt. Only errors saving it are
+// returned; the receiver's errors are kept in the delivery.
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) error {
+	response := s.sender.Send(ctx, webhook.Request{
+		ID:        strconv.FormatInt(delivery.ID, 10),
+		Event:     delivery.EventType,
+		URL:       delivery.URL,
+		Secret:    delivery.Secret,
+		Payload:   delivery.Payload,
+		Timestamp: now,
+	})
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	attempt := &models.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		RequestedAt: now,
+		DurationMs:  response.Duration.Milliseconds(),
+		LibraryID:   delivery.LibraryID,
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode.Valid = response.StatusCode != 0
+	delivery.LastStatusCode.Int64 = int64(response.StatusCode)
+
+	if response.StatusCode != 0 {
+		attempt.StatusCode = delivery.LastStatusCode
+	}
+
+	if response.Body != "" {
+		attempt.ResponseBody.String = response.Body
+		attempt.ResponseBody.Valid = true
+	}
+
+	if response.Err != nil {
+		attempt.Error.String = response.Err.Error()
+		attempt.Error.Valid = true
+
+		delivery.LastError = attempt.Error
+
+		if delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = "Failed"
+		} else {
+			delivery.NextAttemptAt.Time = now.Add(webhookBaseDelay << (delivery.Attempts - 1))
+			delivery.NextAttemptAt.Valid = true
+		}
+	} else {
+		delivery.Status = "Delivered"
+		delivery.LastError.Valid = false
+		delivery.DeliveredAt.Time = now
+		delivery.DeliveredAt.Valid = true
+	}
+
+	if err := s.deliveryStore.RecordAttempt(delivery, attempt); err != nil {
+		return fmt.Errorf("Error al guardar el intento de la entrega %d: %w", delivery.ID, err)
+	}
+
+	return nil
+}
+
+func (s *WebhookService) prepareSubscription(subscription *models.WebhookSubscription) error {
+	if err := validations.ValidateWebhookSubscription(subscription); err != nil {
+		return fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	eventTypes := make([]string, 0, len(subscription.EventTypes))
+
+	for _, eventType := range subscription.EventTypes {
+		if !slices.Contains(circulationEvents, eventType) {
+			return fmt.Errorf("Validación fallida: El evento debe ser: %s", strings.Join(circulationEvents, ", "))
+		}
+
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	subscription.EventTypes = eventTypes
+
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
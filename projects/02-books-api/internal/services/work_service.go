@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+	"github.com/chicho69-cesar/backend-go/books/internal/validations"
+)
+
+type WorkService struct {
+	workStore        store.IWorkStore
+	bookStore        store.IBookStore
+	reservationStore store.IReservationStore
+	auditService     *AuditService
+}
+
+type SeriesService struct {
+	seriesStore  store.ISeriesStore
+	bookStore    store.IBookStore
+	auditService *AuditService
+}
+
+func NewWorkService(workStore store.IWorkStore, bookStore store.IBookStore, reservationStore store.IReservationStore, auditService *AuditService) *WorkService {
+	return &WorkService{
+		workStore:        workStore,
+		bookStore:        bookStore,
+		reservationStore: reservationStore,
+		auditService:     auditService,
+	}
+}
+
+func NewSeriesService(seriesStore store.ISeriesStore, bookStore store.IBookStore, auditService *AuditService) *SeriesService {
+	return &SeriesService{
+		seriesStore:  seriesStore,
+		bookStore:    bookStore,
+		auditService: auditService,
+	}
+}
+
+func (s *WorkService) GetAllWorks(libraryID int64) ([]*models.Work, error) {
+	works, err := s.workStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las obras: %w", err)
+	}
+
+	return works, nil
+}
+
+func (s *WorkService) GetWorkByID(libraryID, id int64) (*models.Work, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la obra es inválido")
+	}
+
+	work, err := s.workStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la obra con ID %d: %w", id, err)
+	}
+
+	if work == nil {
+		return nil, fmt.Errorf("La obra con ID %d no fue encontrada", id)
+	}
+
+	return work, nil
+}
+
+func (s *WorkService) GetWorkEditions(libraryID, id int64) ([]*models.Book, error) {
+	if _, err := s.GetWorkByID(libraryID, id); err != nil {
+		return nil, err
+	}
+
+	editions, err := s.bookStore.GetBooksFiltered(libraryID, store.BookFilter{WorkID: &id})
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las ediciones de la obra: %w", err)
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, editions...); err != nil {
+		return nil, err
+	}
+
+	return editions, nil
+}
+
+func (s *WorkService) CreateWork(ctx context.Context, libraryID int64, work *models.Work) (*models.Work, error) {
+	if err := validations.ValidateWork(work); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	work.LibraryID = libraryID
+	work.Title = strings.TrimSpace(work.Title)
+
+	if work.OriginalTitle.Valid {
+		work.OriginalTitle.String = strings.TrimSpace(work.OriginalTitle.String)
+	}
+
+	if work.Description.Valid {
+		work.Description.String = strings.TrimSpace(work.Description.String)
+	}
+
+	createdWork, err := s.workStore.Create(libraryID, work)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear la obra: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "work", createdWork.ID, "create", nil, createdWork); err != nil {
+		return nil, err
+	}
+
+	return createdWork, nil
+}
+
+func (s *WorkService) UpdateWork(ctx context.Context, libraryID, id int64, work *models.Work) (*models.Work, error) {
+	existingWork, err := s.GetWorkByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validations.ValidateWork(work); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	work.Title = strings.TrimSpace(work.Title)
+
+	if work.OriginalTitle.Valid {
+		work.OriginalTitle.String = strings.TrimSpace(work.OriginalTitle.String)
+	}
+
+	if work.Description.Valid {
+		work.Description.String = strings.TrimSpace(work.Description.String)
+	}
+
+	updatedWork, err := s.workStore.Update(libraryID, id, work)
+	if err != nil {
+		return nil, fmt.Errorf("Error al actualizar la obra con ID %d: %w", id, err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "work", id, "update", existingWork, updatedWork); err != nil {
+		return nil, err
+	}
+
+	return updatedWork, nil
+}
+
+func (s *WorkService) DeleteWork(ctx context.Context, libraryID, id int64) error {
+	existingWork, err := s.GetWorkByID(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	reservations, err := s.reservationStore.GetReservationsFiltered(libraryID, store.ReservationFilter{WorkID: &id})
+	if err != nil {
+		return fmt.Errorf("Error al verificar las reservaciones de la obra: %w", err)
+	}
+
+	for _, reservation := range reservations {
+		if reservation.Status == "Pending" || reservation.Status == "Active" {
+			return errors.New("No se puede eliminar la obra porque tiene reservaciones pendientes o en proceso")
+		}
+	}
+
+	if err := s.workStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar la obra con ID %d: %w", id, err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "work", id, "delete", existingWork, nil)
+}
+
+func (s *SeriesService) GetAllSeries(libraryID int64) ([]*models.Series, error) {
+	seriesList, err := s.seriesStore.GetAll(libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las series: %w", err)
+	}
+
+	return seriesList, nil
+}
+
+func (s *SeriesService) GetSeriesByID(libraryID, id int64) (*models.Series, error) {
+	if id <= 0 {
+		return nil, errors.New("El ID de la serie es inválido")
+	}
+
+	series, err := s.seriesStore.GetByID(libraryID, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener la serie con ID %d: %w", id, err)
+	}
+
+	if series == nil {
+		return nil, fmt.Errorf("La serie con ID %d no fue encontrada", id)
+	}
+
+	return series, nil
+}
+
+func (s *SeriesService) GetSeriesVolumes(libraryID, id int64) ([]*models.Book, error) {
+	if _, err := s.GetSeriesByID(libraryID, id); err != nil {
+		return nil, err
+	}
+
+	volumes, err := s.bookStore.GetBooksFiltered(libraryID, store.BookFilter{SeriesID: &id})
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener los volúmenes de la serie: %w", err)
+	}
+
+	if err := attachAvailability(s.bookStore, libraryID, volumes...); err != nil {
+		return nil, err
+	}
+
+	return volumes, nil
+}
+
+func (s *SeriesService) CreateSeries(ctx context.Context, libraryID int64, series *models.Series) (*models.Series, error) {
+	if err := validations.ValidateSeries(series); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	series.LibraryID = libraryID
+	series.Name = strings.TrimSpace(series.Name)
+
+	if series.Description.Valid {
+		series.Description.String = strings.TrimSpace(series.Description.String)
+	}
+
+	createdSeries, err := s.seriesStore.Create(libraryID, series)
+	if err != nil {
+		return nil, fmt.Errorf("Error al crear la serie: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "series", createdSeries.ID, "create", nil, createdSeries); err != nil {
+		return nil, err
+	}
+
+	return createdSeries, nil
+}
+
+func (s *SeriesService) UpdateSeries(ctx context.Context, libraryID, id int64, series *models.Series) (*models.Series, error) {
+	existingSeries, err := s.GetSeriesByID(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validations.ValidateSeries(series); err != nil {
+		return nil, fmt.Errorf("Validación fallida: %w", err)
+	}
+
+	series.Name = strings.TrimSpace(series.Name)
+
+	if series.Description.Valid {
+		series.Description.String = strings.TrimSpace(series.Description.String)
+	}
+
+	updatedSeries, err := s.seriesStore.Update(libraryID, id, series)
+	if err != nil {
+		return nil, fmt.Errorf("Error al actualizar la serie con ID %d: %w", id, err)
+	}
+
+	if err := s.auditService.Record(ctx, libraryID, "series", id, "update", existingSeries, updatedSeries); err != nil {
+		return nil, err
+	}
+
+	return updatedSeries, nil
+}
+
+func (s *SeriesService) DeleteSeries(ctx context.Context, libraryID, id int64) error {
+	existingSeries, err := s.GetSeriesByID(libraryID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.seriesStore.Delete(libraryID, id); err != nil {
+		return fmt.Errorf("Error al eliminar la serie con ID %d: %w", id, err)
+	}
+
+	return s.auditService.Record(ctx, libraryID, "series", id, "delete", existingSeries, nil)
+}
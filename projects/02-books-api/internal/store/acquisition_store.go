@@ -0,0 +1,1029 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type PurchaseOrderFilter struct {
+	Status   string
+	VendorID *int64
+	BudgetID *int64
+}
+
+type IVendorStore interface {
+	GetAll(libraryID int64) ([]*models.Vendor, error)
+	GetByID(libraryID, id int64) (*models.Vendor, error)
+	GetByName(libraryID int64, name string) (*models.Vendor, error)
+	Create(libraryID int64, vendor *models.Vendor) (*models.Vendor, error)
+	Update(libraryID, id int64, vendor *models.Vendor) (*models.Vendor, error)
+	Delete(libraryID, id int64) error
+}
+
+type IBudgetStore interface {
+	GetAll(libraryID int64, fiscalYear int) ([]*models.Budget, error)
+	GetByID(libraryID, id int64) (*models.Budget, error)
+	GetByName(libraryID int64, name string, fiscalYear int) (*models.Budget, error)
+	Create(libraryID int64, budget *models.Budget) (*models.Budget, error)
+	Update(libraryID, id int64, budget *models.Budget) (*models.Budget, error)
+	Delete(libraryID, id int64) error
+	GetTotals(libraryID, id int64) (committed, spent, invoiced float64, err error)
+}
+
+type IPurchaseOrderStore interface {
+	GetAll(libraryID int64, filter PurchaseOrderFilter) ([]*models.PurchaseOrder, error)
+	GetByID(libraryID, id int64) (*models.PurchaseOrder, error)
+	GetByNumber(libraryID int64, orderNumber string) (*models.PurchaseOrder, error)
+	Create(libraryID int64, order *models.PurchaseOrder) (*models.PurchaseOrder, error)
+	Update(libraryID, id int64, order *models.PurchaseOrder) (*models.PurchaseOrder, error)
+	UpdateStatus(libraryID, id int64, status string) error
+	Delete(libraryID, id int64) error
+
+	GetLines(libraryID, orderID int64) ([]*models.PurchaseOrderLine, error)
+	ReceiveLine(libraryID, orderID, lineID int64, copies []*models.Copy, receivedAt time.Time, librarianID *int64) error
+	GetReceipts(libraryID, orderID int64) ([]*models.PurchaseReceipt, error)
+
+	GetInvoices(libraryID, orderID int64) ([]*models.Invoice, error)
+	GetInvoiceByNumber(libraryID, vendorID int64, invoiceNumber string) (*models.Invoice, error)
+	CreateInvoice(libraryID int64, invoice *models.Invoice) (*models.Invoice, error)
+}
+
+type VendorStore struct {
+	db *sql.DB
+}
+
+type BudgetStore struct {
+	db *sql.DB
+}
+
+type PurchaseOrderStore struct {
+	db *sql.DB
+}
+
+func NewVendorStore(db *sql.DB) IVendorStore {
+	return &VendorStore{
+		db: db,
+	}
+}
+
+func NewBudgetStore(db *sql.DB) IBudgetStore {
+	return &BudgetStore{
+		db: db,
+	}
+}
+
+func NewPurchaseOrderStore(db *sql.DB) IPurchaseOrderStore {
+	return &PurchaseOrderStore{
+		db: db,
+	}
+}
+
+func (s *VendorStore) GetAll(libraryID int64) ([]*models.Vendor, error) {
+	query := `
+		SELECT id, name, contact_name, email, phone, account_number, notes, library_id
+		FROM vendors
+		WHERE library_id = ?
+		ORDER BY name
+	`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vendors []*models.Vendor
+
+	for rows.Next() {
+		vendor := &models.Vendor{}
+
+		err := rows.Scan(
+			&vendor.ID,
+			&vendor.Name,
+			&vendor.ContactName,
+			&vendor.Email,
+			&vendor.Phone,
+			&vendor.AccountNumber,
+			&vendor.Notes,
+			&vendor.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vendors = append(vendors, vendor)
+	}
+
+	return vendors, rows.Err()
+}
+
+func (s *VendorStore) GetByID(libraryID, id int64) (*models.Vendor, error) {
+	query := `
+		SELECT id, name, contact_name, email, phone, account_number, notes, library_id
+		FROM vendors
+		WHERE id = ? AND library_id = ?
+	`
+
+	vendor := &models.Vendor{}
+
+	err := s.db.
+		QueryRow(query, id, libraryID).
+		Scan(
+			&vendor.ID,
+			&vendor.Name,
+			&vendor.ContactName,
+			&vendor.Email,
+			&vendor.Phone,
+			&vendor.AccountNumber,
+			&vendor.Notes,
+			&vendor.LibraryID,
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return vendor, nil
+}
+
+func (s *VendorStore) GetByName(libraryID int64, name string) (*models.Vendor, error) {
+	query := `
+		SELECT id, name, contact_name, email, phone, account_number, notes, library_id
+		FROM vendors
+		WHERE name = ? COLLATE NOCASE AND library_id = ?
+	`
+
+	vendor := &models.Vendor{}
+
+	err := s.db.
+		QueryRow(query, name, libraryID).
+		Scan(
+			&vendor.ID,
+			&vendor.Name,
+			&vendor.ContactName,
+			&vendor.Email,
+			&vendor.Phone,
+			&vendor.AccountNumber,
+			&vendor.Notes,
+			&vendor.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return vendor, nil
+}
+
+func (s *VendorStore) Create(libraryID int64, vendor *models.Vendor) (*models.Vendor, error) {
+	query := `
+		INSERT INTO vendors (name, contact_name, email, phone, account_number, notes, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(
+		query,
+		vendor.Name,
+		vendor.ContactName,
+		vendor.Email,
+		vendor.Phone,
+		vendor.AccountNumber,
+		vendor.Notes,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	vendor.ID = id
+	vendor.LibraryID = libraryID
+
+	return vendor, nil
+}
+
+func (s *VendorStore) Update(libraryID, id int64, vendor *models.Vendor) (*models.Vendor, error) {
+	query := `
+		UPDATE vendors
+		SET name = ?, contact_name = ?, email = ?, phone = ?, account_number = ?, notes = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err := s.db.Exec(
+		query,
+		vendor.Name,
+		vendor.ContactName,
+		vendor.Email,
+		vendor.Phone,
+		vendor.AccountNumber,
+		vendor.Notes,
+		id,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vendor.ID = id
+	vendor.LibraryID = libraryID
+
+	return vendor, nil
+}
+
+func (s *VendorStore) Delete(libraryID, id int64) error {
+	query := `DELETE FROM vendors WHERE id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *BudgetStore) GetAll(libraryID int64, fiscalYear int) ([]*models.Budget, error) {
+	query := `
+		SELECT id, name, fiscal_year, amount, notes, library_id
+		FROM budgets
+		WHERE library_id = ?
+	`
+
+	args := []any{libraryID}
+
+	if fiscalYear != 0 {
+		query += " AND fiscal_year = ?"
+		args = append(args, fiscalYear)
+	}
+
+	query += "\nORDER BY fiscal_year DESC, name"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []*models.Budget
+
+	for rows.Next() {
+		budget := &models.Budget{}
+
+		err := rows.Scan(
+			&budget.ID,
+			&budget.Name,
+			&budget.FiscalYear,
+			&budget.Amount,
+			&budget.Notes,
+			&budget.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
+
+func (s *BudgetStore) GetByID(libraryID, id int64) (*models.Budget, error) {
+	query := `
+		SELECT id, name, fiscal_year, amount, notes, library_id
+		FROM budgets
+		WHERE id = ? AND library_id = ?
+	`
+
+	budget := &models.Budget{}
+
+	err := s.db.
+		QueryRow(query, id, libraryID).
+		Scan(
+			&budget.ID,
+			&budget.Name,
+			&budget.FiscalYear,
+			&budget.Amount,
+			&budget.Notes,
+			&budget.LibraryID,
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *BudgetStore) GetByName(libraryID int64, name string, fiscalYear int) (*models.Budget, error) {
+	query := `
+		SELECT id, name, fiscal_year, amount, notes, library_id
+		FROM budgets
+		WHERE name = ? COLLATE NOCASE AND fiscal_year = ? AND library_id = ?
+	`
+
+	budget := &models.Budget{}
+
+	err := s.db.
+		QueryRow(query, name, fiscalYear, libraryID).
+		Scan(
+			&budget.ID,
+			&budget.Name,
+			&budget.FiscalYear,
+			&budget.Amount,
+			&budget.Notes,
+			&budget.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *BudgetStore) Create(libraryID int64, budget *models.Budget) (*models.Budget, error) {
+	query := `
+		INSERT INTO budgets (name, fiscal_year, amount, notes, library_id)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(query, budget.Name, budget.FiscalYear, budget.Amount, budget.Notes, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	budget.ID = id
+	budget.LibraryID = libraryID
+
+	return budget, nil
+}
+
+func (s *BudgetStore) Update(libraryID, id int64, budget *models.Budget) (*models.Budget, error) {
+	query := `
+		UPDATE budgets
+		SET name = ?, fiscal_year = ?, amount = ?, notes = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err := s.db.Exec(query, budget.Name, budget.FiscalYear, budget.Amount, budget.Notes, id, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.ID = id
+	budget.LibraryID = libraryID
+
+	return budget, nil
+}
+
+func (s *BudgetStore) Delete(libraryID, id int64) error {
+	query := `DELETE FROM budgets WHERE id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTotals adds up the orders charged to the budget. What is still to be
+// received on open orders is committed; what has arrived is spent.
+func (s *BudgetStore) GetTotals(libraryID, id int64) (committed, spent, invoiced float64, err error) {
+	query := `
+		SELECT
+			COALESCE(SUM(CASE
+				WHEN o.status IN ('Ordered', 'PartiallyReceived') THEN (l.quantity - l.quantity_received) * l.unit_price
+				ELSE 0
+			END), 0),
+			COALESCE(SUM(l.quantity_received * l.unit_price), 0)
+		FROM purchase_order_lines l
+		INNER JOIN purchase_orders o ON o.id = l.order_id
+		WHERE o.budget_id = ? AND o.library_id = ?
+	`
+
+	err = s.db.QueryRow(query, id, libraryID).Scan(&committed, &spent)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	query = `
+		SELECT COALESCE(SUM(i.amount), 0)
+		FROM invoices i
+		INNER JOIN purchase_orders o ON o.id = i.order_id
+		WHERE o.budget_id = ? AND o.library_id = ?
+	`
+
+	err = s.db.QueryRow(query, id, libraryID).Scan(&invoiced)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	return committed, spent, invoiced, nil
+}
+
+func (s *PurchaseOrderStore) GetAll(libraryID int64, filter PurchaseOrderFilter) ([]*models.PurchaseOrder, error) {
+	query := `
+		SELECT id, order_number, vendor_id, budget_id, status, notes, created_at, ordered_at, closed_at, library_id
+		FROM purchase_orders
+	`
+
+	var conditions []string
+	var args []any
+
+	conditions = append(conditions, "library_id = ?")
+	args = append(args, libraryID)
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.VendorID != nil {
+		conditions = append(conditions, "vendor_id = ?")
+		args = append(args, *filter.VendorID)
+	}
+
+	if filter.BudgetID != nil {
+		conditions = append(conditions, "budget_id = ?")
+		args = append(args, *filter.BudgetID)
+	}
+
+	query += "\nWHERE " + strings.Join(conditions, " AND ") + "\nORDER BY created_at DESC, id DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.PurchaseOrder
+
+	for rows.Next() {
+		order := &models.PurchaseOrder{}
+
+		err := rows.Scan(
+			&order.ID,
+			&order.OrderNumber,
+			&order.VendorID,
+			&order.BudgetID,
+			&order.Status,
+			&order.Notes,
+			&order.CreatedAt,
+			&order.OrderedAt,
+			&order.ClosedAt,
+			&order.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (s *PurchaseOrderStore) GetByID(libraryID, id int64) (*models.PurchaseOrder, error) {
+	query := `
+		SELECT id, order_number, vendor_id, budget_id, status, notes, created_at, ordered_at, closed_at, library_id
+		FROM purchase_orders
+		WHERE id = ? AND library_id = ?
+	`
+
+	order := &models.PurchaseOrder{}
+
+	err := s.db.
+		QueryRow(query, id, libraryID).
+		Scan(
+			&order.ID,
+			&order.OrderNumber,
+			&order.VendorID,
+			&order.BudgetID,
+			&order.Status,
+			&order.Notes,
+			&order.CreatedAt,
+			&order.OrderedAt,
+			&order.ClosedAt,
+			&order.LibraryID,
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *PurchaseOrderStore) GetByNumber(libraryID int64, orderNumber string) (*models.PurchaseOrder, error) {
+	query := `
+		SELECT id, order_number, vendor_id, budget_id, status, notes, created_at, ordered_at, closed_at, library_id
+		FROM purchase_orders
+		WHERE order_number = ? COLLATE NOCASE AND library_id = ?
+	`
+
+	order := &models.PurchaseOrder{}
+
+	err := s.db.
+		QueryRow(query, orderNumber, libraryID).
+		Scan(
+			&order.ID,
+			&order.OrderNumber,
+			&order.VendorID,
+			&order.BudgetID,
+			&order.Status,
+			&order.Notes,
+			&order.CreatedAt,
+			&order.OrderedAt,
+			&order.ClosedAt,
+			&order.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Create inserts the order and its lines in one transaction.
+func (s *PurchaseOrderStore) Create(libraryID int64, order *models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO purchase_orders (order_number, vendor_id, budget_id, status, notes, created_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(
+		query,
+		order.OrderNumber,
+		order.VendorID,
+		order.BudgetID,
+		order.Status,
+		order.Notes,
+		order.CreatedAt,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertOrderLines(tx, libraryID, id, order.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	order.ID = id
+	order.LibraryID = libraryID
+
+	return order, nil
+}
+
+// Update rewrites the order and replaces its lines in one transaction.
+func (s *PurchaseOrderStore) Update(libraryID, id int64, order *models.PurchaseOrder) (*models.PurchaseOrder, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE purchase_orders
+		SET order_number = ?, vendor_id = ?, budget_id = ?, notes = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err = tx.Exec(query, order.OrderNumber, order.VendorID, order.BudgetID, order.Notes, id, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM purchase_order_lines WHERE order_id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertOrderLines(tx, libraryID, id, order.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	order.ID = id
+	order.LibraryID = libraryID
+
+	return order, nil
+}
+
+// UpdateStatus changes the status of the order, stamping when it was sent to
+// the vendor or closed.
+func (s *PurchaseOrderStore) UpdateStatus(libraryID, id int64, status string) error {
+	query := `UPDATE purchase_orders SET status = ? WHERE id = ? AND library_id = ?`
+
+	switch status {
+		case "Ordered":
+			query = `UPDATE purchase_orders SET status = ?, ordered_at = CURRENT_TIMESTAMP WHERE id = ? AND library_id = ?`
+		case "Received", "Closed", "Cancelled":
+			query = `UPDATE purchase_orders SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ? AND library_id = ?`
+	}
+
+	_, err := s.db.Exec(query, status, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PurchaseOrderStore) Delete(libraryID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM purchase_order_lines WHERE order_id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM purchase_orders WHERE id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PurchaseOrderStore) GetLines(libraryID, orderID int64) ([]*models.PurchaseOrderLine, error) {
+	query := `
+		SELECT id, order_id, book_id, isbn, title, quantity, quantity_received, unit_price, notes, library_id
+		FROM purchase_order_lines
+		WHERE order_id = ? AND library_id = ?
+		ORDER BY id
+	`
+
+	rows, err := s.db.Query(query, orderID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []*models.PurchaseOrderLine
+
+	for rows.Next() {
+		line := &models.PurchaseOrderLine{}
+
+		err := rows.Scan(
+			&line.ID,
+			&line.OrderID,
+			&line.BookID,
+			&line.ISBN,
+			&line.Title,
+			&line.Quantity,
+			&line.QuantityReceived,
+			&line.UnitPrice,
+			&line.Notes,
+			&line.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// ReceiveLine creates the copies received for a line with their Created
+// event and receipt, links the line to the book they belong to and moves the
+// order to PartiallyReceived or Received, in one transaction. It fails with
+// sql.ErrNoRows when the line has fewer copies pending than received.
+func (s *PurchaseOrderStore) ReceiveLine(libraryID, orderID, lineID int64, copies []*models.Copy, receivedAt time.Time, librarianID *int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE purchase_order_lines
+		SET
+			quantity_received = quantity_received + ?,
+			book_id = COALESCE(book_id, ?)
+		WHERE id = ? AND order_id = ? AND library_id = ? AND quantity_received + ? <= quantity
+	`
+
+	var bookID database.NullInt64
+	if len(copies) > 0 {
+		bookID.Valid = true
+		bookID.Int64 = copies[0].BookID
+	}
+
+	result, err := tx.Exec(query, len(copies), bookID, lineID, orderID, libraryID, len(copies))
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	for _, copy := range copies {
+		if err := insertCopy(tx, libraryID, copy); err != nil {
+			return err
+		}
+
+		event := &models.CopyEvent{
+			CopyID:     copy.ID,
+			EventType:  "Created",
+			OccurredAt: receivedAt,
+		}
+
+		event.ToValue.Valid = true
+		event.ToValue.String = copy.Status
+
+		if librarianID != nil {
+			event.LibrarianID.Valid = true
+			event.LibrarianID.Int64 = *librarianID
+		}
+
+		if err := insertCopyEvent(tx, libraryID, event); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO purchase_receipts (line_id, copy_id, librarian_id, received_at, library_id)
+			VALUES (?, ?, ?, ?, ?)
+		`
+
+		_, err := tx.Exec(query, lineID, copy.ID, librarianID, receivedAt, libraryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	var pending int
+
+	query = `
+		SELECT COUNT(*)
+		FROM purchase_order_lines
+		WHERE order_id = ? AND library_id = ? AND quantity_received < quantity
+	`
+
+	err = tx.QueryRow(query, orderID, libraryID).Scan(&pending)
+	if err != nil {
+		return err
+	}
+
+	if pending == 0 {
+		query = `UPDATE purchase_orders SET status = 'Received', closed_at = ? WHERE id = ? AND library_id = ?`
+		_, err = tx.Exec(query, receivedAt, orderID, libraryID)
+	} else {
+		query = `UPDATE purchase_orders SET status = 'PartiallyReceived' WHERE id = ? AND library_id = ?`
+		_, err = tx.Exec(query, orderID, libraryID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PurchaseOrderStore) GetReceipts(libraryID, orderID int64) ([]*models.PurchaseReceipt, error) {
+	query := `
+		SELECT r.id, r.line_id, r.copy_id, r.librarian_id, r.received_at, r.library_id
+		FROM purchase_receipts r
+		INNER JOIN purchase_order_lines l ON l.id = r.line_id
+		WHERE l.order_id = ? AND r.library_id = ?
+		ORDER BY r.received_at, r.id
+	`
+
+	rows, err := s.db.Query(query, orderID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []*models.PurchaseReceipt
+
+	for rows.Next() {
+		receipt := &models.PurchaseReceipt{}
+
+		err := rows.Scan(
+			&receipt.ID,
+			&receipt.LineID,
+			&receipt.CopyID,
+			&receipt.LibrarianID,
+			&receipt.ReceivedAt,
+			&receipt.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, rows.Err()
+}
+
+func (s *PurchaseOrderStore) GetInvoices(libraryID, orderID int64) ([]*models.Invoice, error) {
+	query := `
+		SELECT id, order_id, vendor_id, invoice_number, invoice_date, amount, notes, created_at, library_id
+		FROM invoices
+		WHERE order_id = ? AND library_id = ?
+		ORDER BY created_at, id
+	`
+
+	rows, err := s.db.Query(query, orderID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []*models.Invoice
+
+	for rows.Next() {
+		invoice := &models.Invoice{}
+
+		err := rows.Scan(
+			&invoice.ID,
+			&invoice.OrderID,
+			&invoice.VendorID,
+			&invoice.InvoiceNumber,
+			&invoice.InvoiceDate,
+			&invoice.Amount,
+			&invoice.Notes,
+			&invoice.CreatedAt,
+			&invoice.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, rows.Err()
+}
+
+func (s *PurchaseOrderStore) GetInvoiceByNumber(libraryID, vendorID int64, invoiceNumber string) (*models.Invoice, error) {
+	query := `
+		SELECT id, order_id, vendor_id, invoice_number, invoice_date, amount, notes, created_at, library_id
+		FROM invoices
+		WHERE invoice_number = ? COLLATE NOCASE AND vendor_id = ? AND library_id = ?
+	`
+
+	invoice := &models.Invoice{}
+
+	err := s.db.
+		QueryRow(query, invoiceNumber, vendorID, libraryID).
+		Scan(
+			&invoice.ID,
+			&invoice.OrderID,
+			&invoice.VendorID,
+			&invoice.InvoiceNumber,
+			&invoice.InvoiceDate,
+			&invoice.Amount,
+			&invoice.Notes,
+			&invoice.CreatedAt,
+			&invoice.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func (s *PurchaseOrderStore) CreateInvoice(libraryID int64, invoice *models.Invoice) (*models.Invoice, error) {
+	query := `
+		INSERT INTO invoices (order_id, vendor_id, invoice_number, invoice_date, amount, notes, created_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(
+		query,
+		invoice.OrderID,
+		invoice.VendorID,
+		invoice.InvoiceNumber,
+		invoice.InvoiceDate,
+		invoice.Amount,
+		invoice.Notes,
+		invoice.CreatedAt,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.ID = id
+	invoice.LibraryID = libraryID
+
+	return invoice, nil
+}
+
+func insertOrderLines(tx *sql.Tx, libraryID, orderID int64, lines []*models.PurchaseOrderLine) error {
+	query := `
+		INSERT INTO purchase_order_lines (
+			order_id, book_id, isbn, title, quantity, quantity_received, unit_price, notes, library_id
+		) VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?)
+	`
+
+	for _, line := range lines {
+		result, err := tx.Exec(
+			query,
+			orderID,
+			line.BookID,
+			line.ISBN,
+			line.Title,
+			line.Quantity,
+			line.UnitPrice,
+			line.Notes,
+			libraryID,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		line.ID = id
+		line.OrderID = orderID
+		line.QuantityReceived = 0
+		line.LibraryID = libraryID
+	}
+
+	return nil
+}
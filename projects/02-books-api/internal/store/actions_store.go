@@ -19,6 +19,7 @@ type LoanFilter struct {
 type ReservationFilter struct {
 	UserID  *int64
 	BookID  *int64
+	WorkID  *int64
 	Status  string
 	Expired bool
 }
@@ -44,6 +45,8 @@ type IReservationStore interface {
 	GetAll(libraryID int64) ([]*models.Reservation, error)
 	GetByID(libraryID, id int64) (*models.Reservation, error)
 	GetActiveByUserAndBook(libraryID, userID, bookID int64) (*models.Reservation, error)
+	GetActiveByUserAndWork(libraryID, userID, workID int64) (*models.Reservation, error)
+	GetNextPending(libraryID, bookID int64, workID *int64) (*models.Reservation, error)
 	GetReservationsFiltered(libraryID int64, filter ReservationFilter) ([]*models.Reservation, error)
 	Create(libraryID int64, reservation *models.Reservation) (*models.Reservation, error)
 	Update(libraryID, id int64, reservation *models.Reservation) (*models.Reservation, error)
@@ -357,7 +360,7 @@ func (s *LoanStore) Delete(libraryID, id int64) error {
 func (s *ReservationStore) GetAll(libraryID int64) ([]*models.Reservation, error) {
 	query := `
 		SELECT
-			id, user_id, book_id, reservation_date, expiration_date, 
+			id, user_id, book_id, work_id, reservation_date, expiration_date, 
 			status, priority, notified, library_id
 		FROM reservations 
 		WHERE library_id = ? 
@@ -379,6 +382,7 @@ func (s *ReservationStore) GetAll(libraryID int64) ([]*models.Reservation, error
 			&reservation.ID,
 			&reservation.UserID,
 			&reservation.BookID,
+			&reservation.WorkID,
 			&reservation.ReservationDate,
 			&reservation.ExpirationDate,
 			&reservation.Status,
@@ -400,7 +404,7 @@ func (s *ReservationStore) GetAll(libraryID int64) ([]*models.Reservation, error
 func (s *ReservationStore) GetByID(libraryID, id int64) (*models.Reservation, error) {
 	query := `
 		SELECT
-			id, user_id, book_id, reservation_date, expiration_date, 
+			id, user_id, book_id, work_id, reservation_date, expiration_date, 
 			status, priority, notified, library_id 
 		FROM reservations 
 		WHERE id = ? AND library_id = ?
@@ -414,6 +418,7 @@ func (s *ReservationStore) GetByID(libraryID, id int64) (*models.Reservation, er
 			&reservation.ID,
 			&reservation.UserID,
 			&reservation.BookID,
+			&reservation.WorkID,
 			&reservation.ReservationDate,
 			&reservation.ExpirationDate,
 			&reservation.Status,
@@ -432,7 +437,7 @@ func (s *ReservationStore) GetByID(libraryID, id int64) (*models.Reservation, er
 func (s *ReservationStore) GetActiveByUserAndBook(libraryID, userID, bookID int64) (*models.Reservation, error) {
 	query := `
 		SELECT
-			id, user_id, book_id, reservation_date, expiration_date, 
+			id, user_id, book_id, work_id, reservation_date, expiration_date, 
 			status, priority, notified, library_id
 		FROM reservations 
 		WHERE user_id = ? AND book_id = ? AND status IN ('Pending', 'Active') AND library_id = ?
@@ -447,6 +452,90 @@ func (s *ReservationStore) GetActiveByUserAndBook(libraryID, userID, bookID int6
 			&reservation.ID,
 			&reservation.UserID,
 			&reservation.BookID,
+			&reservation.WorkID,
+			&reservation.ReservationDate,
+			&reservation.ExpirationDate,
+			&reservation.Status,
+			&reservation.Priority,
+			&reservation.Notified,
+			&reservation.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (s *ReservationStore) GetActiveByUserAndWork(libraryID, userID, workID int64) (*models.Reservation, error) {
+	query := `
+		SELECT
+			id, user_id, book_id, work_id, reservation_date, expiration_date, 
+			status, priority, notified, library_id
+		FROM reservations 
+		WHERE user_id = ? AND work_id = ? AND status IN ('Pending', 'Active') AND library_id = ?
+		LIMIT 1
+	`
+
+	reservation := &models.Reservation{}
+
+	err := s.db.
+		QueryRow(query, userID, workID, libraryID).
+		Scan(
+			&reservation.ID,
+			&reservation.UserID,
+			&reservation.BookID,
+			&reservation.WorkID,
+			&reservation.ReservationDate,
+			&reservation.ExpirationDate,
+			&reservation.Status,
+			&reservation.Priority,
+			&reservation.Notified,
+			&reservation.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (s *ReservationStore) GetNextPending(libraryID, bookID int64, workID *int64) (*models.Reservation, error) {
+	query := `
+		SELECT
+			id, user_id, book_id, work_id, reservation_date, expiration_date, 
+			status, priority, notified, library_id
+		FROM reservations 
+		WHERE status = 'Pending' AND library_id = ?
+			AND ((work_id IS NULL AND book_id = ?) OR work_id = ?)
+		ORDER BY priority DESC, reservation_date ASC
+		LIMIT 1
+	`
+
+	var work any
+	if workID != nil {
+		work = *workID
+	}
+
+	reservation := &models.Reservation{}
+
+	err := s.db.
+		QueryRow(query, libraryID, bookID, work).
+		Scan(
+			&reservation.ID,
+			&reservation.UserID,
+			&reservation.BookID,
+			&reservation.WorkID,
 			&reservation.ReservationDate,
 			&reservation.ExpirationDate,
 			&reservation.Status,
@@ -469,7 +558,7 @@ func (s *ReservationStore) GetActiveByUserAndBook(libraryID, userID, bookID int6
 func (s *ReservationStore) GetReservationsFiltered(libraryID int64, filter ReservationFilter) ([]*models.Reservation, error) {
 	query := `
 		SELECT
-			id, user_id, book_id, reservation_date,
+			id, user_id, book_id, work_id, reservation_date,
 			expiration_date, status, priority, notified, library_id
 		FROM reservations 
 	`
@@ -490,6 +579,11 @@ func (s *ReservationStore) GetReservationsFiltered(libraryID int64, filter Reser
 		args = append(args, *filter.BookID)
 	}
 
+	if filter.WorkID != nil {
+		conditions = append(conditions, "work_id = ?")
+		args = append(args, *filter.WorkID)
+	}
+
 	if filter.Status != "" {
 		conditions = append(conditions, "status = ?")
 		args = append(args, filter.Status)
@@ -521,6 +615,7 @@ func (s *ReservationStore) GetReservationsFiltered(libraryID int64, filter Reser
 			&reservation.ID,
 			&reservation.UserID,
 			&reservation.BookID,
+			&reservation.WorkID,
 			&reservation.ReservationDate,
 			&reservation.ExpirationDate,
 			&reservation.Status,
@@ -541,13 +636,13 @@ func (s *ReservationStore) GetReservationsFiltered(libraryID int64, filter Reser
 
 func (s *ReservationStore) Create(libraryID int64, reservation *models.Reservation) (*models.Reservation, error) {
 	query := `
-		INSERT INTO reservations (user_id, book_id, reservation_date, expiration_date, status, priority, notified, library_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO reservations (user_id, book_id, work_id, reservation_date, expiration_date, status, priority, notified, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := s.db.Exec(
 		query,
-		reservation.UserID, reservation.BookID, reservation.ReservationDate,
+		reservation.UserID, reservation.BookID, reservation.WorkID, reservation.ReservationDate,
 		reservation.ExpirationDate, reservation.Status, reservation.Priority,
 		reservation.Notified, libraryID,
 	)
@@ -571,14 +666,14 @@ func (s *ReservationStore) Update(libraryID, id int64, reservation *models.Reser
 	query := `
 		UPDATE reservations 
 		SET
-			user_id = ?, book_id = ?, reservation_date = ?,
+			user_id = ?, book_id = ?, work_id = ?, reservation_date = ?,
 			expiration_date = ?, status = ?, priority = ?, notified = ?
 		WHERE id = ? AND library_id = ?
 	`
 
 	_, err := s.db.Exec(
 		query,
-		reservation.UserID, reservation.BookID, reservation.ReservationDate,
+		reservation.UserID, reservation.BookID, reservation.WorkID, reservation.ReservationDate,
 		reservation.ExpirationDate, reservation.Status, reservation.Priority,
 		reservation.Notified, id, libraryID,
 	)
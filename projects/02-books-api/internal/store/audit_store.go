@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   *int64
+	Action     string
+	ActorID    *int64
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	BeforeID   *int64
+	Limit      int
+}
+
+type IAuditStore interface {
+	GetAll(libraryID int64, filter AuditFilter) ([]*models.AuditEntry, error)
+	GetAfter(libraryID, afterID int64, limit int) ([]*models.AuditEntry, error)
+	Append(entry *models.AuditEntry, seal func(prevHash string)) (*models.AuditEntry, error)
+}
+
+type AuditStore struct {
+	db *sql.DB
+}
+
+func NewAuditStore(db *sql.DB) IAuditStore {
+	return &AuditStore{db: db}
+}
+
+const auditColumns = `
+	id, request_id, actor_id, remote_addr, method, path, entity_type, entity_id, action,
+	before, after, occurred_at, prev_hash, hash, library_id
+`
+
+// GetAll returns the entries of the library, newest first.
+func (s *AuditStore) GetAll(libraryID int64, filter AuditFilter) ([]*models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE library_id = ?`
+	args := []any{libraryID}
+
+	if filter.EntityType != "" {
+		query += ` AND entity_type = ?`
+		args = append(args, filter.EntityType)
+	}
+
+	if filter.EntityID != nil {
+		query += ` AND entity_id = ?`
+		args = append(args, *filter.EntityID)
+	}
+
+	if filter.Action != "" {
+		query += ` AND action = ?`
+		args = append(args, filter.Action)
+	}
+
+	if filter.ActorID != nil {
+		query += ` AND actor_id = ?`
+		args = append(args, *filter.ActorID)
+	}
+
+	if filter.RequestID != "" {
+		query += ` AND request_id = ?`
+		args = append(args, filter.RequestID)
+	}
+
+	if filter.From != nil {
+		query += ` AND datetime(occurred_at) >= datetime(?)`
+		args = append(args, *filter.From)
+	}
+
+	if filter.To != nil {
+		query += ` AND datetime(occurred_at) < datetime(?)`
+		args = append(args, *filter.To)
+	}
+
+	if filter.BeforeID != nil {
+		query += ` AND id < ?`
+		args = append(args, *filter.BeforeID)
+	}
+
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	return s.query(query, args...)
+}
+
+// GetAfter returns the entries of the library after the given one, oldest
+// first, to walk the chain.
+func (s *AuditStore) GetAfter(libraryID, afterID int64, limit int) ([]*models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE library_id = ? AND id > ? ORDER BY id LIMIT ?`
+
+	return s.query(query, libraryID, afterID, limit)
+}
+
+// Append chains the entry to the last one of its library: seal gets the hash
+// of that one ("" for the first) and fills the hash of the entry. The read and
+// the insert run in one write transaction, taken before reading, so another
+// process sharing the database can't chain to the same entry.
+func (s *AuditStore) Append(entry *models.AuditEntry, seal func(prevHash string)) (*models.AuditEntry, error) {
+	ctx := context.Background()
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return nil, err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			conn.ExecContext(ctx, `ROLLBACK`)
+		}
+	}()
+
+	var prevHash string
+
+	err = conn.
+		QueryRowContext(ctx, `SELECT hash FROM audit_log WHERE library_id = ? ORDER BY id DESC LIMIT 1`, entry.LibraryID).
+		Scan(&prevHash)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	seal(prevHash)
+
+	query := `
+		INSERT INTO audit_log (
+			request_id, actor_id, remote_addr, method, path, entity_type, entity_id, action,
+			before, after, occurred_at, prev_hash, hash, library_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := conn.ExecContext(
+		ctx,
+		query,
+		entry.RequestID,
+		entry.ActorID,
+		entry.RemoteAddr,
+		entry.Method,
+		entry.Path,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Action,
+		rawJSON(entry.Before),
+		rawJSON(entry.After),
+		entry.OccurredAt,
+		entry.PrevHash,
+		entry.Hash,
+		entry.LibraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, `COMMIT`); err != nil {
+		return nil, err
+	}
+
+	committed = true
+
+	return entry, nil
+}
+
+func (s *AuditStore) query(query string, args ...any) ([]*models.AuditEntry, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func scanAuditEntry(row rowScanner) (*models.AuditEntry, error) {
+	entry := &models.AuditEntry{}
+
+	var before, after database.NullString
+
+	err := row.Scan(
+		&entry.ID,
+		&entry.RequestID,
+		&entry.ActorID,
+		&entry.RemoteAddr,
+		&entry.Method,
+		&entry.Path,
+		&entry.EntityType,
+		&entry.EntityID,
+		&entry.Action,
+		&before,
+		&after,
+		&entry.OccurredAt,
+		&entry.PrevHash,
+		&entry.Hash,
+		&entry.LibraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if before.Valid {
+		entry.Before = json.RawMessage(before.String)
+	}
+
+	if after.Valid {
+		entry.After = json.RawMessage(after.String)
+	}
+
+	return entry, nil
+}
+
+func rawJSON(value json.RawMessage) database.NullString {
+	var result database.NullString
+
+	if value != nil {
+		result.String = string(value)
+		result.Valid = true
+	}
+
+	return result
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
 )
@@ -9,8 +10,10 @@ import (
 type IAuthorStore interface {
 	GetAll(libraryID int64) ([]*models.Author, error)
 	GetByID(libraryID, id int64) (*models.Author, error)
+	GetByName(libraryID int64, firstName, lastName string) (*models.Author, error)
 	Create(libraryID int64, author *models.Author) (*models.Author, error)
 	Update(libraryID, id int64, author *models.Author) (*models.Author, error)
+	Merge(libraryID, targetID int64, duplicateIDs []int64) error
 	Delete(libraryID, id int64) error
 }
 
@@ -25,7 +28,11 @@ func NewAuthorStore(db *sql.DB) IAuthorStore {
 }
 
 func (s *AuthorStore) GetAll(libraryID int64) ([]*models.Author, error) {
-	query := `SELECT id, first_name, last_name, biography, nationality, library_id FROM authors WHERE library_id = ?`
+	query := `
+		SELECT id, first_name, last_name, biography, nationality, birth_year, death_year, library_id
+		FROM authors
+		WHERE library_id = ?
+	`
 
 	rows, err := s.db.Query(query, libraryID)
 	if err != nil {
@@ -44,6 +51,8 @@ func (s *AuthorStore) GetAll(libraryID int64) ([]*models.Author, error) {
 			&author.LastName,
 			&author.Biography,
 			&author.Nationality,
+			&author.BirthYear,
+			&author.DeathYear,
 			&author.LibraryID,
 		)
 
@@ -54,11 +63,28 @@ func (s *AuthorStore) GetAll(libraryID int64) ([]*models.Author, error) {
 		authors = append(authors, author)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.getAliases(libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, author := range authors {
+		author.Aliases = aliases[author.ID]
+	}
+
 	return authors, nil
 }
 
 func (s *AuthorStore) GetByID(libraryID, id int64) (*models.Author, error) {
-	query := `SELECT id, first_name, last_name, biography, nationality, library_id FROM authors WHERE id = ? AND library_id = ?`
+	query := `
+		SELECT id, first_name, last_name, biography, nationality, birth_year, death_year, library_id
+		FROM authors
+		WHERE id = ? AND library_id = ?
+	`
 
 	author := &models.Author{}
 
@@ -70,6 +96,8 @@ func (s *AuthorStore) GetByID(libraryID, id int64) (*models.Author, error) {
 			&author.LastName,
 			&author.Biography,
 			&author.Nationality,
+			&author.BirthYear,
+			&author.DeathYear,
 			&author.LibraryID,
 		)
 
@@ -77,49 +105,318 @@ func (s *AuthorStore) GetByID(libraryID, id int64) (*models.Author, error) {
 		return nil, err
 	}
 
+	aliases, err := s.getAuthorAliases(libraryID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	author.Aliases = aliases
+
 	return author, nil
 }
 
-func (s *AuthorStore) Create(libraryID int64, author *models.Author) (*models.Author, error) {
-	query := `INSERT INTO authors (first_name, last_name, biography, nationality, library_id) VALUES (?, ?, ?, ?, ?)`
+// GetByName also matches the alias names, so a variant spelling resolves to
+// its authority record.
+func (s *AuthorStore) GetByName(libraryID int64, firstName, lastName string) (*models.Author, error) {
+	query := `
+		SELECT id, first_name, last_name, biography, nationality, birth_year, death_year, library_id
+		FROM authors
+		WHERE library_id = ? AND (
+			(first_name = ? COLLATE NOCASE AND last_name = ? COLLATE NOCASE)
+			OR id IN (SELECT author_id FROM author_aliases WHERE name = ? COLLATE NOCASE AND library_id = ?)
+		)
+		ORDER BY (first_name = ? COLLATE NOCASE AND last_name = ? COLLATE NOCASE) DESC, id
+		LIMIT 1
+	`
+
+	fullName := strings.TrimSpace(firstName + " " + lastName)
+
+	author := &models.Author{}
+
+	err := s.db.
+		QueryRow(query, libraryID, firstName, lastName, fullName, libraryID, firstName, lastName).
+		Scan(
+			&author.ID,
+			&author.FirstName,
+			&author.LastName,
+			&author.Biography,
+			&author.Nationality,
+			&author.BirthYear,
+			&author.DeathYear,
+			&author.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 
-	result, err := s.db.Exec(query, author.FirstName, author.LastName, author.Biography, author.Nationality, libraryID)
 	if err != nil {
 		return nil, err
 	}
 
-	id, err := result.LastInsertId()
+	aliases, err := s.getAuthorAliases(libraryID, author.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	author.ID = id
-	author.LibraryID = libraryID
+	author.Aliases = aliases
+
+	return author, nil
+}
+
+func (s *AuthorStore) Create(libraryID int64, author *models.Author) (*models.Author, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := insertAuthor(tx, libraryID, author); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	return author, nil
 }
 
+// Update keeps the current aliases when author.Aliases is nil; an empty slice
+// removes them.
 func (s *AuthorStore) Update(libraryID, id int64, author *models.Author) (*models.Author, error) {
-	query := `UPDATE authors SET first_name = ?, last_name = ?, biography = ?, nationality = ? WHERE id = ? AND library_id = ?`
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE authors
+		SET first_name = ?, last_name = ?, biography = ?, nationality = ?, birth_year = ?, death_year = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err = tx.Exec(
+		query,
+		author.FirstName,
+		author.LastName,
+		author.Biography,
+		author.Nationality,
+		author.BirthYear,
+		author.DeathYear,
+		id,
+		libraryID,
+	)
 
-	_, err := s.db.Exec(query, author.FirstName, author.LastName, author.Biography, author.Nationality, id, libraryID)
 	if err != nil {
 		return nil, err
 	}
 
-	author.ID = id
-	author.LibraryID = libraryID
-	
-	return author, nil
+	if author.Aliases != nil {
+		if err := replaceAuthorAliases(tx, libraryID, id, author.Aliases); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(libraryID, id)
+}
+
+// Merge repoints the books of every duplicate to the target author, keeps the
+// duplicate names as aliases and deletes the duplicates, all in one transaction.
+func (s *AuthorStore) Merge(libraryID, targetID int64, duplicateIDs []int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, duplicateID := range duplicateIDs {
+		query := `
+			INSERT OR IGNORE INTO book_authors (book_id, author_id, position, library_id)
+			SELECT book_id, ?, position, library_id FROM book_authors WHERE author_id = ? AND library_id = ?
+		`
+
+		_, err = tx.Exec(query, targetID, duplicateID, libraryID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM book_authors WHERE author_id = ? AND library_id = ?`, duplicateID, libraryID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT OR IGNORE INTO author_aliases (author_id, name, library_id)
+			SELECT t.id, d.first_name || ' ' || d.last_name, d.library_id
+			FROM authors d, authors t
+			WHERE d.id = ? AND t.id = ? AND d.library_id = ? AND t.library_id = d.library_id
+				AND (d.first_name || ' ' || d.last_name) <> (t.first_name || ' ' || t.last_name) COLLATE NOCASE
+		`
+
+		_, err = tx.Exec(query, duplicateID, targetID, libraryID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT OR IGNORE INTO author_aliases (author_id, name, library_id)
+			SELECT ?, name, library_id FROM author_aliases WHERE author_id = ? AND library_id = ?
+		`
+
+		_, err = tx.Exec(query, targetID, duplicateID, libraryID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			UPDATE authors SET
+				biography = COALESCE(biography, (SELECT biography FROM authors WHERE id = ?)),
+				nationality = COALESCE(nationality, (SELECT nationality FROM authors WHERE id = ?)),
+				birth_year = COALESCE(birth_year, (SELECT birth_year FROM authors WHERE id = ?)),
+				death_year = COALESCE(death_year, (SELECT death_year FROM authors WHERE id = ?))
+			WHERE id = ? AND library_id = ?
+		`
+
+		_, err = tx.Exec(query, duplicateID, duplicateID, duplicateID, duplicateID, targetID, libraryID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM author_aliases WHERE author_id = ? AND library_id = ?`, duplicateID, libraryID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM authors WHERE id = ? AND library_id = ?`, duplicateID, libraryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (s *AuthorStore) Delete(libraryID, id int64) error {
+	_, err := s.db.Exec(`DELETE FROM author_aliases WHERE author_id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM authors WHERE id = ? AND library_id = ?`
 
-	_, err := s.db.Exec(query, id, libraryID)
+	_, err = s.db.Exec(query, id, libraryID)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (s *AuthorStore) getAliases(libraryID int64) (map[int64][]string, error) {
+	query := `SELECT author_id, name FROM author_aliases WHERE library_id = ? ORDER BY author_id, name`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[int64][]string)
+
+	for rows.Next() {
+		var authorID int64
+		var name string
+
+		if err := rows.Scan(&authorID, &name); err != nil {
+			return nil, err
+		}
+
+		aliases[authorID] = append(aliases[authorID], name)
+	}
+
+	return aliases, rows.Err()
+}
+
+func (s *AuthorStore) getAuthorAliases(libraryID, authorID int64) ([]string, error) {
+	query := `SELECT name FROM author_aliases WHERE author_id = ? AND library_id = ? ORDER BY name`
+
+	rows, err := s.db.Query(query, authorID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []string
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		aliases = append(aliases, name)
+	}
+
+	return aliases, rows.Err()
+}
+
+func replaceAuthorAliases(tx *sql.Tx, libraryID, authorID int64, aliases []string) error {
+	_, err := tx.Exec(`DELETE FROM author_aliases WHERE author_id = ? AND library_id = ?`, authorID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT OR IGNORE INTO author_aliases (author_id, name, library_id) VALUES (?, ?, ?)`
+
+	for _, alias := range aliases {
+		if _, err := tx.Exec(query, authorID, alias, libraryID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertAuthor creates the author with its aliases.
+func insertAuthor(tx *sql.Tx, libraryID int64, author *models.Author) error {
+	query := `
+		INSERT INTO authors (first_name, last_name, biography, nationality, birth_year, death_year, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(
+		query,
+		author.FirstName,
+		author.LastName,
+		author.Biography,
+		author.Nationality,
+		author.BirthYear,
+		author.DeathYear,
+		libraryID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := replaceAuthorAliases(tx, libraryID, id, author.Aliases); err != nil {
+		return err
+	}
+
+	author.ID = id
+	author.LibraryID = libraryID
+
+	return nil
+}
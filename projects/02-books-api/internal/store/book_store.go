@@ -4,15 +4,19 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
 )
 
 type BookFilter struct {
-	ISBN       string
-	ShelfID    *int64
-	AuthorID   *int64
-	CategoryID *int64
+	ISBN         string
+	ShelfID      *int64
+	AuthorID     *int64
+	CategoryID   *int64
+	WorkID       *int64
+	SeriesID     *int64
+	Availability string // Derived status: Available, Borrowed, Reserved, Maintenance, Unavailable
 }
 
 type IBookStore interface {
@@ -32,6 +36,14 @@ type IBookStore interface {
 	GetBookCategories(libraryID, bookID int64) ([]*models.Category, error)
 	AddCategoryToBook(libraryID int64, bookCategory *models.BookCategory) error
 	RemoveCategoryFromBook(libraryID, bookID, categoryID int64) error
+
+	UpdateCallNumber(libraryID, bookID int64, callNumber string) error
+
+	GetAvailability(libraryID, bookID int64) (*models.BookAvailability, error)
+	GetAvailabilityByBookIDs(libraryID int64, bookIDs []int64) ([]*models.BookAvailability, error)
+
+	GetEditedFields(libraryID, bookID int64) ([]string, error)
+	MarkFieldsEdited(libraryID, bookID int64, fields []string) error
 }
 
 type BookStore struct {
@@ -49,7 +61,9 @@ func (s *BookStore) GetAll(libraryID int64) ([]*models.Book, error) {
 		SELECT
 			id, isbn, title, subtitle, edition, language, 
 			publication_year, pages, synopsis, publisher_id, 
-			shelf_id, status, registration_date, library_id
+			shelf_id, work_id, series_id, volume_number,
+			classification_scheme, classification_number, cutter_number, call_number,
+			status, registration_date, library_id
 		FROM books
 		WHERE library_id = ?
 		ORDER BY title
@@ -78,6 +92,13 @@ func (s *BookStore) GetAll(libraryID int64) ([]*models.Book, error) {
 			&book.Synopsis,
 			&book.PublisherID,
 			&book.ShelfID,
+			&book.WorkID,
+			&book.SeriesID,
+			&book.VolumeNumber,
+			&book.ClassScheme,
+			&book.ClassNumber,
+			&book.CutterNumber,
+			&book.CallNumber,
 			&book.Status,
 			&book.RegistrationDate,
 			&book.LibraryID,
@@ -98,7 +119,9 @@ func (s *BookStore) GetByID(libraryID, id int64) (*models.Book, error) {
 		SELECT
 			id, isbn, title, subtitle, edition, language, 
 			publication_year, pages, synopsis, publisher_id, 
-			shelf_id, status, registration_date, library_id
+			shelf_id, work_id, series_id, volume_number,
+			classification_scheme, classification_number, cutter_number, call_number,
+			status, registration_date, library_id
 		FROM books 
 		WHERE id = ? AND library_id = ?
 	`
@@ -119,6 +142,13 @@ func (s *BookStore) GetByID(libraryID, id int64) (*models.Book, error) {
 			&book.Synopsis,
 			&book.PublisherID,
 			&book.ShelfID,
+			&book.WorkID,
+			&book.SeriesID,
+			&book.VolumeNumber,
+			&book.ClassScheme,
+			&book.ClassNumber,
+			&book.CutterNumber,
+			&book.CallNumber,
 			&book.Status,
 			&book.RegistrationDate,
 			&book.LibraryID,
@@ -136,7 +166,9 @@ func (s *BookStore) GetByISBN(libraryID int64, isbn string) (*models.Book, error
 		SELECT
 			id, isbn, title, subtitle, edition, language, 
 			publication_year, pages, synopsis, publisher_id, 
-			shelf_id, status, registration_date, library_id
+			shelf_id, work_id, series_id, volume_number,
+			classification_scheme, classification_number, cutter_number, call_number,
+			status, registration_date, library_id
 		FROM books 
 		WHERE isbn = ? AND library_id = ?
 	`
@@ -157,6 +189,13 @@ func (s *BookStore) GetByISBN(libraryID int64, isbn string) (*models.Book, error
 			&book.Synopsis,
 			&book.PublisherID,
 			&book.ShelfID,
+			&book.WorkID,
+			&book.SeriesID,
+			&book.VolumeNumber,
+			&book.ClassScheme,
+			&book.ClassNumber,
+			&book.CutterNumber,
+			&book.CallNumber,
 			&book.Status,
 			&book.RegistrationDate,
 			&book.LibraryID,
@@ -178,7 +217,9 @@ func (s *BookStore) GetBooksFiltered(libraryID int64, filter BookFilter) ([]*mod
 		SELECT DISTINCT
 			b.id, b.isbn, b.title, b.subtitle, b.edition, b.language, 
 			b.publication_year, b.pages, b.synopsis, b.publisher_id, 
-			b.shelf_id, b.status, b.registration_date, b.library_id
+			b.shelf_id, b.work_id, b.series_id, b.volume_number,
+			b.classification_scheme, b.classification_number, b.cutter_number, b.call_number,
+			b.status, b.registration_date, b.library_id
 		FROM books b
 	`
 
@@ -192,6 +233,10 @@ func (s *BookStore) GetBooksFiltered(libraryID int64, filter BookFilter) ([]*mod
 		joins = append(joins, "INNER JOIN book_categories bc ON b.id = bc.book_id")
 	}
 
+	if filter.Availability != "" {
+		joins = append(joins, "INNER JOIN book_availability av ON b.id = av.book_id")
+	}
+
 	if len(joins) > 0 {
 		query += "\n" + strings.Join(joins, "\n")
 	}
@@ -212,13 +257,32 @@ func (s *BookStore) GetBooksFiltered(libraryID int64, filter BookFilter) ([]*mod
 		args = append(args, *filter.ShelfID)
 	}
 
+	if filter.WorkID != nil {
+		conditions = append(conditions, "b.work_id = ?")
+		args = append(args, *filter.WorkID)
+	}
+
+	if filter.SeriesID != nil {
+		conditions = append(conditions, "b.series_id = ?")
+		args = append(args, *filter.SeriesID)
+	}
+
+	if filter.Availability != "" {
+		conditions = append(conditions, "av.status = ?")
+		args = append(args, filter.Availability)
+	}
+
 	if filter.AuthorID != nil {
 		conditions = append(conditions, "ba.author_id = ?")
 		args = append(args, *filter.AuthorID)
 	}
 
 	if filter.CategoryID != nil {
-		conditions = append(conditions, "bc.category_id = ?")
+		conditions = append(conditions, `bc.category_id IN (
+			SELECT d.id FROM categories d
+			INNER JOIN categories p ON d.library_id = p.library_id AND d.path LIKE p.path || '%'
+			WHERE p.id = ?
+		)`)
 		args = append(args, *filter.CategoryID)
 	}
 
@@ -226,7 +290,16 @@ func (s *BookStore) GetBooksFiltered(libraryID int64, filter BookFilter) ([]*mod
 		query += "\nWHERE " + strings.Join(conditions, " AND ")
 	}
 
-	query += "\nORDER BY b.title"
+	switch {
+		case filter.ShelfID != nil:
+			query += "\nORDER BY b.call_number IS NULL, b.call_number, b.title"
+		case filter.SeriesID != nil:
+			query += "\nORDER BY b.volume_number IS NULL, b.volume_number, b.title"
+		case filter.WorkID != nil:
+			query += "\nORDER BY b.publication_year IS NULL, b.publication_year, b.title"
+		default:
+			query += "\nORDER BY b.title"
+	}
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -251,6 +324,13 @@ func (s *BookStore) GetBooksFiltered(libraryID int64, filter BookFilter) ([]*mod
 			&book.Synopsis,
 			&book.PublisherID,
 			&book.ShelfID,
+			&book.WorkID,
+			&book.SeriesID,
+			&book.VolumeNumber,
+			&book.ClassScheme,
+			&book.ClassNumber,
+			&book.CutterNumber,
+			&book.CallNumber,
 			&book.Status,
 			&book.RegistrationDate,
 			&book.LibraryID,
@@ -267,81 +347,19 @@ func (s *BookStore) GetBooksFiltered(libraryID int64, filter BookFilter) ([]*mod
 }
 
 func (s *BookStore) Create(libraryID int64, book *models.Book) (*models.Book, error) {
-	query := `
-		INSERT INTO books (
-			isbn, title, subtitle, edition, language, 
-			publication_year, pages, synopsis, publisher_id, 
-			shelf_id, status, registration_date, library_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
-	result, err := s.db.Exec(
-		query,
-		book.ISBN,
-		book.Title,
-		book.Subtitle,
-		book.Edition,
-		book.Language,
-		book.PublicationYear,
-		book.Pages,
-		book.Synopsis,
-		book.PublisherID,
-		book.ShelfID,
-		book.Status,
-		book.RegistrationDate,
-		libraryID,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
+	if err := insertBook(s.db, libraryID, book); err != nil {
 		return nil, err
 	}
 
-	book.ID = id
-	book.LibraryID = libraryID
-
 	return book, nil
 }
 
 func (s *BookStore) Update(libraryID, id int64, book *models.Book) (*models.Book, error) {
-	query := `
-		UPDATE books 
-		SET
-			isbn = ?, title = ?, subtitle = ?, edition = ?, language = ?,
-			publication_year = ?, pages = ?, synopsis = ?, publisher_id = ?,
-			shelf_id = ?, status = ?
-		WHERE id = ? AND library_id = ?
-	`
-
-	_, err := s.db.Exec(
-		query,
-		book.ISBN,
-		book.Title,
-		book.Subtitle,
-		book.Edition,
-		book.Language,
-		book.PublicationYear,
-		book.Pages,
-		book.Synopsis,
-		book.PublisherID,
-		book.ShelfID,
-		book.Status,
-		id,
-		libraryID,
-	)
-
-	if err != nil {
+	if err := updateBook(s.db, libraryID, id, book); err != nil {
 		return nil, err
 	}
 
-	book.ID = id
-	book.LibraryID = libraryID
-	
-	return book, nil
+	return s.GetByID(libraryID, id)
 }
 
 func (s *BookStore) Delete(libraryID, id int64) error {
@@ -355,6 +373,16 @@ func (s *BookStore) Delete(libraryID, id int64) error {
 		return err
 	}
 
+	_, err = s.db.Exec("DELETE FROM book_covers WHERE book_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("DELETE FROM book_edited_fields WHERE book_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.db.Exec("DELETE FROM books WHERE id = ? AND library_id = ?", id, libraryID)
 	if err != nil {
 		return err
@@ -365,7 +393,7 @@ func (s *BookStore) Delete(libraryID, id int64) error {
 
 func (s *BookStore) GetBookAuthors(libraryID, bookID int64) ([]*models.Author, error) {
 	query := `
-		SELECT a.id, a.first_name, a.last_name, a.biography, a.nationality, a.library_id
+		SELECT a.id, a.first_name, a.last_name, a.biography, a.nationality, a.birth_year, a.death_year, a.library_id
 		FROM authors a
 		INNER JOIN book_authors ba ON a.id = ba.author_id
 		WHERE ba.book_id = ? AND a.library_id = ?
@@ -389,6 +417,8 @@ func (s *BookStore) GetBookAuthors(libraryID, bookID int64) ([]*models.Author, e
 			&author.LastName,
 			&author.Biography,
 			&author.Nationality,
+			&author.BirthYear,
+			&author.DeathYear,
 			&author.LibraryID,
 		)
 
@@ -421,14 +451,7 @@ func (s *BookStore) AddAuthorToBook(libraryID int64, bookAuthor *models.BookAuth
 		return fmt.Errorf("El autor ya está asociado a este libro")
 	}
 
-	query = `INSERT INTO book_authors (book_id, author_id, position) VALUES (?, ?, ?)`
-
-	_, err = s.db.Exec(query, bookAuthor.BookID, bookAuthor.AuthorID, bookAuthor.Position)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return insertBookAuthor(s.db, libraryID, bookAuthor)
 }
 
 func (s *BookStore) RemoveAuthorFromBook(libraryID, bookID, authorID int64) error {
@@ -473,7 +496,7 @@ func (s *BookStore) UpdateAuthorPosition(libraryID, bookID, authorID int64, posi
 
 func (s *BookStore) GetBookCategories(libraryID, bookID int64) ([]*models.Category, error) {
 	query := `
-		SELECT c.id, c.name, c.description, c.library_id
+		SELECT c.id, c.name, c.description, c.parent_id, c.path, c.depth, c.library_id
 		FROM categories c
 		INNER JOIN book_categories bc ON c.id = bc.category_id
 		WHERE bc.book_id = ? AND c.library_id = ?
@@ -495,6 +518,9 @@ func (s *BookStore) GetBookCategories(libraryID, bookID int64) ([]*models.Catego
 			&category.ID,
 			&category.Name,
 			&category.Description,
+			&category.ParentID,
+			&category.Path,
+			&category.Depth,
 			&category.LibraryID,
 		)
 
@@ -526,9 +552,9 @@ func (s *BookStore) AddCategoryToBook(libraryID int64, bookCategory *models.Book
 		return fmt.Errorf("La categoría ya está asociada a este libro")
 	}
 
-	query = `INSERT INTO book_categories (book_id, category_id) VALUES (?, ?)`
+	query = `INSERT INTO book_categories (book_id, category_id, library_id) VALUES (?, ?, ?)`
 
-	_, err = s.db.Exec(query, bookCategory.BookID, bookCategory.CategoryID)
+	_, err = s.db.Exec(query, bookCategory.BookID, bookCategory.CategoryID, libraryID)
 	if err != nil {
 		return err
 	}
@@ -555,3 +581,241 @@ func (s *BookStore) RemoveCategoryFromBook(libraryID, bookID, categoryID int64)
 
 	return nil
 }
+
+func (s *BookStore) UpdateCallNumber(libraryID, bookID int64, callNumber string) error {
+	query := `UPDATE books SET call_number = NULLIF(?, '') WHERE id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, callNumber, bookID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *BookStore) GetEditedFields(libraryID, bookID int64) ([]string, error) {
+	query := `SELECT field FROM book_edited_fields WHERE book_id = ? AND library_id = ? ORDER BY field`
+
+	rows, err := s.db.Query(query, bookID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []string
+
+	for rows.Next() {
+		var field string
+
+		if err := rows.Scan(&field); err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func (s *BookStore) MarkFieldsEdited(libraryID, bookID int64, fields []string) error {
+	query := `
+		INSERT INTO book_edited_fields (book_id, field, edited_at, library_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(book_id, field) DO UPDATE SET edited_at = excluded.edited_at
+	`
+
+	now := time.Now()
+
+	for _, field := range fields {
+		_, err := s.db.Exec(query, bookID, field, now, libraryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BookStore) GetAvailability(libraryID, bookID int64) (*models.BookAvailability, error) {
+	query := `
+		SELECT
+			book_id, total_copies, available_copies, on_hold_copies, on_loan_copies,
+			earliest_due_date, queue_length, status
+		FROM book_availability
+		WHERE book_id = ? AND library_id = ?
+	`
+
+	availability := &models.BookAvailability{}
+
+	err := s.db.
+		QueryRow(query, bookID, libraryID).
+		Scan(
+			&availability.BookID,
+			&availability.TotalCopies,
+			&availability.AvailableCopies,
+			&availability.OnHoldCopies,
+			&availability.OnLoanCopies,
+			&availability.EarliestDueDate,
+			&availability.QueueLength,
+			&availability.Status,
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return availability, nil
+}
+
+// GetAvailabilityByBookIDs reads the view in batches so a long list stays
+// under the SQLite limit of bound parameters.
+func (s *BookStore) GetAvailabilityByBookIDs(libraryID int64, bookIDs []int64) ([]*models.BookAvailability, error) {
+	const batchSize = 500
+
+	var availabilities []*models.BookAvailability
+
+	for start := 0; start < len(bookIDs); start += batchSize {
+		batch := bookIDs[start:min(start+batchSize, len(bookIDs))]
+
+		query := `
+			SELECT
+				book_id, total_copies, available_copies, on_hold_copies, on_loan_copies,
+				earliest_due_date, queue_length, status
+			FROM book_availability
+			WHERE library_id = ? AND book_id IN (?` + strings.Repeat(", ?", len(batch)-1) + `)
+		`
+
+		args := []any{libraryID}
+		for _, bookID := range batch {
+			args = append(args, bookID)
+		}
+
+		rows, err := s.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			availability := &models.BookAvailability{}
+
+			err := rows.Scan(
+				&availability.BookID,
+				&availability.TotalCopies,
+				&availability.AvailableCopies,
+				&availability.OnHoldCopies,
+				&availability.OnLoanCopies,
+				&availability.EarliestDueDate,
+				&availability.QueueLength,
+				&availability.Status,
+			)
+
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			availabilities = append(availabilities, availability)
+		}
+
+		err = rows.Err()
+		rows.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return availabilities, nil
+}
+
+func insertBook(db execer, libraryID int64, book *models.Book) error {
+	query := `
+		INSERT INTO books (
+			isbn, title, subtitle, edition, language, 
+			publication_year, pages, synopsis, publisher_id, 
+			shelf_id, work_id, series_id, volume_number,
+			classification_scheme, classification_number, cutter_number,
+			status, registration_date, library_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'Unavailable', ?, ?)
+	`
+
+	result, err := db.Exec(
+		query,
+		book.ISBN,
+		book.Title,
+		book.Subtitle,
+		book.Edition,
+		book.Language,
+		book.PublicationYear,
+		book.Pages,
+		book.Synopsis,
+		book.PublisherID,
+		book.ShelfID,
+		book.WorkID,
+		book.SeriesID,
+		book.VolumeNumber,
+		book.ClassScheme,
+		book.ClassNumber,
+		book.CutterNumber,
+		book.RegistrationDate,
+		libraryID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	book.ID = id
+	book.Status = "Unavailable"
+	book.LibraryID = libraryID
+
+	return nil
+}
+
+func updateBook(db execer, libraryID, id int64, book *models.Book) error {
+	query := `
+		UPDATE books 
+		SET
+			isbn = ?, title = ?, subtitle = ?, edition = ?, language = ?,
+			publication_year = ?, pages = ?, synopsis = ?, publisher_id = ?,
+			shelf_id = ?, work_id = ?, series_id = ?, volume_number = ?,
+			classification_scheme = ?, classification_number = ?, cutter_number = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err := db.Exec(
+		query,
+		book.ISBN,
+		book.Title,
+		book.Subtitle,
+		book.Edition,
+		book.Language,
+		book.PublicationYear,
+		book.Pages,
+		book.Synopsis,
+		book.PublisherID,
+		book.ShelfID,
+		book.WorkID,
+		book.SeriesID,
+		book.VolumeNumber,
+		book.ClassScheme,
+		book.ClassNumber,
+		book.CutterNumber,
+		id,
+		libraryID,
+	)
+
+	return err
+}
+
+func insertBookAuthor(db execer, libraryID int64, bookAuthor *models.BookAuthor) error {
+	query := `INSERT INTO book_authors (book_id, author_id, position, library_id) VALUES (?, ?, ?, ?)`
+
+	_, err := db.Exec(query, bookAuthor.BookID, bookAuthor.AuthorID, bookAuthor.Position, libraryID)
+	return err
+}
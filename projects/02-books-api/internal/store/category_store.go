@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
 )
@@ -9,8 +10,12 @@ import (
 type ICategoryStore interface {
 	GetAll(libraryID int64) ([]*models.Category, error)
 	GetByID(libraryID, id int64) (*models.Category, error)
+	GetByName(libraryID int64, parentID *int64, name string) (*models.Category, error)
+	GetChildren(libraryID, id int64) ([]*models.Category, error)
 	Create(libraryID int64, category *models.Category) (*models.Category, error)
 	Update(libraryID, id int64, category *models.Category) (*models.Category, error)
+	Move(libraryID, id int64, parentID *int64) error
+	Merge(libraryID, sourceID, targetID int64) error
 	Delete(libraryID, id int64) error
 }
 
@@ -25,7 +30,12 @@ func NewCategoryStore(db *sql.DB) ICategoryStore {
 }
 
 func (s *CategoryStore) GetAll(libraryID int64) ([]*models.Category, error) {
-	query := `SELECT id, name, description, library_id FROM categories WHERE library_id = ?`
+	query := `
+		SELECT id, name, description, parent_id, path, depth, library_id
+		FROM categories
+		WHERE library_id = ?
+		ORDER BY depth, name
+	`
 
 	rows, err := s.db.Query(query, libraryID)
 	if err != nil {
@@ -42,6 +52,9 @@ func (s *CategoryStore) GetAll(libraryID int64) ([]*models.Category, error) {
 			&category.ID,
 			&category.Name,
 			&category.Description,
+			&category.ParentID,
+			&category.Path,
+			&category.Depth,
 			&category.LibraryID,
 		)
 
@@ -56,7 +69,7 @@ func (s *CategoryStore) GetAll(libraryID int64) ([]*models.Category, error) {
 }
 
 func (s *CategoryStore) GetByID(libraryID, id int64) (*models.Category, error) {
-	query := `SELECT id, name, description, library_id FROM categories WHERE id = ? AND library_id = ?`
+	query := `SELECT id, name, description, parent_id, path, depth, library_id FROM categories WHERE id = ? AND library_id = ?`
 
 	var category = &models.Category{}
 
@@ -66,6 +79,9 @@ func (s *CategoryStore) GetByID(libraryID, id int64) (*models.Category, error) {
 			&category.ID,
 			&category.Name,
 			&category.Description,
+			&category.ParentID,
+			&category.Path,
+			&category.Depth,
 			&category.LibraryID,
 		)
 
@@ -76,10 +92,104 @@ func (s *CategoryStore) GetByID(libraryID, id int64) (*models.Category, error) {
 	return category, nil
 }
 
+func (s *CategoryStore) GetByName(libraryID int64, parentID *int64, name string) (*models.Category, error) {
+	query := `
+		SELECT id, name, description, parent_id, path, depth, library_id
+		FROM categories
+		WHERE library_id = ? AND COALESCE(parent_id, 0) = ? AND name = ? COLLATE NOCASE
+		LIMIT 1
+	`
+
+	var parent int64
+	if parentID != nil {
+		parent = *parentID
+	}
+
+	category := &models.Category{}
+
+	err := s.db.
+		QueryRow(query, libraryID, parent, name).
+		Scan(
+			&category.ID,
+			&category.Name,
+			&category.Description,
+			&category.ParentID,
+			&category.Path,
+			&category.Depth,
+			&category.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *CategoryStore) GetChildren(libraryID, id int64) ([]*models.Category, error) {
+	query := `
+		SELECT id, name, description, parent_id, path, depth, library_id
+		FROM categories
+		WHERE parent_id = ? AND library_id = ?
+		ORDER BY name
+	`
+
+	rows, err := s.db.Query(query, id, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.Category
+
+	for rows.Next() {
+		category := &models.Category{}
+
+		err := rows.Scan(
+			&category.ID,
+			&category.Name,
+			&category.Description,
+			&category.ParentID,
+			&category.Path,
+			&category.Depth,
+			&category.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
 func (s *CategoryStore) Create(libraryID int64, category *models.Category) (*models.Category, error) {
-	query := `INSERT INTO categories (name, description, library_id) VALUES (?, ?, ?)`
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	parentPath, parentDepth := "/", -1
+
+	if category.ParentID.Valid {
+		query := `SELECT path, depth FROM categories WHERE id = ? AND library_id = ?`
 
-	result, err := s.db.Exec(query, category.Name, category.Description, libraryID)
+		err := tx.QueryRow(query, category.ParentID.Int64, libraryID).Scan(&parentPath, &parentDepth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := `INSERT INTO categories (name, description, parent_id, depth, library_id) VALUES (?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, category.Name, category.Description, category.ParentID, parentDepth+1, libraryID)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +199,20 @@ func (s *CategoryStore) Create(libraryID int64, category *models.Category) (*mod
 		return nil, err
 	}
 
+	path := fmt.Sprintf("%s%d/", parentPath, id)
+
+	_, err = tx.Exec(`UPDATE categories SET path = ? WHERE id = ?`, path, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	category.ID = id
+	category.Path = path
+	category.Depth = parentDepth + 1
 	category.LibraryID = libraryID
 
 	return category, nil
@@ -103,16 +226,134 @@ func (s *CategoryStore) Update(libraryID, id int64, category *models.Category) (
 		return nil, err
 	}
 
-	category.ID = id
-	category.LibraryID = libraryID
-	
-	return category, nil
+	return s.GetByID(libraryID, id)
+}
+
+func (s *CategoryStore) Move(libraryID, id int64, parentID *int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := moveCategory(tx, libraryID, id, parentID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CategoryStore) Merge(libraryID, sourceID, targetID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT OR IGNORE INTO book_categories (book_id, category_id, library_id)
+		SELECT book_id, ?, library_id FROM book_categories WHERE category_id = ? AND library_id = ?
+	`
+
+	_, err = tx.Exec(query, targetID, sourceID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM book_categories WHERE category_id = ? AND library_id = ?`, sourceID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id FROM categories WHERE parent_id = ? AND library_id = ?`, sourceID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	var childIDs []int64
+
+	for rows.Next() {
+		var childID int64
+
+		if err := rows.Scan(&childID); err != nil {
+			rows.Close()
+			return err
+		}
+
+		childIDs = append(childIDs, childID)
+	}
+	rows.Close()
+
+	for _, childID := range childIDs {
+		if err := moveCategory(tx, libraryID, childID, &targetID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM categories WHERE id = ? AND library_id = ?`, sourceID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *CategoryStore) Delete(libraryID, id int64) error {
+	_, err := s.db.Exec(`DELETE FROM book_categories WHERE category_id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM categories WHERE id = ? AND library_id = ?`
 
-	_, err := s.db.Exec(query, id, libraryID)
+	_, err = s.db.Exec(query, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func moveCategory(tx *sql.Tx, libraryID, id int64, parentID *int64) error {
+	var oldPath string
+	var oldDepth int
+
+	query := `SELECT path, depth FROM categories WHERE id = ? AND library_id = ?`
+
+	if err := tx.QueryRow(query, id, libraryID).Scan(&oldPath, &oldDepth); err != nil {
+		return err
+	}
+
+	newPath := fmt.Sprintf("/%d/", id)
+	newDepth := 0
+
+	var parent any
+
+	if parentID != nil {
+		var parentPath string
+		var parentDepth int
+
+		if err := tx.QueryRow(query, *parentID, libraryID).Scan(&parentPath, &parentDepth); err != nil {
+			return err
+		}
+
+		newPath = fmt.Sprintf("%s%d/", parentPath, id)
+		newDepth = parentDepth + 1
+		parent = *parentID
+	}
+
+	_, err := tx.Exec(`UPDATE categories SET parent_id = ? WHERE id = ? AND library_id = ?`, parent, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE categories
+		SET path = ? || substr(path, ?), depth = depth + ?
+		WHERE library_id = ? AND path LIKE ? || '%'
+	`
+
+	_, err = tx.Exec(query, newPath, len(oldPath)+1, newDepth-oldDepth, libraryID, oldPath)
 	if err != nil {
 		return err
 	}
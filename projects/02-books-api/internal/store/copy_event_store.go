@@ -0,0 +1,115 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type ICopyEventStore interface {
+	GetByCopyID(libraryID, copyID int64) ([]*models.CopyEvent, error)
+	Create(libraryID int64, event *models.CopyEvent) (*models.CopyEvent, error)
+}
+
+type CopyEventStore struct {
+	db *sql.DB
+}
+
+func NewCopyEventStore(db *sql.DB) ICopyEventStore {
+	return &CopyEventStore{
+		db: db,
+	}
+}
+
+func (s *CopyEventStore) GetByCopyID(libraryID, copyID int64) ([]*models.CopyEvent, error) {
+	query := `
+		SELECT
+			id, copy_id, event_type, from_value, to_value,
+			librarian_id, loan_id, audit_id, occurred_at, library_id
+		FROM copy_events
+		WHERE copy_id = ? AND library_id = ?
+		ORDER BY occurred_at, id
+	`
+
+	rows, err := s.db.Query(query, copyID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.CopyEvent
+
+	for rows.Next() {
+		event := &models.CopyEvent{}
+
+		err := rows.Scan(
+			&event.ID,
+			&event.CopyID,
+			&event.EventType,
+			&event.FromValue,
+			&event.ToValue,
+			&event.LibrarianID,
+			&event.LoanID,
+			&event.AuditID,
+			&event.OccurredAt,
+			&event.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *CopyEventStore) Create(libraryID int64, event *models.CopyEvent) (*models.CopyEvent, error) {
+	if err := insertCopyEvent(s.db, libraryID, event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertCopyEvent lets other stores append events inside their own transaction.
+func insertCopyEvent(db execer, libraryID int64, event *models.CopyEvent) error {
+	query := `
+		INSERT INTO copy_events (
+			copy_id, event_type, from_value, to_value,
+			librarian_id, loan_id, audit_id, occurred_at, library_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := db.Exec(
+		query,
+		event.CopyID,
+		event.EventType,
+		event.FromValue,
+		event.ToValue,
+		event.LibrarianID,
+		event.LoanID,
+		event.AuditID,
+		event.OccurredAt,
+		libraryID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	event.LibraryID = libraryID
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type ICoverStore interface {
+	GetAll(libraryID int64) ([]*models.BookCover, error)
+	GetByBookID(libraryID, bookID int64) (*models.BookCover, error)
+	Save(libraryID int64, cover *models.BookCover) (*models.BookCover, error)
+	Delete(libraryID, bookID int64) error
+}
+
+type CoverStore struct {
+	db *sql.DB
+}
+
+func NewCoverStore(db *sql.DB) ICoverStore {
+	return &CoverStore{
+		db: db,
+	}
+}
+
+func (s *CoverStore) GetAll(libraryID int64) ([]*models.BookCover, error) {
+	query := `
+		SELECT book_id, content_type, size, width, height, etag, updated_at, library_id
+		FROM book_covers
+		WHERE library_id = ?
+	`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var covers []*models.BookCover
+
+	for rows.Next() {
+		cover := &models.BookCover{}
+
+		err := rows.Scan(
+			&cover.BookID,
+			&cover.ContentType,
+			&cover.Size,
+			&cover.Width,
+			&cover.Height,
+			&cover.ETag,
+			&cover.UpdatedAt,
+			&cover.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		covers = append(covers, cover)
+	}
+
+	return covers, nil
+}
+
+func (s *CoverStore) GetByBookID(libraryID, bookID int64) (*models.BookCover, error) {
+	query := `
+		SELECT book_id, content_type, size, width, height, etag, updated_at, library_id
+		FROM book_covers
+		WHERE book_id = ? AND library_id = ?
+	`
+
+	cover := &models.BookCover{}
+
+	err := s.db.
+		QueryRow(query, bookID, libraryID).
+		Scan(
+			&cover.BookID,
+			&cover.ContentType,
+			&cover.Size,
+			&cover.Width,
+			&cover.Height,
+			&cover.ETag,
+			&cover.UpdatedAt,
+			&cover.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cover, nil
+}
+
+func (s *CoverStore) Save(libraryID int64, cover *models.BookCover) (*models.BookCover, error) {
+	query := `
+		INSERT INTO book_covers (book_id, content_type, size, width, height, etag, updated_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(book_id) DO UPDATE SET
+			content_type = excluded.content_type, size = excluded.size, width = excluded.width,
+			height = excluded.height, etag = excluded.etag, updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.Exec(
+		query,
+		cover.BookID,
+		cover.ContentType,
+		cover.Size,
+		cover.Width,
+		cover.Height,
+		cover.ETag,
+		cover.UpdatedAt,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cover.LibraryID = libraryID
+
+	return cover, nil
+}
+
+func (s *CoverStore) Delete(libraryID, bookID int64) error {
+	query := `DELETE FROM book_covers WHERE book_id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, bookID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
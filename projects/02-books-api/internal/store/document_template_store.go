@@ -0,0 +1,124 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type IDocumentTemplateStore interface {
+	GetAll(libraryID int64) ([]*models.DocumentTemplate, error)
+	GetByType(libraryID int64, documentType string) (*models.DocumentTemplate, error)
+	Save(libraryID int64, tmpl *models.DocumentTemplate) (*models.DocumentTemplate, error)
+	Delete(libraryID int64, documentType string) error
+}
+
+type DocumentTemplateStore struct {
+	db *sql.DB
+}
+
+func NewDocumentTemplateStore(db *sql.DB) IDocumentTemplateStore {
+	return &DocumentTemplateStore{
+		db: db,
+	}
+}
+
+func (s *DocumentTemplateStore) GetAll(libraryID int64) ([]*models.DocumentTemplate, error) {
+	query := `
+		SELECT document_type, body, updated_at, library_id
+		FROM document_templates
+		WHERE library_id = ?
+		ORDER BY document_type
+	`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.DocumentTemplate
+
+	for rows.Next() {
+		tmpl := &models.DocumentTemplate{Customized: true}
+
+		err := rows.Scan(
+			&tmpl.DocumentType,
+			&tmpl.Body,
+			&tmpl.UpdatedAt,
+			&tmpl.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, tmpl)
+	}
+
+	return templates, nil
+}
+
+func (s *DocumentTemplateStore) GetByType(libraryID int64, documentType string) (*models.DocumentTemplate, error) {
+	query := `
+		SELECT document_type, body, updated_at, library_id
+		FROM document_templates
+		WHERE document_type = ? AND library_id = ?
+	`
+
+	tmpl := &models.DocumentTemplate{Customized: true}
+
+	err := s.db.
+		QueryRow(query, documentType, libraryID).
+		Scan(
+			&tmpl.DocumentType,
+			&tmpl.Body,
+			&tmpl.UpdatedAt,
+			&tmpl.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func (s *DocumentTemplateStore) Save(libraryID int64, tmpl *models.DocumentTemplate) (*models.DocumentTemplate, error) {
+	query := `
+		INSERT INTO document_templates (document_type, body, updated_at, library_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(document_type, library_id) DO UPDATE SET
+			body = excluded.body, updated_at = excluded.updated_at
+	`
+
+	now := time.Now()
+
+	_, err := s.db.Exec(query, tmpl.DocumentType, tmpl.Body, now, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.Customized = true
+	tmpl.UpdatedAt.Time = now
+	tmpl.UpdatedAt.Valid = true
+	tmpl.LibraryID = libraryID
+
+	return tmpl, nil
+}
+
+func (s *DocumentTemplateStore) Delete(libraryID int64, documentType string) error {
+	query := `DELETE FROM document_templates WHERE document_type = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, documentType, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type IEventStore interface {
+	GetAfter(afterID int64, limit int) ([]*models.Event, error)
+	GetAfterByLibrary(libraryID, afterID int64, limit int) ([]*models.Event, error)
+	GetLastID() (int64, error)
+}
+
+type EventStore struct {
+	db *sql.DB
+}
+
+func NewEventStore(db *sql.DB) IEventStore {
+	return &EventStore{db: db}
+}
+
+const eventColumns = `id, event_type, subject_id, payload, status, occurred_at, library_id`
+
+// GetAfter returns the events of every library recorded after the given one,
+// oldest first.
+func (s *EventStore) GetAfter(afterID int64, limit int) ([]*models.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id > ? ORDER BY id LIMIT ?`
+
+	return s.query(query, afterID, limit)
+}
+
+func (s *EventStore) GetAfterByLibrary(libraryID, afterID int64, limit int) ([]*models.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE library_id = ? AND id > ? ORDER BY id LIMIT ?`
+
+	return s.query(query, libraryID, afterID, limit)
+}
+
+func (s *EventStore) GetLastID() (int64, error) {
+	var lastID int64
+
+	err := s.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM events`).Scan(&lastID)
+	if err != nil {
+		return 0, err
+	}
+
+	return lastID, nil
+}
+
+func (s *EventStore) query(query string, args ...any) ([]*models.Event, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func scanEvent(row rowScanner) (*models.Event, error) {
+	event := &models.Event{}
+
+	err := row.Scan(
+		&event.ID,
+		&event.EventType,
+		&event.SubjectID,
+		&event.Payload,
+		&event.Status,
+		&event.OccurredAt,
+		&event.LibraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type IHealthStore interface {
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (int, error)
+	GetSchemaObjects(ctx context.Context) (map[string]string, error)
+}
+
+type HealthStore struct {
+	db *sql.DB
+}
+
+func NewHealthStore(db *sql.DB) IHealthStore {
+	return &HealthStore{
+		db: db,
+	}
+}
+
+func (s *HealthStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// GetSchemaVersion returns the user_version the migrations left.
+func (s *HealthStore) GetSchemaVersion(ctx context.Context) (int, error) {
+	var version int
+
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// GetSchemaObjects returns the type of every table, view, trigger and index of
+// the database, by name.
+func (s *HealthStore) GetSchemaObjects(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, type FROM sqlite_master`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := map[string]string{}
+
+	for rows.Next() {
+		var name, objectType string
+
+		if err := rows.Scan(&name, &objectType); err != nil {
+			return nil, err
+		}
+
+		objects[name] = objectType
+	}
+
+	return objects, rows.Err()
+}
@@ -0,0 +1,408 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type InventoryAuditFilter struct {
+	Status  string
+	ZoneID  *int64
+	ShelfID *int64
+}
+
+type IInventoryStore interface {
+	GetAudits(libraryID int64, filter InventoryAuditFilter) ([]*models.InventoryAudit, error)
+	GetAuditByID(libraryID, id int64) (*models.InventoryAudit, error)
+	CreateAudit(libraryID int64, audit *models.InventoryAudit) (*models.InventoryAudit, error)
+	CloseAudit(libraryID, id int64, lostCopyIDs []int64, librarianID *int64) (int, error)
+
+	GetScans(libraryID, auditID int64) ([]*models.InventoryScan, error)
+	AddScans(libraryID, auditID int64, scans []*models.InventoryScan) error
+
+	GetAuditItems(libraryID int64, audit *models.InventoryAudit) ([]*models.InventoryItem, error)
+	GetItemByCopyID(libraryID, copyID int64) (*models.InventoryItem, error)
+}
+
+type InventoryStore struct {
+	db *sql.DB
+}
+
+func NewInventoryStore(db *sql.DB) IInventoryStore {
+	return &InventoryStore{
+		db: db,
+	}
+}
+
+func (s *InventoryStore) GetAudits(libraryID int64, filter InventoryAuditFilter) ([]*models.InventoryAudit, error) {
+	query := `
+		SELECT id, zone_id, shelf_id, status, notes, started_at, closed_at, library_id
+		FROM inventory_audits
+	`
+
+	var conditions []string
+	var args []any
+
+	conditions = append(conditions, "library_id = ?")
+	args = append(args, libraryID)
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.ZoneID != nil {
+		conditions = append(conditions, "zone_id = ?")
+		args = append(args, *filter.ZoneID)
+	}
+
+	if filter.ShelfID != nil {
+		conditions = append(conditions, "shelf_id = ?")
+		args = append(args, *filter.ShelfID)
+	}
+
+	query += "\nWHERE " + strings.Join(conditions, " AND ") + "\nORDER BY started_at DESC, id DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var audits []*models.InventoryAudit
+
+	for rows.Next() {
+		audit := &models.InventoryAudit{}
+
+		err := rows.Scan(
+			&audit.ID,
+			&audit.ZoneID,
+			&audit.ShelfID,
+			&audit.Status,
+			&audit.Notes,
+			&audit.StartedAt,
+			&audit.ClosedAt,
+			&audit.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		audits = append(audits, audit)
+	}
+
+	return audits, rows.Err()
+}
+
+func (s *InventoryStore) GetAuditByID(libraryID, id int64) (*models.InventoryAudit, error) {
+	query := `
+		SELECT id, zone_id, shelf_id, status, notes, started_at, closed_at, library_id
+		FROM inventory_audits
+		WHERE id = ? AND library_id = ?
+	`
+
+	audit := &models.InventoryAudit{}
+
+	err := s.db.
+		QueryRow(query, id, libraryID).
+		Scan(
+			&audit.ID,
+			&audit.ZoneID,
+			&audit.ShelfID,
+			&audit.Status,
+			&audit.Notes,
+			&audit.StartedAt,
+			&audit.ClosedAt,
+			&audit.LibraryID,
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
+
+func (s *InventoryStore) CreateAudit(libraryID int64, audit *models.InventoryAudit) (*models.InventoryAudit, error) {
+	query := `
+		INSERT INTO inventory_audits (zone_id, shelf_id, status, notes, started_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(query, audit.ZoneID, audit.ShelfID, audit.Status, audit.Notes, audit.StartedAt, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	audit.ID = id
+	audit.LibraryID = libraryID
+
+	return audit, nil
+}
+
+// CloseAudit closes the audit and marks the given copies as Lost, recording
+// their events, in one transaction. Copies that were loaned meanwhile are left
+// untouched.
+func (s *InventoryStore) CloseAudit(libraryID, id int64, lostCopyIDs []int64, librarianID *int64) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	marked := 0
+
+	for _, copyID := range lostCopyIDs {
+		var status string
+
+		err := tx.QueryRow(`SELECT status FROM copies WHERE id = ? AND library_id = ?`, copyID, libraryID).Scan(&status)
+		if err == sql.ErrNoRows {
+			continue
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		if status == "Borrowed" || status == "Lost" {
+			continue
+		}
+
+		_, err = tx.Exec(`UPDATE copies SET status = 'Lost' WHERE id = ? AND library_id = ?`, copyID, libraryID)
+		if err != nil {
+			return 0, err
+		}
+
+		event := &models.CopyEvent{
+			CopyID:     copyID,
+			EventType:  "MarkedLost",
+			OccurredAt: now,
+		}
+
+		event.FromValue.Valid = true
+		event.FromValue.String = status
+		event.ToValue.Valid = true
+		event.ToValue.String = "Lost"
+		event.AuditID.Valid = true
+		event.AuditID.Int64 = id
+
+		if librarianID != nil {
+			event.LibrarianID.Valid = true
+			event.LibrarianID.Int64 = *librarianID
+		}
+
+		if err := insertCopyEvent(tx, libraryID, event); err != nil {
+			return 0, err
+		}
+
+		marked++
+	}
+
+	result, err := tx.Exec(
+		`UPDATE inventory_audits SET status = 'Closed', closed_at = ? WHERE id = ? AND library_id = ? AND status = 'Open'`,
+		now,
+		id,
+		libraryID,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if affected == 0 {
+		return 0, sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return marked, nil
+}
+
+func (s *InventoryStore) GetScans(libraryID, auditID int64) ([]*models.InventoryScan, error) {
+	query := `
+		SELECT id, audit_id, barcode, shelf_id, copy_id, scanned_at, library_id
+		FROM inventory_scans
+		WHERE audit_id = ? AND library_id = ?
+		ORDER BY scanned_at, id
+	`
+
+	rows, err := s.db.Query(query, auditID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []*models.InventoryScan
+
+	for rows.Next() {
+		scan := &models.InventoryScan{}
+
+		err := rows.Scan(
+			&scan.ID,
+			&scan.AuditID,
+			&scan.Barcode,
+			&scan.ShelfID,
+			&scan.CopyID,
+			&scan.ScannedAt,
+			&scan.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		scans = append(scans, scan)
+	}
+
+	return scans, rows.Err()
+}
+
+func (s *InventoryStore) AddScans(libraryID, auditID int64, scans []*models.InventoryScan) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO inventory_scans (audit_id, barcode, shelf_id, copy_id, scanned_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	for _, scan := range scans {
+		result, err := tx.Exec(query, auditID, scan.Barcode, scan.ShelfID, scan.CopyID, scan.ScannedAt, libraryID)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		scan.ID = id
+		scan.AuditID = auditID
+		scan.LibraryID = libraryID
+	}
+
+	return tx.Commit()
+}
+
+// GetAuditItems returns the copies expected in the audited zone or shelf.
+func (s *InventoryStore) GetAuditItems(libraryID int64, audit *models.InventoryAudit) ([]*models.InventoryItem, error) {
+	query := `
+		SELECT
+			c.id, c.code, c.book_id, c.status, c.condition,
+			c.acquisition_date, c.purchase_price, c.notes,
+			c.shelf_id, c.temporary_location, c.library_id
+		FROM copies c
+		INNER JOIN books b ON b.id = c.book_id
+		INNER JOIN shelves sh ON sh.id = c.shelf_id
+		WHERE c.library_id = ?
+	`
+
+	args := []any{libraryID}
+
+	if audit.ShelfID.Valid {
+		query += " AND sh.id = ?"
+		args = append(args, audit.ShelfID.Int64)
+	} else {
+		query += " AND sh.zone_id = ?"
+		args = append(args, audit.ZoneID.Int64)
+	}
+
+	query += "\nORDER BY sh.code, b.call_number, c.code"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.InventoryItem
+
+	for rows.Next() {
+		item := &models.InventoryItem{Copy: &models.Copy{}}
+
+		err := rows.Scan(
+			&item.Copy.ID,
+			&item.Copy.Code,
+			&item.Copy.BookID,
+			&item.Copy.Status,
+			&item.Copy.Condition,
+			&item.Copy.AcquisitionDate,
+			&item.Copy.PurchasePrice,
+			&item.Copy.Notes,
+			&item.Copy.ShelfID,
+			&item.Copy.TemporaryLocation,
+			&item.Copy.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		item.ExpectedShelfID = item.Copy.ShelfID
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *InventoryStore) GetItemByCopyID(libraryID, copyID int64) (*models.InventoryItem, error) {
+	query := `
+		SELECT
+			c.id, c.code, c.book_id, c.status, c.condition,
+			c.acquisition_date, c.purchase_price, c.notes,
+			c.shelf_id, c.temporary_location, c.library_id
+		FROM copies c
+		WHERE c.id = ? AND c.library_id = ?
+	`
+
+	item := &models.InventoryItem{Copy: &models.Copy{}}
+
+	err := s.db.
+		QueryRow(query, copyID, libraryID).
+		Scan(
+			&item.Copy.ID,
+			&item.Copy.Code,
+			&item.Copy.BookID,
+			&item.Copy.Status,
+			&item.Copy.Condition,
+			&item.Copy.AcquisitionDate,
+			&item.Copy.PurchasePrice,
+			&item.Copy.Notes,
+			&item.Copy.ShelfID,
+			&item.Copy.TemporaryLocation,
+			&item.Copy.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	item.ExpectedShelfID = item.Copy.ShelfID
+
+	return item, nil
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type ILabelLayoutStore interface {
+	GetAll(libraryID int64) ([]*models.LabelLayout, error)
+	GetByID(libraryID, id int64) (*models.LabelLayout, error)
+	GetByCode(libraryID int64, code string) (*models.LabelLayout, error)
+	Create(libraryID int64, layout *models.LabelLayout) (*models.LabelLayout, error)
+	Update(libraryID, id int64, layout *models.LabelLayout) (*models.LabelLayout, error)
+	Delete(libraryID, id int64) error
+}
+
+type LabelLayoutStore struct {
+	db *sql.DB
+}
+
+func NewLabelLayoutStore(db *sql.DB) ILabelLayoutStore {
+	return &LabelLayoutStore{
+		db: db,
+	}
+}
+
+func (s *LabelLayoutStore) GetAll(libraryID int64) ([]*models.LabelLayout, error) {
+	query := `
+		SELECT
+			id, code, name, page_width, page_height, column_count, row_count,
+			label_width, label_height, margin_top, margin_left,
+			horizontal_gap, vertical_gap, library_id
+		FROM label_layouts
+		WHERE library_id = ?
+		ORDER BY code
+	`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var layouts []*models.LabelLayout
+
+	for rows.Next() {
+		layout := &models.LabelLayout{}
+
+		err := rows.Scan(
+			&layout.ID,
+			&layout.Code,
+			&layout.Name,
+			&layout.PageWidth,
+			&layout.PageHeight,
+			&layout.Columns,
+			&layout.Rows,
+			&layout.LabelWidth,
+			&layout.LabelHeight,
+			&layout.MarginTop,
+			&layout.MarginLeft,
+			&layout.HorizontalGap,
+			&layout.VerticalGap,
+			&layout.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		layouts = append(layouts, layout)
+	}
+
+	return layouts, rows.Err()
+}
+
+func (s *LabelLayoutStore) GetByID(libraryID, id int64) (*models.LabelLayout, error) {
+	query := `
+		SELECT
+			id, code, name, page_width, page_height, column_count, row_count,
+			label_width, label_height, margin_top, margin_left,
+			horizontal_gap, vertical_gap, library_id
+		FROM label_layouts
+		WHERE id = ? AND library_id = ?
+	`
+
+	layout := &models.LabelLayout{}
+
+	err := s.db.
+		QueryRow(query, id, libraryID).
+		Scan(
+			&layout.ID,
+			&layout.Code,
+			&layout.Name,
+			&layout.PageWidth,
+			&layout.PageHeight,
+			&layout.Columns,
+			&layout.Rows,
+			&layout.LabelWidth,
+			&layout.LabelHeight,
+			&layout.MarginTop,
+			&layout.MarginLeft,
+			&layout.HorizontalGap,
+			&layout.VerticalGap,
+			&layout.LibraryID,
+		)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return layout, nil
+}
+
+func (s *LabelLayoutStore) GetByCode(libraryID int64, code string) (*models.LabelLayout, error) {
+	query := `
+		SELECT
+			id, code, name, page_width, page_height, column_count, row_count,
+			label_width, label_height, margin_top, margin_left,
+			horizontal_gap, vertical_gap, library_id
+		FROM label_layouts
+		WHERE code = ? AND library_id = ?
+	`
+
+	layout := &models.LabelLayout{}
+
+	err := s.db.
+		QueryRow(query, code, libraryID).
+		Scan(
+			&layout.ID,
+			&layout.Code,
+			&layout.Name,
+			&layout.PageWidth,
+			&layout.PageHeight,
+			&layout.Columns,
+			&layout.Rows,
+			&layout.LabelWidth,
+			&layout.LabelHeight,
+			&layout.MarginTop,
+			&layout.MarginLeft,
+			&layout.HorizontalGap,
+			&layout.VerticalGap,
+			&layout.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return layout, nil
+}
+
+func (s *LabelLayoutStore) Create(libraryID int64, layout *models.LabelLayout) (*models.LabelLayout, error) {
+	query := `
+		INSERT INTO label_layouts (
+			code, name, page_width, page_height, column_count, row_count,
+			label_width, label_height, margin_top, margin_left,
+			horizontal_gap, vertical_gap, library_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(
+		query,
+		layout.Code,
+		layout.Name,
+		layout.PageWidth,
+		layout.PageHeight,
+		layout.Columns,
+		layout.Rows,
+		layout.LabelWidth,
+		layout.LabelHeight,
+		layout.MarginTop,
+		layout.MarginLeft,
+		layout.HorizontalGap,
+		layout.VerticalGap,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	layout.ID = id
+	layout.LibraryID = libraryID
+
+	return layout, nil
+}
+
+func (s *LabelLayoutStore) Update(libraryID, id int64, layout *models.LabelLayout) (*models.LabelLayout, error) {
+	query := `
+		UPDATE label_layouts
+		SET
+			code = ?, name = ?, page_width = ?, page_height = ?, column_count = ?, row_count = ?,
+			label_width = ?, label_height = ?, margin_top = ?, margin_left = ?,
+			horizontal_gap = ?, vertical_gap = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err := s.db.Exec(
+		query,
+		layout.Code,
+		layout.Name,
+		layout.PageWidth,
+		layout.PageHeight,
+		layout.Columns,
+		layout.Rows,
+		layout.LabelWidth,
+		layout.LabelHeight,
+		layout.MarginTop,
+		layout.MarginLeft,
+		layout.HorizontalGap,
+		layout.VerticalGap,
+		id,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	layout.ID = id
+	layout.LibraryID = libraryID
+
+	return layout, nil
+}
+
+func (s *LabelLayoutStore) Delete(libraryID, id int64) error {
+	query := `DELETE FROM label_layouts WHERE id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,7 +2,9 @@ package store
 
 import (
 	"database/sql"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/database"
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
@@ -19,10 +21,13 @@ type ShelfFilter struct {
 }
 
 type CopyFilter struct {
-	Code      string
-	BookID    *int64
-	Status    string
-	Condition string
+	Code          string
+	BookID        *int64
+	ShelfID       *int64
+	ZoneID        *int64
+	Status        string
+	Condition     string
+	AcquiredSince *time.Time
 }
 
 type ILibraryStore interface {
@@ -43,6 +48,7 @@ type ILibraryZoneStore interface {
 	Create(libraryID int64, zone *models.LibraryZone) (*models.LibraryZone, error)
 	Update(libraryID, id int64, zone *models.LibraryZone) (*models.LibraryZone, error)
 	Delete(libraryID, id int64) error
+	DeleteRehoming(libraryID, id int64, shelfIDs []int64, rehomeTo, librarianID *int64) error
 }
 
 type IShelfStore interface {
@@ -53,6 +59,8 @@ type IShelfStore interface {
 	Create(libraryID int64, shelf *models.Shelf) (*models.Shelf, error)
 	Update(libraryID, id int64, shelf *models.Shelf) (*models.Shelf, error)
 	Delete(libraryID, id int64) error
+	DeleteRehoming(libraryID int64, shelfIDs []int64, rehomeTo, librarianID *int64) error
+	CountContents(libraryID int64, shelfIDs []int64) (int, int, error)
 }
 
 type ICopyStore interface {
@@ -63,6 +71,8 @@ type ICopyStore interface {
 	Create(libraryID int64, copy *models.Copy) (*models.Copy, error)
 	Update(libraryID, id int64, copy *models.Copy) (*models.Copy, error)
 	Delete(libraryID, id int64) error
+	Move(libraryID int64, copyIDs []int64, move *models.CopyMove, librarianID *int64) error
+	Withdraw(libraryID int64, copyIDs []int64, reason database.NullString, librarianID *int64) error
 }
 
 type LibraryStore struct {
@@ -456,6 +466,27 @@ func (s *LibraryZoneStore) Delete(libraryID, id int64) error {
 	return nil
 }
 
+// DeleteRehoming deletes the zone and its shelves, moving what is on them to
+// rehomeTo first when given, in one transaction.
+func (s *LibraryZoneStore) DeleteRehoming(libraryID, id int64, shelfIDs []int64, rehomeTo, librarianID *int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteShelves(tx, libraryID, shelfIDs, rehomeTo, librarianID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM library_zones WHERE id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (s *ShelfStore) GetAll(libraryID int64) ([]*models.Shelf, error) {
 	query := `SELECT id, code, zone_id, description, library_id FROM shelves WHERE library_id = ? ORDER BY code`
 
@@ -495,7 +526,7 @@ func (s *ShelfStore) GetByID(libraryID, id int64) (*models.Shelf, error) {
 	shelf := &models.Shelf{}
 
 	err := s.db.
-		QueryRow(query, id).
+		QueryRow(query, id, libraryID).
 		Scan(
 			&shelf.ID,
 			&shelf.Code,
@@ -517,7 +548,7 @@ func (s *ShelfStore) GetByCode(libraryID int64, code string) (*models.Shelf, err
 	shelf := &models.Shelf{}
 
 	err := s.db.
-		QueryRow(query, code).
+		QueryRow(query, code, libraryID).
 		Scan(
 			&shelf.ID,
 			&shelf.Code,
@@ -635,11 +666,124 @@ func (s *ShelfStore) Delete(libraryID, id int64) error {
 	return nil
 }
 
+// DeleteRehoming deletes the shelves, moving what is on them to rehomeTo
+// first when given, in one transaction.
+func (s *ShelfStore) DeleteRehoming(libraryID int64, shelfIDs []int64, rehomeTo, librarianID *int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteShelves(tx, libraryID, shelfIDs, rehomeTo, librarianID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountContents returns how many copies and books are on the shelves.
+func (s *ShelfStore) CountContents(libraryID int64, shelfIDs []int64) (int, int, error) {
+	var copies, books int
+
+	for _, shelfID := range shelfIDs {
+		var shelfCopies, shelfBooks int
+
+		query := `
+			SELECT
+				(SELECT COUNT(*) FROM copies WHERE shelf_id = ? AND library_id = ?),
+				(SELECT COUNT(*) FROM books WHERE shelf_id = ? AND library_id = ?)
+		`
+
+		err := s.db.QueryRow(query, shelfID, libraryID, shelfID, libraryID).Scan(&shelfCopies, &shelfBooks)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		copies += shelfCopies
+		books += shelfBooks
+	}
+
+	return copies, books, nil
+}
+
+// deleteShelves moves the copies and books on the shelves to rehomeTo, with a
+// Moved event per copy, and deletes the shelves.
+func deleteShelves(tx *sql.Tx, libraryID int64, shelfIDs []int64, rehomeTo, librarianID *int64) error {
+	now := time.Now()
+
+	for _, shelfID := range shelfIDs {
+		if rehomeTo != nil {
+			rows, err := tx.Query(`SELECT id FROM copies WHERE shelf_id = ? AND library_id = ?`, shelfID, libraryID)
+			if err != nil {
+				return err
+			}
+
+			var copyIDs []int64
+
+			for rows.Next() {
+				var copyID int64
+				if err := rows.Scan(&copyID); err != nil {
+					rows.Close()
+					return err
+				}
+
+				copyIDs = append(copyIDs, copyID)
+			}
+
+			rows.Close()
+
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for _, copyID := range copyIDs {
+				event := &models.CopyEvent{
+					CopyID:     copyID,
+					EventType:  "Moved",
+					OccurredAt: now,
+				}
+
+				event.FromValue.Valid = true
+				event.FromValue.String = strconv.FormatInt(shelfID, 10)
+				event.ToValue.Valid = true
+				event.ToValue.String = strconv.FormatInt(*rehomeTo, 10)
+
+				if librarianID != nil {
+					event.LibrarianID.Valid = true
+					event.LibrarianID.Int64 = *librarianID
+				}
+
+				if err := insertCopyEvent(tx, libraryID, event); err != nil {
+					return err
+				}
+			}
+
+			_, err = tx.Exec(`UPDATE copies SET shelf_id = ? WHERE shelf_id = ? AND library_id = ?`, *rehomeTo, shelfID, libraryID)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`UPDATE books SET shelf_id = ? WHERE shelf_id = ? AND library_id = ?`, *rehomeTo, shelfID, libraryID)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(`DELETE FROM shelves WHERE id = ? AND library_id = ?`, shelfID, libraryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *CopyStore) GetAll(libraryID int64) ([]*models.Copy, error) {
 	query := `
 		SELECT
 			id, code, book_id, status, condition, 
-			acquisition_date, purchase_price, notes, library_id
+			acquisition_date, purchase_price, notes, shelf_id, temporary_location, library_id
 		FROM copies 
 		WHERE library_id = ? 
 		ORDER BY code
@@ -665,6 +809,8 @@ func (s *CopyStore) GetAll(libraryID int64) ([]*models.Copy, error) {
 			&copy.AcquisitionDate,
 			&copy.PurchasePrice,
 			&copy.Notes,
+			&copy.ShelfID,
+			&copy.TemporaryLocation,
 			&copy.LibraryID,
 		)
 
@@ -682,7 +828,7 @@ func (s *CopyStore) GetByID(libraryID, id int64) (*models.Copy, error) {
 	query := `
 		SELECT
 			id, code, book_id, status, condition, 
-			acquisition_date, purchase_price, notes, library_id 
+			acquisition_date, purchase_price, notes, shelf_id, temporary_location, library_id 
 		FROM copies 
 		WHERE id = ? AND library_id = ?
 	`
@@ -700,6 +846,8 @@ func (s *CopyStore) GetByID(libraryID, id int64) (*models.Copy, error) {
 			&copy.AcquisitionDate,
 			&copy.PurchasePrice,
 			&copy.Notes,
+			&copy.ShelfID,
+			&copy.TemporaryLocation,
 			&copy.LibraryID,
 		)
 
@@ -714,7 +862,7 @@ func (s *CopyStore) GetByCode(libraryID int64, code string) (*models.Copy, error
 	query := `
 		SELECT
 			id, code, book_id, status, condition, 
-			acquisition_date, purchase_price, notes, library_id 
+			acquisition_date, purchase_price, notes, shelf_id, temporary_location, library_id 
 		FROM copies 
 		WHERE code = ? AND library_id = ?
 	`
@@ -732,6 +880,8 @@ func (s *CopyStore) GetByCode(libraryID int64, code string) (*models.Copy, error
 			&copy.AcquisitionDate,
 			&copy.PurchasePrice,
 			&copy.Notes,
+			&copy.ShelfID,
+			&copy.TemporaryLocation,
 			&copy.LibraryID,
 		)
 
@@ -750,7 +900,7 @@ func (s *CopyStore) GetCopiesFiltered(libraryID int64, filter CopyFilter) ([]*mo
 	query := `
 		SELECT
 			id, code, book_id, status, condition, 
-			acquisition_date, purchase_price, notes, library_id
+			acquisition_date, purchase_price, notes, shelf_id, temporary_location, library_id
 		FROM copies
 	`
 
@@ -770,6 +920,16 @@ func (s *CopyStore) GetCopiesFiltered(libraryID int64, filter CopyFilter) ([]*mo
 		args = append(args, *filter.BookID)
 	}
 
+	if filter.ShelfID != nil {
+		conditions = append(conditions, "shelf_id = ?")
+		args = append(args, *filter.ShelfID)
+	}
+
+	if filter.ZoneID != nil {
+		conditions = append(conditions, "shelf_id IN (SELECT id FROM shelves WHERE zone_id = ?)")
+		args = append(args, *filter.ZoneID)
+	}
+
 	if filter.Status != "" {
 		conditions = append(conditions, "status = ?")
 		args = append(args, filter.Status)
@@ -780,6 +940,11 @@ func (s *CopyStore) GetCopiesFiltered(libraryID int64, filter CopyFilter) ([]*mo
 		args = append(args, filter.Condition)
 	}
 
+	if filter.AcquiredSince != nil {
+		conditions = append(conditions, "acquisition_date >= ?")
+		args = append(args, *filter.AcquiredSince)
+	}
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -806,6 +971,8 @@ func (s *CopyStore) GetCopiesFiltered(libraryID int64, filter CopyFilter) ([]*mo
 			&copy.AcquisitionDate,
 			&copy.PurchasePrice,
 			&copy.Notes,
+			&copy.ShelfID,
+			&copy.TemporaryLocation,
 			&copy.LibraryID,
 		)
 
@@ -820,12 +987,23 @@ func (s *CopyStore) GetCopiesFiltered(libraryID int64, filter CopyFilter) ([]*mo
 }
 
 func (s *CopyStore) Create(libraryID int64, copy *models.Copy) (*models.Copy, error) {
+	if err := insertCopy(s.db, libraryID, copy); err != nil {
+		return nil, err
+	}
+
+	return copy, nil
+}
+
+// insertCopy lets other stores add copies inside their own transaction.
+func insertCopy(db execer, libraryID int64, copy *models.Copy) error {
 	query := `
-		INSERT INTO copies (code, book_id, status, condition, acquisition_date, purchase_price, notes, library_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO copies (
+			code, book_id, status, condition, acquisition_date,
+			purchase_price, notes, shelf_id, temporary_location, library_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := s.db.Exec(
+	result, err := db.Exec(
 		query,
 		copy.Code,
 		copy.BookID,
@@ -834,22 +1012,24 @@ func (s *CopyStore) Create(libraryID int64, copy *models.Copy) (*models.Copy, er
 		copy.AcquisitionDate,
 		copy.PurchasePrice,
 		copy.Notes,
+		copy.ShelfID,
+		copy.TemporaryLocation,
 		libraryID,
 	)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	copy.ID = id
 	copy.LibraryID = libraryID
 
-	return copy, nil
+	return nil
 }
 
 func (s *CopyStore) Update(libraryID, id int64, copy *models.Copy) (*models.Copy, error) {
@@ -857,7 +1037,8 @@ func (s *CopyStore) Update(libraryID, id int64, copy *models.Copy) (*models.Copy
 		UPDATE copies 
 		SET
 			code = ?, book_id = ?, status = ?, condition = ?, 
-			acquisition_date = ?, purchase_price = ?, notes = ?
+			acquisition_date = ?, purchase_price = ?, notes = ?,
+			shelf_id = ?, temporary_location = ?
 		WHERE id = ? AND library_id = ?
 	`
 
@@ -870,6 +1051,8 @@ func (s *CopyStore) Update(libraryID, id int64, copy *models.Copy) (*models.Copy
 		copy.AcquisitionDate,
 		copy.PurchasePrice,
 		copy.Notes,
+		copy.ShelfID,
+		copy.TemporaryLocation,
 		id,
 		libraryID,
 	)
@@ -894,3 +1077,138 @@ func (s *CopyStore) Delete(libraryID, id int64) error {
 
 	return nil
 }
+
+// Move relocates the copies and records their Moved and
+// TemporaryLocationChanged events in one transaction. A nil shelf keeps the
+// current shelf of each copy.
+func (s *CopyStore) Move(libraryID int64, copyIDs []int64, move *models.CopyMove, librarianID *int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	for _, copyID := range copyIDs {
+		var shelfID database.NullInt64
+		var temporaryLocation database.NullString
+
+		err := tx.
+			QueryRow(`SELECT shelf_id, temporary_location FROM copies WHERE id = ? AND library_id = ?`, copyID, libraryID).
+			Scan(&shelfID, &temporaryLocation)
+
+		if err != nil {
+			return err
+		}
+
+		newShelfID := shelfID
+		if move.ShelfID.Valid {
+			newShelfID = move.ShelfID
+		}
+
+		_, err = tx.Exec(
+			`UPDATE copies SET shelf_id = ?, temporary_location = ? WHERE id = ? AND library_id = ?`,
+			newShelfID,
+			move.TemporaryLocation,
+			copyID,
+			libraryID,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		var events []*models.CopyEvent
+
+		if newShelfID != shelfID {
+			events = append(events, &models.CopyEvent{
+				EventType: "Moved",
+				FromValue: nullInt64String(shelfID),
+				ToValue:   nullInt64String(newShelfID),
+			})
+		}
+
+		if move.TemporaryLocation != temporaryLocation {
+			events = append(events, &models.CopyEvent{
+				EventType: "TemporaryLocationChanged",
+				FromValue: temporaryLocation,
+				ToValue:   move.TemporaryLocation,
+			})
+		}
+
+		for _, event := range events {
+			event.CopyID = copyID
+			event.OccurredAt = now
+
+			if librarianID != nil {
+				event.LibrarianID.Valid = true
+				event.LibrarianID.Int64 = *librarianID
+			}
+
+			if err := insertCopyEvent(tx, libraryID, event); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Withdraw takes the copies out of the collection, recording a Withdrawn event
+// with the reason, in one transaction. The rows are kept for their history.
+func (s *CopyStore) Withdraw(libraryID int64, copyIDs []int64, reason database.NullString, librarianID *int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	for _, copyID := range copyIDs {
+		var status string
+
+		err := tx.QueryRow(`SELECT status FROM copies WHERE id = ? AND library_id = ?`, copyID, libraryID).Scan(&status)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE copies SET status = 'Withdrawn' WHERE id = ? AND library_id = ?`, copyID, libraryID)
+		if err != nil {
+			return err
+		}
+
+		event := &models.CopyEvent{
+			CopyID:     copyID,
+			EventType:  "Withdrawn",
+			ToValue:    reason,
+			OccurredAt: now,
+		}
+
+		event.FromValue.Valid = true
+		event.FromValue.String = status
+
+		if librarianID != nil {
+			event.LibrarianID.Valid = true
+			event.LibrarianID.Int64 = *librarianID
+		}
+
+		if err := insertCopyEvent(tx, libraryID, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func nullInt64String(value database.NullInt64) database.NullString {
+	var result database.NullString
+
+	if value.Valid {
+		result.Valid = true
+		result.String = strconv.FormatInt(value.Int64, 10)
+	}
+
+	return result
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+// EnrichmentChanges are the rows an enrichment writes. The publisher and the
+// authors without an ID are created, the book is created when it has no ID.
+type EnrichmentChanges struct {
+	Book         *models.Book
+	UpdateBook   bool
+	Publisher    *models.Publisher
+	SetPublisher bool             // The book takes the publisher
+	Authors      []*models.Author // Linked to the book in this order
+}
+
+type IMetadataStore interface {
+	GetCached(provider, isbn string) (*models.BookMetadata, error)
+	SaveCached(provider, isbn string, metadata *models.BookMetadata) error
+	SaveEnrichment(libraryID int64, changes *EnrichmentChanges) error
+}
+
+type MetadataStore struct {
+	db *sql.DB
+}
+
+func NewMetadataStore(db *sql.DB) IMetadataStore {
+	return &MetadataStore{
+		db: db,
+	}
+}
+
+func (s *MetadataStore) GetCached(provider, isbn string) (*models.BookMetadata, error) {
+	query := `SELECT payload FROM metadata_cache WHERE provider = ? AND isbn = ?`
+
+	var payload string
+
+	err := s.db.QueryRow(query, provider, isbn).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &models.BookMetadata{}
+	if err := json.Unmarshal([]byte(payload), metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (s *MetadataStore) SaveCached(provider, isbn string, metadata *models.BookMetadata) error {
+	payload, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO metadata_cache (isbn, provider, payload, fetched_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(isbn, provider) DO UPDATE SET payload = excluded.payload, fetched_at = excluded.fetched_at
+	`
+
+	_, err = s.db.Exec(query, isbn, provider, string(payload), metadata.FetchedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveEnrichment writes the changes in one transaction, so a failure leaves
+// no publisher or author behind without its book.
+func (s *MetadataStore) SaveEnrichment(libraryID int64, changes *EnrichmentChanges) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	book := changes.Book
+
+	if changes.SetPublisher {
+		if changes.Publisher.ID == 0 {
+			if err := insertPublisher(tx, libraryID, changes.Publisher); err != nil {
+				return err
+			}
+		}
+
+		book.PublisherID.Int64 = changes.Publisher.ID
+		book.PublisherID.Valid = true
+	}
+
+	if book.ID == 0 {
+		if err := insertBook(tx, libraryID, book); err != nil {
+			return err
+		}
+	} else if changes.UpdateBook || changes.SetPublisher {
+		if err := updateBook(tx, libraryID, book.ID, book); err != nil {
+			return err
+		}
+	}
+
+	for i, author := range changes.Authors {
+		if author.ID == 0 {
+			if err := insertAuthor(tx, libraryID, author); err != nil {
+				return err
+			}
+		}
+
+		err := insertBookAuthor(tx, libraryID, &models.BookAuthor{
+			BookID:   book.ID,
+			AuthorID: author.ID,
+			Position: i + 1,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type IMetricsStore interface {
+	GetLibraryGauges(asOf time.Time) ([]*models.LibraryGauges, error)
+}
+
+type MetricsStore struct {
+	db *sql.DB
+}
+
+func NewMetricsStore(db *sql.DB) IMetricsStore {
+	return &MetricsStore{
+		db: db,
+	}
+}
+
+// GetLibraryGauges counts for every library the loans not returned, those
+// whose due date is before asOf, and the pending fines with their amount.
+func (s *MetricsStore) GetLibraryGauges(asOf time.Time) ([]*models.LibraryGauges, error) {
+	query := `
+		SELECT
+			lb.id,
+			(
+				SELECT COUNT(*) FROM loans l
+				WHERE l.library_id = lb.id AND l.status IN ('Active', 'Overdue') AND l.return_date IS NULL
+			),
+			(
+				SELECT COUNT(*) FROM loans l
+				WHERE l.library_id = lb.id AND l.status IN ('Active', 'Overdue') AND l.return_date IS NULL
+					AND datetime(l.due_date) < datetime(?)
+			),
+			(SELECT COUNT(*) FROM fines f WHERE f.library_id = lb.id AND f.status = 'Pending'),
+			(SELECT COALESCE(SUM(f.amount), 0) FROM fines f WHERE f.library_id = lb.id AND f.status = 'Pending')
+		FROM libraries lb
+		ORDER BY lb.id
+	`
+
+	rows, err := s.db.Query(query, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gauges []*models.LibraryGauges
+
+	for rows.Next() {
+		gauge := &models.LibraryGauges{}
+
+		err := rows.Scan(
+			&gauge.LibraryID,
+			&gauge.ActiveLoans,
+			&gauge.OverdueLoans,
+			&gauge.PendingFines,
+			&gauge.PendingFinesAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		gauges = append(gauges, gauge)
+	}
+
+	return gauges, rows.Err()
+}
@@ -0,0 +1,447 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type NotificationFilter struct {
+	UserID    *int64
+	Status    string
+	EventType string
+}
+
+type INotificationStore interface {
+	GetAll(libraryID int64, filter NotificationFilter) ([]*models.Notification, error)
+	GetByID(libraryID, id int64) (*models.Notification, error)
+	GetReady(now time.Time, limit int) ([]*models.Notification, error)
+	EnqueueScheduled(now time.Time, dueSoon, cardExpiring time.Duration) (int64, error)
+	UpdateDelivery(libraryID int64, notification *models.Notification) error
+}
+
+type INotificationTemplateStore interface {
+	GetAll(libraryID int64) ([]*models.NotificationTemplate, error)
+	GetByType(libraryID int64, eventType, language string) (*models.NotificationTemplate, error)
+	Save(libraryID int64, tmpl *models.NotificationTemplate) (*models.NotificationTemplate, error)
+	Delete(libraryID int64, eventType, language string) error
+}
+
+type INotificationPreferenceStore interface {
+	GetByUserID(libraryID, userID int64) (*models.NotificationPreferences, error)
+	Save(libraryID int64, preferences *models.NotificationPreferences) (*models.NotificationPreferences, error)
+	Delete(libraryID, userID int64) error
+}
+
+type NotificationStore struct {
+	db *sql.DB
+}
+
+type NotificationTemplateStore struct {
+	db *sql.DB
+}
+
+type NotificationPreferenceStore struct {
+	db *sql.DB
+}
+
+func NewNotificationStore(db *sql.DB) INotificationStore {
+	return &NotificationStore{
+		db: db,
+	}
+}
+
+func NewNotificationTemplateStore(db *sql.DB) INotificationTemplateStore {
+	return &NotificationTemplateStore{
+		db: db,
+	}
+}
+
+func NewNotificationPreferenceStore(db *sql.DB) INotificationPreferenceStore {
+	return &NotificationPreferenceStore{
+		db: db,
+	}
+}
+
+const notificationColumns = `
+	id, event_type, user_id, subject_id, dedup_key, status, recipient, attempts,
+	next_attempt_at, last_error, created_at, sent_at, channel, mandatory, library_id
+`
+
+func (s *NotificationStore) GetAll(libraryID int64, filter NotificationFilter) ([]*models.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notification_outbox WHERE library_id = ?`
+	args := []any{libraryID}
+
+	if filter.UserID != nil {
+		query += " AND user_id = ?"
+		args = append(args, *filter.UserID)
+	}
+
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+
+	if filter.EventType != "" {
+		query += " AND event_type = ?"
+		args = append(args, filter.EventType)
+	}
+
+	query += " ORDER BY created_at DESC, id DESC"
+
+	return s.queryNotifications(query, args...)
+}
+
+func (s *NotificationStore) GetByID(libraryID, id int64) (*models.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notification_outbox WHERE id = ? AND library_id = ?`
+
+	return scanNotification(s.db.QueryRow(query, id, libraryID))
+}
+
+// GetReady returns the pending notifications of every library whose next
+// attempt has come, oldest first.
+func (s *NotificationStore) GetReady(now time.Time, limit int) ([]*models.Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notification_outbox
+		WHERE status = 'Pending' AND datetime(next_attempt_at) <= datetime(?)
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`
+
+	return s.queryNotifications(query, now, limit)
+}
+
+// EnqueueScheduled writes the events that come from the passing of time and
+// not from a change: loans about to be due, loans overdue and cards about to
+// expire. The dedup key includes the date, so a renewed loan or a renewed card
+// is notified again but the same date never twice.
+func (s *NotificationStore) EnqueueScheduled(now time.Time, dueSoon, cardExpiring time.Duration) (int64, error) {
+	queries := []struct {
+		query string
+		args  []any
+	}{
+		{
+			query: `
+				INSERT OR IGNORE INTO notification_outbox (event_type, user_id, subject_id, dedup_key, library_id)
+				SELECT 'due_soon', user_id, id, 'due_soon:' || id || ':' || date(due_date), library_id
+				FROM loans
+				WHERE status = 'Active' AND return_date IS NULL
+					AND datetime(due_date) > datetime(?) AND datetime(due_date) <= datetime(?)
+			`,
+			args: []any{now, now.Add(dueSoon)},
+		},
+		{
+			query: `
+				INSERT OR IGNORE INTO notification_outbox (event_type, user_id, subject_id, dedup_key, library_id)
+				SELECT 'overdue', user_id, id, 'overdue:' || id || ':' || date(due_date), library_id
+				FROM loans
+				WHERE status IN ('Active', 'Overdue') AND return_date IS NULL
+					AND datetime(due_date) <= datetime(?)
+			`,
+			args: []any{now},
+		},
+		{
+			query: `
+				INSERT OR IGNORE INTO notification_outbox (event_type, user_id, subject_id, dedup_key, library_id)
+				SELECT 'card_expiring', id, NULL, 'card_expiring:' || id || ':' || date(card_expiration), library_id
+				FROM users
+				WHERE status = 'Active' AND card_expiration IS NOT NULL
+					AND datetime(card_expiration) > datetime(?) AND datetime(card_expiration) <= datetime(?)
+			`,
+			args: []any{now, now.Add(cardExpiring)},
+		},
+	}
+
+	var enqueued int64
+
+	for _, q := range queries {
+		result, err := s.db.Exec(q.query, q.args...)
+		if err != nil {
+			return enqueued, err
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			return enqueued, err
+		}
+
+		enqueued += count
+	}
+
+	return enqueued, nil
+}
+
+func (s *NotificationStore) UpdateDelivery(libraryID int64, notification *models.Notification) error {
+	query := `
+		UPDATE notification_outbox
+		SET status = ?, recipient = ?, attempts = ?, next_attempt_at = ?, last_error = ?, sent_at = ?,
+			channel = ?, mandatory = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err := s.db.Exec(
+		query,
+		notification.Status,
+		notification.Recipient,
+		notification.Attempts,
+		notification.NextAttemptAt,
+		notification.LastError,
+		notification.SentAt,
+		notification.Channel,
+		notification.Mandatory,
+		notification.ID,
+		libraryID,
+	)
+
+	return err
+}
+
+func (s *NotificationStore) queryNotifications(query string, args ...any) ([]*models.Notification, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*models.Notification
+
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	notification := &models.Notification{}
+
+	err := row.Scan(
+		&notification.ID,
+		&notification.EventType,
+		&notification.UserID,
+		&notification.SubjectID,
+		&notification.DedupKey,
+		&notification.Status,
+		&notification.Recipient,
+		&notification.Attempts,
+		&notification.NextAttemptAt,
+		&notification.LastError,
+		&notification.CreatedAt,
+		&notification.SentAt,
+		&notification.Channel,
+		&notification.Mandatory,
+		&notification.LibraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+func (s *NotificationTemplateStore) GetAll(libraryID int64) ([]*models.NotificationTemplate, error) {
+	query := `
+		SELECT event_type, language, subject, body, updated_at, library_id
+		FROM notification_templates
+		WHERE library_id = ?
+		ORDER BY event_type, language
+	`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.NotificationTemplate
+
+	for rows.Next() {
+		tmpl := &models.NotificationTemplate{Customized: true}
+
+		err := rows.Scan(
+			&tmpl.EventType,
+			&tmpl.Language,
+			&tmpl.Subject,
+			&tmpl.Body,
+			&tmpl.UpdatedAt,
+			&tmpl.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, tmpl)
+	}
+
+	return templates, nil
+}
+
+func (s *NotificationTemplateStore) GetByType(libraryID int64, eventType, language string) (*models.NotificationTemplate, error) {
+	query := `
+		SELECT event_type, language, subject, body, updated_at, library_id
+		FROM notification_templates
+		WHERE event_type = ? AND language = ? AND library_id = ?
+	`
+
+	tmpl := &models.NotificationTemplate{Customized: true}
+
+	err := s.db.
+		QueryRow(query, eventType, language, libraryID).
+		Scan(
+			&tmpl.EventType,
+			&tmpl.Language,
+			&tmpl.Subject,
+			&tmpl.Body,
+			&tmpl.UpdatedAt,
+			&tmpl.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func (s *NotificationTemplateStore) Save(libraryID int64, tmpl *models.NotificationTemplate) (*models.NotificationTemplate, error) {
+	query := `
+		INSERT INTO notification_templates (event_type, language, subject, body, updated_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(event_type, language, library_id) DO UPDATE SET
+			subject = excluded.subject, body = excluded.body, updated_at = excluded.updated_at
+	`
+
+	now := time.Now()
+
+	_, err := s.db.Exec(query, tmpl.EventType, tmpl.Language, tmpl.Subject, tmpl.Body, now, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.Customized = true
+	tmpl.UpdatedAt.Time = now
+	tmpl.UpdatedAt.Valid = true
+	tmpl.LibraryID = libraryID
+
+	return tmpl, nil
+}
+
+func (s *NotificationTemplateStore) Delete(libraryID int64, eventType, language string) error {
+	query := `DELETE FROM notification_templates WHERE event_type = ? AND language = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, eventType, language, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *NotificationPreferenceStore) GetByUserID(libraryID, userID int64) (*models.NotificationPreferences, error) {
+	query := `
+		SELECT user_id, channel, event_types, language, quiet_hours_start, quiet_hours_end, timezone, updated_at, library_id
+		FROM notification_preferences
+		WHERE user_id = ? AND library_id = ?
+	`
+
+	preferences := &models.NotificationPreferences{}
+	var eventTypes string
+
+	err := s.db.
+		QueryRow(query, userID, libraryID).
+		Scan(
+			&preferences.UserID,
+			&preferences.Channel,
+			&eventTypes,
+			&preferences.Language,
+			&preferences.QuietHoursStart,
+			&preferences.QuietHoursEnd,
+			&preferences.Timezone,
+			&preferences.UpdatedAt,
+			&preferences.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(eventTypes), &preferences.EventTypes); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (s *NotificationPreferenceStore) Save(libraryID int64, preferences *models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	query := `
+		INSERT INTO notification_preferences (user_id, channel, event_types, language, quiet_hours_start, quiet_hours_end, timezone, updated_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, library_id) DO UPDATE SET
+			channel = excluded.channel, event_types = excluded.event_types, language = excluded.language,
+			quiet_hours_start = excluded.quiet_hours_start, quiet_hours_end = excluded.quiet_hours_end,
+			timezone = excluded.timezone, updated_at = excluded.updated_at
+	`
+
+	if preferences.EventTypes == nil {
+		preferences.EventTypes = []string{}
+	}
+
+	eventTypes, err := json.Marshal(preferences.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	_, err = s.db.Exec(
+		query,
+		preferences.UserID,
+		preferences.Channel,
+		string(eventTypes),
+		preferences.Language,
+		preferences.QuietHoursStart,
+		preferences.QuietHoursEnd,
+		preferences.Timezone,
+		now,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	preferences.UpdatedAt.Time = now
+	preferences.UpdatedAt.Valid = true
+	preferences.LibraryID = libraryID
+
+	return preferences, nil
+}
+
+func (s *NotificationPreferenceStore) Delete(libraryID, userID int64) error {
+	query := `DELETE FROM notification_preferences WHERE user_id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, userID, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
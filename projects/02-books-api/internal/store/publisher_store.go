@@ -9,6 +9,7 @@ import (
 type IPublisherStore interface {
 	GetAll(libraryID int64) ([]*models.Publisher, error)
 	GetByID(libraryID, id int64) (*models.Publisher, error)
+	GetByName(libraryID int64, name string) (*models.Publisher, error)
 	Create(libraryID int64, publisher *models.Publisher) (*models.Publisher, error)
 	Update(libraryID, id int64, publisher *models.Publisher) (*models.Publisher, error)
 	Delete(libraryID, id int64) error
@@ -76,21 +77,35 @@ func (s *PublisherStore) GetByID(libraryID, id int64) (*models.Publisher, error)
 	return publisher, nil
 }
 
-func (s *PublisherStore) Create(libraryID int64, publisher *models.Publisher) (*models.Publisher, error) {
-	query := `INSERT INTO publishers (name, country, library_id) VALUES (?, ?, ?)`
+func (s *PublisherStore) GetByName(libraryID int64, name string) (*models.Publisher, error) {
+	query := `SELECT id, name, country, library_id FROM publishers WHERE name = ? COLLATE NOCASE AND library_id = ?`
 
-	result, err := s.db.Exec(query, publisher.Name, publisher.Country, libraryID)
-	if err != nil {
-		return nil, err
+	publisher := &models.Publisher{}
+
+	err := s.db.
+		QueryRow(query, name, libraryID).
+		Scan(
+			&publisher.ID,
+			&publisher.Name,
+			&publisher.Country,
+			&publisher.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
 
-	id, err := result.LastInsertId()
 	if err != nil {
 		return nil, err
 	}
 
-	publisher.ID = id
-	publisher.LibraryID = libraryID
+	return publisher, nil
+}
+
+func (s *PublisherStore) Create(libraryID int64, publisher *models.Publisher) (*models.Publisher, error) {
+	if err := insertPublisher(s.db, libraryID, publisher); err != nil {
+		return nil, err
+	}
 
 	return publisher, nil
 }
@@ -119,3 +134,22 @@ func (s *PublisherStore) Delete(libraryID, id int64) error {
 
 	return nil
 }
+
+func insertPublisher(db execer, libraryID int64, publisher *models.Publisher) error {
+	query := `INSERT INTO publishers (name, country, library_id) VALUES (?, ?, ?)`
+
+	result, err := db.Exec(query, publisher.Name, publisher.Country, libraryID)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	publisher.ID = id
+	publisher.LibraryID = libraryID
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type WeedingFilter struct {
+	ZoneID    *int64
+	IdleSince time.Time // Copies not loaned since then; also the window for recent loans
+	OldBefore int       // Publication years before this one are old
+}
+
+type IReportStore interface {
+	GetIdleCopies(libraryID int64, filter WeedingFilter) ([]*models.WeedingCopy, error)
+	GetPoorCopies(libraryID int64, filter WeedingFilter) ([]*models.WeedingCopy, error)
+	GetTitleDemand(libraryID int64, filter WeedingFilter) ([]*models.TitleDemand, error)
+	GetCategoryAges(libraryID int64, filter WeedingFilter) ([]*models.CategoryAge, error)
+	GetOverdueLoans(libraryID int64, asOf time.Time) ([]*models.OverdueLoanRow, error)
+	GetFines(libraryID int64, filter StatsFilter, status string) ([]*models.FineRow, error)
+}
+
+type ReportStore struct {
+	db *sql.DB
+}
+
+func NewReportStore(db *sql.DB) IReportStore {
+	return &ReportStore{
+		db: db,
+	}
+}
+
+const weedingCopyQuery = `
+	SELECT
+		c.id, c.code, c.book_id, b.title, b.call_number, s.zone_id, c.shelf_id,
+		c.status, c.condition, c.acquisition_date, ls.last_loan_date, COALESCE(ls.loan_count, 0)
+	FROM copies c
+	INNER JOIN books b ON b.id = c.book_id
+	LEFT JOIN shelves s ON s.id = c.shelf_id
+	LEFT JOIN (
+		SELECT copy_id, MAX(loan_date) AS last_loan_date, COUNT(*) AS loan_count
+		FROM loans
+		GROUP BY copy_id
+	) ls ON ls.copy_id = c.id
+	WHERE c.library_id = ? AND c.status NOT IN ('Withdrawn', 'Lost')
+`
+
+// GetIdleCopies returns the copies on the shelf whose last loan, or their
+// acquisition when they were never loaned, is older than the filter.
+func (s *ReportStore) GetIdleCopies(libraryID int64, filter WeedingFilter) ([]*models.WeedingCopy, error) {
+	query := weedingCopyQuery + `
+		AND c.status != 'Borrowed'
+		AND COALESCE(ls.last_loan_date, c.acquisition_date, '') < ?
+	`
+
+	args := []any{libraryID, filter.IdleSince}
+
+	if filter.ZoneID != nil {
+		query += " AND s.zone_id = ?"
+		args = append(args, *filter.ZoneID)
+	}
+
+	query += "\nORDER BY COALESCE(ls.last_loan_date, c.acquisition_date, ''), c.id"
+
+	return s.queryWeedingCopies(query, args...)
+}
+
+func (s *ReportStore) GetPoorCopies(libraryID int64, filter WeedingFilter) ([]*models.WeedingCopy, error) {
+	query := weedingCopyQuery + " AND (c.condition = 'Poor' OR c.status = 'Damaged')"
+
+	args := []any{libraryID}
+
+	if filter.ZoneID != nil {
+		query += " AND s.zone_id = ?"
+		args = append(args, *filter.ZoneID)
+	}
+
+	query += "\nORDER BY b.call_number, c.id"
+
+	return s.queryWeedingCopies(query, args...)
+}
+
+// GetTitleDemand returns, per title, its copies still in the collection, the
+// pending reservations on it or its work and its loans since the filter date.
+func (s *ReportStore) GetTitleDemand(libraryID int64, filter WeedingFilter) ([]*models.TitleDemand, error) {
+	query := `
+		SELECT
+			b.id, b.title, b.call_number,
+			(
+				SELECT COUNT(*) FROM copies c
+				WHERE c.book_id = b.id AND c.status NOT IN ('Withdrawn', 'Lost')
+			) AS copies,
+			(
+				SELECT COUNT(*) FROM reservations r
+				WHERE r.status = 'Pending' AND ((r.book_id = b.id AND r.work_id IS NULL) OR r.work_id = b.work_id)
+			) AS pending_reservations,
+			(
+				SELECT COUNT(*) FROM loans l
+				INNER JOIN copies c ON c.id = l.copy_id
+				WHERE c.book_id = b.id AND l.loan_date >= ?
+			) AS recent_loans
+		FROM books b
+		WHERE b.library_id = ?
+	`
+
+	args := []any{filter.IdleSince, libraryID}
+
+	if filter.ZoneID != nil {
+		query += `
+			AND EXISTS (
+				SELECT 1 FROM copies c
+				INNER JOIN shelves s ON s.id = c.shelf_id
+				WHERE c.book_id = b.id AND s.zone_id = ?
+			)
+		`
+		args = append(args, *filter.ZoneID)
+	}
+
+	query += "\nORDER BY b.call_number, b.title"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var titles []*models.TitleDemand
+
+	for rows.Next() {
+		title := &models.TitleDemand{}
+
+		err := rows.Scan(
+			&title.BookID,
+			&title.Title,
+			&title.CallNumber,
+			&title.Copies,
+			&title.PendingReservations,
+			&title.RecentLoans,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		titles = append(titles, title)
+	}
+
+	return titles, rows.Err()
+}
+
+// GetCategoryAges returns the age of the books of every category that has
+// books with a publication year.
+func (s *ReportStore) GetCategoryAges(libraryID int64, filter WeedingFilter) ([]*models.CategoryAge, error) {
+	query := `
+		SELECT
+			cat.id, cat.name, cat.path,
+			COUNT(b.id),
+			SUM(CASE WHEN b.publication_year < ? THEN 1 ELSE 0 END),
+			MIN(b.publication_year),
+			AVG(b.publication_year)
+		FROM categories cat
+		INNER JOIN book_categories bc ON bc.category_id = cat.id
+		INNER JOIN books b ON b.id = bc.book_id
+		WHERE cat.library_id = ? AND b.publication_year IS NOT NULL
+	`
+
+	args := []any{filter.OldBefore, libraryID}
+
+	if filter.ZoneID != nil {
+		query += `
+			AND EXISTS (
+				SELECT 1 FROM copies c
+				INNER JOIN shelves s ON s.id = c.shelf_id
+				WHERE c.book_id = b.id AND s.zone_id = ?
+			)
+		`
+		args = append(args, *filter.ZoneID)
+	}
+
+	query += "\nGROUP BY cat.id, cat.name, cat.path\nORDER BY cat.path, cat.name"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.CategoryAge
+
+	for rows.Next() {
+		category := &models.CategoryAge{}
+
+		err := rows.Scan(
+			&category.CategoryID,
+			&category.Name,
+			&category.Path,
+			&category.Books,
+			&category.OldBooks,
+			&category.OldestYear,
+			&category.AverageYear,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetOverdueLoans returns the loans not returned whose due date is before asOf,
+// the longest overdue first.
+func (s *ReportStore) GetOverdueLoans(libraryID int64, asOf time.Time) ([]*models.OverdueLoanRow, error) {
+	query := `
+		SELECT
+			l.loan_code, u.code, u.first_name || ' ' || u.last_name, u.user_type, u.email,
+			b.title, c.code, l.loan_date, l.due_date,
+			CAST(julianday(?) - julianday(l.due_date) AS INTEGER)
+		FROM loans l
+		INNER JOIN users u ON u.id = l.user_id
+		INNER JOIN copies c ON c.id = l.copy_id
+		INNER JOIN books b ON b.id = c.book_id
+		WHERE l.library_id = ? AND l.status IN ('Active', 'Overdue') AND l.return_date IS NULL
+			AND datetime(l.due_date) < datetime(?)
+		ORDER BY l.due_date, l.id
+	`
+
+	rows, err := s.db.Query(query, asOf, libraryID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []*models.OverdueLoanRow
+
+	for rows.Next() {
+		loan := &models.OverdueLoanRow{}
+
+		err := rows.Scan(
+			&loan.LoanCode,
+			&loan.UserCode,
+			&loan.UserName,
+			&loan.UserType,
+			&loan.Email,
+			&loan.Title,
+			&loan.CopyCode,
+			&loan.LoanDate,
+			&loan.DueDate,
+			&loan.DaysOverdue,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		loans = append(loans, loan)
+	}
+
+	return loans, rows.Err()
+}
+
+// GetFines returns the fines generated in the range, of any status when status
+// is empty.
+func (s *ReportStore) GetFines(libraryID int64, filter StatsFilter, status string) ([]*models.FineRow, error) {
+	query := `
+		SELECT
+			f.id, u.code, u.first_name || ' ' || u.last_name, f.reason, f.amount, f.status,
+			f.generated_date, f.payment_date
+		FROM fines f
+		INNER JOIN users u ON u.id = f.user_id
+		WHERE f.library_id = ? AND date(f.generated_date) BETWEEN ? AND ?
+	`
+
+	args := []any{libraryID, filter.From, filter.To}
+
+	if status != "" {
+		query += " AND f.status = ?"
+		args = append(args, status)
+	}
+
+	query += "\nORDER BY f.generated_date, f.id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fines []*models.FineRow
+
+	for rows.Next() {
+		fine := &models.FineRow{}
+
+		err := rows.Scan(
+			&fine.FineID,
+			&fine.UserCode,
+			&fine.UserName,
+			&fine.Reason,
+			&fine.Amount,
+			&fine.Status,
+			&fine.GeneratedDate,
+			&fine.PaymentDate,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fines = append(fines, fine)
+	}
+
+	return fines, rows.Err()
+}
+
+func (s *ReportStore) queryWeedingCopies(query string, args ...any) ([]*models.WeedingCopy, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var copies []*models.WeedingCopy
+
+	for rows.Next() {
+		copy := &models.WeedingCopy{}
+
+		err := rows.Scan(
+			&copy.CopyID,
+			&copy.Code,
+			&copy.BookID,
+			&copy.Title,
+			&copy.CallNumber,
+			&copy.ZoneID,
+			&copy.ShelfID,
+			&copy.Status,
+			&copy.Condition,
+			&copy.AcquisitionDate,
+			&copy.LastLoanDate,
+			&copy.LoanCount,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		copies = append(copies, copy)
+	}
+
+	return copies, rows.Err()
+}
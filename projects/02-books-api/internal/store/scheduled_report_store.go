@@ -0,0 +1,384 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type ReportRunFilter struct {
+	DefinitionID *int64
+	Status       string
+}
+
+type IReportDefinitionStore interface {
+	GetAll(libraryID int64) ([]*models.ReportDefinition, error)
+	GetByID(libraryID, id int64) (*models.ReportDefinition, error)
+	GetByName(libraryID int64, name string) (*models.ReportDefinition, error)
+	GetDue(now time.Time) ([]*models.ReportDefinition, error)
+	Create(libraryID int64, definition *models.ReportDefinition) (*models.ReportDefinition, error)
+	Update(libraryID, id int64, definition *models.ReportDefinition) (*models.ReportDefinition, error)
+	UpdateRunTimes(libraryID, id int64, lastRunAt database.NullTime, nextRunAt database.NullTime) error
+	Delete(libraryID, id int64) error
+}
+
+type IReportRunStore interface {
+	GetAll(libraryID int64, filter ReportRunFilter) ([]*models.ReportRun, error)
+	GetByID(libraryID, id int64) (*models.ReportRun, error)
+	Create(libraryID int64, run *models.ReportRun) (*models.ReportRun, error)
+	Finish(libraryID int64, run *models.ReportRun) error
+}
+
+type ReportDefinitionStore struct {
+	db *sql.DB
+}
+
+type ReportRunStore struct {
+	db *sql.DB
+}
+
+func NewReportDefinitionStore(db *sql.DB) IReportDefinitionStore {
+	return &ReportDefinitionStore{
+		db: db,
+	}
+}
+
+func NewReportRunStore(db *sql.DB) IReportRunStore {
+	return &ReportRunStore{
+		db: db,
+	}
+}
+
+const reportDefinitionColumns = `
+	id, name, report_type, parameters, format, schedule, paused, last_run_at, next_run_at, created_at, library_id
+`
+
+func (s *ReportDefinitionStore) GetAll(libraryID int64) ([]*models.ReportDefinition, error) {
+	query := `SELECT ` + reportDefinitionColumns + ` FROM report_definitions WHERE library_id = ? ORDER BY name`
+
+	return s.queryDefinitions(query, libraryID)
+}
+
+func (s *ReportDefinitionStore) GetByID(libraryID, id int64) (*models.ReportDefinition, error) {
+	query := `SELECT ` + reportDefinitionColumns + ` FROM report_definitions WHERE id = ? AND library_id = ?`
+
+	return scanReportDefinition(s.db.QueryRow(query, id, libraryID))
+}
+
+func (s *ReportDefinitionStore) GetByName(libraryID int64, name string) (*models.ReportDefinition, error) {
+	query := `SELECT ` + reportDefinitionColumns + ` FROM report_definitions WHERE name = ? COLLATE NOCASE AND library_id = ?`
+
+	definition, err := scanReportDefinition(s.db.QueryRow(query, name, libraryID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return definition, nil
+}
+
+// GetDue returns the definitions of every library, not paused, whose next run is
+// not after now.
+func (s *ReportDefinitionStore) GetDue(now time.Time) ([]*models.ReportDefinition, error) {
+	query := `
+		SELECT ` + reportDefinitionColumns + `
+		FROM report_definitions
+		WHERE paused = 0 AND next_run_at IS NOT NULL AND datetime(next_run_at) <= datetime(?)
+		ORDER BY next_run_at, id
+	`
+
+	return s.queryDefinitions(query, now)
+}
+
+func (s *ReportDefinitionStore) Create(libraryID int64, definition *models.ReportDefinition) (*models.ReportDefinition, error) {
+	parameters, err := json.Marshal(definition.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO report_definitions (name, report_type, parameters, format, schedule, paused, next_run_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(
+		query,
+		definition.Name,
+		definition.ReportType,
+		string(parameters),
+		definition.Format,
+		definition.Schedule,
+		definition.Paused,
+		definition.NextRunAt,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(libraryID, id)
+}
+
+func (s *ReportDefinitionStore) Update(libraryID, id int64, definition *models.ReportDefinition) (*models.ReportDefinition, error) {
+	parameters, err := json.Marshal(definition.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE report_definitions
+		SET name = ?, report_type = ?, parameters = ?, format = ?, schedule = ?, paused = ?, next_run_at = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err = s.db.Exec(
+		query,
+		definition.Name,
+		definition.ReportType,
+		string(parameters),
+		definition.Format,
+		definition.Schedule,
+		definition.Paused,
+		definition.NextRunAt,
+		id,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(libraryID, id)
+}
+
+func (s *ReportDefinitionStore) UpdateRunTimes(libraryID, id int64, lastRunAt database.NullTime, nextRunAt database.NullTime) error {
+	query := `UPDATE report_definitions SET last_run_at = ?, next_run_at = ? WHERE id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, lastRunAt, nextRunAt, id, libraryID)
+
+	return err
+}
+
+// Delete removes the definition with its runs; their files are removed by the
+// service.
+func (s *ReportDefinitionStore) Delete(libraryID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM report_runs WHERE definition_id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM report_definitions WHERE id = ? AND library_id = ?`, id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *ReportDefinitionStore) queryDefinitions(query string, args ...any) ([]*models.ReportDefinition, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var definitions []*models.ReportDefinition
+
+	for rows.Next() {
+		definition, err := scanReportDefinition(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReportDefinition(row rowScanner) (*models.ReportDefinition, error) {
+	definition := &models.ReportDefinition{}
+
+	var parameters string
+
+	err := row.Scan(
+		&definition.ID,
+		&definition.Name,
+		&definition.ReportType,
+		&parameters,
+		&definition.Format,
+		&definition.Schedule,
+		&definition.Paused,
+		&definition.LastRunAt,
+		&definition.NextRunAt,
+		&definition.CreatedAt,
+		&definition.LibraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(parameters), &definition.Parameters); err != nil {
+		return nil, err
+	}
+
+	if definition.Parameters == nil {
+		definition.Parameters = map[string]string{}
+	}
+
+	return definition, nil
+}
+
+const reportRunColumns = `
+	id, definition_id, name, report_type, format, run_trigger, status, file_key, file_name,
+	size, row_count, error, started_at, finished_at, library_id
+`
+
+func (s *ReportRunStore) GetAll(libraryID int64, filter ReportRunFilter) ([]*models.ReportRun, error) {
+	query := `SELECT ` + reportRunColumns + ` FROM report_runs WHERE library_id = ?`
+	args := []any{libraryID}
+
+	if filter.DefinitionID != nil {
+		query += " AND definition_id = ?"
+		args = append(args, *filter.DefinitionID)
+	}
+
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+
+	query += " ORDER BY started_at DESC, id DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*models.ReportRun
+
+	for rows.Next() {
+		run, err := scanReportRun(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (s *ReportRunStore) GetByID(libraryID, id int64) (*models.ReportRun, error) {
+	query := `SELECT ` + reportRunColumns + ` FROM report_runs WHERE id = ? AND library_id = ?`
+
+	return scanReportRun(s.db.QueryRow(query, id, libraryID))
+}
+
+func (s *ReportRunStore) Create(libraryID int64, run *models.ReportRun) (*models.ReportRun, error) {
+	query := `
+		INSERT INTO report_runs (definition_id, name, report_type, format, run_trigger, status, started_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(
+		query,
+		run.DefinitionID,
+		run.Name,
+		run.ReportType,
+		run.Format,
+		run.Trigger,
+		run.Status,
+		run.StartedAt,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	run.ID = id
+	run.LibraryID = libraryID
+
+	return run, nil
+}
+
+func (s *ReportRunStore) Finish(libraryID int64, run *models.ReportRun) error {
+	query := `
+		UPDATE report_runs
+		SET status = ?, file_key = ?, file_name = ?, size = ?, row_count = ?, error = ?, finished_at = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	_, err := s.db.Exec(
+		query,
+		run.Status,
+		run.FileKey,
+		run.FileName,
+		run.Size,
+		run.Rows,
+		run.Error,
+		run.FinishedAt,
+		run.ID,
+		libraryID,
+	)
+
+	return err
+}
+
+func scanReportRun(row rowScanner) (*models.ReportRun, error) {
+	run := &models.ReportRun{}
+
+	err := row.Scan(
+		&run.ID,
+		&run.DefinitionID,
+		&run.Name,
+		&run.ReportType,
+		&run.Format,
+		&run.Trigger,
+		&run.Status,
+		&run.FileKey,
+		&run.FileName,
+		&run.Size,
+		&run.Rows,
+		&run.Error,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.LibraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
@@ -0,0 +1,292 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+// StatsFilter selects a date range, both ends included, as YYYY-MM-DD.
+type StatsFilter struct {
+	From string
+	To   string
+}
+
+type IStatsStore interface {
+	GetVersion(libraryID int64) (int64, error)
+	GetCirculationBuckets(libraryID int64, filter StatsFilter, interval string) ([]*models.CirculationBucket, error)
+	GetCirculationBreakdown(libraryID int64, filter StatsFilter, dimension string) ([]*models.CirculationBreakdown, error)
+	GetTopBooks(libraryID int64, filter StatsFilter, limit int) ([]*models.TopBook, error)
+	GetTopPatrons(libraryID int64, filter StatsFilter, limit int) ([]*models.TopPatron, error)
+}
+
+type StatsStore struct {
+	db *sql.DB
+}
+
+func NewStatsStore(db *sql.DB) IStatsStore {
+	return &StatsStore{
+		db: db,
+	}
+}
+
+// The first day of the bucket of a timestamp column, by interval
+var statsBucketExpressions = map[string]string{
+	"day":   "date(%s)",
+	"week":  "date(%s, 'weekday 0', '-6 days')",
+	"month": "strftime('%%Y-%%m-01', %s)",
+}
+
+// The joins from a loan (l) and its copy (c) to the key and name of a breakdown
+var statsBreakdownJoins = map[string]struct {
+	join string
+	key  string
+	name string
+}{
+	"user_type": {
+		join: "INNER JOIN users u ON u.id = l.user_id",
+		key:  "u.user_type",
+		name: "u.user_type",
+	},
+	"category": {
+		join: "INNER JOIN book_categories bc ON bc.book_id = c.book_id INNER JOIN categories k ON k.id = bc.category_id",
+		key:  "CAST(k.id AS TEXT)",
+		name: "k.name",
+	},
+	"zone": {
+		join: "LEFT JOIN shelves s ON s.id = c.shelf_id LEFT JOIN library_zones z ON z.id = s.zone_id",
+		key:  "COALESCE(z.code, '')",
+		name: "COALESCE(z.name, 'Sin zona')",
+	},
+}
+
+// GetVersion returns the stats version of the library, bumped by triggers on
+// every write to the tables read by the statistics.
+func (s *StatsStore) GetVersion(libraryID int64) (int64, error) {
+	var version int64
+
+	err := s.db.QueryRow(`SELECT version FROM stats_versions WHERE library_id = ?`, libraryID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return version, err
+}
+
+func (s *StatsStore) GetCirculationBuckets(libraryID int64, filter StatsFilter, interval string) ([]*models.CirculationBucket, error) {
+	bucket, ok := statsBucketExpressions[interval]
+	if !ok {
+		return nil, fmt.Errorf("intervalo desconocido: %s", interval)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT period, SUM(loans), SUM(returns), SUM(renewals), SUM(new_patrons), ROUND(SUM(fines), 2)
+		FROM (
+			SELECT %s AS period, 1 AS loans, 0 AS returns, 0 AS renewals, 0 AS new_patrons, 0 AS fines
+			FROM loans WHERE library_id = ? AND date(loan_date) BETWEEN ? AND ?
+			UNION ALL
+			SELECT %s, 0, 1, 0, 0, 0
+			FROM loans WHERE library_id = ? AND date(return_date) BETWEEN ? AND ?
+			UNION ALL
+			SELECT %s, 0, 0, 1, 0, 0
+			FROM copy_events WHERE library_id = ? AND event_type = 'Renewed' AND date(occurred_at) BETWEEN ? AND ?
+			UNION ALL
+			SELECT %s, 0, 0, 0, 1, 0
+			FROM users WHERE library_id = ? AND date(registration_date) BETWEEN ? AND ?
+			UNION ALL
+			SELECT %s, 0, 0, 0, 0, amount
+			FROM fines WHERE library_id = ? AND status = 'Paid' AND date(payment_date) BETWEEN ? AND ?
+		)
+		GROUP BY period
+		ORDER BY period
+	`,
+		fmt.Sprintf(bucket, "loan_date"),
+		fmt.Sprintf(bucket, "return_date"),
+		fmt.Sprintf(bucket, "occurred_at"),
+		fmt.Sprintf(bucket, "registration_date"),
+		fmt.Sprintf(bucket, "payment_date"),
+	)
+
+	var args []any
+	for i := 0; i < 5; i++ {
+		args = append(args, libraryID, filter.From, filter.To)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []*models.CirculationBucket
+
+	for rows.Next() {
+		bucket := &models.CirculationBucket{}
+
+		err := rows.Scan(
+			&bucket.Period,
+			&bucket.Loans,
+			&bucket.Returns,
+			&bucket.Renewals,
+			&bucket.NewPatrons,
+			&bucket.FinesCollected,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+// GetCirculationBreakdown counts loans, returns and renewals by user type,
+// category or current zone of the copy. A loan of a book in several categories
+// counts in each of them.
+func (s *StatsStore) GetCirculationBreakdown(libraryID int64, filter StatsFilter, dimension string) ([]*models.CirculationBreakdown, error) {
+	breakdown, ok := statsBreakdownJoins[dimension]
+	if !ok {
+		return nil, fmt.Errorf("desglose desconocido: %s", dimension)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT key, name, SUM(loans), SUM(returns), SUM(renewals)
+		FROM (
+			SELECT %[2]s AS key, %[3]s AS name, 1 AS loans, 0 AS returns, 0 AS renewals
+			FROM loans l
+			INNER JOIN copies c ON c.id = l.copy_id
+			%[1]s
+			WHERE l.library_id = ? AND date(l.loan_date) BETWEEN ? AND ?
+			UNION ALL
+			SELECT %[2]s, %[3]s, 0, 1, 0
+			FROM loans l
+			INNER JOIN copies c ON c.id = l.copy_id
+			%[1]s
+			WHERE l.library_id = ? AND date(l.return_date) BETWEEN ? AND ?
+			UNION ALL
+			SELECT %[2]s, %[3]s, 0, 0, 1
+			FROM copy_events e
+			INNER JOIN loans l ON l.id = e.loan_id
+			INNER JOIN copies c ON c.id = l.copy_id
+			%[1]s
+			WHERE e.library_id = ? AND e.event_type = 'Renewed' AND date(e.occurred_at) BETWEEN ? AND ?
+		)
+		GROUP BY key, name
+		ORDER BY SUM(loans) DESC, name
+	`, breakdown.join, breakdown.key, breakdown.name)
+
+	var args []any
+	for i := 0; i < 3; i++ {
+		args = append(args, libraryID, filter.From, filter.To)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var breakdowns []*models.CirculationBreakdown
+
+	for rows.Next() {
+		row := &models.CirculationBreakdown{}
+
+		err := rows.Scan(
+			&row.Key,
+			&row.Name,
+			&row.Loans,
+			&row.Returns,
+			&row.Renewals,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		breakdowns = append(breakdowns, row)
+	}
+
+	return breakdowns, rows.Err()
+}
+
+func (s *StatsStore) GetTopBooks(libraryID int64, filter StatsFilter, limit int) ([]*models.TopBook, error) {
+	query := `
+		SELECT b.id, b.title, COUNT(*) AS loans
+		FROM loans l
+		INNER JOIN copies c ON c.id = l.copy_id
+		INNER JOIN books b ON b.id = c.book_id
+		WHERE l.library_id = ? AND date(l.loan_date) BETWEEN ? AND ?
+		GROUP BY b.id, b.title
+		ORDER BY loans DESC, b.title
+		LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, libraryID, filter.From, filter.To, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var books []*models.TopBook
+
+	for rows.Next() {
+		book := &models.TopBook{}
+
+		err := rows.Scan(
+			&book.BookID,
+			&book.Title,
+			&book.Loans,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		books = append(books, book)
+	}
+
+	return books, rows.Err()
+}
+
+func (s *StatsStore) GetTopPatrons(libraryID int64, filter StatsFilter, limit int) ([]*models.TopPatron, error) {
+	query := `
+		SELECT u.id, u.code, u.first_name || ' ' || u.last_name, u.user_type, COUNT(*) AS loans
+		FROM loans l
+		INNER JOIN users u ON u.id = l.user_id
+		WHERE l.library_id = ? AND date(l.loan_date) BETWEEN ? AND ?
+		GROUP BY u.id, u.code, u.first_name, u.last_name, u.user_type
+		ORDER BY loans DESC, u.last_name, u.first_name
+		LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, libraryID, filter.From, filter.To, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var patrons []*models.TopPatron
+
+	for rows.Next() {
+		patron := &models.TopPatron{}
+
+		err := rows.Scan(
+			&patron.UserID,
+			&patron.Code,
+			&patron.Name,
+			&patron.UserType,
+			&patron.Loans,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		patrons = append(patrons, patron)
+	}
+
+	return patrons, rows.Err()
+}
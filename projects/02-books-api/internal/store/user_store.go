@@ -37,7 +37,7 @@ func (s *UserStore) GetAll(libraryID int64) ([]*models.User, error) {
 	query := `
 		SELECT
 			id, code, dni, first_name, last_name, email, phone, 
-			address, user_type, status, registration_date, library_id
+			address, user_type, status, registration_date, card_expiration, library_id
 		FROM users 
 		WHERE library_id = ?
 		ORDER BY last_name, first_name
@@ -66,6 +66,7 @@ func (s *UserStore) GetAll(libraryID int64) ([]*models.User, error) {
 			&user.UserType,
 			&user.Status,
 			&user.RegistrationDate,
+			&user.CardExpiration,
 			&user.LibraryID,
 		)
 
@@ -83,7 +84,7 @@ func (s *UserStore) GetByID(libraryID, id int64) (*models.User, error) {
 	query := `
 		SELECT
 			id, code, dni, first_name, last_name, email, phone, 
-			address, user_type, status, registration_date, library_id
+			address, user_type, status, registration_date, card_expiration, library_id
 		FROM users 
 		WHERE id = ? AND library_id = ?
 	`
@@ -104,6 +105,7 @@ func (s *UserStore) GetByID(libraryID, id int64) (*models.User, error) {
 			&user.UserType,
 			&user.Status,
 			&user.RegistrationDate,
+			&user.CardExpiration,
 			&user.LibraryID,
 		)
 
@@ -118,7 +120,7 @@ func (s *UserStore) GetByCode(libraryID int64, code string) (*models.User, error
 	query := `
 		SELECT
 			id, code, dni, first_name, last_name, email, phone, 
-			address, user_type, status, registration_date, library_id
+			address, user_type, status, registration_date, card_expiration, library_id
 		FROM users 
 		WHERE code = ? AND library_id = ?
 	`
@@ -130,7 +132,7 @@ func (s *UserStore) GetByCode(libraryID int64, code string) (*models.User, error
 		Scan(
 			&user.ID, &user.Code, &user.DNI, &user.FirstName, &user.LastName,
 			&user.Email, &user.Phone, &user.Address, &user.UserType,
-			&user.Status, &user.RegistrationDate, &user.LibraryID,
+			&user.Status, &user.RegistrationDate, &user.CardExpiration, &user.LibraryID,
 		)
 
 	if err == sql.ErrNoRows {
@@ -148,7 +150,7 @@ func (s *UserStore) GetByDNI(libraryID int64, dni string) (*models.User, error)
 	query := `
 		SELECT
 			id, code, dni, first_name, last_name, email, phone, 
-			address, user_type, status, registration_date, library_id
+			address, user_type, status, registration_date, card_expiration, library_id
 		FROM users 
 		WHERE dni = ? AND library_id = ?
 	`
@@ -169,6 +171,7 @@ func (s *UserStore) GetByDNI(libraryID int64, dni string) (*models.User, error)
 			&user.UserType,
 			&user.Status,
 			&user.RegistrationDate,
+			&user.CardExpiration,
 			&user.LibraryID,
 		)
 
@@ -187,7 +190,7 @@ func (s *UserStore) GetUsersFiltered(libraryID int64, filter UserFilter) ([]*mod
 	query := `
 		SELECT
 			id, code, dni, first_name, last_name, email, phone, 
-			address, user_type, status, registration_date, library_id
+			address, user_type, status, registration_date, card_expiration, library_id
 		FROM users
 	`
 
@@ -246,6 +249,7 @@ func (s *UserStore) GetUsersFiltered(libraryID int64, filter UserFilter) ([]*mod
 			&user.UserType,
 			&user.Status,
 			&user.RegistrationDate,
+			&user.CardExpiration,
 			&user.LibraryID,
 		)
 
@@ -261,14 +265,14 @@ func (s *UserStore) GetUsersFiltered(libraryID int64, filter UserFilter) ([]*mod
 
 func (s *UserStore) Create(libraryID int64, user *models.User) (*models.User, error) {
 	query := `
-		INSERT INTO users (code, dni, first_name, last_name, email, phone, address, user_type, status, registration_date, library_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (code, dni, first_name, last_name, email, phone, address, user_type, status, registration_date, card_expiration, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := s.db.Exec(
 		query,
 		user.Code, user.DNI, user.FirstName, user.LastName, user.Email,
-		user.Phone, user.Address, user.UserType, user.Status, user.RegistrationDate, libraryID,
+		user.Phone, user.Address, user.UserType, user.Status, user.RegistrationDate, user.CardExpiration, libraryID,
 	)
 
 	if err != nil {
@@ -291,14 +295,14 @@ func (s *UserStore) Update(libraryID, id int64, user *models.User) (*models.User
 		UPDATE users 
 		SET
 			code = ?, dni = ?, first_name = ?, last_name = ?, email = ?, 
-			phone = ?, address = ?, user_type = ?, status = ?
+			phone = ?, address = ?, user_type = ?, status = ?, card_expiration = ?
 		WHERE id = ? AND library_id = ?
 	`
 
 	_, err := s.db.Exec(
 		query,
 		user.Code, user.DNI, user.FirstName, user.LastName, user.Email,
-		user.Phone, user.Address, user.UserType, user.Status, id, libraryID,
+		user.Phone, user.Address, user.UserType, user.Status, user.CardExpiration, id, libraryID,
 	)
 
 	if err != nil {
@@ -0,0 +1,547 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID *int64
+	Status         string
+	EventType      string
+}
+
+type IWebhookSubscriptionStore interface {
+	GetAll(libraryID int64) ([]*models.WebhookSubscription, error)
+	GetByID(libraryID, id int64) (*models.WebhookSubscription, error)
+	GetActive(libraryID int64) ([]*models.WebhookSubscription, error)
+	Create(libraryID int64, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	Update(libraryID, id int64, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	Delete(libraryID, id int64) error
+}
+
+type IWebhookDeliveryStore interface {
+	GetPendingEvents(limit int) ([]*models.Event, error)
+	FanOut(event *models.Event, subscriptionIDs []int64) error
+	GetReady(now time.Time, limit int) ([]*models.WebhookDelivery, error)
+	RecordAttempt(delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error
+	GetAll(libraryID int64, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error)
+	GetByID(libraryID, id int64) (*models.WebhookDelivery, error)
+	GetAttempts(libraryID, deliveryID int64) ([]*models.WebhookAttempt, error)
+	Replay(libraryID int64, ids []int64, now time.Time) (int64, error)
+}
+
+type WebhookSubscriptionStore struct {
+	db *sql.DB
+}
+
+type WebhookDeliveryStore struct {
+	db *sql.DB
+}
+
+func NewWebhookSubscriptionStore(db *sql.DB) IWebhookSubscriptionStore {
+	return &WebhookSubscriptionStore{
+		db: db,
+	}
+}
+
+func NewWebhookDeliveryStore(db *sql.DB) IWebhookDeliveryStore {
+	return &WebhookDeliveryStore{
+		db: db,
+	}
+}
+
+const webhookSubscriptionColumns = `id, url, event_types, description, active, created_at, updated_at, library_id`
+
+func (s *WebhookSubscriptionStore) GetAll(libraryID int64) ([]*models.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE library_id = ? ORDER BY id`
+
+	return s.querySubscriptions(query, libraryID)
+}
+
+func (s *WebhookSubscriptionStore) GetByID(libraryID, id int64) (*models.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = ? AND library_id = ?`
+
+	return scanWebhookSubscription(s.db.QueryRow(query, id, libraryID))
+}
+
+func (s *WebhookSubscriptionStore) GetActive(libraryID int64) ([]*models.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE library_id = ? AND active = 1 ORDER BY id`
+
+	return s.querySubscriptions(query, libraryID)
+}
+
+func (s *WebhookSubscriptionStore) Create(libraryID int64, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, event_types, description, active, created_at, library_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	result, err := s.db.Exec(
+		query,
+		subscription.URL,
+		subscription.Secret,
+		string(eventTypes),
+		subscription.Description,
+		subscription.Active,
+		now,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.ID = id
+	subscription.CreatedAt = now
+	subscription.LibraryID = libraryID
+
+	return subscription, nil
+}
+
+// Update keeps the secret when the subscription comes without one.
+func (s *WebhookSubscriptionStore) Update(libraryID, id int64, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = ?, secret = COALESCE(NULLIF(?, ''), secret), event_types = ?, description = ?, active = ?, updated_at = ?
+		WHERE id = ? AND library_id = ?
+	`
+
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	_, err = s.db.Exec(
+		query,
+		subscription.URL,
+		subscription.Secret,
+		string(eventTypes),
+		subscription.Description,
+		subscription.Active,
+		now,
+		id,
+		libraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.ID = id
+	subscription.UpdatedAt.Time = now
+	subscription.UpdatedAt.Valid = true
+	subscription.LibraryID = libraryID
+
+	return subscription, nil
+}
+
+// Delete removes the subscription with its deliveries and their attempts.
+func (s *WebhookSubscriptionStore) Delete(libraryID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE subscription_id = ? AND library_id = ?)`,
+		`DELETE FROM webhook_deliveries WHERE subscription_id = ? AND library_id = ?`,
+		`DELETE FROM webhook_subscriptions WHERE id = ? AND library_id = ?`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, id, libraryID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *WebhookSubscriptionStore) querySubscriptions(query string, args ...any) ([]*models.WebhookSubscription, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.WebhookSubscription
+
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func scanWebhookSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{}
+	var eventTypes string
+
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&eventTypes,
+		&subscription.Description,
+		&subscription.Active,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+		&subscription.LibraryID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetPendingEvents returns the events of every library not fanned out yet,
+// oldest first.
+func (s *WebhookDeliveryStore) GetPendingEvents(limit int) ([]*models.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE status = 'Pending' ORDER BY id LIMIT ?`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// FanOut saves the payload of the event and creates its deliveries in a
+// single transaction, so an event is never delivered twice to a subscription.
+func (s *WebhookDeliveryStore) FanOut(event *models.Event, subscriptionIDs []int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE events SET payload = ?, status = 'Processed' WHERE id = ?`,
+		event.Payload,
+		event.ID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	for _, subscriptionID := range subscriptionIDs {
+		_, err := tx.Exec(
+			`INSERT OR IGNORE INTO webhook_deliveries (subscription_id, event_id, next_attempt_at, library_id) VALUES (?, ?, ?, ?)`,
+			subscriptionID,
+			event.ID,
+			time.Now(),
+			event.LibraryID,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const webhookDeliveryColumns = `
+	d.id, d.subscription_id, d.event_id, e.event_type, d.status, d.attempts, d.next_attempt_at,
+	d.last_status_code, d.last_error, d.created_at, d.delivered_at, d.library_id
+`
+
+// GetReady returns the pending deliveries of every library whose next attempt
+// has come, with what is needed to send them. Deliveries of an inactive
+// subscription wait until it is active again.
+func (s *WebhookDeliveryStore) GetReady(now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `, e.payload, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN events e ON e.id = d.event_id
+		JOIN webhook_subscriptions w ON w.id = d.subscription_id
+		WHERE d.status = 'Pending' AND w.active = 1 AND datetime(d.next_attempt_at) <= datetime(?)
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+
+	for rows.Next() {
+		var payload, url, secret string
+
+		delivery, err := scanWebhookDelivery(rows, &payload, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Payload = []byte(payload)
+		delivery.URL = url
+		delivery.Secret = secret
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt logs a request made for the delivery and saves its outcome.
+func (s *WebhookDeliveryStore) RecordAttempt(delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO webhook_attempts (delivery_id, attempt, requested_at, status_code, response_body, error, duration_ms, library_id)
+		VALUES (?, (SELECT COUNT(*) + 1 FROM webhook_attempts WHERE delivery_id = ?), ?, ?, ?, ?, ?, ?)`,
+		delivery.ID,
+		delivery.ID,
+		attempt.RequestedAt,
+		attempt.StatusCode,
+		attempt.ResponseBody,
+		attempt.Error,
+		attempt.DurationMs,
+		delivery.LibraryID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if attempt.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, delivered_at = ?
+		WHERE id = ? AND library_id = ?`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+		delivery.ID,
+		delivery.LibraryID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *WebhookDeliveryStore) GetAll(libraryID int64, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN events e ON e.id = d.event_id
+		WHERE d.library_id = ?
+	`
+	args := []any{libraryID}
+
+	if filter.SubscriptionID != nil {
+		query += " AND d.subscription_id = ?"
+		args = append(args, *filter.SubscriptionID)
+	}
+
+	if filter.Status != "" {
+		query += " AND d.status = ?"
+		args = append(args, filter.Status)
+	}
+
+	if filter.EventType != "" {
+		query += " AND e.event_type = ?"
+		args = append(args, filter.EventType)
+	}
+
+	query += " ORDER BY d.created_at DESC, d.id DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *WebhookDeliveryStore) GetByID(libraryID, id int64) (*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `, e.payload
+		FROM webhook_deliveries d
+		JOIN events e ON e.id = d.event_id
+		WHERE d.id = ? AND d.library_id = ?
+	`
+
+	var payload string
+
+	delivery, err := scanWebhookDelivery(s.db.QueryRow(query, id, libraryID), &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = []byte(payload)
+
+	return delivery, nil
+}
+
+func (s *WebhookDeliveryStore) GetAttempts(libraryID, deliveryID int64) ([]*models.WebhookAttempt, error) {
+	query := `
+		SELECT id, delivery_id, attempt, requested_at, status_code, response_body, error, duration_ms, library_id
+		FROM webhook_attempts
+		WHERE delivery_id = ? AND library_id = ?
+		ORDER BY attempt
+	`
+
+	rows, err := s.db.Query(query, deliveryID, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*models.WebhookAttempt
+
+	for rows.Next() {
+		attempt := &models.WebhookAttempt{}
+
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.Attempt,
+			&attempt.RequestedAt,
+			&attempt.StatusCode,
+			&attempt.ResponseBody,
+			&attempt.Error,
+			&attempt.DurationMs,
+			&attempt.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+// Replay puts the deliveries back in the queue with their attempts starting
+// over; the attempts already logged are kept.
+func (s *WebhookDeliveryStore) Replay(libraryID int64, ids []int64, now time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var replayed int64
+
+	for _, id := range ids {
+		result, err := tx.Exec(
+			`UPDATE webhook_deliveries
+			SET status = 'Pending', attempts = 0, next_attempt_at = ?, delivered_at = NULL
+			WHERE id = ? AND library_id = ?`,
+			now,
+			id,
+			libraryID,
+		)
+
+		if err != nil {
+			return 0, err
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		replayed += count
+	}
+
+	return replayed, tx.Commit()
+}
+
+func scanWebhookDelivery(row rowScanner, extra ...any) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+
+	dest := []any{
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+		&delivery.LibraryID,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+type IWorkStore interface {
+	GetAll(libraryID int64) ([]*models.Work, error)
+	GetByID(libraryID, id int64) (*models.Work, error)
+	Create(libraryID int64, work *models.Work) (*models.Work, error)
+	Update(libraryID, id int64, work *models.Work) (*models.Work, error)
+	Delete(libraryID, id int64) error
+}
+
+type ISeriesStore interface {
+	GetAll(libraryID int64) ([]*models.Series, error)
+	GetByID(libraryID, id int64) (*models.Series, error)
+	Create(libraryID int64, series *models.Series) (*models.Series, error)
+	Update(libraryID, id int64, series *models.Series) (*models.Series, error)
+	Delete(libraryID, id int64) error
+}
+
+type WorkStore struct {
+	db *sql.DB
+}
+
+type SeriesStore struct {
+	db *sql.DB
+}
+
+func NewWorkStore(db *sql.DB) IWorkStore {
+	return &WorkStore{db: db}
+}
+
+func NewSeriesStore(db *sql.DB) ISeriesStore {
+	return &SeriesStore{db: db}
+}
+
+func (s *WorkStore) GetAll(libraryID int64) ([]*models.Work, error) {
+	query := `SELECT id, title, original_title, description, library_id FROM works WHERE library_id = ? ORDER BY title`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var works []*models.Work
+
+	for rows.Next() {
+		work := &models.Work{}
+
+		err := rows.Scan(
+			&work.ID,
+			&work.Title,
+			&work.OriginalTitle,
+			&work.Description,
+			&work.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		works = append(works, work)
+	}
+
+	return works, nil
+}
+
+func (s *WorkStore) GetByID(libraryID, id int64) (*models.Work, error) {
+	query := `SELECT id, title, original_title, description, library_id FROM works WHERE id = ? AND library_id = ?`
+
+	work := &models.Work{}
+
+	err := s.db.
+		QueryRow(query, id, libraryID).
+		Scan(
+			&work.ID,
+			&work.Title,
+			&work.OriginalTitle,
+			&work.Description,
+			&work.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return work, nil
+}
+
+func (s *WorkStore) Create(libraryID int64, work *models.Work) (*models.Work, error) {
+	query := `INSERT INTO works (title, original_title, description, library_id) VALUES (?, ?, ?, ?)`
+
+	result, err := s.db.Exec(query, work.Title, work.OriginalTitle, work.Description, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	work.ID = id
+	work.LibraryID = libraryID
+
+	return work, nil
+}
+
+func (s *WorkStore) Update(libraryID, id int64, work *models.Work) (*models.Work, error) {
+	query := `UPDATE works SET title = ?, original_title = ?, description = ? WHERE id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, work.Title, work.OriginalTitle, work.Description, id, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	work.ID = id
+	work.LibraryID = libraryID
+
+	return work, nil
+}
+
+func (s *WorkStore) Delete(libraryID, id int64) error {
+	_, err := s.db.Exec("UPDATE books SET work_id = NULL WHERE work_id = ? AND library_id = ?", id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("DELETE FROM works WHERE id = ? AND library_id = ?", id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SeriesStore) GetAll(libraryID int64) ([]*models.Series, error) {
+	query := `SELECT id, name, description, library_id FROM series WHERE library_id = ? ORDER BY name`
+
+	rows, err := s.db.Query(query, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seriesList []*models.Series
+
+	for rows.Next() {
+		series := &models.Series{}
+
+		err := rows.Scan(
+			&series.ID,
+			&series.Name,
+			&series.Description,
+			&series.LibraryID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		seriesList = append(seriesList, series)
+	}
+
+	return seriesList, nil
+}
+
+func (s *SeriesStore) GetByID(libraryID, id int64) (*models.Series, error) {
+	query := `SELECT id, name, description, library_id FROM series WHERE id = ? AND library_id = ?`
+
+	series := &models.Series{}
+
+	err := s.db.
+		QueryRow(query, id, libraryID).
+		Scan(
+			&series.ID,
+			&series.Name,
+			&series.Description,
+			&series.LibraryID,
+		)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (s *SeriesStore) Create(libraryID int64, series *models.Series) (*models.Series, error) {
+	query := `INSERT INTO series (name, description, library_id) VALUES (?, ?, ?)`
+
+	result, err := s.db.Exec(query, series.Name, series.Description, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	series.ID = id
+	series.LibraryID = libraryID
+
+	return series, nil
+}
+
+func (s *SeriesStore) Update(libraryID, id int64, series *models.Series) (*models.Series, error) {
+	query := `UPDATE series SET name = ?, description = ? WHERE id = ? AND library_id = ?`
+
+	_, err := s.db.Exec(query, series.Name, series.Description, id, libraryID)
+	if err != nil {
+		return nil, err
+	}
+
+	series.ID = id
+	series.LibraryID = libraryID
+
+	return series, nil
+}
+
+func (s *SeriesStore) Delete(libraryID, id int64) error {
+	_, err := s.db.Exec("UPDATE books SET series_id = NULL, volume_number = NULL WHERE series_id = ? AND library_id = ?", id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("DELETE FROM series WHERE id = ? AND library_id = ?", id, libraryID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,578 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+type VendorHandler struct {
+	vendorService *services.VendorService
+}
+
+type BudgetHandler struct {
+	budgetService *services.BudgetService
+}
+
+type PurchaseOrderHandler struct {
+	orderService *services.PurchaseOrderService
+}
+
+func NewVendorHandler(vendorService *services.VendorService) *VendorHandler {
+	return &VendorHandler{vendorService: vendorService}
+}
+
+func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{budgetService: budgetService}
+}
+
+func NewPurchaseOrderHandler(orderService *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{orderService: orderService}
+}
+
+// GET /vendors - Obtener todos los proveedores
+// POST /vendors - Crear un nuevo proveedor
+func (h *VendorHandler) HandleVendors(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			vendors, err := h.vendorService.GetAllVendors(libraryID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(vendors)
+
+		case http.MethodPost:
+			var vendor models.Vendor
+			err := json.NewDecoder(r.Body).Decode(&vendor)
+			if err != nil {
+				http.Error(w, "Datos del proveedor inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdVendor, err := h.vendorService.CreateVendor(r.Context(), libraryID, &vendor)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdVendor)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /vendors/{id} - Obtener un proveedor por ID
+// PUT /vendors/{id} - Actualizar un proveedor por ID
+// DELETE /vendors/{id} - Eliminar un proveedor por ID
+func (h *VendorHandler) HandleVendorByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/vendors/")
+	if idStr == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	switch r.Method {
+		case http.MethodGet:
+			vendor, err := h.vendorService.GetVendorByID(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(vendor)
+
+		case http.MethodPut:
+			var vendor models.Vendor
+			err := json.NewDecoder(r.Body).Decode(&vendor)
+			if err != nil {
+				http.Error(w, "Datos del proveedor inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedVendor, err := h.vendorService.UpdateVendor(r.Context(), libraryID, id, &vendor)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedVendor)
+
+		case http.MethodDelete:
+			err := h.vendorService.DeleteVendor(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /budgets - Obtener los presupuestos con lo comprometido y lo gastado (?fiscal_year=)
+// POST /budgets - Crear un nuevo presupuesto
+func (h *BudgetHandler) HandleBudgets(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			fiscalYear := 0
+
+			if fiscalYearStr := r.URL.Query().Get("fiscal_year"); fiscalYearStr != "" {
+				fiscalYear, err = strconv.Atoi(fiscalYearStr)
+				if err != nil {
+					http.Error(w, "El año fiscal es inválido", http.StatusBadRequest)
+					return
+				}
+			}
+
+			budgets, err := h.budgetService.GetAllBudgets(libraryID, fiscalYear)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(budgets)
+
+		case http.MethodPost:
+			var budget models.Budget
+			err := json.NewDecoder(r.Body).Decode(&budget)
+			if err != nil {
+				http.Error(w, "Datos del presupuesto inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdBudget, err := h.budgetService.CreateBudget(r.Context(), libraryID, &budget)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdBudget)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /budgets/{id} - Obtener un presupuesto con lo comprometido y lo gastado
+// PUT /budgets/{id} - Actualizar un presupuesto por ID
+// DELETE /budgets/{id} - Eliminar un presupuesto por ID
+func (h *BudgetHandler) HandleBudgetByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/budgets/")
+	if idStr == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	switch r.Method {
+		case http.MethodGet:
+			budget, err := h.budgetService.GetBudgetByID(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(budget)
+
+		case http.MethodPut:
+			var budget models.Budget
+			err := json.NewDecoder(r.Body).Decode(&budget)
+			if err != nil {
+				http.Error(w, "Datos del presupuesto inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedBudget, err := h.budgetService.UpdateBudget(r.Context(), libraryID, id, &budget)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedBudget)
+
+		case http.MethodDelete:
+			err := h.budgetService.DeleteBudget(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /purchase-orders - Obtener las órdenes de compra (?status=&vendor_id=&budget_id=)
+// POST /purchase-orders - Crear una orden de compra en borrador
+func (h *PurchaseOrderHandler) HandlePurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			filter := store.PurchaseOrderFilter{
+				Status: r.URL.Query().Get("status"),
+			}
+
+			vendorIDStr := r.URL.Query().Get("vendor_id")
+			if vendorIDStr != "" {
+				vendorID, err := strconv.ParseInt(vendorIDStr, 10, 64)
+				if err != nil || vendorID <= 0 {
+					http.Error(w, "El ID del proveedor es inválido", http.StatusBadRequest)
+					return
+				}
+
+				filter.VendorID = &vendorID
+			}
+
+			budgetIDStr := r.URL.Query().Get("budget_id")
+			if budgetIDStr != "" {
+				budgetID, err := strconv.ParseInt(budgetIDStr, 10, 64)
+				if err != nil || budgetID <= 0 {
+					http.Error(w, "El ID del presupuesto es inválido", http.StatusBadRequest)
+					return
+				}
+
+				filter.BudgetID = &budgetID
+			}
+
+			orders, err := h.orderService.GetAllOrders(libraryID, filter)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(orders)
+
+		case http.MethodPost:
+			var order models.PurchaseOrder
+			err := json.NewDecoder(r.Body).Decode(&order)
+			if err != nil {
+				http.Error(w, "Datos de la orden inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdOrder, err := h.orderService.CreateOrder(r.Context(), libraryID, &order)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdOrder)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /purchase-orders/{id} - Obtener una orden de compra con sus líneas
+// PUT /purchase-orders/{id} - Actualizar una orden en borrador
+// DELETE /purchase-orders/{id} - Eliminar una orden en borrador
+// POST /purchase-orders/{id}/submit - Enviar la orden al proveedor
+// POST /purchase-orders/{id}/cancel - Cancelar la orden o cerrarla si ya se recibió parte
+// POST /purchase-orders/{id}/lines/{lineId}/receive - Recibir ejemplares de una línea
+// GET /purchase-orders/{id}/receipts - Obtener los ejemplares recibidos
+// GET /purchase-orders/{id}/invoices - Obtener las facturas de la orden
+// POST /purchase-orders/{id}/invoices - Registrar una factura del proveedor
+// GET /purchase-orders/{id}/reconciliation - Conciliar lo facturado con lo recibido
+func (h *PurchaseOrderHandler) HandlePurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/purchase-orders/"), "/")
+	if parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(parts[0])
+	if err != nil || readId <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	if len(parts) > 1 {
+		switch parts[1] {
+			case "submit":
+				h.handleOrderSubmit(w, r, libraryID, id)
+				return
+			case "cancel":
+				h.handleOrderCancel(w, r, libraryID, id)
+				return
+			case "lines":
+				if len(parts) != 4 || parts[3] != "receive" {
+					http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+					return
+				}
+
+				lineID, err := strconv.ParseInt(parts[2], 10, 64)
+				if err != nil || lineID <= 0 {
+					http.Error(w, "El ID de la línea es inválido", http.StatusBadRequest)
+					return
+				}
+
+				h.handleLineReceive(w, r, libraryID, id, lineID)
+				return
+			case "receipts":
+				h.handleOrderReceipts(w, r, libraryID, id)
+				return
+			case "invoices":
+				h.handleOrderInvoices(w, r, libraryID, id)
+				return
+			case "reconciliation":
+				h.handleOrderReconciliation(w, r, libraryID, id)
+				return
+			default:
+				http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+				return
+		}
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			order, err := h.orderService.GetOrderByID(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(order)
+
+		case http.MethodPut:
+			var order models.PurchaseOrder
+			err := json.NewDecoder(r.Body).Decode(&order)
+			if err != nil {
+				http.Error(w, "Datos de la orden inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedOrder, err := h.orderService.UpdateOrder(r.Context(), libraryID, id, &order)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedOrder)
+
+		case http.MethodDelete:
+			err := h.orderService.DeleteOrder(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) handleOrderSubmit(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	order, err := h.orderService.SubmitOrder(r.Context(), libraryID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrBudgetExceeded) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) handleOrderCancel(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	order, err := h.orderService.CancelOrder(r.Context(), libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) handleLineReceive(w http.ResponseWriter, r *http.Request, libraryID, id, lineID int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var receipt models.LineReceipt
+	if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil {
+		http.Error(w, "Datos de la recepción inválidos", http.StatusBadRequest)
+		return
+	}
+
+	librarianID, err := middleware.GetLibrarianID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	copies, err := h.orderService.ReceiveLine(r.Context(), libraryID, id, lineID, &receipt, librarianID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(copies)
+}
+
+func (h *PurchaseOrderHandler) handleOrderReceipts(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	receipts, err := h.orderService.GetReceipts(libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
+
+func (h *PurchaseOrderHandler) handleOrderInvoices(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	switch r.Method {
+		case http.MethodGet:
+			invoices, err := h.orderService.GetInvoices(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(invoices)
+
+		case http.MethodPost:
+			var invoice models.Invoice
+			err := json.NewDecoder(r.Body).Decode(&invoice)
+			if err != nil {
+				http.Error(w, "Datos de la factura inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdInvoice, err := h.orderService.AddInvoice(r.Context(), libraryID, id, &invoice)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdInvoice)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) handleOrderReconciliation(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reconciliation, err := h.orderService.GetReconciliation(libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reconciliation)
+}
@@ -15,7 +15,8 @@ import (
 )
 
 type LoanHandler struct {
-	loanService *services.LoanService
+	loanService     *services.LoanService
+	documentService *services.DocumentService
 }
 
 type ReservationHandler struct {
@@ -23,19 +24,26 @@ type ReservationHandler struct {
 }
 
 type FineHandler struct {
-	fineService *services.FineService
+	fineService     *services.FineService
+	documentService *services.DocumentService
 }
 
-func NewLoanHandler(loanService *services.LoanService) *LoanHandler {
-	return &LoanHandler{loanService: loanService}
+func NewLoanHandler(loanService *services.LoanService, documentService *services.DocumentService) *LoanHandler {
+	return &LoanHandler{
+		loanService:     loanService,
+		documentService: documentService,
+	}
 }
 
 func NewReservationHandler(reservationService *services.ReservationService) *ReservationHandler {
 	return &ReservationHandler{reservationService: reservationService}
 }
 
-func NewFineHandler(fineService *services.FineService) *FineHandler {
-	return &FineHandler{fineService: fineService}
+func NewFineHandler(fineService *services.FineService, documentService *services.DocumentService) *FineHandler {
+	return &FineHandler{
+		fineService:     fineService,
+		documentService: documentService,
+	}
 }
 
 // GET /loans - Obtener todos los préstamos o con filtros
@@ -118,7 +126,7 @@ func (h *LoanHandler) HandleLoans(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			createdLoan, err := h.loanService.CreateLoan(libraryID, &loan)
+			createdLoan, err := h.loanService.CreateLoan(r.Context(), libraryID, &loan)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Error al crear préstamo: %v", err), http.StatusBadRequest)
 				return
@@ -136,6 +144,8 @@ func (h *LoanHandler) HandleLoans(w http.ResponseWriter, r *http.Request) {
 // GET /loans/{id} - Obtener préstamo por ID
 // PUT /loans/{id} - Actualizar préstamo por ID
 // DELETE /loans/{id} - Eliminar préstamo por ID
+// GET /loans/{id}/slip - Obtener el comprobante de préstamo en PDF
+// GET /loans/{id}/notice - Obtener el aviso de préstamo vencido en PDF
 func (h *LoanHandler) HandleLoanByID(w http.ResponseWriter, r *http.Request) {
 	libraryID, err := middleware.GetLibraryID(r)
 	if err != nil {
@@ -155,6 +165,39 @@ func (h *LoanHandler) HandleLoanByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(pathParts) > 2 {
+		if len(pathParts) > 3 {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var document []byte
+		var fileName string
+
+		switch pathParts[2] {
+			case "slip":
+				document, fileName, err = h.documentService.RenderLoanSlip(libraryID, id)
+			case "notice":
+				document, fileName, err = h.documentService.RenderOverdueNotice(libraryID, id)
+			default:
+				http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+				return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writePDF(w, document, fileName)
+		return
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			loan, err := h.loanService.GetByID(libraryID, id)
@@ -173,7 +216,7 @@ func (h *LoanHandler) HandleLoanByID(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			updatedLoan, err := h.loanService.Update(libraryID, id, &loan)
+			updatedLoan, err := h.loanService.Update(r.Context(), libraryID, id, &loan)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Error al actualizar préstamo: %v", err), http.StatusBadRequest)
 				return
@@ -183,7 +226,7 @@ func (h *LoanHandler) HandleLoanByID(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(updatedLoan)
 
 		case http.MethodDelete:
-			if err := h.loanService.Delete(libraryID, id); err != nil {
+			if err := h.loanService.Delete(r.Context(), libraryID, id); err != nil {
 				http.Error(w, fmt.Sprintf("Error al eliminar préstamo: %v", err), http.StatusInternalServerError)
 				return
 			}
@@ -230,7 +273,7 @@ func (h *LoanHandler) HandleLoanRenew(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	renewedLoan, err := h.loanService.RenewLoan(libraryID, id, librarianID)
+	renewedLoan, err := h.loanService.RenewLoan(r.Context(), libraryID, id, librarianID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error al renovar préstamo: %v", err), http.StatusBadRequest)
 		return
@@ -267,14 +310,20 @@ func (h *LoanHandler) HandleLoanReturn(w http.ResponseWriter, r *http.Request) {
 
 	var body map[string]interface{}
 	var notes *string
+	var librarianID *int64
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
 		if notesStr, ok := body["notes"].(string); ok {
 			notes = &notesStr
 		}
+
+		if libID, ok := body["librarian_id"].(float64); ok {
+			libIDInt := int64(libID)
+			librarianID = &libIDInt
+		}
 	}
 
-	returnedLoan, err := h.loanService.ReturnLoan(libraryID, id, notes)
+	returnedLoan, err := h.loanService.ReturnLoan(r.Context(), libraryID, id, notes, librarianID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error al devolver préstamo: %v", err), http.StatusBadRequest)
 		return
@@ -374,7 +423,7 @@ func (h *ReservationHandler) HandleReservations(w http.ResponseWriter, r *http.R
 				reservation.Priority = 5
 			}
 
-			createdReservation, err := h.reservationService.CreateReservation(libraryID, &reservation)
+			createdReservation, err := h.reservationService.CreateReservation(r.Context(), libraryID, &reservation)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Error al crear reservación: %v", err), http.StatusBadRequest)
 				return
@@ -429,7 +478,7 @@ func (h *ReservationHandler) HandleReservationByID(w http.ResponseWriter, r *htt
 				return
 			}
 
-			updatedReservation, err := h.reservationService.Update(libraryID, id, &reservation)
+			updatedReservation, err := h.reservationService.Update(r.Context(), libraryID, id, &reservation)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Error al actualizar reservación: %v", err), http.StatusBadRequest)
 				return
@@ -439,7 +488,7 @@ func (h *ReservationHandler) HandleReservationByID(w http.ResponseWriter, r *htt
 			json.NewEncoder(w).Encode(updatedReservation)
 
 		case http.MethodDelete:
-			if err := h.reservationService.Delete(libraryID, id); err != nil {
+			if err := h.reservationService.Delete(r.Context(), libraryID, id); err != nil {
 				http.Error(w, fmt.Sprintf("Error al eliminar reservación: %v", err), http.StatusInternalServerError)
 				return
 			}
@@ -476,7 +525,7 @@ func (h *ReservationHandler) HandleReservationCancel(w http.ResponseWriter, r *h
 		return
 	}
 
-	cancelledReservation, err := h.reservationService.CancelReservation(libraryID, id)
+	cancelledReservation, err := h.reservationService.CancelReservation(r.Context(), libraryID, id)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error al cancelar reservación: %v", err), http.StatusBadRequest)
 		return
@@ -511,7 +560,7 @@ func (h *ReservationHandler) HandleReservationProcess(w http.ResponseWriter, r *
 		return
 	}
 
-	processedReservation, err := h.reservationService.ProcessReservation(libraryID, id)
+	processedReservation, err := h.reservationService.ProcessReservation(r.Context(), libraryID, id)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error al procesar reservación: %v", err), http.StatusBadRequest)
 		return
@@ -603,7 +652,7 @@ func (h *FineHandler) HandleFines(w http.ResponseWriter, r *http.Request) {
 				fine.Status = "Pending"
 			}
 
-			createdFine, err := h.fineService.CreateFine(libraryID, &fine)
+			createdFine, err := h.fineService.CreateFine(r.Context(), libraryID, &fine)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Error al crear multa: %v", err), http.StatusBadRequest)
 				return
@@ -621,6 +670,7 @@ func (h *FineHandler) HandleFines(w http.ResponseWriter, r *http.Request) {
 // GET /fines/{id} - Obtener multa por ID
 // PUT /fines/{id} - Actualizar multa por ID
 // DELETE /fines/{id} - Eliminar multa por ID
+// GET /fines/{id}/receipt - Obtener el recibo de la multa pagada o condonada en PDF
 func (h *FineHandler) HandleFineByID(w http.ResponseWriter, r *http.Request) {
 	libraryID, err := middleware.GetLibraryID(r)
 	if err != nil {
@@ -640,6 +690,27 @@ func (h *FineHandler) HandleFineByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(pathParts) > 2 {
+		if len(pathParts) > 3 || pathParts[2] != "receipt" {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		receipt, fileName, err := h.documentService.RenderFineReceipt(libraryID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writePDF(w, receipt, fileName)
+		return
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			fine, err := h.fineService.GetByID(libraryID, id)
@@ -658,7 +729,7 @@ func (h *FineHandler) HandleFineByID(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			updatedFine, err := h.fineService.Update(libraryID, id, &fine)
+			updatedFine, err := h.fineService.Update(r.Context(), libraryID, id, &fine)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Error al actualizar multa: %v", err), http.StatusBadRequest)
 				return
@@ -668,7 +739,7 @@ func (h *FineHandler) HandleFineByID(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(updatedFine)
 
 		case http.MethodDelete:
-			if err := h.fineService.Delete(libraryID, id); err != nil {
+			if err := h.fineService.Delete(r.Context(), libraryID, id); err != nil {
 				http.Error(w, fmt.Sprintf("Error al eliminar multa: %v", err), http.StatusInternalServerError)
 				return
 			}
@@ -714,7 +785,7 @@ func (h *FineHandler) HandleFinePay(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	paidFine, err := h.fineService.PayFine(libraryID, id, notes)
+	paidFine, err := h.fineService.PayFine(r.Context(), libraryID, id, notes)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error al pagar multa: %v", err), http.StatusBadRequest)
 		return
@@ -758,7 +829,7 @@ func (h *FineHandler) HandleFineWaive(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	waivedFine, err := h.fineService.WaiveFine(libraryID, id, notes)
+	waivedFine, err := h.fineService.WaiveFine(r.Context(), libraryID, id, notes)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error al condonar multa: %v", err), http.StatusBadRequest)
 		return
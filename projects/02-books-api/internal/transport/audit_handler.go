@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// GET /audit - Obtener el registro de auditoría (entity_type, entity_id, action, actor_id, request_id, from, to, before_id, limit)
+func (h *AuditHandler) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+
+	query := services.AuditQuery{
+		EntityType: params.Get("entity_type"),
+		Action:     params.Get("action"),
+		RequestID:  params.Get("request_id"),
+		From:       params.Get("from"),
+		To:         params.Get("to"),
+	}
+
+	for name, target := range map[string]**int64{
+		"entity_id": &query.EntityID,
+		"actor_id":  &query.ActorID,
+		"before_id": &query.BeforeID,
+	} {
+		value := params.Get(name)
+		if value == "" {
+			continue
+		}
+
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			http.Error(w, fmt.Sprintf("El parámetro %s es inválido", name), http.StatusBadRequest)
+			return
+		}
+
+		*target = &id
+	}
+
+	query.Limit, err = parseIntParam(params.Get("limit"))
+	if err != nil {
+		http.Error(w, "El parámetro limit es inválido", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.auditService.GetEntries(libraryID, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GET /audit/verify - Verificar que la cadena de hashes del registro de auditoría está intacta
+func (h *AuditHandler) HandleAuditVerify(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	verification, err := h.auditService.Verify(libraryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verification)
+}
+
+// Middleware keeps the request ID and who made the call in the context of
+// the request, the services record their changes on its behalf.
+func (h *AuditHandler) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = middleware.WithRequestID(w, r)
+
+		origin := services.AuditOrigin{
+			RequestID:  middleware.GetRequestID(r),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+		}
+
+		if actorID, err := middleware.GetLibrarianID(r); err == nil {
+			origin.ActorID = actorID
+		}
+
+		next(w, r.WithContext(services.WithAuditOrigin(r.Context(), origin)))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
@@ -48,7 +49,7 @@ func (h *AuthorHandler) HandleAuthors(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			createdAuthor, err := h.authorService.CreateAuthor(libraryID, &author)
+			createdAuthor, err := h.authorService.CreateAuthor(r.Context(), libraryID, &author)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -63,6 +64,39 @@ func (h *AuthorHandler) HandleAuthors(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GET /authors/duplicates - Detectar autores duplicados (threshold opcional)
+func (h *AuthorHandler) HandleAuthorDuplicates(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var threshold float64
+
+	if param := r.URL.Query().Get("threshold"); param != "" {
+		threshold, err = strconv.ParseFloat(param, 64)
+		if err != nil {
+			http.Error(w, "El umbral de similitud es inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	groups, err := h.authorService.FindDuplicates(libraryID, threshold)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
 // GET /authors/{id} - Obtener un autor por ID
 // PUT /authors/{id} - Actualizar un autor por ID
 // DELETE /authors/{id} - Eliminar un autor por ID
@@ -73,13 +107,13 @@ func (h *AuthorHandler) HandleAuthorByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	idParam := r.URL.Path[len("/authors/"):]
-	if idParam == "" {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/authors/"), "/")
+	if parts[0] == "" {
 		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
 		return
 	}
 
-	readId, err := strconv.Atoi(idParam)
+	readId, err := strconv.Atoi(parts[0])
 	if err != nil || readId <= 0 {
 		http.Error(w, "El ID es inválido", http.StatusBadRequest)
 		return
@@ -87,6 +121,16 @@ func (h *AuthorHandler) HandleAuthorByID(w http.ResponseWriter, r *http.Request)
 
 	id := int64(readId)
 
+	if len(parts) > 1 {
+		if parts[1] != "merge" || len(parts) > 2 {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		h.handleAuthorMerge(w, r, id)
+		return
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			author, err := h.authorService.GetAuthorByID(libraryID, id)
@@ -107,7 +151,7 @@ func (h *AuthorHandler) HandleAuthorByID(w http.ResponseWriter, r *http.Request)
 			}
 
 			author.LibraryID = libraryID
-			updatedAuthor, err := h.authorService.UpdateAuthor(libraryID, id, &author)
+			updatedAuthor, err := h.authorService.UpdateAuthor(r.Context(), libraryID, id, &author)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -117,7 +161,7 @@ func (h *AuthorHandler) HandleAuthorByID(w http.ResponseWriter, r *http.Request)
 			json.NewEncoder(w).Encode(updatedAuthor)
 
 		case http.MethodDelete:
-			err := h.authorService.DeleteAuthor(libraryID, id)
+			err := h.authorService.DeleteAuthor(r.Context(), libraryID, id)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -129,3 +173,35 @@ func (h *AuthorHandler) HandleAuthorByID(w http.ResponseWriter, r *http.Request)
 			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
 	}
 }
+
+// POST /authors/{id}/merge - Fusionar autores duplicados (duplicate_ids) en el autor
+func (h *AuthorHandler) handleAuthorMerge(w http.ResponseWriter, r *http.Request, id int64) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data struct {
+		DuplicateIDs []int64 `json:"duplicate_ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil || len(data.DuplicateIDs) == 0 {
+		http.Error(w, "Los IDs de los autores duplicados son requeridos", http.StatusBadRequest)
+		return
+	}
+
+	author, err := h.authorService.MergeAuthors(r.Context(), libraryID, id, data.DuplicateIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
@@ -2,6 +2,9 @@ package transport
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,12 +16,14 @@ import (
 )
 
 type BookHandler struct {
-	bookService *services.BookService
+	bookService  *services.BookService
+	coverService *services.CoverService
 }
 
-func NewBookHandler(bookService *services.BookService) *BookHandler {
+func NewBookHandler(bookService *services.BookService, coverService *services.CoverService) *BookHandler {
 	return &BookHandler{
-		bookService: bookService,
+		bookService:  bookService,
+		coverService: coverService,
 	}
 }
 
@@ -78,6 +83,12 @@ func (h *BookHandler) HandleBooks(w http.ResponseWriter, r *http.Request) {
 				hasFilters = true
 			}
 
+			availability := r.URL.Query().Get("availability")
+			if availability != "" {
+				filter.Availability = availability
+				hasFilters = true
+			}
+
 			var books []*models.Book
 			var err error
 
@@ -103,7 +114,7 @@ func (h *BookHandler) HandleBooks(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			createdBook, err := h.bookService.CreateBook(libraryID, &book)
+			createdBook, err := h.bookService.CreateBook(r.Context(), libraryID, &book)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -153,6 +164,9 @@ func (h *BookHandler) HandleBookByID(w http.ResponseWriter, r *http.Request) {
 			case "categories":
 				h.handleBookCategories(w, r, id, parts[2:])
 				return
+			case "cover":
+				h.handleBookCover(w, r, id)
+				return
 			default:
 				http.Error(w, "Ruta no encontrada", http.StatusNotFound)
 				return
@@ -178,7 +192,7 @@ func (h *BookHandler) HandleBookByID(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			updatedBook, err := h.bookService.UpdateBook(libraryID, id, &book)
+			updatedBook, err := h.bookService.UpdateBook(r.Context(), libraryID, id, &book)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -188,7 +202,7 @@ func (h *BookHandler) HandleBookByID(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(updatedBook)
 
 		case http.MethodDelete:
-			err := h.bookService.DeleteBook(libraryID, id)
+			err := h.bookService.DeleteBook(r.Context(), libraryID, id)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -233,7 +247,7 @@ func (h *BookHandler) handleBookAuthors(w http.ResponseWriter, r *http.Request,
 
 			bookAuthor.BookID = bookID
 
-			err = h.bookService.AddAuthorToBook(libraryID, &bookAuthor)
+			err = h.bookService.AddAuthorToBook(r.Context(), libraryID, &bookAuthor)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -257,7 +271,7 @@ func (h *BookHandler) handleBookAuthors(w http.ResponseWriter, r *http.Request,
 				return
 			}
 
-			err = h.bookService.RemoveAuthorFromBook(libraryID, bookID, authorID)
+			err = h.bookService.RemoveAuthorFromBook(r.Context(), libraryID, bookID, authorID)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -287,7 +301,7 @@ func (h *BookHandler) handleBookAuthors(w http.ResponseWriter, r *http.Request,
 				return
 			}
 
-			err = h.bookService.UpdateAuthorPosition(libraryID, bookID, authorID, data.Position)
+			err = h.bookService.UpdateAuthorPosition(r.Context(), libraryID, bookID, authorID, data.Position)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -334,7 +348,7 @@ func (h *BookHandler) handleBookCategories(w http.ResponseWriter, r *http.Reques
 
 			bookCategory.BookID = bookID
 
-			err = h.bookService.AddCategoryToBook(libraryID, &bookCategory)
+			err = h.bookService.AddCategoryToBook(r.Context(), libraryID, &bookCategory)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -358,7 +372,84 @@ func (h *BookHandler) handleBookCategories(w http.ResponseWriter, r *http.Reques
 				return
 			}
 
-			err = h.bookService.RemoveCategoryFromBook(libraryID, bookID, categoryID)
+			err = h.bookService.RemoveCategoryFromBook(r.Context(), libraryID, bookID, categoryID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /books/{id}/cover?size={original|medium|small} - Obtener la portada del libro
+// PUT /books/{id}/cover - Subir o reemplazar la portada del libro (JPEG o PNG)
+// DELETE /books/{id}/cover - Eliminar la portada del libro
+func (h *BookHandler) handleBookCover(w http.ResponseWriter, r *http.Request, bookID int64) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			cover, data, err := h.coverService.GetCover(libraryID, bookID, r.URL.Query().Get("size"))
+			if errors.Is(err, services.ErrCoverNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			etag := `"` + cover.ETag + `"`
+
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "public, max-age=86400, must-revalidate")
+			w.Header().Set("Last-Modified", cover.UpdatedAt.UTC().Format(http.TimeFormat))
+
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("Content-Type", cover.ContentType)
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data)
+
+		case http.MethodPut:
+			data, err := readCoverUpload(w, r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			cover, err := h.coverService.UploadCover(r.Context(), libraryID, bookID, data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"`+cover.ETag+`"`)
+			json.NewEncoder(w).Encode(map[string]any{
+				"cover": cover,
+				"urls":  services.CoverURLsFor(libraryID, bookID),
+			})
+
+		case http.MethodDelete:
+			err := h.coverService.DeleteCover(r.Context(), libraryID, bookID)
+			if errors.Is(err, services.ErrCoverNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -370,3 +461,24 @@ func (h *BookHandler) handleBookCategories(w http.ResponseWriter, r *http.Reques
 			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
 	}
 }
+
+func readCoverUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxCoverBytes+(1<<20))
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("El archivo de portada es requerido en el campo \"file\"")
+		}
+		defer file.Close()
+
+		return io.ReadAll(file)
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("La imagen no puede exceder %d MB", services.MaxCoverBytes>>20)
+	}
+
+	return data, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
@@ -48,7 +49,7 @@ func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Reques
 				return
 			}
 
-			createdCategory, err := h.categoryService.CreateCategory(libraryID, &category)
+			createdCategory, err := h.categoryService.CreateCategory(r.Context(), libraryID, &category)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -63,6 +64,29 @@ func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// GET /categories/tree - Obtener el árbol de categorías
+func (h *CategoryHandler) HandleCategoryTree(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tree, err := h.categoryService.GetCategoryTree(libraryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
 // GET /categories/{id} - Obtener una categoría por ID
 // PUT /categories/{id} - Actualizar una categoría por ID
 // DELETE /categories/{id} - Eliminar una categoría por ID
@@ -73,13 +97,15 @@ func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	idParam := r.URL.Path[len("/categories/"):]
-	if idParam == "" {
+	path := strings.TrimPrefix(r.URL.Path, "/categories/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
 		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
 		return
 	}
 
-	readId, err := strconv.Atoi(idParam)
+	readId, err := strconv.Atoi(parts[0])
 	if err != nil {
 		http.Error(w, "El ID es inválido", http.StatusBadRequest)
 		return
@@ -87,6 +113,20 @@ func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Requ
 
 	id := int64(readId)
 
+	if len(parts) > 1 {
+		switch parts[1] {
+			case "move":
+				h.handleCategoryMove(w, r, id)
+				return
+			case "merge":
+				h.handleCategoryMerge(w, r, id)
+				return
+			default:
+				http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+				return
+		}
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			category, err := h.categoryService.GetCategoryByID(libraryID, id)
@@ -106,7 +146,7 @@ func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Requ
 				return
 			}
 
-			updatedCategory, err := h.categoryService.UpdateCategory(libraryID, id, &category)
+			updatedCategory, err := h.categoryService.UpdateCategory(r.Context(), libraryID, id, &category)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -116,7 +156,7 @@ func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Requ
 			json.NewEncoder(w).Encode(updatedCategory)
 
 		case http.MethodDelete:
-			err := h.categoryService.DeleteCategory(libraryID, id)
+			err := h.categoryService.DeleteCategory(r.Context(), libraryID, id)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -128,3 +168,67 @@ func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Requ
 			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
 	}
 }
+
+// POST /categories/{id}/move - Mover una categoría bajo otro padre (parent_id nulo para la raíz)
+func (h *CategoryHandler) handleCategoryMove(w http.ResponseWriter, r *http.Request, id int64) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data struct {
+		ParentID *int64 `json:"parent_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Datos inválidos", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.categoryService.MoveCategory(r.Context(), libraryID, id, data.ParentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+// POST /categories/{id}/merge - Fusionar la categoría en otra (target_id)
+func (h *CategoryHandler) handleCategoryMerge(w http.ResponseWriter, r *http.Request, id int64) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data struct {
+		TargetID int64 `json:"target_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil || data.TargetID <= 0 {
+		http.Error(w, "El ID de la categoría destino es requerido", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.categoryService.MergeCategories(r.Context(), libraryID, id, data.TargetID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
@@ -51,7 +51,7 @@ func (h *ConfigurationHandler) HandleConfiguration(w http.ResponseWriter, r *htt
 				return
 			}
 
-			updatedConfig, err := h.configService.UpdateConfiguration(libraryID, updates)
+			updatedConfig, err := h.configService.UpdateConfiguration(r.Context(), libraryID, updates)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+type DocumentHandler struct {
+	documentService *services.DocumentService
+}
+
+func NewDocumentHandler(documentService *services.DocumentService) *DocumentHandler {
+	return &DocumentHandler{
+		documentService: documentService,
+	}
+}
+
+// GET /document-templates - Obtener las plantillas de los documentos (propias o predeterminadas)
+func (h *DocumentHandler) HandleDocumentTemplates(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	templates, err := h.documentService.GetTemplates(libraryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GET /document-templates/{type} - Obtener la plantilla de un documento
+// PUT /document-templates/{type} - Personalizar la plantilla de un documento
+// DELETE /document-templates/{type} - Volver a la plantilla predeterminada
+func (h *DocumentHandler) HandleDocumentTemplateByType(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	documentType := strings.TrimPrefix(r.URL.Path, "/document-templates/")
+	if documentType == "" {
+		http.Error(w, "El tipo de documento es requerido", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			tmpl, err := h.documentService.GetTemplate(libraryID, documentType)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(tmpl)
+
+		case http.MethodPut:
+			var tmpl models.DocumentTemplate
+			err := json.NewDecoder(r.Body).Decode(&tmpl)
+			if err != nil {
+				http.Error(w, "Datos de la plantilla inválidos", http.StatusBadRequest)
+				return
+			}
+
+			savedTemplate, err := h.documentService.SaveTemplate(r.Context(), libraryID, documentType, &tmpl)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(savedTemplate)
+
+		case http.MethodDelete:
+			err := h.documentService.ResetTemplate(r.Context(), libraryID, documentType)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+func writePDF(w http.ResponseWriter, data []byte, fileName string) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, fileName))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
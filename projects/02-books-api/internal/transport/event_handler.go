@@ -0,0 +1,122 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+// A comment is sent when nothing else was, so proxies do not close the stream.
+const eventStreamHeartbeat = 15 * time.Second
+
+type EventHandler struct {
+	eventBroker *services.EventBroker
+}
+
+func NewEventHandler(eventBroker *services.EventBroker) *EventHandler {
+	return &EventHandler{
+		eventBroker: eventBroker,
+	}
+}
+
+// GET /events/stream - Recibir los eventos de circulación como Server-Sent Events (Last-Event-ID o last_event_id para continuar)
+func (h *EventHandler) HandleEventStream(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// EventSource sends Last-Event-ID when it reconnects, the query parameter
+	// lets a new page resume from the last event it showed.
+	lastEventIDStr := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if lastEventIDStr == "" {
+		lastEventIDStr = r.URL.Query().Get("last_event_id")
+	}
+
+	lastEventID := h.eventBroker.LastID()
+
+	if lastEventIDStr != "" {
+		lastEventID, err = strconv.ParseInt(lastEventIDStr, 10, 64)
+		if err != nil || lastEventID < 0 {
+			http.Error(w, "El ID del último evento es inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	controller := http.NewResponseController(w)
+
+	// The stream outlives any read or write timeout of the server.
+	controller.SetReadDeadline(time.Time{})
+	controller.SetWriteDeadline(time.Time{})
+
+	listener, unsubscribe := h.eventBroker.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		events, next, err := h.eventBroker.Since(libraryID, lastEventID)
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+			controller.Flush()
+			return
+		}
+
+		for _, event := range events {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.EventType, event.Payload.String)
+		}
+
+		lastEventID = next
+
+		if len(events) > 0 {
+			if err := controller.Flush(); err != nil {
+				return
+			}
+
+			heartbeat.Reset(eventStreamHeartbeat)
+		}
+
+		// Still catching up from the table.
+		if lastEventID < h.eventBroker.LastID() {
+			continue
+		}
+
+		select {
+			case <-r.Context().Done():
+				return
+			case <-h.eventBroker.Stopped():
+				return
+			case <-listener:
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+
+				if err := controller.Flush(); err != nil {
+					return
+				}
+		}
+	}
+}
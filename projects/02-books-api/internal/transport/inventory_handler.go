@@ -0,0 +1,233 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+type InventoryHandler struct {
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryHandler(inventoryService *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// GET /inventory/audits - Obtener las auditorías de inventario (status, zone_id, shelf_id)
+// POST /inventory/audits - Iniciar una auditoría de una zona o un estante
+func (h *InventoryHandler) HandleAudits(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			filter := store.InventoryAuditFilter{
+				Status: r.URL.Query().Get("status"),
+			}
+
+			zoneIDStr := r.URL.Query().Get("zone_id")
+			if zoneIDStr != "" {
+				zoneID, err := strconv.ParseInt(zoneIDStr, 10, 64)
+				if err != nil || zoneID <= 0 {
+					http.Error(w, "El ID de la zona es inválido", http.StatusBadRequest)
+					return
+				}
+
+				filter.ZoneID = &zoneID
+			}
+
+			shelfIDStr := r.URL.Query().Get("shelf_id")
+			if shelfIDStr != "" {
+				shelfID, err := strconv.ParseInt(shelfIDStr, 10, 64)
+				if err != nil || shelfID <= 0 {
+					http.Error(w, "El ID del estante es inválido", http.StatusBadRequest)
+					return
+				}
+
+				filter.ShelfID = &shelfID
+			}
+
+			audits, err := h.inventoryService.GetAudits(libraryID, filter)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(audits)
+
+		case http.MethodPost:
+			var audit models.InventoryAudit
+			err := json.NewDecoder(r.Body).Decode(&audit)
+			if err != nil {
+				http.Error(w, "Datos de auditoría inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdAudit, err := h.inventoryService.StartAudit(r.Context(), libraryID, &audit)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(createdAudit)
+
+		default:
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /inventory/audits/{id} - Obtener una auditoría por ID
+// POST /inventory/audits/{id}/scans - Registrar códigos de barras escaneados
+// GET /inventory/audits/{id}/report - Obtener el reporte de conciliación
+// POST /inventory/audits/{id}/close - Cerrar la auditoría (mark_missing_lost opcional)
+func (h *InventoryHandler) HandleAuditByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/inventory/audits/"), "/")
+	if parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(parts[0])
+	if err != nil || readId <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	if len(parts) > 2 {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		audit, err := h.inventoryService.GetAuditByID(libraryID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(audit)
+		return
+	}
+
+	switch parts[1] {
+		case "scans":
+			h.handleAuditScans(w, r, libraryID, id)
+		case "report":
+			h.handleAuditReport(w, r, libraryID, id)
+		case "close":
+			h.handleAuditClose(w, r, libraryID, id)
+		default:
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+func (h *InventoryHandler) handleAuditScans(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data struct {
+		Barcodes []string `json:"barcodes"`
+		ShelfID  *int64   `json:"shelf_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Datos de escaneo inválidos", http.StatusBadRequest)
+		return
+	}
+
+	librarianID, err := middleware.GetLibrarianID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scans, err := h.inventoryService.AddScans(r.Context(), libraryID, id, data.Barcodes, data.ShelfID, librarianID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scans)
+}
+
+func (h *InventoryHandler) handleAuditReport(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.inventoryService.GetReport(libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *InventoryHandler) handleAuditClose(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data struct {
+		MarkMissingLost bool `json:"mark_missing_lost"`
+	}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			http.Error(w, "Datos de cierre inválidos", http.StatusBadRequest)
+			return
+		}
+	}
+
+	librarianID, err := middleware.GetLibrarianID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.inventoryService.CloseAudit(r.Context(), libraryID, id, data.MarkMissingLost, librarianID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
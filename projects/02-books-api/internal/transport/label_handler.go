@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+type LabelLayoutHandler struct {
+	labelService *services.LabelService
+}
+
+func NewLabelLayoutHandler(labelService *services.LabelService) *LabelLayoutHandler {
+	return &LabelLayoutHandler{
+		labelService: labelService,
+	}
+}
+
+// GET /label-layouts - Obtener los formatos de etiquetas (predefinidos y propios)
+// POST /label-layouts - Crear un formato de etiquetas
+func (h *LabelLayoutHandler) HandleLabelLayouts(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			layouts, err := h.labelService.GetLayouts(libraryID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(layouts)
+
+		case http.MethodPost:
+			var layout models.LabelLayout
+			err := json.NewDecoder(r.Body).Decode(&layout)
+			if err != nil {
+				http.Error(w, "Datos del formato inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdLayout, err := h.labelService.CreateLayout(r.Context(), libraryID, &layout)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdLayout)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /label-layouts/{id} - Obtener un formato de etiquetas por ID
+// PUT /label-layouts/{id} - Actualizar un formato de etiquetas por ID
+// DELETE /label-layouts/{id} - Eliminar un formato de etiquetas por ID
+func (h *LabelLayoutHandler) HandleLabelLayoutByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/label-layouts/")
+	if idStr == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	switch r.Method {
+		case http.MethodGet:
+			layout, err := h.labelService.GetLayoutByID(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(layout)
+
+		case http.MethodPut:
+			var layout models.LabelLayout
+			err := json.NewDecoder(r.Body).Decode(&layout)
+			if err != nil {
+				http.Error(w, "Datos del formato inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedLayout, err := h.labelService.UpdateLayout(r.Context(), libraryID, id, &layout)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedLayout)
+
+		case http.MethodDelete:
+			err := h.labelService.DeleteLayout(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
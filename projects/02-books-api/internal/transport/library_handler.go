@@ -1,7 +1,11 @@
 package transport
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -25,7 +29,8 @@ type ShelfHandler struct {
 }
 
 type CopyHandler struct {
-	copyService *services.CopyService
+	copyService  *services.CopyService
+	labelService *services.LabelService
 }
 
 func NewLibraryHandler(libraryService *services.LibraryService) *LibraryHandler {
@@ -40,8 +45,11 @@ func NewShelfHandler(shelfService *services.ShelfService) *ShelfHandler {
 	return &ShelfHandler{shelfService: shelfService}
 }
 
-func NewCopyHandler(copyService *services.CopyService) *CopyHandler {
-	return &CopyHandler{copyService: copyService}
+func NewCopyHandler(copyService *services.CopyService, labelService *services.LabelService) *CopyHandler {
+	return &CopyHandler{
+		copyService:  copyService,
+		labelService: labelService,
+	}
 }
 
 // GET /libraries - Obtener todas las bibliotecas
@@ -66,7 +74,7 @@ func (h *LibraryHandler) HandleLibraries(w http.ResponseWriter, r *http.Request)
 				return
 			}
 
-			createdLibrary, err := h.libraryService.CreateLibrary(&library)
+			createdLibrary, err := h.libraryService.CreateLibrary(r.Context(), &library)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -86,13 +94,13 @@ func (h *LibraryHandler) HandleLibraries(w http.ResponseWriter, r *http.Request)
 // PUT /libraries/{id} - Actualizar biblioteca por ID
 // DELETE /libraries/{id} - Eliminar biblioteca por ID
 func (h *LibraryHandler) HandleLibraryByID(w http.ResponseWriter, r *http.Request) {
-	idParam := r.URL.Path[len("/libraries/"):]
-	if idParam == "" {
+	parts := strings.Split(r.URL.Path[len("/libraries/"):], "/")
+	if parts[0] == "" {
 		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
 		return
 	}
 
-	readId, err := strconv.Atoi(idParam)
+	readId, err := strconv.Atoi(parts[0])
 	if err != nil || readId <= 0 {
 		http.Error(w, "El ID es inválido", http.StatusBadRequest)
 		return
@@ -100,6 +108,16 @@ func (h *LibraryHandler) HandleLibraryByID(w http.ResponseWriter, r *http.Reques
 
 	id := int64(readId)
 
+	if len(parts) > 1 {
+		if len(parts) > 2 || parts[1] != "logo" {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		h.handleLibraryLogo(w, r, id)
+		return
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			library, err := h.libraryService.GetLibraryByID(id)
@@ -145,7 +163,7 @@ func (h *LibraryHandler) HandleLibraryByID(w http.ResponseWriter, r *http.Reques
 				return
 			}
 
-			updatedLibrary, err := h.libraryService.UpdateLibrary(id, &library)
+			updatedLibrary, err := h.libraryService.UpdateLibrary(r.Context(), id, &library)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -155,7 +173,61 @@ func (h *LibraryHandler) HandleLibraryByID(w http.ResponseWriter, r *http.Reques
 			json.NewEncoder(w).Encode(updatedLibrary)
 
 		case http.MethodDelete:
-			err := h.libraryService.DeleteLibrary(id)
+			err := h.libraryService.DeleteLibrary(r.Context(), id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /libraries/{id}/logo - Obtener el logotipo de la biblioteca
+// PUT /libraries/{id}/logo - Subir o reemplazar el logotipo que aparece en los documentos (JPEG o PNG)
+// DELETE /libraries/{id}/logo - Eliminar el logotipo de la biblioteca
+func (h *LibraryHandler) handleLibraryLogo(w http.ResponseWriter, r *http.Request, id int64) {
+	switch r.Method {
+		case http.MethodGet:
+			data, err := h.libraryService.GetLogo(id)
+			if errors.Is(err, services.ErrLogoNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data)
+
+		case http.MethodPut:
+			data, err := readCoverUpload(w, r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := h.libraryService.UploadLogo(r.Context(), id, data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		case http.MethodDelete:
+			err := h.libraryService.DeleteLogo(r.Context(), id)
+			if errors.Is(err, services.ErrLogoNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -225,7 +297,7 @@ func (h *LibraryZoneHandler) HandleZones(w http.ResponseWriter, r *http.Request)
 				return
 			}
 
-			createdZone, err := h.zoneService.CreateZone(libraryID, &zone)
+			createdZone, err := h.zoneService.CreateZone(r.Context(), libraryID, &zone)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -283,7 +355,7 @@ func (h *LibraryZoneHandler) HandleZoneByID(w http.ResponseWriter, r *http.Reque
 				return
 			}
 
-			updatedZone, err := h.zoneService.UpdateZone(libraryID, id, &zone)
+			updatedZone, err := h.zoneService.UpdateZone(r.Context(), libraryID, id, &zone)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -293,7 +365,24 @@ func (h *LibraryZoneHandler) HandleZoneByID(w http.ResponseWriter, r *http.Reque
 			json.NewEncoder(w).Encode(updatedZone)
 
 		case http.MethodDelete:
-			err := h.zoneService.DeleteZone(libraryID, id)
+			rehomeTo, err := parseRehomeTo(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			librarianID, err := middleware.GetLibrarianID(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			err = h.zoneService.DeleteZone(r.Context(), libraryID, id, rehomeTo, librarianID)
+			if errors.Is(err, services.ErrLocationInUse) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -363,7 +452,7 @@ func (h *ShelfHandler) HandleShelves(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			createdShelf, err := h.shelfService.CreateShelf(libraryID, &shelf)
+			createdShelf, err := h.shelfService.CreateShelf(r.Context(), libraryID, &shelf)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -381,6 +470,7 @@ func (h *ShelfHandler) HandleShelves(w http.ResponseWriter, r *http.Request) {
 // GET /shelves/{id} - Obtener estante por ID
 // PUT /shelves/{id} - Actualizar estante por ID
 // DELETE /shelves/{id} - Eliminar estante por ID
+// GET /shelves/{id}/books - Obtener los libros del estante ordenados por signatura
 func (h *ShelfHandler) HandleShelfByID(w http.ResponseWriter, r *http.Request) {
 	libraryID, err := middleware.GetLibraryID(r)
 	if err != nil {
@@ -388,13 +478,13 @@ func (h *ShelfHandler) HandleShelfByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	idParam := strings.TrimPrefix(r.URL.Path, "/shelves/")
-	if idParam == "" {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/shelves/"), "/")
+	if parts[0] == "" {
 		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
 		return
 	}
 
-	readId, err := strconv.Atoi(idParam)
+	readId, err := strconv.Atoi(parts[0])
 	if err != nil || readId <= 0 {
 		http.Error(w, "El ID es inválido", http.StatusBadRequest)
 		return
@@ -402,6 +492,28 @@ func (h *ShelfHandler) HandleShelfByID(w http.ResponseWriter, r *http.Request) {
 
 	id := int64(readId)
 
+	if len(parts) > 1 {
+		if parts[1] != "books" || len(parts) > 2 {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		books, err := h.shelfService.GetShelfBooks(libraryID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(books)
+		return
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			shelf, err := h.shelfService.GetShelfByID(libraryID, id)
@@ -421,7 +533,7 @@ func (h *ShelfHandler) HandleShelfByID(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			updatedShelf, err := h.shelfService.UpdateShelf(libraryID, id, &shelf)
+			updatedShelf, err := h.shelfService.UpdateShelf(r.Context(), libraryID, id, &shelf)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -431,7 +543,24 @@ func (h *ShelfHandler) HandleShelfByID(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(updatedShelf)
 
 		case http.MethodDelete:
-			err := h.shelfService.DeleteShelf(libraryID, id)
+			rehomeTo, err := parseRehomeTo(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			librarianID, err := middleware.GetLibrarianID(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			err = h.shelfService.DeleteShelf(r.Context(), libraryID, id, rehomeTo, librarianID)
+			if errors.Is(err, services.ErrLocationInUse) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -476,6 +605,30 @@ func (h *CopyHandler) HandleCopies(w http.ResponseWriter, r *http.Request) {
 				hasFilters = true
 			}
 
+			shelfIDStr := r.URL.Query().Get("shelf_id")
+			if shelfIDStr != "" {
+				shelfID, err := strconv.ParseInt(shelfIDStr, 10, 64)
+				if err != nil || shelfID <= 0 {
+					http.Error(w, "El parámetro shelf_id es inválido", http.StatusBadRequest)
+					return
+				}
+
+				filter.ShelfID = &shelfID
+				hasFilters = true
+			}
+
+			zoneIDStr := r.URL.Query().Get("zone_id")
+			if zoneIDStr != "" {
+				zoneID, err := strconv.ParseInt(zoneIDStr, 10, 64)
+				if err != nil || zoneID <= 0 {
+					http.Error(w, "El parámetro zone_id es inválido", http.StatusBadRequest)
+					return
+				}
+
+				filter.ZoneID = &zoneID
+				hasFilters = true
+			}
+
 			status := r.URL.Query().Get("status")
 			if status != "" {
 				filter.Status = status
@@ -513,7 +666,13 @@ func (h *CopyHandler) HandleCopies(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			createdCopy, err := h.copyService.CreateCopy(libraryID, &copy)
+			librarianID, err := middleware.GetLibrarianID(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			createdCopy, err := h.copyService.CreateCopy(r.Context(), libraryID, &copy, librarianID)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -531,6 +690,10 @@ func (h *CopyHandler) HandleCopies(w http.ResponseWriter, r *http.Request) {
 // GET /copies/{id} - Obtener copia por ID
 // PUT /copies/{id} - Actualizar copia por ID
 // DELETE /copies/{id} - Eliminar copia por ID
+// GET /copies/{id}/history - Obtener el historial de la copia
+// GET /copies/{id}/label - Obtener la etiqueta de la copia (?format=svg|png&layout=)
+// POST /copies/{id}/move - Mover la copia a otro estante o a una ubicación temporal
+// POST /copies/{id}/withdraw - Dar de baja la copia (descarte)
 func (h *CopyHandler) HandleCopyByID(w http.ResponseWriter, r *http.Request) {
 	libraryID, err := middleware.GetLibraryID(r)
 	if err != nil {
@@ -538,13 +701,13 @@ func (h *CopyHandler) HandleCopyByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	idParam := strings.TrimPrefix(r.URL.Path, "/copies/")
-	if idParam == "" {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/copies/"), "/")
+	if parts[0] == "" {
 		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
 		return
 	}
 
-	readId, err := strconv.Atoi(idParam)
+	readId, err := strconv.Atoi(parts[0])
 	if err != nil || readId <= 0 {
 		http.Error(w, "El ID es inválido", http.StatusBadRequest)
 		return
@@ -552,6 +715,31 @@ func (h *CopyHandler) HandleCopyByID(w http.ResponseWriter, r *http.Request) {
 
 	id := int64(readId)
 
+	if len(parts) > 1 {
+		if len(parts) > 2 {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		switch parts[1] {
+			case "history":
+				h.handleCopyHistory(w, r, libraryID, id)
+				return
+			case "label":
+				h.handleCopyLabel(w, r, libraryID, id)
+				return
+			case "move":
+				h.handleCopyMove(w, r, libraryID, id)
+				return
+			case "withdraw":
+				h.handleCopyWithdraw(w, r, libraryID, id)
+				return
+			default:
+				http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+				return
+		}
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			copy, err := h.copyService.GetCopyByID(libraryID, id)
@@ -571,7 +759,13 @@ func (h *CopyHandler) HandleCopyByID(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			updatedCopy, err := h.copyService.UpdateCopy(libraryID, id, &copy)
+			librarianID, err := middleware.GetLibrarianID(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			updatedCopy, err := h.copyService.UpdateCopy(r.Context(), libraryID, id, &copy, librarianID)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -581,7 +775,13 @@ func (h *CopyHandler) HandleCopyByID(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(updatedCopy)
 
 		case http.MethodDelete:
-			err := h.copyService.DeleteCopy(libraryID, id)
+			librarianID, err := middleware.GetLibrarianID(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			err = h.copyService.DeleteCopy(r.Context(), libraryID, id, librarianID)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -593,3 +793,237 @@ func (h *CopyHandler) HandleCopyByID(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
 	}
 }
+
+func (h *CopyHandler) handleCopyHistory(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	history, err := h.copyService.GetCopyHistory(libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+func (h *CopyHandler) handleCopyLabel(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	label, contentType, err := h.labelService.RenderCopyLabel(libraryID, id, query.Get("layout"), query.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(label)
+}
+
+func (h *CopyHandler) handleCopyMove(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var move models.CopyMove
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		http.Error(w, "Datos del movimiento inválidos", http.StatusBadRequest)
+		return
+	}
+
+	librarianID, err := middleware.GetLibrarianID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movedCopy, err := h.copyService.MoveCopy(r.Context(), libraryID, id, &move, librarianID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movedCopy)
+}
+
+// POST /copies/move - Mover varias copias a otro estante o a una ubicación temporal
+func (h *CopyHandler) HandleCopiesMove(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var move models.CopyMove
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		http.Error(w, "Datos del movimiento inválidos", http.StatusBadRequest)
+		return
+	}
+
+	librarianID, err := middleware.GetLibrarianID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movedCopies, err := h.copyService.MoveCopies(r.Context(), libraryID, &move, librarianID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movedCopies)
+}
+
+func (h *CopyHandler) handleCopyWithdraw(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var withdrawal models.CopyWithdrawal
+	if err := json.NewDecoder(r.Body).Decode(&withdrawal); err != nil {
+		http.Error(w, "Datos de la baja inválidos", http.StatusBadRequest)
+		return
+	}
+
+	librarianID, err := middleware.GetLibrarianID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	withdrawnCopy, err := h.copyService.WithdrawCopy(r.Context(), libraryID, id, &withdrawal, librarianID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(withdrawnCopy)
+}
+
+// POST /copies/withdraw - Dar de baja varias copias (descarte)
+func (h *CopyHandler) HandleCopiesWithdraw(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var withdrawal models.CopyWithdrawal
+	if err := json.NewDecoder(r.Body).Decode(&withdrawal); err != nil {
+		http.Error(w, "Datos de la baja inválidos", http.StatusBadRequest)
+		return
+	}
+
+	librarianID, err := middleware.GetLibrarianID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	withdrawnCopies, err := h.copyService.WithdrawCopies(r.Context(), libraryID, &withdrawal, librarianID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(withdrawnCopies)
+}
+
+// POST /copies/labels - Generar las hojas de etiquetas de varias copias
+func (h *CopyHandler) HandleCopyLabels(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var batch models.LabelBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		http.Error(w, "Datos de las etiquetas inválidos", http.StatusBadRequest)
+		return
+	}
+
+	pages, contentType, err := h.labelService.RenderLabels(libraryID, &batch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(pages) == 1 {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(pages[0])
+		return
+	}
+
+	// Several sheets go out as a zip with one file per page
+	extension := "svg"
+	if contentType == "image/png" {
+		extension = "png"
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for i, page := range pages {
+		file, err := archive.Create(fmt.Sprintf("label-page-%d.%s", i+1, extension))
+		if err != nil {
+			http.Error(w, "Error al empaquetar las etiquetas", http.StatusInternalServerError)
+			return
+		}
+
+		file.Write(page)
+	}
+
+	if err := archive.Close(); err != nil {
+		http.Error(w, "Error al empaquetar las etiquetas", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="labels.zip"`)
+	w.Write(buf.Bytes())
+}
+
+func parseRehomeTo(r *http.Request) (*int64, error) {
+	rehomeToStr := r.URL.Query().Get("rehome_to")
+	if rehomeToStr == "" {
+		return nil, nil
+	}
+
+	rehomeTo, err := strconv.ParseInt(rehomeToStr, 10, 64)
+	if err != nil || rehomeTo <= 0 {
+		return nil, errors.New("El parámetro rehome_to es inválido")
+	}
+
+	return &rehomeTo, nil
+}
@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/metadata"
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+type MetadataHandler struct {
+	metadataService *services.MetadataService
+}
+
+func NewMetadataHandler(metadataService *services.MetadataService) *MetadataHandler {
+	return &MetadataHandler{
+		metadataService: metadataService,
+	}
+}
+
+// POST /books/enrich?isbn={isbn} - Completar un libro con metadatos bibliográficos
+func (h *MetadataHandler) HandleBookEnrich(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	isbn := strings.TrimSpace(r.URL.Query().Get("isbn"))
+	if isbn == "" {
+		http.Error(w, "El parámetro isbn es requerido", http.StatusBadRequest)
+		return
+	}
+
+	normalizedISBN := metadata.NormalizeISBN(isbn)
+	if len(normalizedISBN) != 10 && len(normalizedISBN) != 13 {
+		http.Error(w, "El ISBN debe tener 10 o 13 dígitos", http.StatusBadRequest)
+		return
+	}
+
+	refresh := r.URL.Query().Get("refresh") == "true"
+
+	enrichment, err := h.metadataService.EnrichBook(r.Context(), libraryID, isbn, refresh)
+	if err != nil {
+		if errors.Is(err, metadata.ErrMetadataNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if enrichment.Created {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	json.NewEncoder(w).Encode(enrichment)
+}
@@ -0,0 +1,216 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GET /notifications - Obtener las notificaciones del outbox (user_id, status, event_type)
+func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter := store.NotificationFilter{
+		Status:    r.URL.Query().Get("status"),
+		EventType: r.URL.Query().Get("event_type"),
+	}
+
+	userIDStr := r.URL.Query().Get("user_id")
+	if userIDStr != "" {
+		userID, err := strconv.ParseInt(userIDStr, 10, 64)
+		if err != nil || userID <= 0 {
+			http.Error(w, "El ID del usuario es inválido", http.StatusBadRequest)
+			return
+		}
+
+		filter.UserID = &userID
+	}
+
+	notifications, err := h.notificationService.GetNotifications(libraryID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// GET /notifications/{id} - Obtener una notificación por ID
+// POST /notifications/{id}/retry - Volver a intentar una notificación fallida (mandatory la envía sin importar las preferencias)
+func (h *NotificationHandler) HandleNotificationByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/notifications/"), "/")
+	if parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(parts[0])
+	if err != nil || readId <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	if len(parts) > 1 {
+		if len(parts) > 2 || parts[1] != "retry" {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var data struct {
+			Mandatory bool `json:"mandatory"`
+		}
+
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+				http.Error(w, "Datos del reintento inválidos", http.StatusBadRequest)
+				return
+			}
+		}
+
+		notification, err := h.notificationService.RetryNotification(r.Context(), libraryID, id, data.Mandatory)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(notification)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	notification, err := h.notificationService.GetNotification(libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notification)
+}
+
+// GET /notification-templates - Obtener las plantillas de las notificaciones (propias o predeterminadas, language)
+func (h *NotificationHandler) HandleNotificationTemplates(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	templates, err := h.notificationService.GetTemplates(libraryID, r.URL.Query().Get("language"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GET /notification-templates/{type} - Obtener la plantilla de una notificación (language, es por defecto)
+// PUT /notification-templates/{type} - Personalizar el asunto y el mensaje de una notificación
+// DELETE /notification-templates/{type} - Volver a la plantilla predeterminada
+func (h *NotificationHandler) HandleNotificationTemplateByType(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	eventType := strings.TrimPrefix(r.URL.Path, "/notification-templates/")
+	if eventType == "" {
+		http.Error(w, "El tipo de notificación es requerido", http.StatusBadRequest)
+		return
+	}
+
+	language := r.URL.Query().Get("language")
+
+	switch r.Method {
+		case http.MethodGet:
+			tmpl, err := h.notificationService.GetTemplate(libraryID, eventType, language)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(tmpl)
+
+		case http.MethodPut:
+			var tmpl models.NotificationTemplate
+			err := json.NewDecoder(r.Body).Decode(&tmpl)
+			if err != nil {
+				http.Error(w, "Datos de la plantilla inválidos", http.StatusBadRequest)
+				return
+			}
+
+			savedTemplate, err := h.notificationService.SaveTemplate(r.Context(), libraryID, eventType, language, &tmpl)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(savedTemplate)
+
+		case http.MethodDelete:
+			err := h.notificationService.ResetTemplate(r.Context(), libraryID, eventType, language)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
@@ -48,7 +48,7 @@ func (h *PublisherHandler) HandlePublishers(w http.ResponseWriter, r *http.Reque
 				return
 			}
 
-			createdPublisher, err := h.publisherService.CreatePublisher(libraryID, &publisher)
+			createdPublisher, err := h.publisherService.CreatePublisher(r.Context(), libraryID, &publisher)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -106,7 +106,7 @@ func (h *PublisherHandler) HandlePublisherByID(w http.ResponseWriter, r *http.Re
 				return
 			}
 
-			updatedPublisher, err := h.publisherService.UpdatePublisher(libraryID, id, &publisher)
+			updatedPublisher, err := h.publisherService.UpdatePublisher(r.Context(), libraryID, id, &publisher)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -116,7 +116,7 @@ func (h *PublisherHandler) HandlePublisherByID(w http.ResponseWriter, r *http.Re
 			json.NewEncoder(w).Encode(updatedPublisher)
 
 		case http.MethodDelete:
-			err := h.publisherService.DeletePublisher(libraryID, id)
+			err := h.publisherService.DeletePublisher(r.Context(), libraryID, id)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
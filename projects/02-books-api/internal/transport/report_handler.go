@@ -0,0 +1,248 @@
+package transport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/database"
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GET /reports/weeding - Obtener el reporte de descarte (zone_id, months, max_age, holds_ratio)
+// GET /reports/weeding/{section} - Obtener una sección del reporte (?format=json|csv)
+// Secciones: not-loaned, poor-condition, understocked, overstocked, categories
+func (h *ReportHandler) HandleWeedingReport(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	section := strings.Trim(strings.TrimPrefix(r.URL.Path, "/reports/weeding"), "/")
+	if strings.Contains(section, "/") {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "json" && format != "csv" {
+		http.Error(w, "El formato debe ser: json o csv", http.StatusBadRequest)
+		return
+	}
+
+	if section == "" && format == "csv" {
+		http.Error(w, "La exportación a CSV requiere una sección del reporte", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var zoneID *int64
+
+	zoneIDStr := query.Get("zone_id")
+	if zoneIDStr != "" {
+		id, err := strconv.ParseInt(zoneIDStr, 10, 64)
+		if err != nil || id <= 0 {
+			http.Error(w, "El ID de la zona es inválido", http.StatusBadRequest)
+			return
+		}
+
+		zoneID = &id
+	}
+
+	months, err := parseIntParam(query.Get("months"))
+	if err != nil {
+		http.Error(w, "El parámetro months es inválido", http.StatusBadRequest)
+		return
+	}
+
+	maxAge, err := parseIntParam(query.Get("max_age"))
+	if err != nil {
+		http.Error(w, "El parámetro max_age es inválido", http.StatusBadRequest)
+		return
+	}
+
+	var holdsRatio float64
+
+	holdsRatioStr := query.Get("holds_ratio")
+	if holdsRatioStr != "" {
+		holdsRatio, err = strconv.ParseFloat(holdsRatioStr, 64)
+		if err != nil {
+			http.Error(w, "El parámetro holds_ratio es inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.reportService.GetWeedingReport(libraryID, zoneID, months, maxAge, holdsRatio)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if section == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	var data any
+	var rows [][]string
+
+	switch section {
+		case "not-loaned":
+			data, rows = report.NotLoaned, weedingCopyRows(report.NotLoaned)
+		case "poor-condition":
+			data, rows = report.PoorCondition, weedingCopyRows(report.PoorCondition)
+		case "understocked":
+			data, rows = report.Understocked, titleDemandRows(report.Understocked)
+		case "overstocked":
+			data, rows = report.Overstocked, titleDemandRows(report.Overstocked)
+		case "categories":
+			data, rows = report.AgingCategories, categoryAgeRows(report.AgingCategories)
+		default:
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="weeding-%s.csv"`, section))
+
+	writer := csv.NewWriter(w)
+	writer.WriteAll(rows)
+}
+
+func parseIntParam(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+func weedingCopyRows(copies []*models.WeedingCopy) [][]string {
+	rows := [][]string{{
+		"copy_id", "code", "book_id", "title", "call_number", "zone_id", "shelf_id",
+		"status", "condition", "acquisition_date", "last_loan_date", "loan_count",
+	}}
+
+	for _, copy := range copies {
+		rows = append(rows, []string{
+			strconv.FormatInt(copy.CopyID, 10),
+			copy.Code,
+			strconv.FormatInt(copy.BookID, 10),
+			copy.Title,
+			csvString(copy.CallNumber),
+			csvInt(copy.ZoneID),
+			csvInt(copy.ShelfID),
+			copy.Status,
+			copy.Condition,
+			csvDate(copy.AcquisitionDate),
+			csvDate(copy.LastLoanDate),
+			strconv.Itoa(copy.LoanCount),
+		})
+	}
+
+	return rows
+}
+
+func titleDemandRows(titles []*models.TitleDemand) [][]string {
+	rows := [][]string{{
+		"book_id", "title", "call_number", "copies", "pending_reservations", "recent_loans", "suggested_change",
+	}}
+
+	for _, title := range titles {
+		rows = append(rows, []string{
+			strconv.FormatInt(title.BookID, 10),
+			title.Title,
+			csvString(title.CallNumber),
+			strconv.Itoa(title.Copies),
+			strconv.Itoa(title.PendingReservations),
+			strconv.Itoa(title.RecentLoans),
+			strconv.Itoa(title.SuggestedChange),
+		})
+	}
+
+	return rows
+}
+
+func categoryAgeRows(categories []*models.CategoryAge) [][]string {
+	rows := [][]string{{
+		"category_id", "name", "path", "books", "old_books", "old_share", "oldest_year", "average_year",
+	}}
+
+	for _, category := range categories {
+		averageYear := ""
+		if category.AverageYear.Valid {
+			averageYear = strconv.FormatFloat(category.AverageYear.Float64, 'f', 1, 64)
+		}
+
+		rows = append(rows, []string{
+			strconv.FormatInt(category.CategoryID, 10),
+			category.Name,
+			category.Path,
+			strconv.Itoa(category.Books),
+			strconv.Itoa(category.OldBooks),
+			strconv.FormatFloat(category.OldShare, 'f', -1, 64),
+			csvInt(category.OldestYear),
+			averageYear,
+		})
+	}
+
+	return rows
+}
+
+func csvString(value database.NullString) string {
+	if !value.Valid {
+		return ""
+	}
+
+	return value.String
+}
+
+func csvInt(value database.NullInt64) string {
+	if !value.Valid {
+		return ""
+	}
+
+	return strconv.FormatInt(value.Int64, 10)
+}
+
+func csvDate(value database.NullTime) string {
+	if !value.Valid {
+		return ""
+	}
+
+	return value.Time.Format(time.DateOnly)
+}
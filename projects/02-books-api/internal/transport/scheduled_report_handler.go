@@ -0,0 +1,262 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+type ScheduledReportHandler struct {
+	reportService *services.ScheduledReportService
+}
+
+func NewScheduledReportHandler(reportService *services.ScheduledReportService) *ScheduledReportHandler {
+	return &ScheduledReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GET /reports/types - Obtener los tipos de reporte disponibles con sus parámetros
+func (h *ScheduledReportHandler) HandleReportTypes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.reportService.GetReportTypes())
+}
+
+// GET /reports/definitions - Obtener los reportes definidos
+// POST /reports/definitions - Definir un reporte (tipo, parámetros, formato y programación cron)
+func (h *ScheduledReportHandler) HandleDefinitions(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			definitions, err := h.reportService.GetDefinitions(libraryID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(definitions)
+
+		case http.MethodPost:
+			var definition models.ReportDefinition
+			err := json.NewDecoder(r.Body).Decode(&definition)
+			if err != nil {
+				http.Error(w, "Datos del reporte inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdDefinition, err := h.reportService.CreateDefinition(r.Context(), libraryID, &definition)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdDefinition)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /reports/definitions/{id} - Obtener un reporte definido por ID
+// PUT /reports/definitions/{id} - Actualizar un reporte definido
+// DELETE /reports/definitions/{id} - Eliminar un reporte con sus ejecuciones y archivos
+// POST /reports/definitions/{id}/run - Generar el reporte ahora
+func (h *ScheduledReportHandler) HandleDefinitionByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/reports/definitions/"), "/")
+	if parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(parts[0])
+	if err != nil || readId <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	if len(parts) > 1 {
+		if len(parts) > 2 || parts[1] != "run" {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		run, err := h.reportService.RunDefinition(r.Context(), libraryID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(run)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			definition, err := h.reportService.GetDefinitionByID(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(definition)
+
+		case http.MethodPut:
+			var definition models.ReportDefinition
+			err := json.NewDecoder(r.Body).Decode(&definition)
+			if err != nil {
+				http.Error(w, "Datos del reporte inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedDefinition, err := h.reportService.UpdateDefinition(r.Context(), libraryID, id, &definition)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedDefinition)
+
+		case http.MethodDelete:
+			err := h.reportService.DeleteDefinition(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /reports/runs - Obtener los reportes generados con su enlace de descarga (definition_id, status)
+func (h *ScheduledReportHandler) HandleRuns(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter := store.ReportRunFilter{
+		Status: r.URL.Query().Get("status"),
+	}
+
+	definitionIDStr := r.URL.Query().Get("definition_id")
+	if definitionIDStr != "" {
+		definitionID, err := strconv.ParseInt(definitionIDStr, 10, 64)
+		if err != nil || definitionID <= 0 {
+			http.Error(w, "El ID del reporte es inválido", http.StatusBadRequest)
+			return
+		}
+
+		filter.DefinitionID = &definitionID
+	}
+
+	runs, err := h.reportService.GetRuns(libraryID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// GET /reports/runs/{id} - Obtener una ejecución por ID
+// GET /reports/runs/{id}/download - Descargar el archivo generado
+func (h *ScheduledReportHandler) HandleRunByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/reports/runs/"), "/")
+	if parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(parts[0])
+	if err != nil || readId <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	if len(parts) == 1 {
+		run, err := h.reportService.GetRunByID(libraryID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(run)
+		return
+	}
+
+	if len(parts) > 2 || parts[1] != "download" {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	run, data, contentType, err := h.reportService.GetRunFile(libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, run.FileName.String))
+	w.Write(data)
+}
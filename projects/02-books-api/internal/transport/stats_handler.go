@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+type StatsHandler struct {
+	statsService *services.StatsService
+}
+
+func NewStatsHandler(statsService *services.StatsService) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GET /stats/circulation - Obtener las estadísticas de circulación (from, to, interval, breakdown, top)
+func (h *StatsHandler) HandleCirculation(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+
+	query := services.CirculationQuery{
+		From:     params.Get("from"),
+		To:       params.Get("to"),
+		Interval: params.Get("interval"),
+	}
+
+	for _, breakdown := range params["breakdown"] {
+		query.Breakdowns = append(query.Breakdowns, strings.Split(breakdown, ",")...)
+	}
+
+	query.Top, err = parseIntParam(params.Get("top"))
+	if err != nil {
+		http.Error(w, "El parámetro top es inválido", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.statsService.GetCirculationStats(libraryID, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -13,11 +13,17 @@ import (
 )
 
 type UserHandler struct {
-	userService *services.UserService
+	userService         *services.UserService
+	documentService     *services.DocumentService
+	notificationService *services.NotificationService
 }
 
-func NewUserHandler(userService *services.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *services.UserService, documentService *services.DocumentService, notificationService *services.NotificationService) *UserHandler {
+	return &UserHandler{
+		userService:         userService,
+		documentService:     documentService,
+		notificationService: notificationService,
+	}
 }
 
 // GET /users - Obtener todos los usuarios o con filtros
@@ -83,7 +89,7 @@ func (h *UserHandler) HandleUsers(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			createdUser, err := h.userService.CreateUser(libraryID, &user)
+			createdUser, err := h.userService.CreateUser(r.Context(), libraryID, &user)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -101,6 +107,11 @@ func (h *UserHandler) HandleUsers(w http.ResponseWriter, r *http.Request) {
 // GET /users/{id} - Obtener usuario por ID
 // PUT /users/{id} - Actualizar usuario por ID
 // DELETE /users/{id} - Eliminar usuario por ID
+// GET /users/{id}/notice - Obtener el aviso de todos los préstamos vencidos del usuario en PDF
+// GET /users/{id}/statement - Obtener el estado de cuenta del usuario en PDF
+// GET /users/{id}/preferences - Obtener las preferencias de notificación del usuario
+// PUT /users/{id}/preferences - Cambiar el canal, los avisos, el idioma y el horario de silencio
+// DELETE /users/{id}/preferences - Volver a las preferencias predeterminadas
 func (h *UserHandler) HandleUserByID(w http.ResponseWriter, r *http.Request) {
 	libraryID, err := middleware.GetLibraryID(r)
 	if err != nil {
@@ -108,13 +119,13 @@ func (h *UserHandler) HandleUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	idParam := strings.TrimPrefix(r.URL.Path, "/users/")
-	if idParam == "" {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+	if parts[0] == "" {
 		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
 		return
 	}
 
-	readId, err := strconv.Atoi(idParam)
+	readId, err := strconv.Atoi(parts[0])
 	if err != nil || readId <= 0 {
 		http.Error(w, "El ID es inválido", http.StatusBadRequest)
 		return
@@ -122,6 +133,44 @@ func (h *UserHandler) HandleUserByID(w http.ResponseWriter, r *http.Request) {
 
 	id := int64(readId)
 
+	if len(parts) > 1 {
+		if len(parts) > 2 {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if parts[1] == "preferences" {
+			h.handleUserPreferences(w, r, libraryID, id)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var document []byte
+		var fileName string
+
+		switch parts[1] {
+			case "notice":
+				document, fileName, err = h.documentService.RenderPatronOverdueNotice(libraryID, id)
+			case "statement":
+				document, fileName, err = h.documentService.RenderPatronStatement(libraryID, id)
+			default:
+				http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+				return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writePDF(w, document, fileName)
+		return
+	}
+
 	switch r.Method {
 		case http.MethodGet:
 			user, err := h.userService.GetUserByID(libraryID, id)
@@ -141,7 +190,7 @@ func (h *UserHandler) HandleUserByID(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			updatedUser, err := h.userService.UpdateUser(libraryID, id, &user)
+			updatedUser, err := h.userService.UpdateUser(r.Context(), libraryID, id, &user)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -151,7 +200,7 @@ func (h *UserHandler) HandleUserByID(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(updatedUser)
 
 		case http.MethodDelete:
-			err := h.userService.DeleteUser(libraryID, id)
+			err := h.userService.DeleteUser(r.Context(), libraryID, id)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -163,3 +212,46 @@ func (h *UserHandler) HandleUserByID(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
 	}
 }
+
+func (h *UserHandler) handleUserPreferences(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	switch r.Method {
+		case http.MethodGet:
+			preferences, err := h.notificationService.GetPreferences(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(preferences)
+
+		case http.MethodPut:
+			var preferences models.NotificationPreferences
+			err := json.NewDecoder(r.Body).Decode(&preferences)
+			if err != nil {
+				http.Error(w, "Datos de las preferencias inválidos", http.StatusBadRequest)
+				return
+			}
+
+			savedPreferences, err := h.notificationService.SavePreferences(r.Context(), libraryID, id, &preferences)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(savedPreferences)
+
+		case http.MethodDelete:
+			err := h.notificationService.ResetPreferences(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
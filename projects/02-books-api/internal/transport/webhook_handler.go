@@ -0,0 +1,236 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+	"github.com/chicho69-cesar/backend-go/books/internal/store"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// GET /webhooks - Obtener los webhooks de la biblioteca
+// POST /webhooks - Suscribir una URL a los eventos (url, event_types, secret opcional)
+func (h *WebhookHandler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			subscriptions, err := h.webhookService.GetSubscriptions(libraryID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(subscriptions)
+
+		case http.MethodPost:
+			var subscription models.WebhookSubscription
+			err := json.NewDecoder(r.Body).Decode(&subscription)
+			if err != nil {
+				http.Error(w, "Datos del webhook inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdSubscription, err := h.webhookService.CreateSubscription(r.Context(), libraryID, &subscription)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdSubscription)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /webhooks/{id} - Obtener un webhook por ID
+// PUT /webhooks/{id} - Actualizar un webhook (sin secret se conserva el actual)
+// DELETE /webhooks/{id} - Eliminar un webhook con sus entregas
+// GET /webhooks/{id}/deliveries - Obtener las entregas del webhook (status, event_type)
+// GET /webhooks/{id}/deliveries/{deliveryId} - Obtener una entrega con su contenido y sus intentos
+// POST /webhooks/{id}/deliveries/{deliveryId}/replay - Volver a enviar una entrega
+// POST /webhooks/{id}/replay - Volver a enviar todas las entregas fallidas
+func (h *WebhookHandler) HandleWebhookByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/webhooks/"), "/")
+	if parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	readId, err := strconv.Atoi(parts[0])
+	if err != nil || readId <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	id := int64(readId)
+
+	if len(parts) == 1 {
+		h.handleWebhook(w, r, libraryID, id)
+		return
+	}
+
+	switch {
+		case parts[1] == "deliveries":
+			h.handleWebhookDeliveries(w, r, libraryID, id, parts[2:])
+		case parts[1] == "replay" && len(parts) == 2:
+			h.handleWebhookReplay(w, r, libraryID, id)
+		default:
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+func (h *WebhookHandler) handleWebhook(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	switch r.Method {
+		case http.MethodGet:
+			subscription, err := h.webhookService.GetSubscription(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(subscription)
+
+		case http.MethodPut:
+			var subscription models.WebhookSubscription
+			err := json.NewDecoder(r.Body).Decode(&subscription)
+			if err != nil {
+				http.Error(w, "Datos del webhook inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedSubscription, err := h.webhookService.UpdateSubscription(r.Context(), libraryID, id, &subscription)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedSubscription)
+
+		case http.MethodDelete:
+			err := h.webhookService.DeleteSubscription(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *WebhookHandler) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request, libraryID, id int64, parts []string) {
+	if len(parts) == 0 || parts[0] == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter := store.WebhookDeliveryFilter{
+			Status:    r.URL.Query().Get("status"),
+			EventType: r.URL.Query().Get("event_type"),
+		}
+
+		deliveries, err := h.webhookService.GetDeliveries(libraryID, id, filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deliveries)
+		return
+	}
+
+	readId, err := strconv.Atoi(parts[0])
+	if err != nil || readId <= 0 {
+		http.Error(w, "El ID de la entrega es inválido", http.StatusBadRequest)
+		return
+	}
+
+	deliveryID := int64(readId)
+
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "replay") {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		delivery, err := h.webhookService.ReplayDelivery(r.Context(), libraryID, id, deliveryID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(delivery)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(libraryID, id, deliveryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+func (h *WebhookHandler) handleWebhookReplay(w http.ResponseWriter, r *http.Request, libraryID, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Unavailable Method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	replayed, err := h.webhookService.ReplayFailed(r.Context(), libraryID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"replayed": replayed})
+}
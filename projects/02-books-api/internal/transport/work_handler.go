@@ -0,0 +1,296 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+	"github.com/chicho69-cesar/backend-go/books/internal/services"
+)
+
+type WorkHandler struct {
+	workService *services.WorkService
+}
+
+type SeriesHandler struct {
+	seriesService *services.SeriesService
+}
+
+func NewWorkHandler(workService *services.WorkService) *WorkHandler {
+	return &WorkHandler{
+		workService: workService,
+	}
+}
+
+func NewSeriesHandler(seriesService *services.SeriesService) *SeriesHandler {
+	return &SeriesHandler{
+		seriesService: seriesService,
+	}
+}
+
+// GET /works - Obtener todas las obras
+// POST /works - Crear una nueva obra
+func (h *WorkHandler) HandleWorks(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			works, err := h.workService.GetAllWorks(libraryID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(works)
+
+		case http.MethodPost:
+			var work models.Work
+			err := json.NewDecoder(r.Body).Decode(&work)
+			if err != nil {
+				http.Error(w, "Datos de obra inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdWork, err := h.workService.CreateWork(r.Context(), libraryID, &work)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdWork)
+
+		default:
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /works/{id} - Obtener una obra por ID
+// PUT /works/{id} - Actualizar una obra por ID
+// DELETE /works/{id} - Eliminar una obra por ID
+// GET /works/{id}/books - Obtener las ediciones de la obra
+func (h *WorkHandler) HandleWorkByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/works/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) > 1 {
+		if parts[1] != "books" || len(parts) > 2 {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		books, err := h.workService.GetWorkEditions(libraryID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(books)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			work, err := h.workService.GetWorkByID(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(work)
+
+		case http.MethodPut:
+			var work models.Work
+			err := json.NewDecoder(r.Body).Decode(&work)
+			if err != nil {
+				http.Error(w, "Datos de obra inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedWork, err := h.workService.UpdateWork(r.Context(), libraryID, id, &work)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedWork)
+
+		case http.MethodDelete:
+			err := h.workService.DeleteWork(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /series - Obtener todas las series
+// POST /series - Crear una nueva serie
+func (h *SeriesHandler) HandleSeries(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			seriesList, err := h.seriesService.GetAllSeries(libraryID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(seriesList)
+
+		case http.MethodPost:
+			var series models.Series
+			err := json.NewDecoder(r.Body).Decode(&series)
+			if err != nil {
+				http.Error(w, "Datos de serie inválidos", http.StatusBadRequest)
+				return
+			}
+
+			createdSeries, err := h.seriesService.CreateSeries(r.Context(), libraryID, &series)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(createdSeries)
+
+		default:
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /series/{id} - Obtener una serie por ID
+// PUT /series/{id} - Actualizar una serie por ID
+// DELETE /series/{id} - Eliminar una serie por ID
+// GET /series/{id}/books - Obtener los volúmenes de la serie en orden
+func (h *SeriesHandler) HandleSeriesByID(w http.ResponseWriter, r *http.Request) {
+	libraryID, err := middleware.GetLibraryID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/series/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		http.Error(w, "El parámetro ID es requerido", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "El ID es inválido", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) > 1 {
+		if parts[1] != "books" || len(parts) > 2 {
+			http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		books, err := h.seriesService.GetSeriesVolumes(libraryID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(books)
+		return
+	}
+
+	switch r.Method {
+		case http.MethodGet:
+			series, err := h.seriesService.GetSeriesByID(libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(series)
+
+		case http.MethodPut:
+			var series models.Series
+			err := json.NewDecoder(r.Body).Decode(&series)
+			if err != nil {
+				http.Error(w, "Datos de serie inválidos", http.StatusBadRequest)
+				return
+			}
+
+			updatedSeries, err := h.seriesService.UpdateSeries(r.Context(), libraryID, id, &series)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updatedSeries)
+
+		case http.MethodDelete:
+			err := h.seriesService.DeleteSeries(r.Context(), libraryID, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Unavailable method", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,212 @@
+package validations
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+var (
+	orderNumberRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9/-]{2,29}$`)
+	lineISBNRegex    = regexp.MustCompile(`^(?:[0-9]{9}[0-9X]|97[89][0-9]{10})$`)
+)
+
+func ValidateVendor(vendor *models.Vendor) error {
+	if vendor == nil {
+		return errors.New("El proveedor no puede estar vacío")
+	}
+
+	if strings.TrimSpace(vendor.Name) == "" {
+		return errors.New("El nombre del proveedor es requerido")
+	}
+
+	if len(vendor.Name) > 200 {
+		return errors.New("El nombre del proveedor no puede exceder 200 caracteres")
+	}
+
+	if vendor.ContactName.Valid && len(vendor.ContactName.String) > 200 {
+		return errors.New("El nombre del contacto no puede exceder 200 caracteres")
+	}
+
+	if vendor.Email.Valid {
+		if !emailRegex.MatchString(vendor.Email.String) {
+			return errors.New("El formato del email es inválido")
+		}
+
+		if len(vendor.Email.String) > 255 {
+			return errors.New("El email no puede exceder 255 caracteres")
+		}
+	}
+
+	if vendor.Phone.Valid && len(vendor.Phone.String) > 30 {
+		return errors.New("El teléfono no puede exceder 30 caracteres")
+	}
+
+	if vendor.AccountNumber.Valid && len(vendor.AccountNumber.String) > 50 {
+		return errors.New("El número de cuenta no puede exceder 50 caracteres")
+	}
+
+	if vendor.Notes.Valid && len(vendor.Notes.String) > 1000 {
+		return errors.New("Las notas no pueden exceder 1000 caracteres")
+	}
+
+	return nil
+}
+
+func ValidateBudget(budget *models.Budget) error {
+	if budget == nil {
+		return errors.New("El presupuesto no puede estar vacío")
+	}
+
+	if strings.TrimSpace(budget.Name) == "" {
+		return errors.New("El nombre del presupuesto es requerido")
+	}
+
+	if len(budget.Name) > 100 {
+		return errors.New("El nombre del presupuesto no puede exceder 100 caracteres")
+	}
+
+	if budget.FiscalYear < 2000 || budget.FiscalYear > 2100 {
+		return errors.New("El año fiscal debe estar entre 2000 y 2100")
+	}
+
+	if budget.Amount < 0 {
+		return errors.New("El monto del presupuesto no puede ser negativo")
+	}
+
+	if budget.Notes.Valid && len(budget.Notes.String) > 1000 {
+		return errors.New("Las notas no pueden exceder 1000 caracteres")
+	}
+
+	return nil
+}
+
+func ValidatePurchaseOrder(order *models.PurchaseOrder) error {
+	if order == nil {
+		return errors.New("La orden de compra no puede estar vacía")
+	}
+
+	if !orderNumberRegex.MatchString(order.OrderNumber) {
+		return errors.New("El número de orden debe tener de 3 a 30 caracteres alfanuméricos, guiones o diagonales")
+	}
+
+	if order.VendorID <= 0 {
+		return errors.New("El ID del proveedor debe ser un número positivo")
+	}
+
+	if order.BudgetID <= 0 {
+		return errors.New("El ID del presupuesto debe ser un número positivo")
+	}
+
+	if order.Notes.Valid && len(order.Notes.String) > 1000 {
+		return errors.New("Las notas no pueden exceder 1000 caracteres")
+	}
+
+	if len(order.Lines) == 0 {
+		return errors.New("La orden debe tener al menos una línea")
+	}
+
+	if len(order.Lines) > 500 {
+		return errors.New("La orden no puede tener más de 500 líneas")
+	}
+
+	for _, line := range order.Lines {
+		if err := validatePurchaseOrderLine(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePurchaseOrderLine(line *models.PurchaseOrderLine) error {
+	if line == nil {
+		return errors.New("La línea de la orden no puede estar vacía")
+	}
+
+	if !line.BookID.Valid && !line.ISBN.Valid {
+		return errors.New("Cada línea debe indicar el libro o su ISBN")
+	}
+
+	if line.BookID.Valid && line.BookID.Int64 <= 0 {
+		return errors.New("El ID del libro debe ser un número positivo")
+	}
+
+	if line.ISBN.Valid && !lineISBNRegex.MatchString(line.ISBN.String) {
+		return errors.New("El ISBN de la línea debe tener 10 o 13 dígitos")
+	}
+
+	if line.Title.Valid && len(line.Title.String) > 255 {
+		return errors.New("El título no puede exceder 255 caracteres")
+	}
+
+	if line.Quantity < 1 || line.Quantity > 999 {
+		return errors.New("La cantidad debe estar entre 1 y 999")
+	}
+
+	if line.UnitPrice < 0 {
+		return errors.New("El precio unitario no puede ser negativo")
+	}
+
+	if line.Notes.Valid && len(line.Notes.String) > 500 {
+		return errors.New("Las notas de la línea no pueden exceder 500 caracteres")
+	}
+
+	return nil
+}
+
+func ValidateLineReceipt(receipt *models.LineReceipt) error {
+	if receipt == nil {
+		return errors.New("La recepción no puede estar vacía")
+	}
+
+	if receipt.Quantity < 1 {
+		return errors.New("La cantidad recibida debe ser al menos 1")
+	}
+
+	if len(receipt.Codes) > 0 && len(receipt.Codes) != receipt.Quantity {
+		return errors.New("Debe indicar un código por cada ejemplar recibido")
+	}
+
+	for _, code := range receipt.Codes {
+		if !copyCodeRegex.MatchString(code) {
+			return errors.New("El código debe ser alfanumérico de 6-20 caracteres")
+		}
+	}
+
+	if receipt.Condition != "" && !validCopyConditions[receipt.Condition] {
+		return errors.New("La condición debe ser: New, Good, Fair o Poor")
+	}
+
+	if receipt.ShelfID.Valid && receipt.ShelfID.Int64 <= 0 {
+		return errors.New("El ID del estante debe ser un número positivo")
+	}
+
+	return nil
+}
+
+func ValidateInvoice(invoice *models.Invoice) error {
+	if invoice == nil {
+		return errors.New("La factura no puede estar vacía")
+	}
+
+	if strings.TrimSpace(invoice.InvoiceNumber) == "" {
+		return errors.New("El número de factura es requerido")
+	}
+
+	if len(invoice.InvoiceNumber) > 50 {
+		return errors.New("El número de factura no puede exceder 50 caracteres")
+	}
+
+	if invoice.Amount < 0 {
+		return errors.New("El monto de la factura no puede ser negativo")
+	}
+
+	if invoice.Notes.Valid && len(invoice.Notes.String) > 1000 {
+		return errors.New("Las notas no pueden exceder 1000 caracteres")
+	}
+
+	return nil
+}
@@ -122,7 +122,11 @@ func ValidateReservation(reservation *models.Reservation) error {
 		return errors.New("El ID del usuario debe ser un número positivo")
 	}
 
-	if reservation.BookID <= 0 {
+	if reservation.WorkID.Valid {
+		if reservation.WorkID.Int64 <= 0 {
+			return errors.New("El ID de la obra debe ser un número positivo")
+		}
+	} else if reservation.BookID <= 0 {
 		return errors.New("El ID del libro debe ser un número positivo")
 	}
 
@@ -3,6 +3,7 @@ package validations
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/chicho69-cesar/backend-go/books/internal/models"
 )
@@ -46,5 +47,31 @@ func ValidateAuthor(author *models.Author) error {
 		}
 	}
 
+	currentYear := int64(time.Now().Year())
+
+	if author.BirthYear.Valid && (author.BirthYear.Int64 < -3000 || author.BirthYear.Int64 > currentYear) {
+		return errors.New("El año de nacimiento es inválido")
+	}
+
+	if author.DeathYear.Valid {
+		if author.DeathYear.Int64 < -3000 || author.DeathYear.Int64 > currentYear {
+			return errors.New("El año de fallecimiento es inválido")
+		}
+
+		if author.BirthYear.Valid && author.DeathYear.Int64 < author.BirthYear.Int64 {
+			return errors.New("El año de fallecimiento no puede ser anterior al de nacimiento")
+		}
+	}
+
+	for _, alias := range author.Aliases {
+		if len(strings.TrimSpace(alias)) < 2 {
+			return errors.New("Cada alias debe tener al menos 2 caracteres")
+		}
+
+		if len(alias) > 200 {
+			return errors.New("Un alias no puede exceder 200 caracteres")
+		}
+	}
+
 	return nil
 }
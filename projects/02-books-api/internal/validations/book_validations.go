@@ -10,13 +10,25 @@ import (
 )
 
 var (
-	isbnRegex = regexp.MustCompile(`^(?:ISBN(?:-1[03])?:? )?(?=[0-9X]{10}$|(?=(?:[0-9]+[- ]){3})[- 0-9X]{13}$|97[89][0-9]{10}$|(?=(?:[0-9]+[- ]){4})[- 0-9]{17}$)(?:97[89][- ]?)?[0-9]{1,5}[- ]?[0-9]+[- ]?[0-9]+[- ]?[0-9X]$`)
+	// Go regexps have no lookahead, so the accepted shapes of an ISBN are
+	// checked one by one before the group pattern.
+	isbnPrefixRegex    = regexp.MustCompile(`^ISBN(?:-1[03])?:? `)
+	isbn10Regex        = regexp.MustCompile(`^[0-9X]{10}$`)
+	isbn13Regex        = regexp.MustCompile(`^97[89][0-9]{10}$`)
+	isbn10GroupedRegex = regexp.MustCompile(`^(?:[0-9]+[- ]){3}[- 0-9X]*$`)
+	isbn13GroupedRegex = regexp.MustCompile(`^(?:[0-9]+[- ]){4}[- 0-9]*$`)
+	isbnGroupsRegex    = regexp.MustCompile(`^(?:97[89][- ]?)?[0-9]{1,5}[- ]?[0-9]+[- ]?[0-9]+[- ]?[0-9X]$`)
+
+	deweyRegex  = regexp.MustCompile(`^[0-9]{3}(\.[0-9]+)?$`)
+	cduRegex    = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*([:+/][0-9]+(\.[0-9]+)*)*(\([0-9][0-9.=-]*\)|"[0-9][0-9./-]*"|=[0-9][0-9.]*|-[0-9][0-9.]*|\.0[0-9]*)*$`)
+	cutterRegex = regexp.MustCompile(`^[A-Z][0-9]{1,4}[a-z]{0,2}$`)
 
 	validStatuses = map[string]bool{
 		"Available":   true,
 		"Borrowed":    true,
 		"Reserved":    true,
 		"Maintenance": true,
+		"Unavailable": true,
 	}
 )
 
@@ -36,7 +48,7 @@ func ValidateBook(book *models.Book) error {
 		return errors.New("El ISBN debe tener 10 o 13 dígitos")
 	}
 
-	if !isbnRegex.MatchString(book.ISBN) {
+	if !isValidISBNFormat(book.ISBN) {
 		return errors.New("El formato del ISBN es inválido")
 	}
 
@@ -104,12 +116,71 @@ func ValidateBook(book *models.Book) error {
 		return errors.New("El ID del estante debe ser valido")
 	}
 
-	if strings.TrimSpace(book.Status) == "" {
-		return errors.New("El estado es requerido")
+	if book.WorkID.Valid && book.WorkID.Int64 <= 0 {
+		return errors.New("El ID de la obra debe ser un número positivo")
 	}
 
-	if !validStatuses[book.Status] {
-		return errors.New("El estado debe ser: Available, Borrowed, Reserved o Maintenance")
+	if book.SeriesID.Valid && book.SeriesID.Int64 <= 0 {
+		return errors.New("El ID de la serie debe ser un número positivo")
+	}
+
+	if book.VolumeNumber.Valid {
+		if !book.SeriesID.Valid {
+			return errors.New("El número de volumen requiere que el libro pertenezca a una serie")
+		}
+
+		if book.VolumeNumber.Int64 < 1 {
+			return errors.New("El número de volumen debe ser al menos 1")
+		}
+	}
+
+	if err := validateClassification(book); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateBookStatus checks a derived availability status, the book status is
+// never written by clients.
+func ValidateBookStatus(status string) error {
+	if !validStatuses[status] {
+		return errors.New("El estado debe ser: Available, Borrowed, Reserved, Maintenance o Unavailable")
+	}
+
+	return nil
+}
+
+func validateClassification(book *models.Book) error {
+	if !book.ClassScheme.Valid && !book.ClassNumber.Valid {
+		if book.CutterNumber.Valid {
+			return errors.New("El código Cutter requiere un número de clasificación")
+		}
+
+		return nil
+	}
+
+	if !book.ClassScheme.Valid || !book.ClassNumber.Valid {
+		return errors.New("El sistema y el número de clasificación deben indicarse juntos")
+	}
+
+	number := strings.TrimSpace(book.ClassNumber.String)
+
+	switch book.ClassScheme.String {
+		case "DDC":
+			if !deweyRegex.MatchString(number) {
+				return errors.New("El número Dewey debe tener tres dígitos y decimales opcionales (ej: 863.64)")
+			}
+		case "CDU":
+			if !cduRegex.MatchString(number) {
+				return errors.New("El número CDU es inválido (ej: 821.134.2-31)")
+			}
+		default:
+			return errors.New("El sistema de clasificación debe ser: DDC o CDU")
+	}
+
+	if book.CutterNumber.Valid && !cutterRegex.MatchString(strings.TrimSpace(book.CutterNumber.String)) {
+		return errors.New("El código Cutter debe ser una letra mayúscula seguida de dígitos (ej: C47)")
 	}
 
 	return nil
@@ -154,3 +225,14 @@ func ValidateBookCategory(bookCategory *models.BookCategory) error {
 
 	return nil
 }
+
+func isValidISBNFormat(isbn string) bool {
+	isbn = isbnPrefixRegex.ReplaceAllString(isbn, "")
+
+	shape := isbn10Regex.MatchString(isbn) ||
+		isbn13Regex.MatchString(isbn) ||
+		(len(isbn) == 13 && isbn10GroupedRegex.MatchString(isbn)) ||
+		(len(isbn) == 17 && isbn13GroupedRegex.MatchString(isbn))
+
+	return shape && isbnGroupsRegex.MatchString(isbn)
+}
@@ -34,5 +34,9 @@ func ValidateCategory(category *models.Category) error {
 		}
 	}
 
+	if category.ParentID.Valid && category.ParentID.Int64 <= 0 {
+		return errors.New("El ID de la categoría padre debe ser un número positivo")
+	}
+
 	return nil
 }
\ No newline at end of file
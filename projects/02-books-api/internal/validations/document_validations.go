@@ -0,0 +1,24 @@
+package validations
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+func ValidateDocumentTemplate(tmpl *models.DocumentTemplate) error {
+	if tmpl == nil {
+		return errors.New("La plantilla no puede estar vacía")
+	}
+
+	if strings.TrimSpace(tmpl.Body) == "" {
+		return errors.New("El contenido de la plantilla es requerido")
+	}
+
+	if len(tmpl.Body) > 20000 {
+		return errors.New("La plantilla no puede exceder 20000 caracteres")
+	}
+
+	return nil
+}
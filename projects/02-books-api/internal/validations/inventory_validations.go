@@ -0,0 +1,49 @@
+package validations
+
+import (
+	"errors"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+func ValidateInventoryAudit(audit *models.InventoryAudit) error {
+	if audit == nil {
+		return errors.New("La auditoría no puede ser nula")
+	}
+
+	if audit.ZoneID.Valid == audit.ShelfID.Valid {
+		return errors.New("La auditoría debe indicar una zona o un estante, pero no ambos")
+	}
+
+	if audit.ZoneID.Valid && audit.ZoneID.Int64 <= 0 {
+		return errors.New("El ID de la zona es inválido")
+	}
+
+	if audit.ShelfID.Valid && audit.ShelfID.Int64 <= 0 {
+		return errors.New("El ID del estante es inválido")
+	}
+
+	if audit.Notes.Valid && len(audit.Notes.String) > 1000 {
+		return errors.New("Las notas no pueden exceder 1000 caracteres")
+	}
+
+	return nil
+}
+
+func ValidateInventoryScans(barcodes []string) error {
+	if len(barcodes) == 0 {
+		return errors.New("Se requiere al menos un código de barras")
+	}
+
+	if len(barcodes) > 1000 {
+		return errors.New("No se pueden registrar más de 1000 códigos por envío")
+	}
+
+	for _, barcode := range barcodes {
+		if len(barcode) > 100 {
+			return errors.New("El código de barras no puede exceder 100 caracteres")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package validations
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+var (
+	labelLayoutCodeRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,49}$`)
+
+	validLabelFormats = map[string]bool{
+		"svg": true,
+		"png": true,
+	}
+)
+
+func ValidateLabelLayout(layout *models.LabelLayout) error {
+	if layout == nil {
+		return errors.New("El formato de etiquetas no puede ser nulo")
+	}
+
+	if !labelLayoutCodeRegex.MatchString(layout.Code) {
+		return errors.New("El código debe tener de 2 a 50 caracteres en minúsculas, números o guiones (ej: avery-l7160)")
+	}
+
+	if strings.TrimSpace(layout.Name) == "" {
+		return errors.New("El nombre del formato es requerido")
+	}
+
+	if len(layout.Name) > 100 {
+		return errors.New("El nombre no puede exceder 100 caracteres")
+	}
+
+	if layout.PageWidth <= 0 || layout.PageHeight <= 0 || layout.PageWidth > 1000 || layout.PageHeight > 1000 {
+		return errors.New("Las medidas de la página deben estar entre 0 y 1000 mm")
+	}
+
+	if layout.Columns < 1 || layout.Rows < 1 || layout.Columns*layout.Rows > 200 {
+		return errors.New("El formato debe tener al menos una fila y una columna, y como máximo 200 etiquetas")
+	}
+
+	if layout.LabelWidth < 25 || layout.LabelHeight < 12 {
+		return errors.New("Las etiquetas deben medir al menos 25 x 12 mm")
+	}
+
+	if layout.MarginTop < 0 || layout.MarginLeft < 0 || layout.HorizontalGap < 0 || layout.VerticalGap < 0 {
+		return errors.New("Los márgenes y separaciones no pueden ser negativos")
+	}
+
+	width := layout.MarginLeft + float64(layout.Columns)*layout.LabelWidth + float64(layout.Columns-1)*layout.HorizontalGap
+	if width > layout.PageWidth+0.01 {
+		return errors.New("Las columnas de etiquetas no caben en el ancho de la página")
+	}
+
+	height := layout.MarginTop + float64(layout.Rows)*layout.LabelHeight + float64(layout.Rows-1)*layout.VerticalGap
+	if height > layout.PageHeight+0.01 {
+		return errors.New("Las filas de etiquetas no caben en el alto de la página")
+	}
+
+	return nil
+}
+
+func ValidateLabelBatch(batch *models.LabelBatch) error {
+	if batch == nil {
+		return errors.New("La solicitud de etiquetas no puede ser nula")
+	}
+
+	if len(batch.CopyIDs) == 0 && !batch.AcquiredSince.Valid {
+		return errors.New("Se requieren las copias o la fecha de adquisición desde la que imprimir")
+	}
+
+	if len(batch.CopyIDs) > 0 && batch.AcquiredSince.Valid {
+		return errors.New("Indique las copias o la fecha de adquisición, pero no ambas")
+	}
+
+	if len(batch.CopyIDs) > 1000 {
+		return errors.New("No se pueden imprimir más de 1000 etiquetas a la vez")
+	}
+
+	for _, id := range batch.CopyIDs {
+		if id <= 0 {
+			return errors.New("Los IDs de las copias deben ser números positivos")
+		}
+	}
+
+	if batch.Format != "" && !validLabelFormats[batch.Format] {
+		return errors.New("El formato debe ser: svg o png")
+	}
+
+	if batch.Skip < 0 {
+		return errors.New("Las posiciones a omitir no pueden ser negativas")
+	}
+
+	return nil
+}
@@ -13,7 +13,15 @@ var (
 	shelfCodeRegex = regexp.MustCompile(`^[A-Z][0-9]{1,2}-[0-9]{2}$`)
 	copyCodeRegex  = regexp.MustCompile(`^[A-Z0-9]{6,20}$`)
 	websiteRegex   = regexp.MustCompile(`^(https?://)?(www\.)?[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}(/[\w.-]*)*/?$`)
-	passwordRegex  = regexp.MustCompile(`^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)(?=.*[@$!%*?&])[A-Za-z\d@$!%*?&]{6,100}$`)
+	passwordRegex  = regexp.MustCompile(`^[A-Za-z\d@$!%*?&]{6,100}$`)
+
+	// Each class must appear at least once in the password.
+	passwordClassRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`[a-z]`),
+		regexp.MustCompile(`[A-Z]`),
+		regexp.MustCompile(`\d`),
+		regexp.MustCompile(`[@$!%*?&]`),
+	}
 
 	validCopyStatuses = map[string]bool{
 		"Available": true,
@@ -21,6 +29,7 @@ var (
 		"Reserved":  true,
 		"Damaged":   true,
 		"Lost":      true,
+		"Withdrawn": true,
 	}
 
 	validCopyConditions = map[string]bool{
@@ -110,7 +119,7 @@ func ValidateLibrary(library *models.Library) error {
 			return errors.New("La contraseña no puede exceder 100 caracteres")
 		}
 
-		if !passwordRegex.MatchString(library.Password) {
+		if !isValidPassword(library.Password) {
 			return errors.New("La contraseña debe contener al menos una letra mayúscula, una letra minúscula, un número y un carácter especial")
 		}
 	}
@@ -208,7 +217,7 @@ func ValidateCopy(copy *models.Copy) error {
 	}
 
 	if !validCopyStatuses[copy.Status] {
-		return errors.New("El estado debe ser: Available, Borrowed, Reserved, Damaged o Lost")
+		return errors.New("El estado debe ser: Available, Borrowed, Reserved, Damaged, Lost o Withdrawn")
 	}
 
 	if strings.TrimSpace(copy.Condition) == "" {
@@ -227,5 +236,83 @@ func ValidateCopy(copy *models.Copy) error {
 		return errors.New("Las notas no pueden exceder 1000 caracteres")
 	}
 
+	if copy.ShelfID.Valid && copy.ShelfID.Int64 <= 0 {
+		return errors.New("El ID del estante debe ser un número positivo")
+	}
+
+	if copy.TemporaryLocation.Valid && len(copy.TemporaryLocation.String) > 100 {
+		return errors.New("La ubicación temporal no puede exceder 100 caracteres")
+	}
+
 	return nil
 }
+
+func ValidateCopyMove(move *models.CopyMove) error {
+	if move == nil {
+		return errors.New("El movimiento no puede estar vacío")
+	}
+
+	if !move.ShelfID.Valid && !move.TemporaryLocation.Valid {
+		return errors.New("Se requiere el estante destino o una ubicación temporal")
+	}
+
+	if move.ShelfID.Valid && move.ShelfID.Int64 <= 0 {
+		return errors.New("El ID del estante debe ser un número positivo")
+	}
+
+	if move.TemporaryLocation.Valid && strings.TrimSpace(move.TemporaryLocation.String) == "" {
+		return errors.New("La ubicación temporal no puede estar vacía")
+	}
+
+	if move.TemporaryLocation.Valid && len(move.TemporaryLocation.String) > 100 {
+		return errors.New("La ubicación temporal no puede exceder 100 caracteres")
+	}
+
+	if len(move.CopyIDs) > 500 {
+		return errors.New("No se pueden mover más de 500 copias a la vez")
+	}
+
+	for _, id := range move.CopyIDs {
+		if id <= 0 {
+			return errors.New("Los IDs de las copias deben ser números positivos")
+		}
+	}
+
+	return nil
+}
+
+func ValidateCopyWithdrawal(withdrawal *models.CopyWithdrawal) error {
+	if withdrawal == nil {
+		return errors.New("La baja no puede estar vacía")
+	}
+
+	if withdrawal.Reason.Valid && len(withdrawal.Reason.String) > 500 {
+		return errors.New("El motivo de la baja no puede exceder 500 caracteres")
+	}
+
+	if len(withdrawal.CopyIDs) > 500 {
+		return errors.New("No se pueden dar de baja más de 500 copias a la vez")
+	}
+
+	for _, id := range withdrawal.CopyIDs {
+		if id <= 0 {
+			return errors.New("Los IDs de las copias deben ser números positivos")
+		}
+	}
+
+	return nil
+}
+
+func isValidPassword(password string) bool {
+	if !passwordRegex.MatchString(password) {
+		return false
+	}
+
+	for _, class := range passwordClassRegexes {
+		if !class.MatchString(password) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package validations
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+var (
+	quietHoursRegex = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+	validNotificationChannels = map[string]bool{
+		"email": true,
+		"sms":   true,
+		"none":  true,
+	}
+
+	validNotificationLanguages = map[string]bool{
+		"es": true,
+		"en": true,
+	}
+)
+
+func ValidateNotificationTemplate(tmpl *models.NotificationTemplate) error {
+	if tmpl == nil {
+		return errors.New("La plantilla no puede estar vacía")
+	}
+
+	if !validNotificationLanguages[tmpl.Language] {
+		return errors.New("El idioma debe ser: es o en")
+	}
+
+	if strings.TrimSpace(tmpl.Subject) == "" {
+		return errors.New("El asunto de la plantilla es requerido")
+	}
+
+	if len(tmpl.Subject) > 500 {
+		return errors.New("El asunto no puede exceder 500 caracteres")
+	}
+
+	if strings.TrimSpace(tmpl.Body) == "" {
+		return errors.New("El contenido de la plantilla es requerido")
+	}
+
+	if len(tmpl.Body) > 10000 {
+		return errors.New("La plantilla no puede exceder 10000 caracteres")
+	}
+
+	return nil
+}
+
+func ValidateNotificationPreferences(preferences *models.NotificationPreferences) error {
+	if preferences == nil {
+		return errors.New("Las preferencias no pueden estar vacías")
+	}
+
+	if !validNotificationChannels[preferences.Channel] {
+		return errors.New("El canal debe ser: email, sms o none")
+	}
+
+	if !validNotificationLanguages[preferences.Language] {
+		return errors.New("El idioma debe ser: es o en")
+	}
+
+	if preferences.QuietHoursStart.Valid != preferences.QuietHoursEnd.Valid {
+		return errors.New("El horario de silencio requiere la hora de inicio y la de fin")
+	}
+
+	if preferences.QuietHoursStart.Valid {
+		if !quietHoursRegex.MatchString(preferences.QuietHoursStart.String) || !quietHoursRegex.MatchString(preferences.QuietHoursEnd.String) {
+			return errors.New("Las horas del horario de silencio deben tener el formato HH:MM")
+		}
+
+		if preferences.QuietHoursStart.String == preferences.QuietHoursEnd.String {
+			return errors.New("El horario de silencio no puede empezar y terminar a la misma hora")
+		}
+	}
+
+	if preferences.QuietHoursStart.Valid && !preferences.Timezone.Valid {
+		return errors.New("El horario de silencio requiere la zona horaria del usuario, por ejemplo America/Mexico_City")
+	}
+
+	if preferences.Timezone.Valid {
+		if _, err := time.LoadLocation(preferences.Timezone.String); err != nil || preferences.Timezone.String == "" {
+			return errors.New("La zona horaria es inválida")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package validations
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+var validReportFormats = map[string]bool{
+	"csv":  true,
+	"xlsx": true,
+	"json": true,
+}
+
+func ValidateReportDefinition(definition *models.ReportDefinition) error {
+	if definition == nil {
+		return errors.New("La definición del reporte no puede estar vacía")
+	}
+
+	if strings.TrimSpace(definition.Name) == "" {
+		return errors.New("El nombre del reporte es requerido")
+	}
+
+	if len(definition.Name) > 100 {
+		return errors.New("El nombre del reporte no puede exceder 100 caracteres")
+	}
+
+	if strings.TrimSpace(definition.ReportType) == "" {
+		return errors.New("El tipo de reporte es requerido")
+	}
+
+	if definition.Format != "" && !validReportFormats[definition.Format] {
+		return errors.New("El formato debe ser: csv, xlsx o json")
+	}
+
+	if definition.Schedule.Valid && len(definition.Schedule.String) > 100 {
+		return errors.New("La programación no puede exceder 100 caracteres")
+	}
+
+	if len(definition.Parameters) > 20 {
+		return errors.New("El reporte no puede tener más de 20 parámetros")
+	}
+
+	for name, value := range definition.Parameters {
+		if len(name) > 50 || len(value) > 200 {
+			return errors.New("Los parámetros no pueden exceder 50 caracteres de nombre ni 200 de valor")
+		}
+	}
+
+	return nil
+}
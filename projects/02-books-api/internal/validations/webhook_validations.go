@@ -0,0 +1,38 @@
+package validations
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+func ValidateWebhookSubscription(subscription *models.WebhookSubscription) error {
+	if subscription == nil {
+		return errors.New("La suscripción no puede estar vacía")
+	}
+
+	if strings.TrimSpace(subscription.URL) == "" {
+		return errors.New("La URL del webhook es requerida")
+	}
+
+	if len(subscription.URL) > 2000 {
+		return errors.New("La URL no puede exceder 2000 caracteres")
+	}
+
+	parsed, err := url.Parse(subscription.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("La URL del webhook debe ser una dirección http o https válida")
+	}
+
+	if subscription.Secret != "" && (len(subscription.Secret) < 16 || len(subscription.Secret) > 200) {
+		return errors.New("El secreto debe tener entre 16 y 200 caracteres")
+	}
+
+	if subscription.Description.Valid && len(subscription.Description.String) > 500 {
+		return errors.New("La descripción no puede exceder 500 caracteres")
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package validations
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/chicho69-cesar/backend-go/books/internal/models"
+)
+
+func ValidateWork(work *models.Work) error {
+	if work == nil {
+		return errors.New("La obra no puede ser nula")
+	}
+
+	if strings.TrimSpace(work.Title) == "" {
+		return errors.New("El título de la obra es requerido")
+	}
+
+	if len(work.Title) > 255 {
+		return errors.New("El título de la obra no puede exceder 255 caracteres")
+	}
+
+	if work.OriginalTitle.Valid && len(work.OriginalTitle.String) > 255 {
+		return errors.New("El título original no puede exceder 255 caracteres")
+	}
+
+	if work.Description.Valid && len(work.Description.String) > 5000 {
+		return errors.New("La descripción de la obra no puede exceder 5000 caracteres")
+	}
+
+	return nil
+}
+
+func ValidateSeries(series *models.Series) error {
+	if series == nil {
+		return errors.New("La serie no puede ser nula")
+	}
+
+	if strings.TrimSpace(series.Name) == "" {
+		return errors.New("El nombre de la serie es requerido")
+	}
+
+	if len(series.Name) < 2 {
+		return errors.New("El nombre de la serie debe tener al menos 2 caracteres")
+	}
+
+	if len(series.Name) > 200 {
+		return errors.New("El nombre de la serie no puede exceder 200 caracteres")
+	}
+
+	if series.Description.Valid && len(series.Description.String) > 1000 {
+		return errors.New("La descripción de la serie no puede exceder 1000 caracteres")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxResponseBody = 1024
+
+// Request is a delivery ready to be posted to the subscriber.
+type Request struct {
+	ID        string // Same on every attempt, so the receiver can drop repeats
+	Event     string
+	URL       string
+	Secret    string
+	Payload   []byte
+	Timestamp time.Time
+}
+
+// Response is what the receiver answered; Err is set when the request could
+// not be made or the status was not 2xx.
+type Response struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+	Err        error
+}
+
+// Sender posts the payloads of the webhooks. Redirects are not followed, the
+// subscriber has to register the final URL.
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Sign returns the signature of the payload sent at timestamp: the hex
+// HMAC-SHA256, keyed with the secret, of "timestamp.payload". The timestamp is
+// part of it so a captured request cannot be replayed later.
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Sender) Send(ctx context.Context, r Request) Response {
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Payload))
+	if err != nil {
+		return Response{Err: err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "books-api-webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", r.ID)
+	req.Header.Set("X-Webhook-Event", r.Event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(r.Timestamp.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", Sign(r.Secret, r.Timestamp, r.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Response{Duration: time.Since(start), Err: fmt.Errorf("Error al enviar el webhook: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	io.Copy(io.Discard, resp.Body)
+
+	response := Response{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+		Duration:   time.Since(start),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		response.Err = fmt.Errorf("El receptor respondió con estado %d", resp.StatusCode)
+	}
+
+	return response
+}
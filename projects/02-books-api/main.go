@@ -1,29 +1,60 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // The alpine image has no zoneinfo, the quiet hours need it
 
-	_ "github.com/mattn/go-sqlite3"
-
+	"github.com/chicho69-cesar/backend-go/books/internal/config"
 	"github.com/chicho69-cesar/backend-go/books/internal/database"
 	"github.com/chicho69-cesar/backend-go/books/internal/logger"
+	"github.com/chicho69-cesar/backend-go/books/internal/metadata"
+	"github.com/chicho69-cesar/backend-go/books/internal/metrics"
+	"github.com/chicho69-cesar/backend-go/books/internal/middleware"
+	"github.com/chicho69-cesar/backend-go/books/internal/notify"
 	"github.com/chicho69-cesar/backend-go/books/internal/services"
+	"github.com/chicho69-cesar/backend-go/books/internal/storage"
 	"github.com/chicho69-cesar/backend-go/books/internal/store"
 	"github.com/chicho69-cesar/backend-go/books/internal/transport"
+	"github.com/chicho69-cesar/backend-go/books/internal/webhook"
 )
 
 func main() {
-	db, err := sql.Open("sqlite3", "./books.db")
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
 	if err != nil {
-		fmt.Println("Error al conectar a la base de datos:", err)
+		fmt.Println("Error al cargar la configuración:", err)
 		log.Fatal("Error: ", err)
 		return
 	}
+
+	if cfg.PrintOnly {
+		cfg.Print(os.Stdout)
+		return
+	}
+
+	db := database.Open(cfg.Database.Path, cfg.Database.Pragmas...)
 	defer db.Close()
 
+	if err := db.Ping(); err != nil {
+		fmt.Println("Error al conectar a la base de datos:", err)
+		log.Fatal("Error: ", err)
+		return
+	}
+
 	schema := database.GetMigrationSchema()
 	_, err = db.Exec(schema)
 	if err != nil {
@@ -32,7 +63,14 @@ func main() {
 		return
 	}
 
-	apiLogger, err := logger.NewLogger("./api.log")
+	err = database.ApplyMigrationAlterations(db)
+	if err != nil {
+		fmt.Println("Error al ejecutar las migraciones:", err)
+		log.Fatal("Error: ", err)
+		return
+	}
+
+	apiLogger, err := logger.NewLogger(cfg.LoggerConfig())
 	if err != nil {
 		fmt.Println("Error al inicializar el logger:", err)
 		log.Fatal("Error: ", err)
@@ -40,183 +78,531 @@ func main() {
 	}
 	defer apiLogger.Close()
 
+	slog.SetDefault(apiLogger.Logger)
+	slog.Info("Configuración cargada", "file", cfg.File, "config", cfg)
+
+	// SIGTERM stops the background workers and drains the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+
+	blobStore, err := storage.NewLocalBlobStore(cfg.Storage.Path)
+	if err != nil {
+		fmt.Println("Error al inicializar el almacenamiento de archivos:", err)
+		log.Fatal("Error: ", err)
+		return
+	}
+
+	reportBlobStore, err := storage.NewLocalBlobStore(cfg.Storage.ReportsPath)
+	if err != nil {
+		fmt.Println("Error al inicializar el directorio de reportes:", err)
+		log.Fatal("Error: ", err)
+		return
+	}
+
+	auditStore := store.NewAuditStore(db)
+	auditService := services.NewAuditService(auditStore)
+
 	libraryStore := store.NewLibraryStore(db)
-	libraryService := services.NewLibraryService(libraryStore)
+	libraryService := services.NewLibraryService(libraryStore, blobStore, auditService)
 	libraryHandler := transport.NewLibraryHandler(libraryService)
 
 	authorStore := store.NewAuthorStore(db)
-	authorService := services.NewAuthorService(authorStore)
+	bookStore := store.NewBookStore(db)
+	authorService := services.NewAuthorService(authorStore, bookStore, auditService)
 	authorHandler := transport.NewAuthorHandler(authorService)
 
-	bookStore := store.NewBookStore(db)
 	copyStore := store.NewCopyStore(db)
 	reservationStore := store.NewReservationStore(db)
-	bookService := services.NewBookService(bookStore, authorStore, copyStore, reservationStore)
-	bookHandler := transport.NewBookHandler(bookService)
+	coverStore := store.NewCoverStore(db)
+	copyEventStore := store.NewCopyEventStore(db)
+	bookService := services.NewBookService(bookStore, authorStore, copyStore, reservationStore, coverStore, blobStore, auditService)
+	coverService := services.NewCoverService(coverStore, bookStore, blobStore, auditService)
+	bookHandler := transport.NewBookHandler(bookService, coverService)
 
 	categoryStore := store.NewCategoryStore(db)
-	categoryService := services.NewCategoryService(categoryStore)
+	categoryService := services.NewCategoryService(categoryStore, auditService)
 	categoryHandler := transport.NewCategoryHandler(categoryService)
 
 	configStore := store.NewConfigurationStore(db)
-	configService := services.NewConfigurationService(configStore)
+	configService := services.NewConfigurationService(configStore, auditService)
 	configHandler := transport.NewConfigurationHandler(configService)
 
 	loanStore := store.NewLoanStore(db)
-	copyService := services.NewCopyService(copyStore, bookStore, loanStore)
-	copyHandler := transport.NewCopyHandler(copyService)
+	zoneStore := store.NewLibraryZoneStore(db)
+	shelfStore := store.NewShelfStore(db)
+	copyService := services.NewCopyService(copyStore, bookStore, loanStore, shelfStore, copyEventStore, auditService)
+	labelLayoutStore := store.NewLabelLayoutStore(db)
+	labelService := services.NewLabelService(labelLayoutStore, copyStore, bookStore, auditService)
+	copyHandler := transport.NewCopyHandler(copyService, labelService)
+	labelLayoutHandler := transport.NewLabelLayoutHandler(labelService)
 
 	publisherStore := store.NewPublisherStore(db)
-	publisherService := services.NewPublisherService(publisherStore)
+	publisherService := services.NewPublisherService(publisherStore, auditService)
 	publisherHandler := transport.NewPublisherHandler(publisherService)
 
-	zoneStore := store.NewLibraryZoneStore(db)
-	zoneService := services.NewLibraryZoneService(zoneStore)
+	zoneService := services.NewLibraryZoneService(zoneStore, shelfStore, copyStore, bookStore, auditService)
 	zoneHandler := transport.NewLibraryZoneHandler(zoneService)
 
-	shelfStore := store.NewShelfStore(db)
-	shelfService := services.NewShelfService(shelfStore, zoneStore)
+	shelfService := services.NewShelfService(shelfStore, zoneStore, bookStore, copyStore, auditService)
 	shelfHandler := transport.NewShelfHandler(shelfService)
 
 	userStore := store.NewUserStore(db)
 	fineStore := store.NewFineStore(db)
-	userService := services.NewUserService(userStore, loanStore, reservationStore, fineStore)
-	userHandler := transport.NewUserHandler(userService)
+	documentTemplateStore := store.NewDocumentTemplateStore(db)
+	documentService := services.NewDocumentService(documentTemplateStore, libraryService, loanStore, fineStore, reservationStore, userStore, copyStore, bookStore, auditService)
+	documentHandler := transport.NewDocumentHandler(documentService)
 
-	loanService := services.NewLoanService(loanStore, userStore, copyStore, fineStore)
-	loanHandler := transport.NewLoanHandler(loanService)
+	notifiers := map[string]notify.Notifier{}
 
-	reservationService := services.NewReservationService(reservationStore, userStore, bookStore, copyStore, fineStore)
+	if cfg.SMTP.Addr != "" {
+		emailNotifier, err := notify.NewSMTPNotifier(cfg.SMTP.Addr, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From, 30*time.Second)
+		if err != nil {
+			fmt.Println("Error al configurar el envío de correos:", err)
+			log.Fatal("Error: ", err)
+			return
+		}
+
+		notifiers["email"] = emailNotifier
+	}
+
+	if cfg.SMS.GatewayURL != "" {
+		smsNotifier, err := notify.NewSMSGatewayNotifier(cfg.SMS.GatewayURL, cfg.SMS.Token, 30*time.Second)
+		if err != nil {
+			fmt.Println("Error al configurar el envío de SMS:", err)
+			log.Fatal("Error: ", err)
+			return
+		}
+
+		notifiers["sms"] = smsNotifier
+	}
+
+	notificationStore := store.NewNotificationStore(db)
+	notificationTemplateStore := store.NewNotificationTemplateStore(db)
+	notificationPreferenceStore := store.NewNotificationPreferenceStore(db)
+	notificationService := services.NewNotificationService(notificationStore, notificationTemplateStore, notificationPreferenceStore, libraryService, userStore, loanStore, fineStore, reservationStore, copyStore, bookStore, notifiers, auditService)
+
+	userService := services.NewUserService(userStore, loanStore, reservationStore, fineStore, auditService)
+	userHandler := transport.NewUserHandler(userService, documentService, notificationService)
+
+	loanService := services.NewLoanService(loanStore, userStore, copyStore, fineStore, reservationStore, bookStore, copyEventStore, auditService)
+	loanHandler := transport.NewLoanHandler(loanService, documentService)
+
+	reservationService := services.NewReservationService(reservationStore, userStore, bookStore, copyStore, fineStore, auditService)
 	reservationHandler := transport.NewReservationHandler(reservationService)
 
-	fineService := services.NewFineService(fineStore, userStore, loanStore)
-	fineHandler := transport.NewFineHandler(fineService)
+	fineService := services.NewFineService(fineStore, userStore, loanStore, auditService)
+	fineHandler := transport.NewFineHandler(fineService, documentService)
+
+	workStore := store.NewWorkStore(db)
+	workService := services.NewWorkService(workStore, bookStore, reservationStore, auditService)
+	workHandler := transport.NewWorkHandler(workService)
+
+	seriesStore := store.NewSeriesStore(db)
+	seriesService := services.NewSeriesService(seriesStore, bookStore, auditService)
+	seriesHandler := transport.NewSeriesHandler(seriesService)
+
+	inventoryStore := store.NewInventoryStore(db)
+	inventoryService := services.NewInventoryService(inventoryStore, zoneStore, shelfStore, copyStore, copyEventStore, auditService)
+	inventoryHandler := transport.NewInventoryHandler(inventoryService)
+
+	reportStore := store.NewReportStore(db)
+	reportService := services.NewReportService(reportStore, zoneStore)
+	reportHandler := transport.NewReportHandler(reportService)
+
+	statsStore := store.NewStatsStore(db)
+	statsService := services.NewStatsService(statsStore)
+	statsHandler := transport.NewStatsHandler(statsService)
+
+	reportDefinitionStore := store.NewReportDefinitionStore(db)
+	reportRunStore := store.NewReportRunStore(db)
+	scheduledReportService := services.NewScheduledReportService(reportDefinitionStore, reportRunStore, reportStore, statsService, reportBlobStore, auditService)
+	scheduledReportHandler := transport.NewScheduledReportHandler(scheduledReportService)
+
+	reportScheduler := services.NewReportScheduler(scheduledReportService, cfg.Jobs.ReportInterval)
+	workers.Go(func() { reportScheduler.Start(ctx) })
+
+	notificationHandler := transport.NewNotificationHandler(notificationService)
+
+	if len(notifiers) > 0 {
+		notificationDispatcher := services.NewNotificationDispatcher(notificationService, cfg.Jobs.NotificationInterval)
+		workers.Go(func() { notificationDispatcher.Start(ctx) })
+	} else {
+		slog.Warn("SMTP_ADDR ni SMS_GATEWAY_URL están configurados, las notificaciones quedan pendientes sin enviarse")
+	}
+
+	eventStore := store.NewEventStore(db)
+	eventService := services.NewEventService(loanStore, fineStore, reservationStore, userStore)
+	eventBroker := services.NewEventBroker(eventStore, eventService, 1000, cfg.Jobs.EventInterval)
+	eventHandler := transport.NewEventHandler(eventBroker)
 
+	workers.Go(func() {
+		if err := eventBroker.Start(ctx); err != nil {
+			slog.Warn("El flujo de eventos no está disponible", "error", err)
+		}
+	})
+
+	webhookSubscriptionStore := store.NewWebhookSubscriptionStore(db)
+	webhookDeliveryStore := store.NewWebhookDeliveryStore(db)
+	webhookService := services.NewWebhookService(webhookSubscriptionStore, webhookDeliveryStore, eventService, webhook.NewSender(10*time.Second), auditService)
+	webhookHandler := transport.NewWebhookHandler(webhookService)
+
+	webhookDispatcher := services.NewWebhookDispatcher(webhookService, cfg.Jobs.WebhookInterval)
+	workers.Go(func() { webhookDispatcher.Start(ctx) })
+
+	vendorStore := store.NewVendorStore(db)
+	budgetStore := store.NewBudgetStore(db)
+	purchaseOrderStore := store.NewPurchaseOrderStore(db)
+	vendorService := services.NewVendorService(vendorStore, purchaseOrderStore, auditService)
+	vendorHandler := transport.NewVendorHandler(vendorService)
+	budgetService := services.NewBudgetService(budgetStore, purchaseOrderStore, auditService)
+	budgetHandler := transport.NewBudgetHandler(budgetService)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderStore, vendorStore, budgetStore, bookStore, copyStore, copyService, auditService)
+	purchaseOrderHandler := transport.NewPurchaseOrderHandler(purchaseOrderService)
+
+	auditHandler := transport.NewAuditHandler(auditService)
+
+	healthStore := store.NewHealthStore(db)
+	healthService := services.NewHealthService(healthStore)
+	healthHandler := transport.NewHealthHandler(healthService)
+
+	metricsStore := store.NewMetricsStore(db)
+	metricsService := services.NewMetricsService(metricsStore)
+	metrics.Register(metrics.CollectorFunc(metricsService.Collect))
+
+	metadataProvider := metadata.NewOpenLibraryProvider(cfg.Metadata.BaseURL, 10*time.Second)
+	metadataStore := store.NewMetadataStore(db)
+	metadataService := services.NewMetadataService(metadataProvider, metadataStore, bookStore, authorStore, publisherStore, auditService)
+	metadataHandler := transport.NewMetadataHandler(metadataService)
+
+	http.HandleFunc(
+		"/audit",
+		apiLogger.Middleware(auditHandler.Middleware(auditHandler.HandleAudit)),
+	)
+	http.HandleFunc(
+		"/audit/verify",
+		apiLogger.Middleware(auditHandler.Middleware(auditHandler.HandleAuditVerify)),
+	)
 	http.HandleFunc(
 		"/authors",
-		apiLogger.Middleware(authorHandler.HandleAuthors),
+		apiLogger.Middleware(auditHandler.Middleware(authorHandler.HandleAuthors)),
 	)
 	http.HandleFunc(
 		"/authors/",
-		apiLogger.Middleware(authorHandler.HandleAuthorByID),
+		apiLogger.Middleware(auditHandler.Middleware(authorHandler.HandleAuthorByID)),
+	)
+	http.HandleFunc(
+		"/authors/duplicates",
+		apiLogger.Middleware(auditHandler.Middleware(authorHandler.HandleAuthorDuplicates)),
 	)
 	http.HandleFunc(
 		"/books",
-		apiLogger.Middleware(bookHandler.HandleBooks),
+		apiLogger.Middleware(auditHandler.Middleware(bookHandler.HandleBooks)),
 	)
 	http.HandleFunc(
 		"/books/",
-		apiLogger.Middleware(bookHandler.HandleBookByID),
+		apiLogger.Middleware(auditHandler.Middleware(bookHandler.HandleBookByID)),
+	)
+	http.HandleFunc(
+		"/books/enrich",
+		apiLogger.Middleware(auditHandler.Middleware(metadataHandler.HandleBookEnrich)),
+	)
+	http.HandleFunc(
+		"/budgets",
+		apiLogger.Middleware(auditHandler.Middleware(budgetHandler.HandleBudgets)),
+	)
+	http.HandleFunc(
+		"/budgets/",
+		apiLogger.Middleware(auditHandler.Middleware(budgetHandler.HandleBudgetByID)),
 	)
 	http.HandleFunc(
 		"/categories",
-		apiLogger.Middleware(categoryHandler.HandleCategories),
+		apiLogger.Middleware(auditHandler.Middleware(categoryHandler.HandleCategories)),
 	)
 	http.HandleFunc(
 		"/categories/",
-		apiLogger.Middleware(categoryHandler.HandleCategoryByID),
+		apiLogger.Middleware(auditHandler.Middleware(categoryHandler.HandleCategoryByID)),
+	)
+	http.HandleFunc(
+		"/categories/tree",
+		apiLogger.Middleware(auditHandler.Middleware(categoryHandler.HandleCategoryTree)),
 	)
 	http.HandleFunc(
 		"/configuration",
-		apiLogger.Middleware(configHandler.HandleConfiguration),
+		apiLogger.Middleware(auditHandler.Middleware(configHandler.HandleConfiguration)),
 	)
 	http.HandleFunc(
 		"/copies",
-		apiLogger.Middleware(copyHandler.HandleCopies),
+		apiLogger.Middleware(auditHandler.Middleware(copyHandler.HandleCopies)),
 	)
 	http.HandleFunc(
 		"/copies/",
-		apiLogger.Middleware(copyHandler.HandleCopyByID),
+		apiLogger.Middleware(auditHandler.Middleware(copyHandler.HandleCopyByID)),
+	)
+	http.HandleFunc(
+		"/copies/labels",
+		apiLogger.Middleware(auditHandler.Middleware(copyHandler.HandleCopyLabels)),
+	)
+	http.HandleFunc(
+		"/copies/move",
+		apiLogger.Middleware(auditHandler.Middleware(copyHandler.HandleCopiesMove)),
+	)
+	http.HandleFunc(
+		"/copies/withdraw",
+		apiLogger.Middleware(auditHandler.Middleware(copyHandler.HandleCopiesWithdraw)),
+	)
+	http.HandleFunc(
+		"/document-templates",
+		apiLogger.Middleware(auditHandler.Middleware(documentHandler.HandleDocumentTemplates)),
+	)
+	http.HandleFunc(
+		"/document-templates/",
+		apiLogger.Middleware(auditHandler.Middleware(documentHandler.HandleDocumentTemplateByType)),
+	)
+	http.HandleFunc(
+		"/events/stream",
+		apiLogger.Middleware(auditHandler.Middleware(eventHandler.HandleEventStream)),
 	)
 	http.HandleFunc(
 		"/fines",
-		apiLogger.Middleware(fineHandler.HandleFines),
+		apiLogger.Middleware(auditHandler.Middleware(fineHandler.HandleFines)),
 	)
 	http.HandleFunc(
 		"/fines/",
-		apiLogger.Middleware(fineHandler.HandleFineByID),
+		apiLogger.Middleware(auditHandler.Middleware(fineHandler.HandleFineByID)),
 	)
 	http.HandleFunc(
 		"/fines/pay/",
-		apiLogger.Middleware(fineHandler.HandleFinePay),
+		apiLogger.Middleware(auditHandler.Middleware(fineHandler.HandleFinePay)),
 	)
 	http.HandleFunc(
 		"/fines/waive/",
-		apiLogger.Middleware(fineHandler.HandleFineWaive),
+		apiLogger.Middleware(auditHandler.Middleware(fineHandler.HandleFineWaive)),
+	)
+	// Probed every few seconds, so they are left out of the log and the audit
+	http.HandleFunc("/healthz", healthHandler.HandleHealthz)
+	http.HandleFunc(
+		"/inventory/audits",
+		apiLogger.Middleware(auditHandler.Middleware(inventoryHandler.HandleAudits)),
+	)
+	http.HandleFunc(
+		"/inventory/audits/",
+		apiLogger.Middleware(auditHandler.Middleware(inventoryHandler.HandleAuditByID)),
+	)
+	http.HandleFunc(
+		"/label-layouts",
+		apiLogger.Middleware(auditHandler.Middleware(labelLayoutHandler.HandleLabelLayouts)),
+	)
+	http.HandleFunc(
+		"/label-layouts/",
+		apiLogger.Middleware(auditHandler.Middleware(labelLayoutHandler.HandleLabelLayoutByID)),
 	)
 	http.HandleFunc(
 		"/libraries",
-		apiLogger.Middleware(libraryHandler.HandleLibraries),
+		apiLogger.Middleware(auditHandler.Middleware(libraryHandler.HandleLibraries)),
 	)
 	http.HandleFunc(
 		"/libraries/",
-		apiLogger.Middleware(libraryHandler.HandleLibraryByID),
+		apiLogger.Middleware(auditHandler.Middleware(libraryHandler.HandleLibraryByID)),
 	)
 	http.HandleFunc(
 		"/loans",
-		apiLogger.Middleware(loanHandler.HandleLoans),
+		apiLogger.Middleware(auditHandler.Middleware(loanHandler.HandleLoans)),
 	)
 	http.HandleFunc(
 		"/loans/",
-		apiLogger.Middleware(loanHandler.HandleLoanByID),
+		apiLogger.Middleware(auditHandler.Middleware(loanHandler.HandleLoanByID)),
 	)
 	http.HandleFunc(
 		"/loans/renew/",
-		apiLogger.Middleware(loanHandler.HandleLoanRenew),
+		apiLogger.Middleware(auditHandler.Middleware(loanHandler.HandleLoanRenew)),
 	)
 	http.HandleFunc(
 		"/loans/return/",
-		apiLogger.Middleware(loanHandler.HandleLoanReturn),
+		apiLogger.Middleware(auditHandler.Middleware(loanHandler.HandleLoanReturn)),
+	)
+	// Scraped every few seconds, so they are left out of the log and the audit
+	http.HandleFunc("/metrics", metrics.Handler())
+	http.HandleFunc(
+		"/notification-templates",
+		apiLogger.Middleware(auditHandler.Middleware(notificationHandler.HandleNotificationTemplates)),
+	)
+	http.HandleFunc(
+		"/notification-templates/",
+		apiLogger.Middleware(auditHandler.Middleware(notificationHandler.HandleNotificationTemplateByType)),
+	)
+	http.HandleFunc(
+		"/notifications",
+		apiLogger.Middleware(auditHandler.Middleware(notificationHandler.HandleNotifications)),
+	)
+	http.HandleFunc(
+		"/notifications/",
+		apiLogger.Middleware(auditHandler.Middleware(notificationHandler.HandleNotificationByID)),
 	)
 	http.HandleFunc(
 		"/publishers",
-		apiLogger.Middleware(publisherHandler.HandlePublishers),
+		apiLogger.Middleware(auditHandler.Middleware(publisherHandler.HandlePublishers)),
 	)
 	http.HandleFunc(
 		"/publishers/",
-		apiLogger.Middleware(publisherHandler.HandlePublisherByID),
+		apiLogger.Middleware(auditHandler.Middleware(publisherHandler.HandlePublisherByID)),
+	)
+	http.HandleFunc(
+		"/purchase-orders",
+		apiLogger.Middleware(auditHandler.Middleware(purchaseOrderHandler.HandlePurchaseOrders)),
+	)
+	http.HandleFunc(
+		"/purchase-orders/",
+		apiLogger.Middleware(auditHandler.Middleware(purchaseOrderHandler.HandlePurchaseOrderByID)),
+	)
+	http.HandleFunc("/readyz", healthHandler.HandleReadyz)
+	http.HandleFunc(
+		"/reports/definitions",
+		apiLogger.Middleware(auditHandler.Middleware(scheduledReportHandler.HandleDefinitions)),
+	)
+	http.HandleFunc(
+		"/reports/definitions/",
+		apiLogger.Middleware(auditHandler.Middleware(scheduledReportHandler.HandleDefinitionByID)),
+	)
+	http.HandleFunc(
+		"/reports/runs",
+		apiLogger.Middleware(auditHandler.Middleware(scheduledReportHandler.HandleRuns)),
+	)
+	http.HandleFunc(
+		"/reports/runs/",
+		apiLogger.Middleware(auditHandler.Middleware(scheduledReportHandler.HandleRunByID)),
+	)
+	http.HandleFunc(
+		"/reports/types",
+		apiLogger.Middleware(auditHandler.Middleware(scheduledReportHandler.HandleReportTypes)),
+	)
+	http.HandleFunc(
+		"/reports/weeding",
+		apiLogger.Middleware(auditHandler.Middleware(reportHandler.HandleWeedingReport)),
+	)
+	http.HandleFunc(
+		"/reports/weeding/",
+		apiLogger.Middleware(auditHandler.Middleware(reportHandler.HandleWeedingReport)),
 	)
 	http.HandleFunc(
 		"/reservations",
-		apiLogger.Middleware(reservationHandler.HandleReservations),
+		apiLogger.Middleware(auditHandler.Middleware(reservationHandler.HandleReservations)),
 	)
 	http.HandleFunc(
 		"/reservations/",
-		apiLogger.Middleware(reservationHandler.HandleReservationByID),
+		apiLogger.Middleware(auditHandler.Middleware(reservationHandler.HandleReservationByID)),
 	)
 	http.HandleFunc(
 		"/reservations/cancel/",
-		apiLogger.Middleware(reservationHandler.HandleReservationCancel),
+		apiLogger.Middleware(auditHandler.Middleware(reservationHandler.HandleReservationCancel)),
 	)
 	http.HandleFunc(
 		"/reservations/process/",
-		apiLogger.Middleware(reservationHandler.HandleReservationProcess),
+		apiLogger.Middleware(auditHandler.Middleware(reservationHandler.HandleReservationProcess)),
+	)
+	http.HandleFunc(
+		"/series",
+		apiLogger.Middleware(auditHandler.Middleware(seriesHandler.HandleSeries)),
+	)
+	http.HandleFunc(
+		"/series/",
+		apiLogger.Middleware(auditHandler.Middleware(seriesHandler.HandleSeriesByID)),
 	)
 	http.HandleFunc(
 		"/shelves",
-		apiLogger.Middleware(shelfHandler.HandleShelves),
+		apiLogger.Middleware(auditHandler.Middleware(shelfHandler.HandleShelves)),
 	)
 	http.HandleFunc(
 		"/shelves/",
-		apiLogger.Middleware(shelfHandler.HandleShelfByID),
+		apiLogger.Middleware(auditHandler.Middleware(shelfHandler.HandleShelfByID)),
+	)
+	http.HandleFunc(
+		"/stats/circulation",
+		apiLogger.Middleware(auditHandler.Middleware(statsHandler.HandleCirculation)),
 	)
 	http.HandleFunc(
 		"/users",
-		apiLogger.Middleware(userHandler.HandleUsers),
+		apiLogger.Middleware(auditHandler.Middleware(userHandler.HandleUsers)),
 	)
 	http.HandleFunc(
 		"/users/",
-		apiLogger.Middleware(userHandler.HandleUserByID),
+		apiLogger.Middleware(auditHandler.Middleware(userHandler.HandleUserByID)),
+	)
+	http.HandleFunc(
+		"/vendors",
+		apiLogger.Middleware(auditHandler.Middleware(vendorHandler.HandleVendors)),
+	)
+	http.HandleFunc(
+		"/vendors/",
+		apiLogger.Middleware(auditHandler.Middleware(vendorHandler.HandleVendorByID)),
+	)
+	http.HandleFunc(
+		"/webhooks",
+		apiLogger.Middleware(auditHandler.Middleware(webhookHandler.HandleWebhooks)),
+	)
+	http.HandleFunc(
+		"/webhooks/",
+		apiLogger.Middleware(auditHandler.Middleware(webhookHandler.HandleWebhookByID)),
+	)
+	http.HandleFunc(
+		"/works",
+		apiLogger.Middleware(auditHandler.Middleware(workHandler.HandleWorks)),
+	)
+	http.HandleFunc(
+		"/works/",
+		apiLogger.Middleware(auditHandler.Middleware(workHandler.HandleWorkByID)),
 	)
 	http.HandleFunc(
 		"/zones",
-		apiLogger.Middleware(zoneHandler.HandleZones),
+		apiLogger.Middleware(auditHandler.Middleware(zoneHandler.HandleZones)),
 	)
 	http.HandleFunc(
 		"/zones/",
-		apiLogger.Middleware(zoneHandler.HandleZoneByID),
+		apiLogger.Middleware(auditHandler.Middleware(zoneHandler.HandleZoneByID)),
 	)
 
-	fmt.Println("Servidor escuchando en el puerto 8080...")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	// The write timeout covers the exports and reports; the event stream
+	// clears it for itself.
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:           middleware.CORS(cfg.CORS.Origins, metrics.Middleware(http.DefaultServeMux)),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		slog.Info("Servidor escuchando", "port", cfg.Server.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+		case err := <-serverErr:
+			fmt.Println("Error al iniciar el servidor:", err)
+			log.Fatal("Error: ", err)
+			return
+		case <-ctx.Done():
+			slog.Info("Deteniendo el servidor")
+	}
+
+	// Readiness fails first, the workers stop, which ends the event streams,
+	// and the requests in flight get the shutdown timeout to finish.
+	healthService.Drain()
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Las peticiones en curso no terminaron a tiempo", "error", err)
+		server.Close()
+	}
+
+	workers.Wait()
+
+	slog.Info("Servidor detenido")
 }